@@ -0,0 +1,40 @@
+// Package schema embeds the JSON Schemas of the documents llm-translate
+// writes for other programs: the --format json output, the --jsonl and
+// daemon results, the --report usage report and the --run-report of
+// directory runs.
+package schema
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// Version is the version of the schemas. It changes only when a field is
+// removed or changes its meaning; new optional fields keep it.
+const Version = "v1"
+
+//go:embed v1/*.schema.json
+var files embed.FS
+
+// Names returns the names of the schemas, sorted.
+func Names() []string {
+	entries, _ := fs.ReadDir(files, Version)
+	var names []string
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".schema.json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the schema called name.
+func Get(name string) ([]byte, error) {
+	data, err := files.ReadFile(Version + "/" + name + ".schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("unknown schema %q, available: %s", name, strings.Join(Names(), ", "))
+	}
+	return data, nil
+}
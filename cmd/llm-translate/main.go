@@ -6,10 +6,16 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/foxzi/llm-translate/internal/cli"
+	"github.com/foxzi/llm-translate/internal/logging"
 )
 
+// shutdownGrace is how long an interrupted run may take to save its state,
+// below the 30s Kubernetes default termination grace period.
+const shutdownGrace = 20 * time.Second
+
 func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -17,8 +23,17 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
-		<-sigChan
+		sig := <-sigChan
+		logging.Warn("Received %s, finishing up (send again to force exit)", sig)
 		cancel()
+
+		// Give the run time to write its report, then exit even if stuck
+		select {
+		case <-sigChan:
+		case <-time.After(shutdownGrace):
+		}
+		logging.Error("Forced exit")
+		os.Exit(1)
 	}()
 
 	if err := cli.Execute(ctx); err != nil {
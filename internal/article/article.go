@@ -0,0 +1,385 @@
+// Package article extracts the main article of a web page, the way
+// reader modes do: navigation, sidebars, comments and ads are dropped and
+// the text is returned as Markdown or clean HTML.
+package article
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Article is the main content of a page.
+type Article struct {
+	Title string
+	// nodes are the content elements, the best candidate and the siblings
+	// that belong to it
+	nodes []*html.Node
+	base  *url.URL
+}
+
+var (
+	// unlikelyRe matches class and id values of page furniture.
+	unlikelyRe = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|footer|gdpr|header|legend|menu|modal|nav|newsletter|pager|pagination|popup|promo|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|tags|tool|widget|advert|\bads?\b`)
+	// likelyRe matches class and id values that keep an element matching
+	// unlikelyRe.
+	likelyRe = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow|entry|post|story|text`)
+	// positiveRe and negativeRe weigh candidates by class and id.
+	positiveRe = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|pagination|post|text|blog|story`)
+	negativeRe = regexp.MustCompile(`(?i)-ad-|hidden|^hid$| hid$| hid |^hid |banner|combx|comment|com-|contact|footer|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|widget`)
+)
+
+// removed are elements never part of an article.
+var removed = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Iframe: true,
+	atom.Form: true, atom.Nav: true, atom.Aside: true, atom.Footer: true,
+	atom.Svg: true, atom.Button: true, atom.Input: true, atom.Select: true,
+	atom.Textarea: true, atom.Template: true, atom.Object: true, atom.Embed: true,
+	atom.Canvas: true, atom.Link: true, atom.Meta: true,
+}
+
+// Extract finds the article of the HTML page. base resolves relative
+// links and images.
+func Extract(page []byte, base *url.URL) (*Article, error) {
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse page: %w", err)
+	}
+	body := find(doc, atom.Body)
+	if body == nil {
+		return nil, fmt.Errorf("page has no body")
+	}
+
+	a := &Article{Title: pageTitle(doc), base: base}
+	prune(body)
+	a.nodes = mainContent(body)
+	for _, n := range a.nodes {
+		cleanConditionally(n)
+	}
+	a.dropTitleHeading()
+	if strings.TrimSpace(a.text()) == "" {
+		return nil, fmt.Errorf("no article text found on the page")
+	}
+	return a, nil
+}
+
+// Fragment returns an HTML fragment, such as the content of a feed item,
+// as an article without a title. Only page furniture is removed, the
+// content is not searched for.
+func Fragment(fragment string, base *url.URL) (*Article, error) {
+	doc, err := html.Parse(strings.NewReader(fragment))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	// The parser always adds a body
+	body := find(doc, atom.Body)
+	prune(body)
+	return &Article{nodes: []*html.Node{body}, base: base}, nil
+}
+
+// pageTitle returns og:title, or the title element without the site name
+// appended after a separator.
+func pageTitle(doc *html.Node) string {
+	var title string
+	walk(doc, func(n *html.Node) bool {
+		if n.DataAtom == atom.Meta && (attr(n, "property") == "og:title" || attr(n, "name") == "twitter:title") && title == "" {
+			title = strings.TrimSpace(attr(n, "content"))
+		}
+		return true
+	})
+	if title != "" {
+		return title
+	}
+	if n := find(doc, atom.Title); n != nil {
+		title = collapse(textContent(n))
+	}
+	for _, sep := range []string{" | ", " - ", " – ", " — ", " :: ", " / "} {
+		if i := strings.LastIndex(title, sep); i > 0 && len(strings.Fields(title[:i])) >= 3 {
+			return strings.TrimSpace(title[:i])
+		}
+	}
+	return title
+}
+
+// prune removes elements that are never content and page furniture
+// recognized by class or id.
+func prune(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch {
+		case c.Type == html.CommentNode:
+			n.RemoveChild(c)
+		case c.Type != html.ElementNode:
+		case removed[c.DataAtom] || attr(c, "hidden") != "" || attr(c, "aria-hidden") == "true":
+			n.RemoveChild(c)
+		case isUnlikely(c):
+			n.RemoveChild(c)
+		default:
+			prune(c)
+		}
+		c = next
+	}
+}
+
+func isUnlikely(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.Article, atom.Main, atom.Body, atom.A, atom.Table, atom.Tbody, atom.Tr, atom.Td:
+		return false
+	}
+	if attr(n, "role") == "navigation" || attr(n, "role") == "complementary" || attr(n, "role") == "banner" {
+		return true
+	}
+	match := attr(n, "class") + " " + attr(n, "id")
+	return unlikelyRe.MatchString(match) && !likelyRe.MatchString(match)
+}
+
+// mainContent scores the containers of paragraphs and returns the best
+// one with its siblings that look like part of the article.
+func mainContent(body *html.Node) []*html.Node {
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+
+	walk(body, func(n *html.Node) bool {
+		switch n.DataAtom {
+		case atom.P, atom.Pre, atom.Td, atom.Blockquote:
+		default:
+			return true
+		}
+		text := collapse(textContent(n))
+		if len(text) < 25 {
+			return false
+		}
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+		addScore(n.Parent, score)
+		if n.Parent != nil {
+			addScore(n.Parent.Parent, score/2)
+		}
+		return false
+	})
+
+	var top *html.Node
+	for _, c := range candidates {
+		scores[c] *= 1 - linkDensity(c)
+		if top == nil || scores[c] > scores[top] {
+			top = c
+		}
+	}
+	if top == nil {
+		return []*html.Node{body}
+	}
+
+	// Siblings with enough score or a long paragraph belong to the article
+	threshold := math.Max(10, scores[top]*0.2)
+	var nodes []*html.Node
+	for s := top.Parent.FirstChild; s != nil; s = s.NextSibling {
+		if s == top {
+			nodes = append(nodes, s)
+			continue
+		}
+		if s.Type != html.ElementNode {
+			continue
+		}
+		if score, ok := scores[s]; ok && score >= threshold {
+			nodes = append(nodes, s)
+			continue
+		}
+		if s.DataAtom == atom.P {
+			text := collapse(textContent(s))
+			if len(text) > 80 && linkDensity(s) < 0.25 || len(text) > 0 && linkDensity(s) == 0 && strings.Contains(text, ". ") {
+				nodes = append(nodes, s)
+			}
+		}
+	}
+	return nodes
+}
+
+// initialScore weighs a candidate by its tag, class and id.
+func initialScore(n *html.Node) float64 {
+	score := classWeight(n)
+	switch n.DataAtom {
+	case atom.Div, atom.Article, atom.Main:
+		score += 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		score += 3
+	case atom.Address, atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li:
+		score -= 3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		score -= 5
+	}
+	return score
+}
+
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	for _, value := range []string{attr(n, "class"), attr(n, "id")} {
+		if value == "" {
+			continue
+		}
+		if negativeRe.MatchString(value) {
+			weight -= 25
+		}
+		if positiveRe.MatchString(value) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// linkDensity is the share of the text of n inside links.
+func linkDensity(n *html.Node) float64 {
+	total := len(collapse(textContent(n)))
+	if total == 0 {
+		return 0
+	}
+	linked := 0
+	walk(n, func(c *html.Node) bool {
+		if c.DataAtom == atom.A {
+			linked += len(collapse(textContent(c)))
+			return false
+		}
+		return true
+	})
+	return float64(linked) / float64(total)
+}
+
+// cleanConditionally removes blocks within the content that look like
+// link lists or widgets rather than article text.
+func cleanConditionally(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode {
+			switch c.DataAtom {
+			case atom.Div, atom.Section, atom.Ul, atom.Ol, atom.Table:
+				text := collapse(textContent(c))
+				images := count(c, atom.Img)
+				if classWeight(c) < 0 ||
+					len(text) < 200 && linkDensity(c) > 0.5 && images == 0 ||
+					(c.DataAtom == atom.Div || c.DataAtom == atom.Section) && len(text) < 25 && images == 0 && count(c, atom.Pre) == 0 {
+					n.RemoveChild(c)
+					c = next
+					continue
+				}
+			}
+			cleanConditionally(c)
+		}
+		c = next
+	}
+}
+
+// dropTitleHeading removes the first heading when it repeats the title,
+// since the title is written as the first heading.
+func (a *Article) dropTitleHeading() {
+	var heading *html.Node
+	for _, n := range a.nodes {
+		walk(n, func(c *html.Node) bool {
+			if heading == nil && (c.DataAtom == atom.H1 || c.DataAtom == atom.H2) {
+				heading = c
+			}
+			return heading == nil
+		})
+	}
+	if heading == nil {
+		return
+	}
+	text := collapse(textContent(heading))
+	if a.Title == "" {
+		a.Title = text
+	}
+	if strings.EqualFold(text, a.Title) && heading.Parent != nil {
+		heading.Parent.RemoveChild(heading)
+	}
+}
+
+func (a *Article) text() string {
+	var b strings.Builder
+	for _, n := range a.nodes {
+		b.WriteString(textContent(n))
+	}
+	return b.String()
+}
+
+// resolve returns ref relative to the page as an absolute URL.
+func (a *Article) resolve(ref string) string {
+	if a.base == nil || ref == "" {
+		return ref
+	}
+	u, err := a.base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+func find(n *html.Node, a atom.Atom) *html.Node {
+	var found *html.Node
+	walk(n, func(c *html.Node) bool {
+		if found == nil && c.DataAtom == a {
+			found = c
+		}
+		return found == nil
+	})
+	return found
+}
+
+func count(n *html.Node, a atom.Atom) int {
+	total := 0
+	walk(n, func(c *html.Node) bool {
+		if c.DataAtom == a {
+			total++
+		}
+		return true
+	})
+	return total
+}
+
+// walk calls fn for n and its descendants in document order, skipping the
+// children of nodes fn returns false for.
+func walk(n *html.Node, fn func(*html.Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+// collapse joins the words of s with single spaces.
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
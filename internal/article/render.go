@@ -0,0 +1,437 @@
+package article
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Markdown returns the article as Markdown, with the title as the first
+// heading and links and images made absolute.
+func (a *Article) Markdown() string {
+	r := &markdownRenderer{a: a}
+	if a.Title != "" {
+		r.block("# " + escapeMarkdown(a.Title))
+	}
+	for _, n := range a.nodes {
+		r.node(n, "")
+	}
+	return strings.TrimSpace(r.out.String()) + "\n"
+}
+
+type markdownRenderer struct {
+	a   *Article
+	out strings.Builder
+}
+
+// block writes a block separated from the previous one by a blank line.
+func (r *markdownRenderer) block(text string) {
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	if r.out.Len() > 0 {
+		r.out.WriteString("\n\n")
+	}
+	r.out.WriteString(text)
+}
+
+// node writes the blocks of n, each line prefixed with indent (list
+// nesting and quotes).
+func (r *markdownRenderer) node(n *nethtml.Node, indent string) {
+	if n.Type == nethtml.TextNode {
+		r.block(indent + strings.TrimSpace(collapse(n.Data)))
+		return
+	}
+	if n.Type != nethtml.ElementNode && n.Type != nethtml.DocumentNode {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		r.block(indent + strings.Repeat("#", level) + " " + r.inline(n))
+	case atom.P, atom.Dt, atom.Dd, atom.Figcaption, atom.Caption:
+		r.block(prefixLines(r.inline(n), indent))
+	case atom.Pre:
+		fence := "```"
+		if code := find(n, atom.Code); code != nil {
+			if lang := codeLanguage(attr(code, "class")); lang != "" {
+				fence += lang
+			}
+		}
+		r.block(prefixLines(fence+"\n"+strings.TrimRight(textContent(n), "\n")+"\n```", indent))
+	case atom.Blockquote:
+		r.children(n, indent+"> ")
+	case atom.Ul, atom.Ol:
+		// Items of one list are not separated by blank lines
+		var items []string
+		for li := n.FirstChild; li != nil; li = li.NextSibling {
+			if li.DataAtom != atom.Li {
+				continue
+			}
+			marker := "- "
+			if n.DataAtom == atom.Ol {
+				marker = fmt.Sprintf("%d. ", len(items)+1)
+			}
+			items = append(items, r.listItem(li, marker))
+		}
+		r.block(prefixLines(strings.Join(items, "\n"), indent))
+	case atom.Table:
+		r.table(n, indent)
+	case atom.Hr:
+		r.block(indent + "* * *")
+	case atom.Img:
+		r.block(indent + r.inline(n))
+	default:
+		if isBlockContainer(n) {
+			r.children(n, indent)
+		} else {
+			r.block(prefixLines(r.inline(n), indent))
+		}
+	}
+}
+
+func (r *markdownRenderer) children(n *nethtml.Node, indent string) {
+	// Runs of inline nodes between blocks form a paragraph
+	var run []*nethtml.Node
+	flush := func() {
+		var b strings.Builder
+		for _, c := range run {
+			b.WriteString(r.inlineNode(c))
+		}
+		r.block(prefixLines(strings.TrimSpace(collapseInline(b.String())), indent))
+		run = nil
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if isBlock(c) {
+			flush()
+			r.node(c, indent)
+		} else {
+			run = append(run, c)
+		}
+	}
+	flush()
+}
+
+// listItem returns a list item with its nested lists indented below it.
+func (r *markdownRenderer) listItem(li *nethtml.Node, marker string) string {
+	var text strings.Builder
+	var nested []string
+	for c := li.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.DataAtom == atom.Ul || c.DataAtom == atom.Ol:
+			sub := &markdownRenderer{a: r.a}
+			sub.node(c, "")
+			nested = append(nested, prefixLines(sub.out.String(), strings.Repeat(" ", len(marker))))
+		case isBlock(c):
+			text.WriteString(" " + r.inline(c) + " ")
+		default:
+			text.WriteString(r.inlineNode(c))
+		}
+	}
+	item := marker + strings.TrimSpace(collapseInline(text.String()))
+	return strings.Join(append([]string{item}, nested...), "\n")
+}
+
+func (r *markdownRenderer) table(n *nethtml.Node, indent string) {
+	var rows [][]string
+	walk(n, func(c *nethtml.Node) bool {
+		if c.DataAtom != atom.Tr {
+			return true
+		}
+		var cells []string
+		for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.DataAtom == atom.Td || cell.DataAtom == atom.Th {
+				cells = append(cells, strings.ReplaceAll(r.inline(cell), "|", `\|`))
+			}
+		}
+		if len(cells) > 0 {
+			rows = append(rows, cells)
+		}
+		return false
+	})
+	if len(rows) == 0 {
+		return
+	}
+	width := 0
+	for _, row := range rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	var lines []string
+	for i, row := range rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		lines = append(lines, indent+"| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, indent+"|"+strings.Repeat(" --- |", width))
+		}
+	}
+	r.block(strings.Join(lines, "\n"))
+}
+
+// inline returns the inline Markdown of the content of n.
+func (r *markdownRenderer) inline(n *nethtml.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(r.inlineNode(c))
+	}
+	if n.DataAtom == atom.Img {
+		b.WriteString(r.inlineNode(n))
+	}
+	return strings.TrimSpace(collapseInline(b.String()))
+}
+
+func (r *markdownRenderer) inlineNode(n *nethtml.Node) string {
+	if n.Type == nethtml.TextNode {
+		return escapeMarkdown(n.Data)
+	}
+	if n.Type != nethtml.ElementNode {
+		return ""
+	}
+	content := func() string {
+		var b strings.Builder
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			b.WriteString(r.inlineNode(c))
+		}
+		return b.String()
+	}
+	wrap := func(mark string) string {
+		text := content()
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" {
+			return text
+		}
+		// Keep the spaces outside the markers
+		lead := text[:len(text)-len(strings.TrimLeft(text, " \t\n"))]
+		trail := text[len(strings.TrimRight(text, " \t\n")):]
+		return lead + mark + trimmed + mark + trail
+	}
+
+	switch n.DataAtom {
+	case atom.Em, atom.I:
+		return wrap("*")
+	case atom.Strong, atom.B:
+		return wrap("**")
+	case atom.Code, atom.Kbd, atom.Samp:
+		return "`" + textContent(n) + "`"
+	case atom.Br:
+		return "\n"
+	case atom.A:
+		text := strings.TrimSpace(content())
+		href := r.a.resolve(attr(n, "href"))
+		if text == "" || href == "" || strings.HasPrefix(href, "javascript:") {
+			return text
+		}
+		return "[" + text + "](" + href + ")"
+	case atom.Img:
+		src := r.a.resolve(imageSource(n))
+		if src == "" {
+			return ""
+		}
+		return "![" + escapeMarkdown(attr(n, "alt")) + "](" + src + ")"
+	}
+	return content()
+}
+
+// HTML returns the article as HTML with the title as h1. Only structural
+// and inline formatting elements with their links and image sources are
+// kept; other elements are replaced by their content. Blocks are
+// separated by blank lines, so the article is chunked between them.
+func (a *Article) HTML() string {
+	var b strings.Builder
+	if a.Title != "" {
+		b.WriteString("<h1>" + html.EscapeString(a.Title) + "</h1>\n\n")
+	}
+	for _, n := range a.nodes {
+		a.writeHTML(&b, n, false)
+	}
+	out := strings.TrimSpace(b.String())
+	for strings.Contains(out, "\n\n\n") {
+		out = strings.ReplaceAll(out, "\n\n\n", "\n\n")
+	}
+	return out + "\n"
+}
+
+// keptElements are the elements HTML keeps, with the attributes they keep.
+var keptElements = map[atom.Atom][]string{
+	atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil, atom.H5: nil, atom.H6: nil,
+	atom.P: nil, atom.Blockquote: nil, atom.Pre: nil, atom.Code: nil,
+	atom.Ul: nil, atom.Ol: nil, atom.Li: nil, atom.Dl: nil, atom.Dt: nil, atom.Dd: nil,
+	atom.Table: nil, atom.Thead: nil, atom.Tbody: nil, atom.Tr: nil, atom.Th: {"colspan", "rowspan"}, atom.Td: {"colspan", "rowspan"},
+	atom.Caption: nil, atom.Figure: nil, atom.Figcaption: nil,
+	atom.Em: nil, atom.I: nil, atom.Strong: nil, atom.B: nil, atom.Sub: nil, atom.Sup: nil,
+	atom.Kbd: nil, atom.Samp: nil, atom.Mark: nil, atom.Q: nil, atom.Abbr: {"title"},
+	atom.A: {"href", "title"}, atom.Img: {"src", "alt", "title"}, atom.Br: nil, atom.Hr: nil,
+}
+
+// writeHTML writes n and its content. Top-level blocks are separated by
+// blank lines, blocks nested in kept elements start on a new line.
+func (a *Article) writeHTML(b *strings.Builder, n *nethtml.Node, nested bool) {
+	switch n.Type {
+	case nethtml.TextNode:
+		if insidePre(n) {
+			b.WriteString(html.EscapeString(n.Data))
+			return
+		}
+		// Whitespace between blocks is dropped
+		if strings.TrimSpace(n.Data) == "" && (n.PrevSibling == nil || isBlock(n.PrevSibling) || n.NextSibling == nil || isBlock(n.NextSibling)) {
+			return
+		}
+		b.WriteString(html.EscapeString(collapseInline(strings.ReplaceAll(n.Data, "\n", " "))))
+		return
+	case nethtml.ElementNode:
+	default:
+		return
+	}
+
+	attrs, kept := keptElements[n.DataAtom]
+	if !kept {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			a.writeHTML(b, c, nested)
+		}
+		if isBlock(n) && !nested {
+			b.WriteString("\n\n")
+		}
+		return
+	}
+
+	before, after := "\n\n", "\n\n"
+	if nested {
+		before, after = "\n", ""
+	}
+	if isBlock(n) {
+		b.WriteString(before)
+	}
+	b.WriteString("<" + n.Data)
+	for _, key := range attrs {
+		value := attr(n, key)
+		if key == "src" {
+			value = imageSource(n)
+		}
+		if key == "href" || key == "src" {
+			value = a.resolve(value)
+		}
+		if value != "" {
+			fmt.Fprintf(b, ` %s="%s"`, key, html.EscapeString(value))
+		}
+	}
+	b.WriteString(">")
+	if n.DataAtom == atom.Img || n.DataAtom == atom.Br || n.DataAtom == atom.Hr {
+		if isBlock(n) {
+			b.WriteString(after)
+		}
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		a.writeHTML(b, c, true)
+	}
+	if isBlock(n) && hasBlockChild(n) {
+		b.WriteString("\n")
+	}
+	b.WriteString("</" + n.Data + ">")
+	if isBlock(n) {
+		b.WriteString(after)
+	}
+}
+
+// imageSource returns the src of an image, or the lazy-loading source
+// some sites keep in a data attribute.
+func imageSource(n *nethtml.Node) string {
+	for _, key := range []string{"src", "data-src", "data-original"} {
+		if value := attr(n, key); value != "" && !strings.HasPrefix(value, "data:") {
+			return value
+		}
+	}
+	return ""
+}
+
+// blockElements start a new block.
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Dd: true, atom.Details: true, atom.Div: true, atom.Dl: true, atom.Dt: true,
+	atom.Figcaption: true, atom.Figure: true, atom.Footer: true, atom.H1: true,
+	atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hr: true, atom.Li: true, atom.Main: true, atom.Ol: true,
+	atom.P: true, atom.Pre: true, atom.Section: true, atom.Table: true, atom.Ul: true,
+	atom.Caption: true, atom.Summary: true,
+}
+
+func isBlock(n *nethtml.Node) bool {
+	return n.Type == nethtml.ElementNode && blockElements[n.DataAtom]
+}
+
+// isBlockContainer reports whether n holds blocks, so its children are
+// rendered as blocks rather than as one paragraph.
+func isBlockContainer(n *nethtml.Node) bool {
+	return hasBlockChild(n) || isBlock(n) && n.DataAtom != atom.Li
+}
+
+func hasBlockChild(n *nethtml.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if isBlock(c) {
+			return true
+		}
+	}
+	return false
+}
+
+func insidePre(n *nethtml.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.DataAtom == atom.Pre {
+			return true
+		}
+	}
+	return false
+}
+
+// codeLanguage returns the language of a language-xxx or lang-xxx class.
+func codeLanguage(class string) string {
+	for _, c := range strings.Fields(class) {
+		for _, prefix := range []string{"language-", "lang-"} {
+			if strings.HasPrefix(c, prefix) {
+				return strings.TrimPrefix(c, prefix)
+			}
+		}
+	}
+	return ""
+}
+
+// collapseInline collapses runs of whitespace to one space, keeping line
+// breaks from br elements.
+func collapseInline(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		lines[i] = strings.Join(fields, " ")
+		if len(fields) > 0 {
+			if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+				lines[i] = " " + lines[i]
+			}
+			if strings.HasSuffix(line, " ") || strings.HasSuffix(line, "\t") {
+				lines[i] += " "
+			}
+		} else if line != "" {
+			lines[i] = " "
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// escapeMarkdown escapes the characters that would start Markdown
+// formatting in text.
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`).Replace(s)
+}
+
+func prefixLines(text, prefix string) string {
+	if text == "" {
+		return ""
+	}
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/foxzi/llm-translate/internal/translator"
+)
+
+// alignFile receives the sentence pairs of --align-export, nil without it.
+var alignFile *os.File
+
+// blockPrefixRe matches the Markdown markers of headings, list items and
+// quotes, which are not part of the sentences.
+var blockPrefixRe = regexp.MustCompile(`^\s*(#{1,6}\s+|[-*+]\s+|\d+[.)]\s+|>\s?)+`)
+
+// alignedPair is a JSONL line of --align-export.
+type alignedPair struct {
+	Source     string `json:"source"`
+	Target     string `json:"target"`
+	SourceLang string `json:"source_lang,omitempty"`
+	TargetLang string `json:"target_lang"`
+	File       string `json:"file,omitempty"`
+}
+
+// openAlignExport creates the --align-export file. The format follows its
+// extension: .jsonl, or .tsv with a source and target column.
+func openAlignExport() error {
+	switch strings.ToLower(filepath.Ext(alignExport)) {
+	case ".tsv", ".jsonl":
+	default:
+		return fmt.Errorf("--align-export file must end in .tsv or .jsonl")
+	}
+	f, err := os.Create(alignExport)
+	if err != nil {
+		return fmt.Errorf("failed to create alignment export: %w", err)
+	}
+	alignFile = f
+	return nil
+}
+
+// exportAlignment writes the sentence pairs of a translated document to
+// the --align-export file. Paragraphs are paired by position, their
+// sentences when both have as many, otherwise the paragraphs themselves.
+// Documents whose paragraphs do not line up are skipped.
+func exportAlignment(name, source string, result translator.TranslateResponse) {
+	if alignFile == nil {
+		return
+	}
+	src, dst := paragraphs(source), paragraphs(result.Text)
+	if len(src) != len(dst) {
+		logWarn("%s: source has %d paragraphs, the translation %d; no sentence pairs exported", name, len(src), len(dst))
+		return
+	}
+
+	lang := sourceLang
+	if result.DetectedLang != "" {
+		lang = result.DetectedLang
+	}
+	if lang == "auto" {
+		lang = ""
+	}
+	jsonl := strings.EqualFold(filepath.Ext(alignExport), ".jsonl")
+
+	var b strings.Builder
+	for i := range src {
+		// Code blocks and text left as it is are not translations
+		if src[i] == dst[i] || strings.HasPrefix(strings.TrimSpace(src[i]), "```") || strings.HasPrefix(strings.TrimSpace(src[i]), "~~~") {
+			continue
+		}
+		s, d := sentences(src[i]), sentences(dst[i])
+		if len(s) != len(d) {
+			s, d = []string{blockText(src[i])}, []string{blockText(dst[i])}
+		}
+		for j := range s {
+			if s[j] == "" || d[j] == "" {
+				continue
+			}
+			if !jsonl {
+				fmt.Fprintf(&b, "%s\t%s\n", s[j], d[j])
+				continue
+			}
+			line, _ := json.Marshal(alignedPair{Source: s[j], Target: d[j], SourceLang: lang, TargetLang: targetLang, File: name})
+			b.Write(append(line, '\n'))
+		}
+	}
+	if _, err := alignFile.WriteString(b.String()); err != nil {
+		logError("Failed to write alignment export: %v", err)
+	}
+}
+
+// blockText returns the text of a paragraph on one line, without Markdown
+// block markers.
+func blockText(paragraph string) string {
+	lines := strings.Split(paragraph, "\n")
+	for i, line := range lines {
+		lines[i] = blockPrefixRe.ReplaceAllString(line, "")
+	}
+	return strings.Join(strings.Fields(strings.Join(lines, " ")), " ")
+}
+
+// sentences splits the lines of a paragraph into sentences. A sentence
+// ends at . ! ? or … followed by a space and a letter that is not
+// lowercase, so most abbreviations stay inside, or at the full stops of
+// Chinese and Japanese.
+func sentences(paragraph string) []string {
+	var result []string
+	for _, line := range strings.Split(paragraph, "\n") {
+		text := strings.Join(strings.Fields(blockPrefixRe.ReplaceAllString(line, "")), " ")
+		start := 0
+		for i, r := range text {
+			end := i + utf8.RuneLen(r)
+			switch r {
+			case '。', '！', '？':
+			case '.', '!', '?', '…':
+				next, _ := utf8.DecodeRuneInString(strings.TrimLeft(text[end:], `"'”’»)]`))
+				rest := strings.TrimLeft(text[end:], `"'”’»)] `)
+				first, _ := utf8.DecodeRuneInString(rest)
+				if next != ' ' || rest == "" || unicode.IsLower(first) {
+					continue
+				}
+				end += len(text[end:]) - len(strings.TrimLeft(text[end:], `"'”’»)]`))
+			default:
+				continue
+			}
+			if sentence := strings.TrimSpace(text[start:end]); sentence != "" {
+				result = append(result, sentence)
+			}
+			start = end
+		}
+		if sentence := strings.TrimSpace(text[start:]); sentence != "" {
+			result = append(result, sentence)
+		}
+	}
+	return result
+}
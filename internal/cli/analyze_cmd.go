@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/foxzi/llm-translate/internal/translator"
+	"github.com/spf13/cobra"
+)
+
+// newAnalyzeCmd builds the "analyze" command that runs the analysis pipeline
+// on the original text without translating it. It accepts all root flags.
+func newAnalyzeCmd(rootCmd *cobra.Command) *cobra.Command {
+	analyzeCmd := &cobra.Command{
+		Use:          "analyze",
+		Short:        "Run text analysis without translation, results go to frontmatter or JSON",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAnalyze(cmd.Context(), cmd)
+		},
+	}
+
+	analyzeCmd.Flags().AddFlagSet(rootCmd.Flags())
+
+	return analyzeCmd
+}
+
+func runAnalyze(ctx context.Context, cmd *cobra.Command) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	if outputFormat != "text" && outputFormat != "json" {
+		return fmt.Errorf("invalid format %q: use text or json", outputFormat)
+	}
+
+	inputText, err := readInput()
+	if err != nil {
+		return err
+	}
+
+	frontmatter, content := extractFrontmatter(inputText)
+
+	t := translator.New(cfg, verbose)
+
+	analysisText := redactForAnalysis(cfg, content)
+	fmUpdates := runAnalysis(ctx, t, cfg, analysisText, targetLang, verbose)
+	runHeadline(ctx, t, cfg, analysisText, fmUpdates, verbose)
+	runReadability(cfg, content, targetLang, fmUpdates)
+
+	if len(fmUpdates) == 0 {
+		logWarn("No analysis results, enable analyses with flags like --sentiment or --tags 5")
+	}
+
+	var output string
+	if outputFormat == "json" {
+		usage := t.Meter().Total()
+		data, err := json.MarshalIndent(outputDocument{
+			SourceLang: sourceLang,
+			TargetLang: targetLang,
+			Provider:   cfg.DefaultProvider,
+			Model:      getModelForProvider(cfg),
+			TokensUsed: usage.Tokens(),
+			Usage:      t.Meter().Snapshot(),
+			Metadata:   fmUpdates,
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+		output = string(data) + "\n"
+	} else {
+		if len(fmUpdates) > 0 {
+			frontmatter = updateFrontmatter(frontmatter, fmUpdates)
+		}
+		output = frontmatter + content
+	}
+
+	if err := writeOutput(output); err != nil {
+		return err
+	}
+
+	if verbose {
+		logUsage(t.Meter().Total())
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/translator"
+)
+
+// annotation is a check result to mark in the output. Find is the text it
+// is about; without it, or when it cannot be found, the note applies to the
+// whole file.
+type annotation struct {
+	kind   string
+	detail string
+	find   string
+}
+
+// annotateIssues returns the translated text with an HTML comment such as
+// <!-- llm-translate:warning injection line 42: "as an AI" --> at the end of
+// each line with a problem found by the checks. Notes about the whole file,
+// like missing numbers, are added at the end. Line numbers count from the
+// top of the output file, including the frontmatter. Does nothing without
+// --annotate.
+func annotateIssues(cfg *config.Config, frontmatter string, result translator.TranslateResponse, fmUpdates map[string]interface{}) string {
+	if !cfg.Settings.Annotate {
+		return result.Text
+	}
+	notes := collectAnnotations(result, fmUpdates)
+	if len(notes) == 0 {
+		return result.Text
+	}
+
+	lines := strings.Split(result.Text, "\n")
+	offset := strings.Count(frontmatter, "\n") + 1
+	inCode := codeLines(lines)
+
+	var fileNotes []string
+	for _, note := range notes {
+		i := findLine(lines, inCode, note.find)
+		if i < 0 {
+			fileNotes = append(fileNotes, annotationComment(note, 0))
+			continue
+		}
+		lines[i] += " " + annotationComment(note, i+offset)
+	}
+
+	text := strings.Join(lines, "\n")
+	if len(fileNotes) > 0 {
+		text = strings.TrimRight(text, "\n") + "\n\n" + strings.Join(fileNotes, "\n") + "\n"
+	}
+	return text
+}
+
+// collectAnnotations turns the check results into annotations.
+func collectAnnotations(result translator.TranslateResponse, fmUpdates map[string]interface{}) []annotation {
+	var notes []annotation
+	if missing, ok := fmUpdates["numeric_issues"].([]string); ok {
+		notes = append(notes, annotation{kind: "numbers-missing", detail: strings.Join(missing, ", ")})
+	}
+	if missing, ok := fmUpdates["missing_links"].([]string); ok {
+		notes = append(notes, annotation{kind: "links-missing", detail: strings.Join(missing, ", ")})
+	}
+	if markers, ok := fmUpdates["injection_suspected"].([]string); ok {
+		for _, marker := range markers {
+			notes = append(notes, annotation{kind: "injection", detail: fmt.Sprintf("%q", marker), find: marker})
+		}
+	}
+	if issues, ok := fmUpdates["capitalization_issues"].([]string); ok {
+		for _, issue := range issues {
+			// Issues end with the heading or word in question
+			find := ""
+			if i := strings.LastIndex(issue, ": "); i >= 0 {
+				find = strings.Trim(issue[i+2:], `"`)
+			}
+			notes = append(notes, annotation{kind: "capitalization", detail: issue, find: find})
+		}
+	}
+	for _, issue := range result.TermIssues {
+		for _, rendering := range issue.Renderings {
+			if rendering != issue.Expected {
+				notes = append(notes, annotation{
+					kind:   "terminology",
+					detail: fmt.Sprintf("%s: %s, expected %s", issue.Term, rendering, issue.Expected),
+					find:   rendering,
+				})
+			}
+		}
+	}
+	return notes
+}
+
+func annotationComment(note annotation, line int) string {
+	// "--" would end the comment early
+	detail := strings.ReplaceAll(note.detail, "--", "- -")
+	if line > 0 {
+		return fmt.Sprintf("<!-- llm-translate:warning %s line %d: %s -->", note.kind, line, detail)
+	}
+	return fmt.Sprintf("<!-- llm-translate:warning %s: %s -->", note.kind, detail)
+}
+
+// codeLines marks the lines of fenced code blocks, where a comment would
+// show up as code.
+func codeLines(lines []string) []bool {
+	inCode := make([]bool, len(lines))
+	fenced := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+			inCode[i] = true
+			continue
+		}
+		inCode[i] = fenced
+	}
+	return inCode
+}
+
+// findLine returns the first line outside code containing text, ignoring
+// case, or -1.
+func findLine(lines []string, inCode []bool, text string) int {
+	if text == "" {
+		return -1
+	}
+	text = strings.ToLower(text)
+	for i, line := range lines {
+		if !inCode[i] && strings.Contains(strings.ToLower(line), text) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/translator"
+)
+
+// outputText returns the text written for a translated document: the
+// translation, laid out with the source by --bilingual, with the problems
+// found by the checks marked by --annotate.
+func outputText(cfg *config.Config, frontmatter, source string, result translator.TranslateResponse, fmUpdates map[string]interface{}) string {
+	result.Text = bilingualText(cfg.Settings.Bilingual, source, result.Text)
+	return frontmatter + annotateIssues(cfg, frontmatter, result, fmUpdates)
+}
+
+// bilingualText pairs the paragraphs of source and translation by position:
+// inline follows each source paragraph with its translation as a quote,
+// interleaved alternates the paragraphs as they are, table puts them in the
+// columns of a Markdown table. Paragraphs left unchanged, such as code
+// blocks, are not repeated. Without a layout, translation is returned.
+func bilingualText(layout, source, translation string) string {
+	if layout == "" {
+		return translation
+	}
+	src, dst := paragraphs(source), paragraphs(translation)
+	if len(src) != len(dst) {
+		logWarn("Source has %d paragraphs, the translation %d; bilingual paragraphs may not line up", len(src), len(dst))
+	}
+	rows := len(src)
+	if len(dst) > rows {
+		rows = len(dst)
+	}
+
+	var blocks []string
+	if layout == "table" {
+		header := "Source"
+		if sourceLang != "" && sourceLang != "auto" {
+			header += " (" + sourceLang + ")"
+		}
+		blocks = append(blocks, fmt.Sprintf("| %s | Translation (%s) |\n|---|---|", header, targetLang))
+	}
+	for i := 0; i < rows; i++ {
+		var s, d string
+		if i < len(src) {
+			s = src[i]
+		}
+		if i < len(dst) {
+			d = dst[i]
+		}
+		switch {
+		case layout == "table":
+			blocks = append(blocks, fmt.Sprintf("| %s | %s |", markdownCell(s), markdownCell(d)))
+		case s == d || d == "":
+			blocks = append(blocks, s)
+		case s == "":
+			blocks = append(blocks, d)
+		case layout == "inline":
+			quoted := strings.Split(d, "\n")
+			for j, line := range quoted {
+				quoted[j] = strings.TrimRight("> "+line, " ")
+			}
+			blocks = append(blocks, s+"\n\n"+strings.Join(quoted, "\n"))
+		default:
+			blocks = append(blocks, s, d)
+		}
+	}
+
+	separator := "\n\n"
+	if layout == "table" {
+		separator = "\n"
+	}
+	return strings.Join(blocks, separator) + "\n"
+}
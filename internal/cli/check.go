@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/metering"
+	"github.com/foxzi/llm-translate/internal/translator"
+	"github.com/foxzi/llm-translate/internal/validator"
+)
+
+// runCheck is the read-only --check mode for CI. It finds sources whose
+// translation is missing or stale by the translation manifest, plans their
+// chunks and estimates tokens, and runs the local checks a translation run
+// would run on the existing outputs. Nothing is written and no provider is
+// called. Returns an error when any translation is missing or stale, or has
+// validation issues.
+func runCheck(cfg *config.Config) error {
+	var pairs [][2]string
+	manifestDir := inputDir
+	switch {
+	case inputDir != "":
+		extList := parseExtensions(extensions)
+		if len(extList) == 0 {
+			return fmt.Errorf("no valid extensions specified")
+		}
+		files, err := findFiles(inputDir, extList)
+		if err != nil {
+			return fmt.Errorf("failed to scan directory: %w", err)
+		}
+		files = filterTranslatedFiles(files, outSuffix, outPrefix, targetLang)
+		if cfg.Settings.Dedupe > 0 {
+			// Skipped duplicates never get a translation, do not report them
+			files, _ = dedupeFiles(files, cfg.Settings.Dedupe)
+		}
+		for _, f := range files {
+			pairs = append(pairs, [2]string{f, generateOutputPath(f, outSuffix, outPrefix, targetLang)})
+		}
+	case inputFile != "" && outputFile != "":
+		pairs = append(pairs, [2]string{inputFile, outputFile})
+		manifestDir = filepath.Dir(inputFile)
+	default:
+		return fmt.Errorf("--check needs -d <dir>, or -i <file> with -o <file>")
+	}
+
+	manifest, err := loadTranslationManifest(manifestDir)
+	if err != nil {
+		return err
+	}
+
+	t := translator.New(cfg, verbose)
+
+	var pending, issues, chunks, tokens int
+	for _, pair := range pairs {
+		source, output := pair[0], pair[1]
+
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", source, err)
+		}
+		frontmatter, content := extractFrontmatter(string(data))
+		if translationDisabled(frontmatter) {
+			continue
+		}
+
+		change, err := manifest.change(source, output)
+		if err != nil {
+			return fmt.Errorf("failed to compare %s: %w", source, err)
+		}
+		state := ""
+		switch change {
+		case "new":
+			state = "missing"
+		case "changed":
+			state = "stale"
+		}
+
+		if state == "" {
+			if checkOutput(cfg, content, output) {
+				issues++
+			}
+			continue
+		}
+
+		// Translation needs one request per chunk, the output is assumed
+		// to be about as long as the input
+		n := len(t.PlanChunks(content))
+		estimate := 2 * metering.EstimateTokens(content)
+		pending++
+		chunks += n
+		tokens += estimate
+		logInfo("%s: %s, %d chunks, ~%d tokens", source, state, n, estimate)
+	}
+
+	if pending > 0 {
+		logInfo("Translating them would take ~%d requests and ~%d tokens (estimated, analyses not included)", chunks, tokens)
+	}
+
+	switch {
+	case pending > 0 && issues > 0:
+		return fmt.Errorf("%d of %d translations missing or stale, %d with validation issues", pending, len(pairs), issues)
+	case pending > 0:
+		return fmt.Errorf("%d of %d translations missing or stale", pending, len(pairs))
+	case issues > 0:
+		return fmt.Errorf("%d existing translations have validation issues", issues)
+	}
+	logInfo("Check passed: all %d translations are up to date", len(pairs))
+	return nil
+}
+
+// checkOutput runs the enabled local checks on an existing translation and
+// reports whether any of them found a problem.
+func checkOutput(cfg *config.Config, source, output string) bool {
+	if strings.TrimSpace(source) == "" {
+		// Nothing was translated, see empty_files
+		return false
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		logWarn("%s: %v", output, err)
+		return true
+	}
+	_, translated := extractFrontmatter(string(data))
+
+	if strings.TrimSpace(translated) == "" {
+		logWarn("%s: translation is empty", output)
+		return true
+	}
+
+	found := false
+	if cfg.Settings.CheckNumbers {
+		if missing := validator.CheckNumbers(source, translated); len(missing) > 0 {
+			logWarn("%s: numbers missing or altered: %s", output, strings.Join(missing, ", "))
+			found = true
+		}
+	}
+	if cfg.Settings.CheckLinks {
+		if missing := validator.CheckLinks(source, translated); len(missing) > 0 {
+			logWarn("%s: links missing or altered: %s", output, strings.Join(missing, ", "))
+			found = true
+		}
+	}
+	if len(cfg.Settings.BannedTerms) > 0 {
+		if banned := validator.CheckBanned(translated, cfg.Settings.BannedTerms); len(banned) > 0 {
+			logWarn("%s: banned terms: %s", output, strings.Join(banned, ", "))
+			found = true
+		}
+	}
+	if cfg.Settings.InjectionGuard {
+		if markers := validator.CheckInjection(source, translated); len(markers) > 0 {
+			logWarn("%s: possible model reply instead of translation: %s", output, strings.Join(markers, ", "))
+			found = true
+		}
+	}
+	if cfg.Settings.CheckCaps || cfg.Settings.FixCaps {
+		if issues := validator.CheckCapitalization(translated, targetLang); len(issues) > 0 {
+			logWarn("%s: capitalization issues: %s", output, strings.Join(issues, "; "))
+			found = true
+		}
+	}
+	if cfg.StrongValidation.Enabled && sourceLang != "auto" {
+		if ok, fragments := validator.New(cfg.StrongValidation).Validate(translated, sourceLang, targetLang); !ok {
+			logWarn("%s: untranslated %s text: %s", output, sourceLang, strings.Join(fragments, ", "))
+			found = true
+		}
+	}
+	return found
+}
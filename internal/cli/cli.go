@@ -1,16 +1,31 @@
 package cli
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/foxzi/llm-translate/internal/clipboard"
 	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/dedupe"
+	"github.com/foxzi/llm-translate/internal/digest"
+	"github.com/foxzi/llm-translate/internal/logging"
+	"github.com/foxzi/llm-translate/internal/metering"
 	llmprovider "github.com/foxzi/llm-translate/internal/provider"
+	"github.com/foxzi/llm-translate/internal/readability"
+	"github.com/foxzi/llm-translate/internal/redact"
+	"github.com/foxzi/llm-translate/internal/telemetry"
 	"github.com/foxzi/llm-translate/internal/translator"
+	"github.com/foxzi/llm-translate/internal/validator"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
@@ -19,6 +34,11 @@ var (
 	Version = "dev"
 
 	inputFile      string
+	inputArg       string // text given as command arguments
+	clipboardMode  bool
+	splitOn        string
+	jsonlMode      bool
+	jsonlWorkers   int
 	outputFile     string
 	inputDir       string
 	extensions     string
@@ -35,15 +55,30 @@ var (
 	maxTokens      int
 	timeout        int
 	chunkSize      int
+	chunkConc      int
+	chunkOverlap   int
+	rateLimit      int
+	carrySentences int
+	carrySummary   bool
+	checkTerms     bool
+	analysisJSON   bool
+	analyzers      string
+	structuredOut  bool
+	embeddings     string
+	fixTerms       bool
+	trimGlossary   bool
 	contextStr     string
 	style          string
 	glossaryFile   string
 	preserveFormat bool
+	keepHTML       bool
 	strongMode     bool
 	strongRetries  int
 	verbose        bool
 	quiet          bool
 	dryRun         bool
+	diffMode       bool
+	checkMode      bool
 	proxyURL       string
 	proxyAuth      string
 	noProxy        bool
@@ -59,21 +94,96 @@ var (
 	usefulness     bool
 	timeFocus      bool
 	adDetect       bool
+	headline       bool
+	readabilityOn  bool
+	readingLevel   string
+	tone           string
+	audience       string
+	formality      string
+	readingRetries int
+	maxLen         int
+	maxLenRatio    float64
+	lengthRetries  int
+	bannedTerms    string
+	bannedRetries  int
+	protect        string
+	protectRetries int
+	checkNumbers   bool
+	redactPII      bool
+	promptFile     string
+	currencyCode   string
+	checkLinks     bool
+	injectionGuard bool
+	checkCaps      bool
+	fixCaps        bool
+	localizeNums   bool
+	localizeDates  bool
+	convertUnits   string
+	diagramLabels  bool
+	reviewDir      string
+	annotate       bool
+	bilingual      string
+	maxCost        float64
+	budgetAlerts   []float64
+	budgetPace     string
+	emptyFiles     string
+	fmFields       []string
+	dataKeyNames   []string
+	ensembleSpecs  []string
+	ensembleJudge  string
+	ensembleMerge  bool
+	profileName    string
+	domainName     string
+	domainGlossary []config.GlossaryEntry // glossary hints of the selected domain
+	digestPath     string
+	outputFormat   string
+	outputMeta     string
+	runReportPath  string
+	reportPath     string
+	alignExport    string
+	dedupeSim      float64
+	fallbackCache  string
+	otlpEndpoint   string
+	logLevel       string
+	logFormat      string
+	logFile        string
+	recordPath     string
+	replayPath     string
 )
 
 func Execute(ctx context.Context) error {
 	rootCmd := &cobra.Command{
-		Use:   "llm-translate",
+		Use:   "llm-translate [text]",
 		Short: "Translate text using LLM APIs",
 		Long:  `A CLI tool for translating text between languages using various LLM providers.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := setupLogging(cmd); err != nil {
+				return err
+			}
+			return setupCassette()
+		},
+		// Arguments are the text to translate
+		Args:                       textArgs,
+		SuggestionsMinimumDistance: 2,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			inputArg = strings.Join(args, " ")
 			return runTranslate(ctx, cmd)
 		},
 	}
 
-	rootCmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input file (default: stdin)")
-	rootCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file (default: stdout)")
-	rootCmd.Flags().StringVarP(&inputDir, "dir", "d", "", "Input directory for recursive translation")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "Log level: info, warn or error")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "Log format: text or json")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "Append logs to file instead of stderr")
+	rootCmd.PersistentFlags().StringVar(&recordPath, "record", "", "Record provider HTTP traffic to this cassette file")
+	rootCmd.PersistentFlags().StringVar(&replayPath, "replay", "", "Answer provider requests from this cassette file instead of sending them")
+
+	rootCmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input file, web page or s3://, gs://, az:// URL (default: stdin)")
+	rootCmd.Flags().BoolVar(&clipboardMode, "clipboard", false, "Translate the clipboard content and copy the translation back to the clipboard")
+	rootCmd.Flags().StringVar(&splitOn, "split-on", "", "Translate documents of the input separated by lines equal to this delimiter independently")
+	rootCmd.Flags().BoolVar(&jsonlMode, "jsonl", false, "Translate JSON records {\"id\",\"text\",\"to\"} of the input lines and write JSON results")
+	rootCmd.Flags().IntVar(&jsonlWorkers, "jsonl-concurrency", 4, "Number of records translated at once with --jsonl")
+	rootCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file or s3://, gs://, az:// URL (default: stdout)")
+	rootCmd.Flags().StringVarP(&inputDir, "dir", "d", "", "Input directory or s3://, gs://, az:// prefix for recursive translation")
 	rootCmd.Flags().StringVar(&extensions, "ext", ".md,.txt", "File extensions to translate (comma-separated)")
 	rootCmd.Flags().StringVar(&outSuffix, "suffix", "", "Output file suffix (e.g., _ru)")
 	rootCmd.Flags().StringVar(&outPrefix, "prefix", "", "Output file prefix (e.g., ru_)")
@@ -81,6 +191,8 @@ func Execute(ctx context.Context) error {
 	rootCmd.Flags().StringVarP(&targetLang, "to", "t", "en", "Target language")
 	rootCmd.Flags().StringVarP(&provider, "provider", "p", "", "LLM provider")
 	rootCmd.Flags().StringVarP(&model, "model", "m", "", "Model to use")
+	rootCmd.Flags().StringVar(&domainName, "domain", "", "Domain preset: legal, medical, software, marketing or one from config")
+	rootCmd.Flags().StringVar(&profileName, "profile", "", "Named profile from config (provider, model, style, glossary, settings)")
 	rootCmd.Flags().StringVarP(&configPath, "config", "c", "", "Config file path")
 	rootCmd.Flags().StringVarP(&apiKey, "api-key", "k", "", "API key (overrides config)")
 	rootCmd.Flags().StringVarP(&baseURL, "base-url", "u", "", "Base URL for API")
@@ -88,15 +200,32 @@ func Execute(ctx context.Context) error {
 	rootCmd.Flags().IntVar(&maxTokens, "max-tokens", 4096, "Maximum tokens in response")
 	rootCmd.Flags().IntVar(&timeout, "timeout", 60, "Request timeout in seconds")
 	rootCmd.Flags().IntVar(&chunkSize, "chunk-size", 3000, "Chunk size for long texts")
+	rootCmd.Flags().IntVar(&chunkConc, "chunk-concurrency", 1, "Number of chunks of one document translated in parallel")
+	rootCmd.Flags().IntVar(&chunkOverlap, "chunk-overlap", 0, "Sentences of the previous chunk translated again at the start of each chunk")
+	rootCmd.Flags().IntVar(&rateLimit, "rate-limit", 0, "Maximum translation requests per minute (0 = unlimited)")
+	rootCmd.Flags().IntVar(&carrySentences, "carry-sentences", 0, "Pass the last N translated sentences of a chunk as context to the next one")
+	rootCmd.Flags().BoolVar(&checkTerms, "check-terms", false, "Check that key terms are translated consistently across the document and with the glossary")
+	rootCmd.Flags().BoolVar(&fixTerms, "fix-terms", false, "Re-translate chunks with inconsistent terminology (implies --check-terms)")
+	rootCmd.Flags().BoolVar(&trimGlossary, "trim-glossary", true, "Send only the glossary terms found in each chunk")
+	rootCmd.Flags().BoolVar(&carrySummary, "carry-summary", false, "Pass a rolling summary of translated chunks as context to the next one")
 	rootCmd.Flags().StringVar(&contextStr, "context", "", "Additional context for translation")
-	rootCmd.Flags().StringVar(&style, "style", "", "Translation style: formal, informal, technical, literary")
+	rootCmd.Flags().StringVar(&style, "style", "", "Translation style: formal, informal, technical, literary or one of prompts.styles")
+	rootCmd.Flags().StringVar(&tone, "tone", "", "Tone of the translation: "+config.Choices(config.Tones))
+	rootCmd.Flags().StringVar(&audience, "audience", "", "Readers of the translation: "+config.Choices(config.Audiences))
+	rootCmd.Flags().StringVar(&formality, "formality", "", "Form of address: "+config.Choices(config.Formalities))
 	rootCmd.Flags().StringVarP(&glossaryFile, "glossary", "g", "", "Glossary file")
+	rootCmd.Flags().StringVar(&promptFile, "system-prompt-file", "", "File with system prompt template (overrides prompts.system)")
 	rootCmd.Flags().BoolVar(&preserveFormat, "preserve-format", false, "Preserve formatting (markdown, html)")
+	rootCmd.Flags().BoolVar(&keepHTML, "keep-html", false, "Translate the article of a web page input as HTML instead of Markdown")
 	rootCmd.Flags().BoolVarP(&strongMode, "strong", "s", false, "Check for absence of source language in translation")
 	rootCmd.Flags().IntVar(&strongRetries, "strong-retries", 3, "Number of retries for strong mode")
 	rootCmd.Flags().BoolVar(&verbose, "verbose", false, "Verbose output")
 	rootCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Quiet mode (only result)")
-	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show request without sending")
+	rootCmd.Flags().StringVar(&outputFormat, "format", "text", "Output format: text or json")
+	rootCmd.Flags().StringVar(&outputMeta, "output-meta", "", "Write run metadata (languages, model, tokens, analysis) as JSON to file")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the request body of each chunk without sending it")
+	rootCmd.Flags().BoolVar(&diffMode, "diff", false, "Translate only new files and sources changed since the last run; with --dry-run, preview them (directory mode)")
+	rootCmd.Flags().BoolVar(&checkMode, "check", false, "Read-only check for CI: fail if translations are missing or stale, no writes, no API calls")
 	rootCmd.Flags().StringVarP(&proxyURL, "proxy", "x", "", "Proxy server URL")
 	rootCmd.Flags().StringVar(&proxyAuth, "proxy-auth", "", "Proxy authentication (user:pass)")
 	rootCmd.Flags().BoolVar(&noProxy, "no-proxy", false, "Ignore proxy from config")
@@ -109,9 +238,58 @@ func Execute(ctx context.Context) error {
 	rootCmd.Flags().BoolVar(&sensationalism, "sensationalism", false, "Analyze sensationalism level (neutral, emotional, clickbait, manipulative)")
 	rootCmd.Flags().BoolVar(&entities, "entities", false, "Extract named entities (persons, organizations, locations, dates, amounts)")
 	rootCmd.Flags().BoolVar(&events, "events", false, "Extract key events from text")
+	rootCmd.Flags().BoolVar(&analysisJSON, "analysis-json", false, "Request combined analysis as one JSON response (JSON mode where the provider supports it)")
+	rootCmd.Flags().StringVar(&analyzers, "analyzers", "", "Comma-separated custom analyzers from config to run")
+	rootCmd.Flags().BoolVar(&structuredOut, "structured-output", false, "Use JSON schema structured output for analyses (OpenAI, Anthropic, Google)")
 	rootCmd.Flags().BoolVar(&usefulness, "usefulness", false, "Analyze content usefulness (detect useless/spam content)")
 	rootCmd.Flags().BoolVar(&timeFocus, "time-focus", false, "Analyze temporal focus (past/present/future) and detect predictions")
 	rootCmd.Flags().BoolVar(&adDetect, "ad-detect", false, "Detect advertising content (direct, native, sponsored, PR)")
+	rootCmd.Flags().BoolVar(&headline, "headline", false, "Generate translated title and description into frontmatter")
+	rootCmd.Flags().BoolVar(&readabilityOn, "readability", false, "Compute readability grade of translated text")
+	rootCmd.Flags().StringVar(&embeddings, "embeddings", "", "Compute an embedding of the translation: sidecar (<output>.embedding.json) or frontmatter")
+	rootCmd.Flags().Lookup("embeddings").NoOptDefVal = "sidecar"
+	rootCmd.Flags().StringVar(&readingLevel, "reading-level", "", "Target reading level (e.g. B1, \"8th grade\")")
+	rootCmd.Flags().IntVar(&readingRetries, "reading-retries", 2, "Number of retries when output misses the reading level")
+	rootCmd.Flags().IntVar(&maxLen, "max-len", 0, "Maximum characters per translated segment (0 = unlimited)")
+	rootCmd.Flags().Float64Var(&maxLenRatio, "max-len-ratio", 0, "Maximum translated segment length relative to source (e.g. 1.2)")
+	rootCmd.Flags().IntVar(&lengthRetries, "length-retries", 2, "Number of retries with shorten feedback when a segment is too long")
+	rootCmd.Flags().StringVar(&bannedTerms, "banned-terms", "", "Comma-separated words and phrases that must not appear in the translation")
+	rootCmd.Flags().IntVar(&bannedRetries, "banned-retries", 2, "Number of retries when the translation uses banned terms")
+	rootCmd.Flags().StringVar(&protect, "protect", "", "Comma-separated placeholder syntaxes kept unchanged: mustache, jinja, printf, shell, emoji")
+	rootCmd.Flags().IntVar(&protectRetries, "protect-retries", 2, "Number of retries when the translation loses placeholders")
+	rootCmd.Flags().BoolVar(&checkNumbers, "check-numbers", false, "Verify numbers and amounts from the source are preserved in translation")
+	rootCmd.Flags().StringVar(&runReportPath, "run-report", "", "Write JSON run report (translated, failed, pending files) in directory mode")
+	rootCmd.Flags().StringVar(&reportPath, "report", "", "Write JSON usage report (files, chunks, tokens, retries, cost) to file")
+	rootCmd.Flags().StringVar(&alignExport, "align-export", "", "Write aligned source and translation sentences to a .tsv or .jsonl file")
+	rootCmd.Flags().StringVar(&digestPath, "digest", "", "Write aggregate digest of analysis results to file (directory mode)")
+	rootCmd.Flags().Float64Var(&dedupeSim, "dedupe", 0, "Translate only one of near-duplicate sources at this similarity (0-1) in directory mode")
+	rootCmd.Flags().Lookup("dedupe").NoOptDefVal = "0.8"
+	rootCmd.Flags().StringVar(&fallbackCache, "fallback-cache", "", "File of last good chunk translations, used when a chunk fails to translate")
+	rootCmd.PersistentFlags().StringVar(&otlpEndpoint, "otlp-endpoint", "", "Export traces and metrics to this OpenTelemetry collector (OTLP/HTTP)")
+	rootCmd.Flags().BoolVar(&checkLinks, "check-links", false, "Verify cited URLs from the source are preserved in translation")
+	rootCmd.Flags().BoolVar(&injectionGuard, "injection-guard", false, "Fence document text off from instructions and flag replies instead of translations")
+	rootCmd.Flags().BoolVar(&checkCaps, "check-capitalization", false, "Check the translation follows target language capitalization conventions")
+	rootCmd.Flags().BoolVar(&fixCaps, "fix-capitalization", false, "Lowercase capitalized month and weekday names where the target language requires it (implies --check-capitalization)")
+	rootCmd.Flags().BoolVar(&localizeNums, "localize-numbers", false, "Write decimal and thousands separators of numbers from the source in target language conventions")
+	rootCmd.Flags().BoolVar(&localizeDates, "localize-dates", false, "Write numeric dates from the source in target language order and separators (e.g. DD.MM.YYYY)")
+	rootCmd.Flags().StringVar(&convertUnits, "convert-units", "", "Append converted values to measurements: metric or imperial")
+	rootCmd.Flags().BoolVar(&diagramLabels, "translate-diagrams", false, "Translate labels in mermaid and plantuml blocks, keeping keywords and IDs")
+	rootCmd.Flags().StringVar(&reviewDir, "review-dir", "", "Write translations that fail checks to this directory for review instead of their output path (directory mode)")
+	rootCmd.Flags().Lookup("review-dir").NoOptDefVal = defaultReviewDir
+	rootCmd.Flags().BoolVar(&annotate, "annotate", false, "Mark problems found by the checks with HTML comments in the output")
+	rootCmd.Flags().StringVar(&bilingual, "bilingual", "", "Write each source paragraph with its translation: inline, table or interleaved")
+	rootCmd.Flags().Lookup("bilingual").NoOptDefVal = "inline"
+	rootCmd.Flags().Float64Var(&maxCost, "max-cost", 0, "Stop translating once the estimated cost reaches this amount (0 = unlimited)")
+	rootCmd.Flags().Float64SliceVar(&budgetAlerts, "budget-alerts", nil, "Warn when the estimated cost reaches these percentages of --max-cost (e.g. 50,80)")
+	rootCmd.Flags().StringVar(&budgetPace, "budget-pace", "", "Spread --max-cost over this duration, pausing when spending runs ahead (e.g. 8h)")
+	rootCmd.Flags().StringVar(&emptyFiles, "empty-files", "fail", "Files without text to translate (empty, whitespace or TOML frontmatter only): fail, skip or copy")
+	rootCmd.Flags().StringSliceVar(&ensembleSpecs, "ensemble", nil, "Translate each chunk with these providers as provider[:model] and let a judge pick the best")
+	rootCmd.Flags().StringVar(&ensembleJudge, "ensemble-judge", "", "Provider[:model] judging the ensemble (default: default provider)")
+	rootCmd.Flags().BoolVar(&ensembleMerge, "ensemble-merge", false, "Have the ensemble judge merge the translations instead of picking one")
+	rootCmd.Flags().StringSliceVar(&dataKeyNames, "data-keys", nil, "Keys translated in YAML, JSON and TOML data files (default: "+strings.Join(defaultDataKeys, ",")+")")
+	rootCmd.Flags().StringSliceVar(&fmFields, "frontmatter-fields", nil, "Fields translated in files that are YAML frontmatter only (default: "+strings.Join(defaultFrontmatterFields, ",")+")")
+	rootCmd.Flags().BoolVar(&redactPII, "redact", false, "Mask emails, phones and card numbers before sending text to the provider")
+	rootCmd.Flags().StringVar(&currencyCode, "convert-currency", "", "Annotate amounts with converted value in this currency (rates from config)")
 	rootCmd.Flags().BoolP("help", "h", false, "Show help")
 
 	rootCmd.Version = Version
@@ -125,16 +303,42 @@ func Execute(ctx context.Context) error {
 		},
 	}
 	rootCmd.AddCommand(versionCmd)
-
-	return rootCmd.ExecuteContext(ctx)
+	rootCmd.AddCommand(newConfigCmd(rootCmd))
+	rootCmd.AddCommand(newTagsCmd())
+	rootCmd.AddCommand(newSiteCmd(rootCmd))
+	rootCmd.AddCommand(newFeedCmd(rootCmd))
+	rootCmd.AddCommand(newReplCmd(rootCmd))
+	rootCmd.AddCommand(newAnalyzeCmd(rootCmd))
+	rootCmd.AddCommand(newReviewCmd())
+	rootCmd.AddCommand(newModelsCmd())
+	rootCmd.AddCommand(newDoctorCmd())
+	rootCmd.AddCommand(newSelftestCmd())
+	rootCmd.AddCommand(newConformanceCmd())
+	rootCmd.AddCommand(newCompareCmd(rootCmd))
+	rootCmd.AddCommand(newDaemonCmd(rootCmd))
+	rootCmd.AddCommand(newServeCmd(rootCmd))
+	rootCmd.AddCommand(newSchemaCmd())
+	rootCmd.AddCommand(newLanguagesCmd())
+
+	err := rootCmd.ExecuteContext(ctx)
+
+	// Not ctx, the run may have been interrupted
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if flushErr := telemetry.Shutdown(flushCtx); flushErr != nil {
+		logWarn("Telemetry export failed: %v", flushErr)
+	}
+	return err
 }
 
 // runAnalysis performs all enabled text analyses, using a single combined LLM
 // call when 2+ analyses are requested, or individual calls for 0-1 analyses.
 // Returns a map of frontmatter key-value updates.
-func runAnalysis(ctx context.Context, t *translator.Translator, cfg *config.Config, text string, verbose bool) map[string]interface{} {
+func runAnalysis(ctx context.Context, t *translator.Translator, cfg *config.Config, text, lang string, verbose bool) map[string]interface{} {
 	fmUpdates := make(map[string]interface{})
 
+	runCustomAnalyzers(ctx, t, cfg, text, lang, fmUpdates, verbose)
+
 	// Count enabled analyses
 	enabledCount := 0
 	if cfg.Settings.Sentiment {
@@ -197,6 +401,7 @@ func runAnalysis(ctx context.Context, t *translator.Translator, cfg *config.Conf
 			Events:         cfg.Settings.Events,
 			TimeFocus:      cfg.Settings.TimeFocus,
 			AdDetect:       cfg.Settings.AdDetect,
+			JSON:           cfg.Settings.AnalysisJSON,
 		}
 
 		resp, err := t.AnalyzeCombined(ctx, req)
@@ -208,7 +413,14 @@ func runAnalysis(ctx context.Context, t *translator.Translator, cfg *config.Conf
 		} else {
 			// Unpack combined response into fmUpdates
 			mapCombinedResponse(resp, fmUpdates)
-			return fmUpdates
+			if len(resp.Failed) == 0 {
+				return fmUpdates
+			}
+			// Retry sections the model did not answer in parsable form
+			if verbose {
+				logWarn("Combined analysis could not parse: %s, retrying individually", strings.Join(resp.Failed, ", "))
+			}
+			cfg = onlyAnalyses(cfg, resp.Failed)
 		}
 	}
 
@@ -222,6 +434,7 @@ func runAnalysis(ctx context.Context, t *translator.Translator, cfg *config.Conf
 			if verbose {
 				logWarn("Sentiment analysis failed: %v", err)
 			}
+			recordRaw(fmUpdates, "sentiment", err)
 		} else {
 			fmUpdates["sentiment"] = sentimentResult.Sentiment
 			fmUpdates["sentiment_score"] = sentimentResult.Score
@@ -237,6 +450,7 @@ func runAnalysis(ctx context.Context, t *translator.Translator, cfg *config.Conf
 			if verbose {
 				logWarn("Tags extraction failed: %v", err)
 			}
+			recordRaw(fmUpdates, "tags", err)
 		} else {
 			fmUpdates["tags"] = tagsResult.Tags
 		}
@@ -251,6 +465,7 @@ func runAnalysis(ctx context.Context, t *translator.Translator, cfg *config.Conf
 			if verbose {
 				logWarn("Classification failed: %v", err)
 			}
+			recordRaw(fmUpdates, "classify", err)
 		} else {
 			if len(classifyResult.Topics) > 0 {
 				fmUpdates["topics"] = classifyResult.Topics
@@ -273,6 +488,7 @@ func runAnalysis(ctx context.Context, t *translator.Translator, cfg *config.Conf
 			if verbose {
 				logWarn("Emotions analysis failed: %v", err)
 			}
+			recordRaw(fmUpdates, "emotions", err)
 		} else {
 			if len(emotionsResult.Emotions) > 0 {
 				fmUpdates["emotions"] = emotionsResult.Emotions
@@ -289,6 +505,7 @@ func runAnalysis(ctx context.Context, t *translator.Translator, cfg *config.Conf
 			if verbose {
 				logWarn("Factuality analysis failed: %v", err)
 			}
+			recordRaw(fmUpdates, "factuality", err)
 		} else {
 			fmUpdates["factuality"] = factualityResult.Type
 			fmUpdates["factuality_confidence"] = factualityResult.Confidence
@@ -307,6 +524,7 @@ func runAnalysis(ctx context.Context, t *translator.Translator, cfg *config.Conf
 			if verbose {
 				logWarn("Impact analysis failed: %v", err)
 			}
+			recordRaw(fmUpdates, "impact", err)
 		} else {
 			if len(impactResult.Affected) > 0 {
 				fmUpdates["affected"] = impactResult.Affected
@@ -323,6 +541,7 @@ func runAnalysis(ctx context.Context, t *translator.Translator, cfg *config.Conf
 			if verbose {
 				logWarn("Sensationalism analysis failed: %v", err)
 			}
+			recordRaw(fmUpdates, "sensationalism", err)
 		} else {
 			fmUpdates["sensationalism"] = sensResult.Type
 			fmUpdates["sensationalism_confidence"] = sensResult.Confidence
@@ -341,6 +560,7 @@ func runAnalysis(ctx context.Context, t *translator.Translator, cfg *config.Conf
 			if verbose {
 				logWarn("Entities extraction failed: %v", err)
 			}
+			recordRaw(fmUpdates, "entities", err)
 		} else {
 			if len(entitiesResult.Persons) > 0 {
 				fmUpdates["persons"] = entitiesResult.Persons
@@ -369,6 +589,7 @@ func runAnalysis(ctx context.Context, t *translator.Translator, cfg *config.Conf
 			if verbose {
 				logWarn("Events extraction failed: %v", err)
 			}
+			recordRaw(fmUpdates, "events", err)
 		} else {
 			if len(eventsResult.Events) > 0 {
 				fmUpdates["events"] = eventsResult.Events
@@ -385,6 +606,7 @@ func runAnalysis(ctx context.Context, t *translator.Translator, cfg *config.Conf
 			if verbose {
 				logWarn("Usefulness analysis failed: %v", err)
 			}
+			recordRaw(fmUpdates, "usefulness", err)
 		} else {
 			fmUpdates["useful_content"] = usefulnessResult.IsUseful
 			fmUpdates["useful_confidence"] = usefulnessResult.Confidence
@@ -411,6 +633,7 @@ func runAnalysis(ctx context.Context, t *translator.Translator, cfg *config.Conf
 			if verbose {
 				logWarn("Time focus analysis failed: %v", err)
 			}
+			recordRaw(fmUpdates, "time_focus", err)
 		} else {
 			fmUpdates["time_focus"] = timeFocusResult.Focus
 			fmUpdates["time_focus_confidence"] = timeFocusResult.Confidence
@@ -430,6 +653,7 @@ func runAnalysis(ctx context.Context, t *translator.Translator, cfg *config.Conf
 			if verbose {
 				logWarn("Ad detection failed: %v", err)
 			}
+			recordRaw(fmUpdates, "ad_detect", err)
 		} else {
 			fmUpdates["ad_type"] = adResult.AdType
 			fmUpdates["ad_confidence"] = adResult.Confidence
@@ -442,6 +666,251 @@ func runAnalysis(ctx context.Context, t *translator.Translator, cfg *config.Conf
 	return fmUpdates
 }
 
+// redactForAnalysis masks sensitive values in the translated text so that
+// analysis requests do not send them to the provider either.
+func redactForAnalysis(cfg *config.Config, text string) string {
+	if !cfg.Redaction.Enabled {
+		return text
+	}
+	masked, _ := redact.New(cfg.Redaction).Redact(text)
+	return masked
+}
+
+// onlyAnalyses returns a copy of cfg with only the named analyses enabled.
+func onlyAnalyses(cfg *config.Config, names []string) *config.Config {
+	enabled := make(map[string]bool)
+	for _, name := range names {
+		enabled[name] = true
+	}
+
+	retry := *cfg
+	s := &retry.Settings
+	s.Sentiment = enabled["sentiment"]
+	if !enabled["tags"] {
+		s.TagsCount = 0
+	}
+	s.Classify = enabled["classify"]
+	s.Emotions = enabled["emotions"]
+	s.Factuality = enabled["factuality"]
+	s.Impact = enabled["impact"]
+	s.Sensationalism = enabled["sensationalism"]
+	s.Entities = enabled["entities"]
+	s.Events = enabled["events"]
+	s.Usefulness = enabled["usefulness"]
+	s.TimeFocus = enabled["time_focus"]
+	s.AdDetect = enabled["ad_detect"]
+	return &retry
+}
+
+// recordRaw stores model output that could not be parsed under <name>_raw,
+// so the data is kept for postprocessing instead of being dropped.
+func recordRaw(fmUpdates map[string]interface{}, name string, err error) {
+	var parseErr *llmprovider.ParseError
+	if errors.As(err, &parseErr) {
+		fmUpdates[name+"_raw"] = parseErr.Raw
+	}
+}
+
+// runCustomAnalyzers runs the analyzers listed in settings.run_analyzers,
+// each with its own request, and stores their values under their keys.
+// lang fills the {target_lang} placeholder of their prompts.
+func runCustomAnalyzers(ctx context.Context, t *translator.Translator, cfg *config.Config, text, lang string, fmUpdates map[string]interface{}, verbose bool) {
+	for _, name := range cfg.Settings.RunAnalyzers {
+		a, ok := cfg.Analyzers[name]
+		if !ok {
+			logWarn("Analyzer %s is not defined in config", name)
+			continue
+		}
+		key := a.Key
+		if key == "" {
+			key = name
+		}
+
+		if verbose {
+			logInfo("Running analyzer %s...", name)
+		}
+		value, err := t.RunAnalyzer(ctx, a, text, lang)
+		if err != nil {
+			if verbose {
+				logWarn("Analyzer %s failed: %v", name, err)
+			}
+			recordRaw(fmUpdates, key, err)
+			continue
+		}
+		fmUpdates[key] = value
+	}
+}
+
+// runHeadline generates a title and description for the translated text
+// and stores them in the frontmatter updates map as title/description.
+func runHeadline(ctx context.Context, t *translator.Translator, cfg *config.Config, text string, fmUpdates map[string]interface{}, verbose bool) {
+	if !cfg.Settings.Headline {
+		return
+	}
+
+	if verbose {
+		logInfo("Generating headline...")
+	}
+	headlineResult, err := t.GenerateHeadline(ctx, text)
+	if err != nil {
+		if verbose {
+			logWarn("Headline generation failed: %v", err)
+		}
+		recordRaw(fmUpdates, "headline", err)
+		return
+	}
+
+	fmUpdates["title"] = headlineResult.Title
+	if headlineResult.Description != "" {
+		fmUpdates["description"] = headlineResult.Description
+	}
+}
+
+// runEmbedding computes an embedding of the translated text for similarity
+// search downstream. In sidecar mode it is written next to outputPath as
+// <output>.embedding.json, otherwise (or when writing to stdout) it is stored
+// in the frontmatter updates map as embedding.
+func runEmbedding(ctx context.Context, t *translator.Translator, cfg *config.Config, text, outputPath string, fmUpdates map[string]interface{}, verbose bool) {
+	mode := cfg.Settings.Embeddings
+	if mode == "" {
+		return
+	}
+
+	if verbose {
+		logInfo("Computing embedding...")
+	}
+	embedding, err := t.Embed(ctx, text)
+	if err != nil {
+		logWarn("Embedding failed: %v", err)
+		return
+	}
+
+	if mode != "sidecar" || outputPath == "" {
+		fmUpdates["embedding"] = embedding.Vector
+		fmUpdates["embedding_model"] = embedding.Model
+		return
+	}
+
+	data, err := json.Marshal(embeddingSidecar{
+		Provider:   cfg.DefaultProvider,
+		Model:      embedding.Model,
+		Dimensions: len(embedding.Vector),
+		Embedding:  embedding.Vector,
+	})
+	if err != nil {
+		logWarn("Failed to encode embedding: %v", err)
+		return
+	}
+	if err := os.WriteFile(outputPath+".embedding.json", append(data, '\n'), 0644); err != nil {
+		logWarn("Failed to write embedding: %v", err)
+	}
+}
+
+type embeddingSidecar struct {
+	Provider   string    `json:"provider"`
+	Model      string    `json:"model"`
+	Dimensions int       `json:"dimensions"`
+	Embedding  []float64 `json:"embedding"`
+}
+
+// runReadability stores the readability grade of the translated text
+// in the frontmatter updates map. Computed locally, no LLM call.
+func runReadability(cfg *config.Config, text, lang string, fmUpdates map[string]interface{}) {
+	if !cfg.Settings.Readability {
+		return
+	}
+
+	score := readability.Analyze(text, lang)
+	if score.Words == 0 {
+		return
+	}
+
+	fmUpdates["readability_grade"] = score.Grade
+	if cfg.Settings.ReadingLevel != "" {
+		fmUpdates["reading_level"] = cfg.Settings.ReadingLevel
+	}
+}
+
+// runNumberCheck flags numbers, percentages and amounts from the source
+// that are missing or altered in the translation.
+func runNumberCheck(cfg *config.Config, source, translated string, fmUpdates map[string]interface{}) {
+	if !cfg.Settings.CheckNumbers {
+		return
+	}
+
+	missing := validator.CheckNumbers(source, translated)
+	if len(missing) == 0 {
+		return
+	}
+
+	logWarn("Numbers missing or altered in translation: %s", strings.Join(missing, ", "))
+	fmUpdates["numeric_issues"] = missing
+}
+
+// runInjectionCheck flags translations that look like the model answered
+// or obeyed the document instead of translating it.
+func runInjectionCheck(cfg *config.Config, source, translated string, fmUpdates map[string]interface{}) {
+	if !cfg.Settings.InjectionGuard {
+		return
+	}
+
+	markers := validator.CheckInjection(source, translated)
+	if len(markers) == 0 {
+		return
+	}
+
+	logWarn("Translation may contain a model reply instead of text, found: %s", strings.Join(markers, ", "))
+	fmUpdates["injection_suspected"] = markers
+}
+
+// runCapitalizationCheck flags capitalization that breaks the conventions
+// of the target language and was not fixed automatically.
+func runCapitalizationCheck(cfg *config.Config, targetLang, translated string, fmUpdates map[string]interface{}) {
+	if !cfg.Settings.CheckCaps && !cfg.Settings.FixCaps {
+		return
+	}
+
+	issues := validator.CheckCapitalization(translated, targetLang)
+	if len(issues) == 0 {
+		return
+	}
+
+	logWarn("Capitalization issues: %s", strings.Join(issues, "; "))
+	fmUpdates["capitalization_issues"] = issues
+}
+
+// runTermCheck reports terms that stayed inconsistent after translation.
+func runTermCheck(result translator.TranslateResponse, fmUpdates map[string]interface{}) {
+	if len(result.TermIssues) == 0 {
+		return
+	}
+
+	issues := make([]string, len(result.TermIssues))
+	for i, issue := range result.TermIssues {
+		issues[i] = issue.String()
+	}
+
+	logWarn("Inconsistent terminology: %s", strings.Join(issues, "; "))
+	fmUpdates["term_issues"] = issues
+}
+
+// runLinkCheck lists cited URLs from the source that were dropped in the
+// translation. Runs with --check-links and along with factuality analysis,
+// where lost sources make claims look unsourced.
+func runLinkCheck(cfg *config.Config, source, translated string, fmUpdates map[string]interface{}) {
+	if !cfg.Settings.CheckLinks && !cfg.Settings.Factuality {
+		return
+	}
+
+	missing := validator.CheckLinks(source, translated)
+	if len(missing) == 0 {
+		return
+	}
+
+	logWarn("Links missing or altered in translation: %s", strings.Join(missing, ", "))
+	fmUpdates["missing_links"] = missing
+}
+
 // mapCombinedResponse unpacks a CombinedAnalysisResponse into the frontmatter updates map.
 func mapCombinedResponse(resp llmprovider.CombinedAnalysisResponse, fmUpdates map[string]interface{}) {
 	if resp.Sentiment != nil {
@@ -545,46 +1014,207 @@ func mapCombinedResponse(resp llmprovider.CombinedAnalysisResponse, fmUpdates ma
 	}
 }
 
-func runTranslate(ctx context.Context, cmd *cobra.Command) error {
+// outputDocument is the result written with --format json and, without
+// the text, to the --output-meta file. Only this document goes to stdout
+// in JSON mode; all logs go to stderr.
+type outputDocument struct {
+	Text        string                     `json:"text,omitempty"`
+	Frontmatter string                     `json:"frontmatter,omitempty"`
+	SourceLang  string                     `json:"source_lang"`
+	TargetLang  string                     `json:"target_lang"`
+	Provider    string                     `json:"provider"`
+	Model       string                     `json:"model"`
+	TokensUsed  int                        `json:"tokens_used"`
+	Usage       []metering.Usage           `json:"usage,omitempty"`
+	StaleChunks int                        `json:"stale_chunks,omitempty"`
+	Providers   []metering.ProviderHealth  `json:"providers,omitempty"` // request latency and errors
+	Responses   []translator.ChunkResponse `json:"responses,omitempty"` // provider response IDs per chunk
+	Metadata    map[string]interface{}     `json:"metadata,omitempty"`
+}
+
+// loadConfig builds the effective configuration: config file and
+// environment, then the domain preset, then the selected profile, then
+// command-line flags.
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
 	cfg, err := config.Load(configPath)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if domainName != "" {
+		domain, err := cfg.ApplyDomain(domainName)
+		if err != nil {
+			return nil, err
+		}
+		domainGlossary = domain.Glossary
+		if !cmd.Flags().Changed("style") && strings.TrimSpace(domain.Prompt) != "" {
+			style = domainName
+		}
+	}
+
+	if profileName != "" {
+		profile, err := cfg.ApplyProfile(profileName)
+		if err != nil {
+			return nil, err
+		}
+		applyProfileFlags(cmd, profile)
+	}
+
+	sourceLang, targetLang, err = normalizeLangs(sourceLang, targetLang)
+	if err != nil {
+		return nil, err
 	}
 
 	applyCLIOverrides(cmd, cfg)
+	telemetry.Setup(cfg.Settings.OTLPEndpoint)
+
+	if _, ok := cfg.Prompts.Styles[style]; style != "" && !ok {
+		return nil, fmt.Errorf("unknown style %s (use %s)", style, config.Choices(cfg.Prompts.Styles))
+	}
+	if err := cfg.Settings.CheckVoice(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Settings.BudgetPace != "" {
+		if _, err := time.ParseDuration(cfg.Settings.BudgetPace); err != nil {
+			return nil, fmt.Errorf("invalid budget pace %q: use a duration like 8h", cfg.Settings.BudgetPace)
+		}
+	}
+
+	// Per-request options read the flag variables, pick up values set by
+	// the config, domain or profile
+	temperature = cfg.Settings.Temperature
+	strongMode = cfg.StrongValidation.Enabled
+	strongRetries = cfg.StrongValidation.MaxRetries
+
+	if promptFile != "" {
+		data, err := os.ReadFile(promptFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read system prompt file: %w", err)
+		}
+		cfg.Prompts.System = string(data)
+		// The file is given for this run, it wins over per-provider prompts
+		cfg.Prompts.SystemOverride = nil
+	}
+
+	return cfg, nil
+}
+
+func runTranslate(ctx context.Context, cmd *cobra.Command) (err error) {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	if outputFormat != "text" && outputFormat != "json" {
+		return fmt.Errorf("invalid format %q: use text or json", outputFormat)
+	}
+
+	switch cfg.Settings.Embeddings {
+	case "", "sidecar", "frontmatter":
+	default:
+		return fmt.Errorf("invalid embeddings mode %q: use sidecar or frontmatter", cfg.Settings.Embeddings)
+	}
+
+	switch cfg.Settings.EmptyFiles {
+	case "", "fail", "skip", "copy":
+	default:
+		return fmt.Errorf("invalid empty files policy %q: use fail, skip or copy", cfg.Settings.EmptyFiles)
+	}
+
+	switch cfg.Settings.Bilingual {
+	case "", "inline", "table", "interleaved":
+	default:
+		return fmt.Errorf("invalid bilingual layout %q: use inline, table or interleaved", cfg.Settings.Bilingual)
+	}
+
+	switch cfg.Settings.ConvertUnits {
+	case "", "metric", "imperial":
+	default:
+		return fmt.Errorf("invalid unit system %q: use metric or imperial", cfg.Settings.ConvertUnits)
+	}
+
+	for _, name := range cfg.Settings.Protect {
+		if _, ok := config.PlaceholderSyntaxes[name]; !ok {
+			return fmt.Errorf("invalid placeholder syntax %q: use mustache, jinja, printf, shell or emoji", name)
+		}
+	}
+
+	if len(cfg.Ensemble.Members) == 1 {
+		return fmt.Errorf("ensemble needs at least two providers")
+	}
+
+	if inputArg != "" && (inputFile != "" || inputDir != "") {
+		return fmt.Errorf("text arguments cannot be combined with --input or --dir")
+	}
+	if clipboardMode && (inputArg != "" || inputFile != "" || inputDir != "" || outputFile != "" || jsonlMode) {
+		return fmt.Errorf("--clipboard cannot be combined with text arguments, --input, --dir, --output or --jsonl")
+	}
+
+	remote, err := stageRemote(ctx)
+	if err != nil {
+		return err
+	}
+	if remote != nil {
+		defer func() { err = remote.finish(ctx, err) }()
+	}
+
+	if alignExport != "" && !checkMode {
+		if err := openAlignExport(); err != nil {
+			return err
+		}
+		defer alignFile.Close()
+	}
+
+	if checkMode {
+		// A failed check is a result, not a usage error
+		cmd.SilenceUsage = true
+		return runCheck(cfg)
+	}
 
 	// Directory mode
 	if inputDir != "" {
 		return runDirectoryTranslate(ctx, cfg)
 	}
 
-	var input io.Reader = os.Stdin
-	if inputFile != "" {
-		file, err := os.Open(inputFile)
-		if err != nil {
-			return fmt.Errorf("failed to open input file: %w", err)
-		}
-		defer file.Close()
-		input = file
-	} else {
-		// Check if stdin is a terminal (no piped input)
-		stat, _ := os.Stdin.Stat()
-		if (stat.Mode() & os.ModeCharDevice) != 0 {
-			return fmt.Errorf("no input provided. Use -i <file>, -d <dir> or pipe text to stdin")
+	if jsonlMode {
+		if inputArg != "" || splitOn != "" || dryRun {
+			return fmt.Errorf("--jsonl cannot be combined with text arguments, --split-on or --dry-run")
 		}
+		// Failed records are reported in the output, not usage errors
+		cmd.SilenceUsage = true
+		return runJSONL(ctx, cfg)
 	}
 
-	inputText, err := io.ReadAll(input)
+	var inputText string
+	if isWebURL(inputFile) {
+		inputText, err = fetchArticle(ctx, cfg, inputFile)
+		// The page structure is kept in the translation
+		preserveFormat = true
+	} else {
+		inputText, err = readInput()
+	}
 	if err != nil {
-		return fmt.Errorf("failed to read input: %w", err)
+		return err
 	}
 
-	if len(inputText) == 0 {
-		return fmt.Errorf("input is empty")
+	if splitOn != "" {
+		if dryRun {
+			return fmt.Errorf("--split-on cannot be combined with --dry-run")
+		}
+		return runSplitTranslate(ctx, cfg, inputText)
 	}
 
 	// Extract frontmatter if present
-	frontmatter, content := extractFrontmatter(string(inputText))
+	frontmatter, content := extractFrontmatter(inputText)
+	if translationDisabled(frontmatter) {
+		if dryRun {
+			logInfo("translate: false in frontmatter, nothing would be sent")
+			return nil
+		}
+		logInfo("translate: false in frontmatter, input copied unchanged")
+		return writeOutput(inputText)
+	}
 
 	if verbose {
 		logInfo("Provider: %s, Model: %s", cfg.DefaultProvider, getModelForProvider(cfg))
@@ -595,19 +1225,20 @@ func runTranslate(ctx context.Context, cmd *cobra.Command) error {
 		}
 	}
 
-	if dryRun {
-		logInfo("Dry run mode - showing request configuration:")
-		logInfo("Provider: %s", cfg.DefaultProvider)
-		logInfo("Model: %s", getModelForProvider(cfg))
-		logInfo("Source: %s -> Target: %s", sourceLang, targetLang)
-		logInfo("Temperature: %.2f", temperature)
-		logInfo("Max tokens: %d", maxTokens)
-		logInfo("Text preview (first 200 chars): %s", truncateText(content, 200))
+	t := translator.New(cfg, verbose)
+
+	if strings.TrimSpace(content) == "" && strings.HasPrefix(frontmatter, "---") && !dryRun {
+		translated, err := translateFrontmatterOnly(ctx, t, cfg, frontmatter)
+		if err != nil {
+			return fmt.Errorf("translation failed: %w", err)
+		}
+		if err := writeOutput(translated); err != nil {
+			return err
+		}
+		recordFileTranslation(remote)
 		return nil
 	}
 
-	t := translator.New(cfg, verbose)
-
 	req := translator.TranslateRequest{
 		Text:           content,
 		SourceLang:     sourceLang,
@@ -619,23 +1250,50 @@ func runTranslate(ctx context.Context, cmd *cobra.Command) error {
 		PreserveFormat: preserveFormat,
 		StrongMode:     strongMode,
 		StrongRetries:  strongRetries,
+		ReadingLevel:   cfg.Settings.ReadingLevel,
+		ReadingRetries: cfg.Settings.ReadingRetries,
+		MaxLength:      cfg.Settings.MaxLength,
+		MaxLenRatio:    cfg.Settings.MaxLenRatio,
+		LengthRetries:  cfg.Settings.LengthRetries,
 	}
 
-	if glossaryFile != "" {
-		glossary, err := loadGlossary(glossaryFile)
-		if err != nil {
-			return fmt.Errorf("failed to load glossary: %w", err)
-		}
-		req.Glossary = glossary
+	glossary, err := loadRunGlossary()
+	if err != nil {
+		return err
+	}
+	req.Glossary = glossary
+
+	if dryRun {
+		logInfo("Dry run mode - showing request configuration:")
+		logInfo("Provider: %s", cfg.DefaultProvider)
+		logInfo("Model: %s", getModelForProvider(cfg))
+		logInfo("Source: %s -> Target: %s", sourceLang, targetLang)
+		logInfo("Temperature: %.2f", temperature)
+		logInfo("Max tokens: %d", maxTokens)
+		return printDryRun(ctx, t, req)
+	}
+
+	reportName := inputFile
+	if reportName == "" {
+		reportName = "stdin"
 	}
+	report := newUsageReport(cfg)
+	defer report.write(cfg, t)
 
+	ctx, span := telemetry.Start(ctx, "translate_file", telemetry.String("file", inputFile), telemetry.String("target_lang", targetLang))
 	result, err := t.Translate(ctx, req)
 	if err != nil {
+		span.End(err)
+		report.addFile(t, reportName, 0, report.StartedAt, translator.TranslateResponse{}, err)
 		return fmt.Errorf("translation failed: %w", err)
 	}
+	if result.StaleChunks > 0 {
+		logWarn("%d chunks failed to translate, used cached translations", result.StaleChunks)
+	}
 
-	// Run all enabled analyses (combined or individual)
-	fmUpdates := runAnalysis(ctx, t, cfg, result.Text, verbose)
+	fmUpdates := analyzeTranslation(ctx, t, cfg, content, result, targetLang, outputFile)
+	span.End(nil)
+	report.addFile(t, reportName, 0, report.StartedAt, result, nil)
 
 	// Update frontmatter with analysis results if any
 	if len(fmUpdates) > 0 {
@@ -643,48 +1301,235 @@ func runTranslate(ctx context.Context, cmd *cobra.Command) error {
 	}
 
 	// Combine frontmatter with translated content
-	finalOutput := frontmatter + result.Text
-
-	// Write to output file or stdout
-	if outputFile != "" {
-		if err := os.WriteFile(outputFile, []byte(finalOutput), 0644); err != nil {
-			return fmt.Errorf("failed to write output file: %w", err)
+	finalOutput := outputText(cfg, frontmatter, content, result, fmUpdates)
+	exportAlignment(reportName, content, result)
+
+	detectedLang := sourceLang
+	if result.DetectedLang != "" {
+		detectedLang = result.DetectedLang
+	}
+
+	usage := t.Meter().Total()
+	meta := outputDocument{
+		Frontmatter: frontmatter,
+		SourceLang:  detectedLang,
+		TargetLang:  targetLang,
+		Provider:    cfg.DefaultProvider,
+		Model:       getModelForProvider(cfg),
+		TokensUsed:  usage.Tokens(),
+		Usage:       t.Meter().Snapshot(),
+		StaleChunks: result.StaleChunks,
+		Providers:   t.Health().Snapshot(),
+		Responses:   result.Responses,
+		Metadata:    fmUpdates,
+	}
+
+	if outputMeta != "" {
+		data, err := json.MarshalIndent(meta, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode metadata: %w", err)
 		}
-	} else {
-		if _, err := os.Stdout.Write([]byte(finalOutput)); err != nil {
-			return fmt.Errorf("failed to write output: %w", err)
+		if err := os.WriteFile(outputMeta, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write metadata file: %w", err)
+		}
+	}
+
+	if outputFormat == "json" {
+		meta.Text = result.Text
+		data, err := json.MarshalIndent(meta, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
 		}
+		finalOutput = string(data) + "\n"
+	}
+
+	if err := writeOutput(finalOutput); err != nil {
+		return err
 	}
+	recordFileTranslation(remote)
 
 	if verbose {
 		logInfo("Translation complete. Output: %d characters", len(result.Text))
-		if result.TokensUsed > 0 {
-			logInfo("Tokens used: %d", result.TokensUsed)
-		}
+		logUsage(usage)
+		logHealth(t.Health().Snapshot())
 	}
 
 	return nil
 }
 
-func applyCLIOverrides(cmd *cobra.Command, cfg *config.Config) {
-	changed := func(name string) bool {
-		return cmd.Flags().Changed(name)
-	}
+// analyzeTranslation runs the enabled analyses and checks on the
+// translation of content into lang and returns their frontmatter fields.
+// outputPath places the embedding sidecar.
+func analyzeTranslation(ctx context.Context, t *translator.Translator, cfg *config.Config, content string, result translator.TranslateResponse, lang, outputPath string) map[string]interface{} {
+	analysisText := redactForAnalysis(cfg, result.Text)
+	fmUpdates := runAnalysis(ctx, t, cfg, analysisText, lang, verbose)
+	runHeadline(ctx, t, cfg, analysisText, fmUpdates, verbose)
+	runReadability(cfg, result.Text, lang, fmUpdates)
+	runNumberCheck(cfg, content, result.Text, fmUpdates)
+	runInjectionCheck(cfg, content, result.Text, fmUpdates)
+	runCapitalizationCheck(cfg, lang, result.Text, fmUpdates)
+	runLinkCheck(cfg, content, result.Text, fmUpdates)
+	runTermCheck(result, fmUpdates)
+	runEmbedding(ctx, t, cfg, analysisText, outputPath, fmUpdates, verbose)
+	return fmUpdates
+}
 
-	if changed("to") {
-		cfg.DefaultTargetLanguage = targetLang
+// textArgs accepts the text to translate. When its first word is close to
+// a command name, the command is suggested in case it was mistyped. Text
+// after "--" is taken as is.
+func textArgs(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 || cmd.ArgsLenAtDash() == 0 {
+		return nil
 	}
-
-	if changed("provider") {
-		cfg.DefaultProvider = provider
+	for _, name := range cmd.SuggestionsFor(args[0]) {
+		// Suggestions include commands the word is a prefix of
+		if diff := len(name) - len(args[0]); name != "help" && diff >= -2 && diff <= 2 {
+			logWarn("Translating %q as text, did you mean the %q command? Put -- before text to silence this", args[0], name)
+			return nil
+		}
 	}
+	return nil
+}
 
-	if changed("temperature") {
-		cfg.Settings.Temperature = temperature
+// writeOutput writes text to the output file, or stdout when none is given.
+// With --clipboard, text is copied to the clipboard and a preview printed.
+func writeOutput(text string) error {
+	if clipboardMode {
+		if err := clipboard.Write(text); err != nil {
+			return fmt.Errorf("failed to write clipboard: %w", err)
+		}
+		fmt.Println(clipboardPreview(text))
+		logInfo("Copied %d characters to the clipboard", utf8.RuneCountInString(text))
+		return nil
+	}
+	if outputFile != "" {
+		if err := os.WriteFile(outputFile, []byte(text), 0644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		return nil
 	}
+	if _, err := os.Stdout.Write([]byte(text)); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
 
-	if changed("max-tokens") {
-		cfg.Settings.MaxTokens = maxTokens
+// clipboardPreview returns the start of text on one line.
+func clipboardPreview(text string) string {
+	preview := strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(preview) > 120 {
+		preview = string([]rune(preview)[:120]) + "..."
+	}
+	return preview
+}
+
+func logUsage(usage metering.Usage) {
+	if usage.Tokens() == 0 {
+		return
+	}
+	estimated := ""
+	if usage.Estimated {
+		estimated = ", estimated"
+	}
+	logInfo("Tokens used: %d (input %d, output %d%s) in %d requests",
+		usage.Tokens(), usage.InputTokens, usage.OutputTokens, estimated, usage.Requests)
+}
+
+// logHealth prints request count, error rate and latency per provider.
+func logHealth(stats []metering.ProviderHealth) {
+	for _, s := range stats {
+		logInfo("Provider %s: %d requests, %d failed, latency %dms average, %dms recent",
+			s.Provider, s.Requests, s.Errors, s.AvgLatencyMs, s.RecentLatencyMs)
+	}
+}
+
+// readInput reads the input file, or stdin when no file is given.
+func readInput() (string, error) {
+	if inputArg != "" {
+		return inputArg + "\n", nil
+	}
+	if clipboardMode {
+		text, err := clipboard.Read()
+		if err != nil {
+			return "", fmt.Errorf("failed to read clipboard: %w", err)
+		}
+		if strings.TrimSpace(text) == "" {
+			return "", fmt.Errorf("clipboard is empty")
+		}
+		return text, nil
+	}
+
+	var input io.Reader = os.Stdin
+	if inputFile != "" {
+		file, err := os.Open(inputFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to open input file: %w", err)
+		}
+		defer file.Close()
+		input = file
+	} else {
+		// Check if stdin is a terminal (no piped input)
+		stat, _ := os.Stdin.Stat()
+		if (stat.Mode() & os.ModeCharDevice) != 0 {
+			return "", fmt.Errorf("no input provided. Use -i <file>, -d <dir>, pass the text as an argument or pipe text to stdin")
+		}
+	}
+
+	inputText, err := io.ReadAll(input)
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+
+	if len(inputText) == 0 {
+		return "", fmt.Errorf("input is empty")
+	}
+
+	return string(inputText), nil
+}
+
+// applyProfileFlags fills per-run options from a profile unless they were
+// given explicitly on the command line.
+func applyProfileFlags(cmd *cobra.Command, profile *config.Profile) {
+	changed := func(name string) bool {
+		return cmd.Flags().Changed(name)
+	}
+
+	if profile.TargetLang != "" && !changed("to") {
+		targetLang = profile.TargetLang
+	}
+
+	if profile.Style != "" && !changed("style") {
+		style = profile.Style
+	}
+
+	if profile.Glossary != "" && !changed("glossary") {
+		glossaryFile = profile.Glossary
+	}
+
+	if profile.Context != "" && !changed("context") {
+		contextStr = profile.Context
+	}
+}
+
+func applyCLIOverrides(cmd *cobra.Command, cfg *config.Config) {
+	changed := func(name string) bool {
+		return cmd.Flags().Changed(name)
+	}
+
+	if changed("to") {
+		cfg.DefaultTargetLanguage = targetLang
+	}
+
+	if changed("provider") {
+		cfg.DefaultProvider = provider
+	}
+
+	if changed("temperature") {
+		cfg.Settings.Temperature = temperature
+	}
+
+	if changed("max-tokens") {
+		cfg.Settings.MaxTokens = maxTokens
 	}
 
 	if changed("timeout") {
@@ -695,6 +1540,38 @@ func applyCLIOverrides(cmd *cobra.Command, cfg *config.Config) {
 		cfg.Settings.ChunkSize = chunkSize
 	}
 
+	if changed("chunk-concurrency") {
+		cfg.Settings.ChunkConcurrency = chunkConc
+	}
+
+	if changed("chunk-overlap") {
+		cfg.Settings.ChunkOverlap = chunkOverlap
+	}
+
+	if changed("rate-limit") {
+		cfg.Settings.RateLimit = rateLimit
+	}
+
+	if changed("check-terms") {
+		cfg.Settings.CheckTerms = checkTerms
+	}
+
+	if changed("fix-terms") {
+		cfg.Settings.FixTerms = fixTerms
+	}
+
+	if changed("trim-glossary") {
+		cfg.Settings.TrimGlossary = trimGlossary
+	}
+
+	if changed("carry-sentences") {
+		cfg.Settings.CarrySentences = carrySentences
+	}
+
+	if changed("carry-summary") {
+		cfg.Settings.CarrySummary = carrySummary
+	}
+
 	if changed("preserve-format") {
 		cfg.Settings.PreserveFormat = preserveFormat
 	}
@@ -707,6 +1584,18 @@ func applyCLIOverrides(cmd *cobra.Command, cfg *config.Config) {
 		cfg.StrongValidation.MaxRetries = strongRetries
 	}
 
+	if changed("check-links") {
+		cfg.Settings.CheckLinks = checkLinks
+	}
+
+	if changed("redact") {
+		cfg.Redaction.Enabled = redactPII
+	}
+
+	if changed("convert-currency") {
+		cfg.Currency.Target = currencyCode
+	}
+
 	if changed("proxy") {
 		cfg.Proxy.URL = proxyURL
 	}
@@ -763,6 +1652,170 @@ func applyCLIOverrides(cmd *cobra.Command, cfg *config.Config) {
 		cfg.Settings.AdDetect = adDetect
 	}
 
+	if changed("analysis-json") {
+		cfg.Settings.AnalysisJSON = analysisJSON
+	}
+
+	if changed("analyzers") {
+		cfg.Settings.RunAnalyzers = nil
+		for _, name := range strings.Split(analyzers, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				cfg.Settings.RunAnalyzers = append(cfg.Settings.RunAnalyzers, name)
+			}
+		}
+	}
+
+	if changed("structured-output") {
+		cfg.Settings.StructuredOutput = structuredOut
+	}
+
+	if changed("headline") {
+		cfg.Settings.Headline = headline
+	}
+
+	if changed("readability") {
+		cfg.Settings.Readability = readabilityOn
+	}
+
+	if changed("embeddings") {
+		cfg.Settings.Embeddings = embeddings
+	}
+
+	if changed("dedupe") {
+		cfg.Settings.Dedupe = dedupeSim
+	}
+
+	if changed("fallback-cache") {
+		cfg.Settings.FallbackCache = fallbackCache
+	}
+	if changed("otlp-endpoint") {
+		cfg.Settings.OTLPEndpoint = otlpEndpoint
+	}
+
+	if changed("reading-level") {
+		cfg.Settings.ReadingLevel = readingLevel
+	}
+	if changed("tone") {
+		cfg.Settings.Tone = tone
+	}
+	if changed("audience") {
+		cfg.Settings.Audience = audience
+	}
+	if changed("formality") {
+		cfg.Settings.Formality = formality
+	}
+
+	if changed("reading-retries") {
+		cfg.Settings.ReadingRetries = readingRetries
+	}
+
+	if changed("max-len") {
+		cfg.Settings.MaxLength = maxLen
+	}
+
+	if changed("max-len-ratio") {
+		cfg.Settings.MaxLenRatio = maxLenRatio
+	}
+
+	if changed("length-retries") {
+		cfg.Settings.LengthRetries = lengthRetries
+	}
+
+	if changed("banned-terms") {
+		for _, term := range strings.Split(bannedTerms, ",") {
+			if term = strings.TrimSpace(term); term != "" {
+				cfg.Settings.BannedTerms = append(cfg.Settings.BannedTerms, term)
+			}
+		}
+	}
+
+	if changed("banned-retries") {
+		cfg.Settings.BannedRetries = bannedRetries
+	}
+
+	if changed("protect") {
+		cfg.Settings.Protect = nil
+		for _, name := range strings.Split(protect, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				cfg.Settings.Protect = append(cfg.Settings.Protect, name)
+			}
+		}
+	}
+
+	if changed("protect-retries") {
+		cfg.Settings.ProtectRetries = protectRetries
+	}
+
+	if changed("check-numbers") {
+		cfg.Settings.CheckNumbers = checkNumbers
+	}
+	if changed("injection-guard") {
+		cfg.Settings.InjectionGuard = injectionGuard
+	}
+	if changed("check-capitalization") {
+		cfg.Settings.CheckCaps = checkCaps
+	}
+	if changed("fix-capitalization") {
+		cfg.Settings.FixCaps = fixCaps
+	}
+	if changed("localize-numbers") {
+		cfg.Settings.LocalizeNumbers = localizeNums
+	}
+	if changed("localize-dates") {
+		cfg.Settings.LocalizeDates = localizeDates
+	}
+	if changed("convert-units") {
+		cfg.Settings.ConvertUnits = convertUnits
+	}
+	if changed("translate-diagrams") {
+		cfg.Settings.Diagrams = diagramLabels
+	}
+	if changed("review-dir") {
+		cfg.Settings.ReviewDir = reviewDir
+	}
+	if changed("annotate") {
+		cfg.Settings.Annotate = annotate
+	}
+	if changed("bilingual") {
+		cfg.Settings.Bilingual = bilingual
+	}
+
+	if changed("max-cost") {
+		cfg.Settings.MaxCost = maxCost
+	}
+
+	if changed("budget-alerts") {
+		cfg.Settings.BudgetAlerts = budgetAlerts
+	}
+
+	if changed("budget-pace") {
+		cfg.Settings.BudgetPace = budgetPace
+	}
+
+	if changed("empty-files") {
+		cfg.Settings.EmptyFiles = emptyFiles
+	}
+
+	if changed("frontmatter-fields") {
+		cfg.Settings.FrontmatterFields = fmFields
+	}
+
+	if changed("data-keys") {
+		cfg.Settings.DataKeys = dataKeyNames
+	}
+
+	if changed("ensemble") {
+		cfg.Ensemble.Members = ensembleSpecs
+	}
+
+	if changed("ensemble-judge") {
+		cfg.Ensemble.Judge = ensembleJudge
+	}
+
+	if changed("ensemble-merge") {
+		cfg.Ensemble.Merge = ensembleMerge
+	}
+
 	providerCfg, ok := cfg.Providers[cfg.DefaultProvider]
 	if !ok {
 		providerCfg = config.ProviderConfig{}
@@ -790,6 +1843,20 @@ func getModelForProvider(cfg *config.Config) string {
 	return "default"
 }
 
+// loadRunGlossary loads the --glossary file and adds the glossary hints of
+// the selected domain.
+func loadRunGlossary() ([]config.GlossaryEntry, error) {
+	var glossary []config.GlossaryEntry
+	if glossaryFile != "" {
+		terms, err := loadGlossary(glossaryFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load glossary: %w", err)
+		}
+		glossary = terms
+	}
+	return append(glossary, domainGlossary...), nil
+}
+
 func loadGlossary(path string) ([]config.GlossaryEntry, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -807,31 +1874,66 @@ func loadGlossary(path string) ([]config.GlossaryEntry, error) {
 	return glossaryFile.Terms, nil
 }
 
-func truncateText(text string, maxLen int) string {
-	runes := []rune(text)
-	if len(runes) <= maxLen {
-		return text
+// printDryRun writes the request body each chunk would be sent with,
+// pretty-printed, to stdout instead of translating.
+func printDryRun(ctx context.Context, t *translator.Translator, req translator.TranslateRequest) error {
+	chunks, err := t.DryRun(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, chunk := range chunks {
+		fmt.Fprintf(&b, "=== Chunk %d/%d (%d chars) ===\n", chunk.Chunk, len(chunks), len(chunk.Text))
+		if chunk.Payload == nil {
+			// CLI providers build their prompt from these fields
+			data, err := json.MarshalIndent(chunk.Request, "", "  ")
+			if err != nil {
+				return err
+			}
+			b.WriteString("(local command, no HTTP request; request fields:)\n")
+			b.Write(data)
+			b.WriteString("\n\n")
+			continue
+		}
+
+		fmt.Fprintf(&b, "%s %s\n", chunk.Payload.Method, chunk.Payload.URL)
+		var body bytes.Buffer
+		if err := json.Indent(&body, chunk.Payload.Body, "", "  "); err != nil {
+			body.Reset()
+			body.Write(chunk.Payload.Body)
+		}
+		b.Write(body.Bytes())
+		b.WriteString("\n\n")
 	}
-	return string(runes[:maxLen]) + "..."
+	// Not the output: the file, clipboard or upload keep the translation
+	if _, err := os.Stdout.WriteString(b.String()); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
 }
 
-// extractFrontmatter extracts YAML frontmatter from markdown content.
+// extractFrontmatter extracts YAML (---) or TOML (+++) frontmatter from markdown content.
 // Returns frontmatter (with delimiters) and remaining content.
 // If no frontmatter found, returns empty string and original content.
 func extractFrontmatter(text string) (string, string) {
-	if !strings.HasPrefix(text, "---") {
+	// YAML (---) or TOML (+++, used by Hugo)
+	delim := "---"
+	if strings.HasPrefix(text, "+++") {
+		delim = "+++"
+	} else if !strings.HasPrefix(text, "---") {
 		return "", text
 	}
 
-	// Find the closing ---
+	// Find the closing delimiter
 	rest := text[3:]
-	idx := strings.Index(rest, "\n---")
+	idx := strings.Index(rest, "\n"+delim)
 	if idx == -1 {
 		return "", text
 	}
 
-	// Include the closing --- and newline
-	endIdx := 3 + idx + 4 // "---" + content + "\n---"
+	// Include the closing delimiter and newline
+	endIdx := 3 + idx + 4 // delimiter + content + "\n" + delimiter
 
 	// Check if there's a newline after closing ---
 	if endIdx < len(text) && text[endIdx] == '\n' {
@@ -844,6 +1946,13 @@ func extractFrontmatter(text string) (string, string) {
 	return frontmatter, content
 }
 
+// translationDisabled reports whether frontmatter has translate: false,
+// which keeps the document out of translation.
+func translationDisabled(frontmatter string) bool {
+	value, ok := parseFrontmatter(frontmatter)["translate"].(bool)
+	return ok && !value
+}
+
 // parseFrontmatter parses frontmatter string into a map.
 // Returns nil if parsing fails.
 func parseFrontmatter(frontmatter string) map[string]interface{} {
@@ -887,6 +1996,12 @@ func buildFrontmatter(data map[string]interface{}) string {
 // updateFrontmatter adds or updates fields in frontmatter.
 // If frontmatter is empty, creates a new one.
 func updateFrontmatter(frontmatter string, updates map[string]interface{}) string {
+	// TOML frontmatter is kept as is, it cannot be rewritten without losing data
+	if strings.HasPrefix(frontmatter, "+++") {
+		logWarn("Analysis results not added: TOML frontmatter is not supported")
+		return frontmatter
+	}
+
 	data := parseFrontmatter(frontmatter)
 	if data == nil {
 		data = make(map[string]interface{})
@@ -899,20 +2014,39 @@ func updateFrontmatter(frontmatter string, updates map[string]interface{}) strin
 	return buildFrontmatter(data)
 }
 
-func logInfo(format string, args ...interface{}) {
-	if !quiet {
-		fmt.Fprintf(os.Stderr, "[INFO] "+format+"\n", args...)
+// setupLogging applies the log flags. --quiet keeps only errors unless
+// --log-level is given.
+func setupLogging(cmd *cobra.Command) error {
+	level := logLevel
+	if quiet && !cmd.Flags().Changed("log-level") {
+		level = "error"
 	}
+	return logging.Setup(level, logFormat, logFile)
+}
+
+// setupCassette applies --record and --replay.
+func setupCassette() error {
+	switch {
+	case recordPath != "" && replayPath != "":
+		return fmt.Errorf("--record and --replay cannot be used together")
+	case recordPath != "":
+		llmprovider.RecordCassette(recordPath)
+	case replayPath != "":
+		return llmprovider.ReplayCassette(replayPath)
+	}
+	return nil
+}
+
+func logInfo(format string, args ...interface{}) {
+	logging.Info(format, args...)
 }
 
 func logError(format string, args ...interface{}) {
-	fmt.Fprintf(os.Stderr, "[ERROR] "+format+"\n", args...)
+	logging.Error(format, args...)
 }
 
 func logWarn(format string, args ...interface{}) {
-	if !quiet {
-		fmt.Fprintf(os.Stderr, "[WARN] "+format+"\n", args...)
-	}
+	logging.Warn(format, args...)
 }
 
 func runDirectoryTranslate(ctx context.Context, cfg *config.Config) error {
@@ -939,40 +2073,242 @@ func runDirectoryTranslate(ctx context.Context, cfg *config.Config) error {
 		return nil
 	}
 
+	var duplicates []duplicateFile
+	if cfg.Settings.Dedupe > 0 {
+		files, duplicates = dedupeFiles(files, cfg.Settings.Dedupe)
+		for _, d := range duplicates {
+			logInfo("Skipping %s: near-duplicate of %s (similarity %.2f)", d.Path, d.Of, d.Similarity)
+		}
+	}
+
+	manifest, err := loadTranslationManifest(inputDir)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		return runDiffPreview(cfg, files, manifest)
+	}
+	if diffMode {
+		total := len(files)
+		if files, err = changedFiles(files, manifest); err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			logInfo("All %d translations are up to date", total)
+			return manifest.save()
+		}
+		logInfo("Skipping %d unchanged files", total-len(files))
+	}
+
 	logInfo("Found %d files to translate", len(files))
 
 	// Load glossary once
-	var glossary []config.GlossaryEntry
-	if glossaryFile != "" {
-		glossary, err = loadGlossary(glossaryFile)
-		if err != nil {
-			return fmt.Errorf("failed to load glossary: %w", err)
-		}
+	glossary, err := loadRunGlossary()
+	if err != nil {
+		return err
 	}
 
 	t := translator.New(cfg, verbose)
 
+	var runDigest *digest.Digest
+	if digestPath != "" {
+		runDigest = digest.New()
+	}
+
+	report := &runReport{
+		Status:     "completed",
+		StartedAt:  time.Now(),
+		Total:      len(files),
+		Translated: []string{},
+		Duplicates: duplicates,
+	}
+
+	usage := newUsageReport(cfg)
+	defer usage.write(cfg, t)
+	defer func() {
+		if err := manifest.save(); err != nil {
+			logError("Failed to write translation manifest: %v", err)
+		}
+	}()
+
 	// Translate each file
 	for i, inputPath := range files {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
+		if ctx.Err() != nil {
+			report.interrupt(files[i:])
+			break
 		}
 
 		outputPath := generateOutputPath(inputPath, outSuffix, outPrefix, targetLang)
 		logInfo("[%d/%d] %s -> %s", i+1, len(files), filepath.Base(inputPath), filepath.Base(outputPath))
 
-		if err := translateFile(ctx, t, cfg, inputPath, outputPath, glossary); err != nil {
+		chunksBefore, start := t.Chunks(), time.Now()
+		fmUpdates, result, written, err := translateFile(ctx, t, cfg, inputPath, outputPath, glossary)
+		if errors.Is(err, errSkipped) {
+			continue
+		}
+		usage.addFile(t, inputPath, chunksBefore, start, result, err)
+		if err != nil {
+			// Cancelled mid-file: nothing was written, the file stays pending
+			if ctx.Err() != nil {
+				report.interrupt(files[i:])
+				break
+			}
+			// Later files would fail the same way, leave them pending
+			if errors.Is(err, translator.ErrBudgetExceeded) {
+				logError("Stopped at %s: %v", inputPath, err)
+				report.interrupt(files[i:])
+				report.Status = "budget_exceeded"
+				break
+			}
 			logError("Failed to translate %s: %v", inputPath, err)
+			report.Failed = append(report.Failed, failedFile{Path: inputPath, Error: err.Error()})
 			continue
 		}
+		report.Translated = append(report.Translated, inputPath)
+		if written {
+			if err := manifest.record(inputPath, outputPath); err != nil {
+				logWarn("Failed to record %s in the translation manifest: %v", inputPath, err)
+			}
+		} else {
+			// The output is not a translation of this source, --diff retries it
+			manifest.invalidate(inputPath, outputPath)
+		}
+		if result.StaleChunks > 0 {
+			logWarn("%s: %d chunks used cached translations", inputPath, result.StaleChunks)
+			report.Stale = append(report.Stale, staleFile{Path: inputPath, Chunks: result.StaleChunks})
+		}
+
+		if runDigest != nil {
+			relPath, err := filepath.Rel(inputDir, inputPath)
+			if err != nil {
+				relPath = inputPath
+			}
+			runDigest.Add(relPath, fmUpdates)
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	report.Providers = t.Health().Snapshot()
+	if runReportPath != "" {
+		if err := report.write(runReportPath); err != nil {
+			logError("Failed to write run report: %v", err)
+		}
+	}
+
+	if report.Status == "interrupted" {
+		logWarn("Interrupted: %d translated, %d failed, %d pending. Run again with --diff to resume.",
+			len(report.Translated), len(report.Failed), len(report.Pending))
+		return ctx.Err()
+	}
+	if report.Status == "budget_exceeded" {
+		logWarn("Budget exceeded: %d translated, %d failed, %d pending. Raise --max-cost or run again later with --diff to resume.",
+			len(report.Translated), len(report.Failed), len(report.Pending))
+		return translator.ErrBudgetExceeded
+	}
+
+	if runDigest != nil {
+		if err := os.WriteFile(digestPath, []byte(runDigest.Render()), 0644); err != nil {
+			return fmt.Errorf("failed to write digest: %w", err)
+		}
+		logInfo("Digest written to %s", digestPath)
 	}
 
 	logInfo("Translation complete")
+	if verbose {
+		logUsage(t.Meter().Total())
+		logHealth(t.Health().Snapshot())
+	}
 	return nil
 }
 
+// runReport records the outcome of a directory run. It is written on normal
+// completion and on interruption, so an orchestrator can see what is left.
+// Translated files are recorded in the manifest, so a re-run with --diff
+// skips them.
+type runReport struct {
+	Status     string                    `json:"status"` // completed, interrupted or budget_exceeded
+	StartedAt  time.Time                 `json:"started_at"`
+	FinishedAt time.Time                 `json:"finished_at"`
+	Total      int                       `json:"total"`
+	Translated []string                  `json:"translated"`
+	Failed     []failedFile              `json:"failed,omitempty"`
+	Pending    []string                  `json:"pending,omitempty"`
+	Stale      []staleFile               `json:"stale,omitempty"`
+	Providers  []metering.ProviderHealth `json:"providers,omitempty"`
+	Duplicates []duplicateFile           `json:"duplicates,omitempty"`
+}
+
+type failedFile struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// staleFile is a translated file in which Chunks chunks failed and were
+// taken from the fallback cache.
+type staleFile struct {
+	Path   string `json:"path"`
+	Chunks int    `json:"chunks"`
+}
+
+// duplicateFile is a source skipped by --dedupe, Of is the file translated
+// in its place.
+type duplicateFile struct {
+	Path       string  `json:"path"`
+	Of         string  `json:"of"`
+	Similarity float64 `json:"similarity"`
+}
+
+// dedupeFiles drops sources that are near-duplicates of an earlier file in
+// the list. Files that cannot be read are kept, so the error is reported
+// when translating them.
+func dedupeFiles(files []string, threshold float64) ([]string, []duplicateFile) {
+	texts := make([]string, len(files))
+	for i, f := range files {
+		if data, err := os.ReadFile(f); err == nil {
+			_, texts[i] = extractFrontmatter(string(data))
+		}
+	}
+
+	skip := make(map[int]bool)
+	var duplicates []duplicateFile
+	for _, d := range dedupe.Find(texts, threshold) {
+		skip[d.Index] = true
+		duplicates = append(duplicates, duplicateFile{
+			Path:       files[d.Index],
+			Of:         files[d.Of],
+			Similarity: math.Round(d.Similarity*100) / 100,
+		})
+	}
+
+	var kept []string
+	for i, f := range files {
+		if !skip[i] {
+			kept = append(kept, f)
+		}
+	}
+	return kept, duplicates
+}
+
+func (r *runReport) interrupt(pending []string) {
+	r.Status = "interrupted"
+	r.Pending = append(r.Pending, pending...)
+}
+
+// write saves the report atomically so a killed process never leaves a
+// truncated file behind.
+func (r *runReport) write(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
 func parseExtensions(ext string) []string {
 	parts := strings.Split(ext, ",")
 	var result []string
@@ -1051,18 +2387,98 @@ func generateOutputPath(inputPath, suffix, prefix, lang string) string {
 	return filepath.Join(dir, newName)
 }
 
-func translateFile(ctx context.Context, t *translator.Translator, cfg *config.Config, inputPath, outputPath string, glossary []config.GlossaryEntry) error {
-	inputText, err := os.ReadFile(inputPath)
+// errSkipped is returned for files without text under the skip policy
+// and files with translate: false in their frontmatter. Callers move on
+// without reporting them.
+var errSkipped = errors.New("file skipped")
+
+// defaultFrontmatterFields are translated in frontmatter-only files and by
+// the site command unless other fields are configured.
+var defaultFrontmatterFields = []string{"title", "description", "summary", "linkTitle"}
+
+// translateFrontmatterOnly translates a document that is YAML frontmatter
+// only, such as a data file of a static site, by translating the fields of
+// frontmatter_fields. The other values are returned unchanged.
+func translateFrontmatterOnly(ctx context.Context, t *translator.Translator, cfg *config.Config, frontmatter string) (string, error) {
+	names := cfg.Settings.FrontmatterFields
+	if len(names) == 0 {
+		names = defaultFrontmatterFields
+	}
+	fields := make(map[string]bool)
+	for _, name := range names {
+		fields[name] = true
+	}
+
+	translated, err := translateFrontmatter(ctx, t, frontmatter, fields)
 	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+		return "", err
+	}
+	if translated == frontmatter {
+		logWarn("Frontmatter has none of the fields %s, copied unchanged", strings.Join(names, ", "))
 	}
+	return translated, nil
+}
 
-	if len(inputText) == 0 {
-		return fmt.Errorf("file is empty")
+// handleEmptyFile applies the empty_files policy to a file that is empty,
+// whitespace or TOML frontmatter only, which leaves nothing to translate.
+func handleEmptyFile(cfg *config.Config, outputPath string, data []byte) error {
+	switch cfg.Settings.EmptyFiles {
+	case "skip":
+		return errSkipped
+	case "copy":
+		if err := os.WriteFile(outputPath, data, 0644); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("file has no text to translate")
+	}
+}
+
+// translateFile translates a single file and returns the frontmatter
+// updates computed by the analyses and the translation result, with the
+// number of chunks taken from the fallback cache and the provider responses.
+// written reports whether the translation was written to outputPath, not
+// queued for review or skipped.
+func translateFile(ctx context.Context, t *translator.Translator, cfg *config.Config, inputPath, outputPath string, glossary []config.GlossaryEntry) (map[string]interface{}, translator.TranslateResponse, bool, error) {
+	ctx, span := telemetry.Start(ctx, "translate_file", telemetry.String("file", inputPath), telemetry.String("target_lang", targetLang))
+	fmUpdates, result, written, err := translateFileContent(ctx, t, cfg, inputPath, outputPath, glossary)
+	span.End(err)
+	return fmUpdates, result, written, err
+}
+
+func translateFileContent(ctx context.Context, t *translator.Translator, cfg *config.Config, inputPath, outputPath string, glossary []config.GlossaryEntry) (map[string]interface{}, translator.TranslateResponse, bool, error) {
+	if isDataFile(inputPath) {
+		err := translateDataFile(ctx, t, inputPath, outputPath, dataKeys(cfg), "data file")
+		return nil, translator.TranslateResponse{}, err == nil, err
+	}
+
+	inputText, err := os.ReadFile(inputPath)
+	if err != nil {
+		return nil, translator.TranslateResponse{}, false, fmt.Errorf("failed to read file: %w", err)
 	}
 
 	// Extract frontmatter if present
 	frontmatter, content := extractFrontmatter(string(inputText))
+	if translationDisabled(frontmatter) {
+		logInfo("Skipping %s: translate: false in frontmatter", inputPath)
+		return nil, translator.TranslateResponse{}, false, errSkipped
+	}
+
+	if strings.TrimSpace(content) == "" {
+		if strings.HasPrefix(frontmatter, "---") {
+			translated, err := translateFrontmatterOnly(ctx, t, cfg, frontmatter)
+			if err != nil {
+				return nil, translator.TranslateResponse{}, false, err
+			}
+			if err := os.WriteFile(outputPath, []byte(translated), 0644); err != nil {
+				return nil, translator.TranslateResponse{}, false, fmt.Errorf("failed to write file: %w", err)
+			}
+			return nil, translator.TranslateResponse{}, true, nil
+		}
+		// A copy of the source is not a translation to record
+		return nil, translator.TranslateResponse{}, false, handleEmptyFile(cfg, outputPath, inputText)
+	}
 
 	req := translator.TranslateRequest{
 		Text:           content,
@@ -1075,16 +2491,20 @@ func translateFile(ctx context.Context, t *translator.Translator, cfg *config.Co
 		PreserveFormat: preserveFormat,
 		StrongMode:     strongMode,
 		StrongRetries:  strongRetries,
+		ReadingLevel:   cfg.Settings.ReadingLevel,
+		ReadingRetries: cfg.Settings.ReadingRetries,
+		MaxLength:      cfg.Settings.MaxLength,
+		MaxLenRatio:    cfg.Settings.MaxLenRatio,
+		LengthRetries:  cfg.Settings.LengthRetries,
 		Glossary:       glossary,
 	}
 
 	result, err := t.Translate(ctx, req)
 	if err != nil {
-		return err
+		return nil, translator.TranslateResponse{}, false, err
 	}
 
-	// Run all enabled analyses (combined or individual)
-	fmUpdates := runAnalysis(ctx, t, cfg, result.Text, verbose)
+	fmUpdates := analyzeTranslation(ctx, t, cfg, content, result, targetLang, outputPath)
 
 	// Update frontmatter with analysis results if any
 	if len(fmUpdates) > 0 {
@@ -1092,11 +2512,25 @@ func translateFile(ctx context.Context, t *translator.Translator, cfg *config.Co
 	}
 
 	// Combine frontmatter with translated content
-	finalOutput := frontmatter + result.Text
+	finalOutput := outputText(cfg, frontmatter, content, result, fmUpdates)
+	exportAlignment(inputPath, content, result)
+
+	if dir := cfg.Settings.ReviewDir; dir != "" {
+		if reasons := qaFailures(fmUpdates, result.StaleChunks); len(reasons) > 0 {
+			if err := queueForReview(dir, inputPath, outputPath, finalOutput, reasons); err != nil {
+				return nil, translator.TranslateResponse{}, false, fmt.Errorf("failed to queue for review: %w", err)
+			}
+			logWarn("%s failed checks (%s), queued for review in %s", inputPath, strings.Join(reasons, ", "), dir)
+			return fmUpdates, result, false, nil
+		}
+		if err := clearReview(dir, outputPath); err != nil {
+			logWarn("Failed to update review manifest: %v", err)
+		}
+	}
 
 	if err := os.WriteFile(outputPath, []byte(finalOutput), 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+		return nil, translator.TranslateResponse{}, false, fmt.Errorf("failed to write file: %w", err)
 	}
 
-	return nil
+	return fmUpdates, result, true, nil
 }
@@ -0,0 +1,282 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/translator"
+	"github.com/spf13/cobra"
+)
+
+var (
+	compareProviders []string
+	compareJudge     string
+)
+
+// judgePrompt asks for the better translation. Candidates are labeled
+// with letters only, so the judge does not favor a provider by name.
+const judgePrompt = `You compare translations into {target_lang} of the same source text. Judge accuracy first, then fluency and preserved formatting. Answer with the letter of the best translation and a one or two sentence reason.`
+
+// compareRun is the translation of the input with one provider and model.
+type compareRun struct {
+	label    string
+	provider string
+	model    string
+	text     string
+	err      error
+	elapsed  time.Duration
+	tokens   int
+	cost     float64
+}
+
+// newCompareCmd builds the "compare" command that translates the same input
+// with several providers or models. It accepts all root flags.
+func newCompareCmd(rootCmd *cobra.Command) *cobra.Command {
+	compareCmd := &cobra.Command{
+		Use:          "compare",
+		Short:        "Translate the input with several providers or models and compare side by side",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCompare(cmd.Context(), cmd)
+		},
+	}
+
+	compareCmd.Flags().AddFlagSet(rootCmd.Flags())
+	compareCmd.Flags().StringSliceVar(&compareProviders, "providers", nil, "Providers to compare as provider[:model], comma-separated")
+	compareCmd.Flags().StringVar(&compareJudge, "judge", "", "Provider[:model] that picks the best translation")
+	compareCmd.MarkFlagRequired("providers")
+
+	return compareCmd
+}
+
+func runCompare(ctx context.Context, cmd *cobra.Command) error {
+	if len(compareProviders) < 2 {
+		return fmt.Errorf("--providers needs at least two entries to compare")
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	inputText, err := readInput()
+	if err != nil {
+		return err
+	}
+	_, content := extractFrontmatter(inputText)
+
+	glossary, err := loadRunGlossary()
+	if err != nil {
+		return err
+	}
+
+	var runs []*compareRun
+	for i, spec := range compareProviders {
+		name, model := config.SplitProviderSpec(spec)
+		runCfg := compareConfig(cfg, name, model)
+		run := &compareRun{
+			label:    string(rune('A' + i)),
+			provider: name,
+			model:    getModelForProvider(runCfg),
+		}
+		runs = append(runs, run)
+
+		logInfo("Translating with %s (%s)", name, run.model)
+		t := translator.New(runCfg, verbose)
+		start := time.Now()
+		result, err := t.Translate(ctx, translator.TranslateRequest{
+			Text:           content,
+			SourceLang:     sourceLang,
+			TargetLang:     targetLang,
+			Style:          style,
+			Context:        contextStr,
+			Glossary:       glossary,
+			Temperature:    temperature,
+			MaxTokens:      maxTokens,
+			PreserveFormat: preserveFormat,
+			StrongMode:     strongMode,
+			StrongRetries:  strongRetries,
+			ReadingLevel:   cfg.Settings.ReadingLevel,
+			ReadingRetries: cfg.Settings.ReadingRetries,
+			MaxLength:      cfg.Settings.MaxLength,
+			MaxLenRatio:    cfg.Settings.MaxLenRatio,
+			LengthRetries:  cfg.Settings.LengthRetries,
+		})
+		run.elapsed = time.Since(start)
+		usage := t.Meter().Total()
+		run.tokens = usage.Tokens()
+		run.cost = runCfg.Providers[name].Cost(usage.InputTokens, usage.OutputTokens)
+		if err != nil {
+			logWarn("%s failed: %v", name, err)
+			run.err = err
+			continue
+		}
+		run.text = result.Text
+	}
+
+	verdict := ""
+	if compareJudge != "" {
+		verdict = judgeTranslations(ctx, cfg, content, runs)
+	}
+
+	report := compareReport(content, runs, verdict)
+	if outputFile != "" {
+		if err := os.WriteFile(outputFile, []byte(report), 0644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+	} else if _, err := os.Stdout.Write([]byte(report)); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	for _, run := range runs {
+		if run.err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("all translations failed")
+}
+
+// compareConfig returns a copy of cfg using provider name with model, or
+// its configured model when empty. The fallback cache is left out so each
+// column shows what the provider returned.
+func compareConfig(cfg *config.Config, name, model string) *config.Config {
+	runCfg := *cfg
+	runCfg.Providers = make(map[string]config.ProviderConfig, len(cfg.Providers)+1)
+	for key, value := range cfg.Providers {
+		runCfg.Providers[key] = value
+	}
+	providerCfg := runCfg.Providers[name]
+	if model != "" {
+		providerCfg.Model = model
+	}
+	runCfg.Providers[name] = providerCfg
+	runCfg.DefaultProvider = name
+	runCfg.Settings.FallbackCache = ""
+	return &runCfg
+}
+
+// judgeTranslations asks the --judge provider which translation is best and
+// returns its verdict, or an empty string when it could not be had.
+func judgeTranslations(ctx context.Context, cfg *config.Config, source string, runs []*compareRun) string {
+	var text strings.Builder
+	text.WriteString("Source:\n" + source + "\n")
+	candidates := 0
+	for _, run := range runs {
+		if run.err != nil {
+			continue
+		}
+		fmt.Fprintf(&text, "\nTranslation %s:\n%s\n", run.label, run.text)
+		candidates++
+	}
+	if candidates < 2 {
+		logWarn("Fewer than two translations succeeded, skipping the judge")
+		return ""
+	}
+
+	name, model := config.SplitProviderSpec(compareJudge)
+	t := translator.New(compareConfig(cfg, name, model), verbose)
+	value, err := t.RunAnalyzer(ctx, config.Analyzer{
+		Prompt: judgePrompt,
+		Schema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"winner": map[string]interface{}{"type": "string"},
+				"reason": map[string]interface{}{"type": "string"},
+			},
+			"required": []interface{}{"winner", "reason"},
+		},
+	}, text.String(), targetLang)
+	if err != nil {
+		logWarn("Judge %s failed: %v", name, err)
+		return ""
+	}
+
+	answer, _ := value.(map[string]interface{})
+	return fmt.Sprintf("%v: %v", answer["winner"], answer["reason"])
+}
+
+// compareReport renders the runs as markdown: a summary table, then the
+// source and translations side by side, one row per paragraph.
+func compareReport(source string, runs []*compareRun, verdict string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Translation comparison (%s -> %s)\n\n", sourceLang, targetLang)
+
+	b.WriteString("| | Provider | Model | Time | Tokens | Cost | Result |\n")
+	b.WriteString("|---|---|---|---|---|---|---|\n")
+	for _, run := range runs {
+		result := "ok"
+		if run.err != nil {
+			result = "failed: " + run.err.Error()
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %v | %d | %.4f | %s |\n", run.label, run.provider, run.model,
+			run.elapsed.Round(100*time.Millisecond), run.tokens, run.cost, markdownCell(result))
+	}
+
+	columns := [][]string{paragraphs(source)}
+	b.WriteString("\n| # | Source |")
+	for _, run := range runs {
+		fmt.Fprintf(&b, " %s |", run.label)
+		columns = append(columns, paragraphs(run.text))
+	}
+	b.WriteString("\n|---|---|" + strings.Repeat("---|", len(runs)) + "\n")
+
+	rows := 0
+	for _, column := range columns {
+		if len(column) > rows {
+			rows = len(column)
+		}
+	}
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&b, "| %d |", i+1)
+		for _, column := range columns {
+			cell := ""
+			if i < len(column) {
+				cell = column[i]
+			}
+			fmt.Fprintf(&b, " %s |", markdownCell(cell))
+		}
+		b.WriteString("\n")
+	}
+
+	if verdict != "" {
+		fmt.Fprintf(&b, "\n**Judge (%s):** %s\n", compareJudge, verdict)
+	}
+	return b.String()
+}
+
+// paragraphs splits text at blank lines outside fenced code blocks.
+// Translations usually keep the paragraphs of the source, so rows line up.
+func paragraphs(text string) []string {
+	var result, lines []string
+	flush := func() {
+		if p := strings.Trim(strings.Join(lines, "\n"), "\n"); strings.TrimSpace(p) != "" {
+			result = append(result, p)
+		}
+		lines = nil
+	}
+	fenced := false
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+		}
+		if trimmed == "" && !fenced {
+			flush()
+			continue
+		}
+		lines = append(lines, line)
+	}
+	flush()
+	return result
+}
+
+// markdownCell keeps text inside one table cell.
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "|", `\|`)
+	return strings.ReplaceAll(text, "\n", "<br>")
+}
@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	initForce    bool
+	showRedacted bool
+)
+
+// newConfigCmd builds the "config" command group. "config show" accepts all
+// translation flags so the printed config matches what a run would use.
+func newConfigCmd(rootCmd *cobra.Command) *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage configuration",
+	}
+
+	initCmd := &cobra.Command{
+		Use:          "init [path]",
+		Short:        "Create a commented config file",
+		SilenceUsage: true,
+		Args:         cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := filepath.Join(os.Getenv("HOME"), ".config", "llm-translate", "config.yaml")
+			if len(args) > 0 {
+				path = args[0]
+			}
+			return runConfigInit(path)
+		},
+	}
+	initCmd.Flags().BoolVarP(&initForce, "force", "f", false, "Overwrite existing file")
+
+	validateCmd := &cobra.Command{
+		Use:          "validate",
+		Short:        "Check config for unknown keys, missing API keys and bad proxy URLs",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigValidate()
+		},
+	}
+	validateCmd.Flags().StringVarP(&configPath, "config", "c", "", "Config file path")
+
+	showCmd := &cobra.Command{
+		Use:          "show",
+		Short:        "Print effective configuration after env overrides and flags",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigShow(cmd)
+		},
+	}
+	showCmd.Flags().AddFlagSet(rootCmd.Flags())
+	showCmd.Flags().BoolVar(&showRedacted, "redacted", false, "Mask API keys and proxy credentials")
+
+	domainCmd := &cobra.Command{
+		Use:          "domain [name]",
+		Short:        "List domain presets, or print one to copy into the config",
+		SilenceUsage: true,
+		Args:         cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return runDomainList()
+			}
+			return runDomainShow(args[0])
+		},
+	}
+	domainCmd.Flags().StringVarP(&configPath, "config", "c", "", "Config file path")
+
+	configCmd.AddCommand(initCmd, validateCmd, showCmd, domainCmd)
+	return configCmd
+}
+
+func runConfigInit(path string) error {
+	if _, err := os.Stat(path); err == nil && !initForce {
+		return fmt.Errorf("config file %s already exists (use --force to overwrite)", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, []byte(config.Template), 0600); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	logInfo("Config written to %s", path)
+	return nil
+}
+
+func runConfigValidate() error {
+	path := findConfigFile()
+	if path == "" {
+		return fmt.Errorf("no config file found")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	problems := config.CheckUnknownKeys(data)
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	problems = append(problems, cfg.Validate()...)
+
+	if len(problems) == 0 {
+		logInfo("Config %s is valid", path)
+		return nil
+	}
+
+	for _, p := range problems {
+		logError("%s", p)
+	}
+	return fmt.Errorf("config %s has %d problem(s)", path, len(problems))
+}
+
+func runConfigShow(cmd *cobra.Command) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	if showRedacted {
+		redactSecrets(cfg)
+	}
+
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	fmt.Print(string(data))
+	return nil
+}
+
+func runDomainList() error {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	for _, name := range cfg.DomainNames() {
+		description := cfg.Domains[name].Description
+		if description == "" {
+			if data, err := config.DomainPreset(name); err == nil {
+				var domain config.Domain
+				if yaml.Unmarshal(data, &domain) == nil {
+					description = domain.Description
+				}
+			}
+		}
+		fmt.Printf("%-12s %s\n", name, description)
+	}
+	return nil
+}
+
+// runDomainShow prints a built-in preset as a "domains" config section.
+func runDomainShow(name string) error {
+	data, err := config.DomainPreset(name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("domains:")
+	fmt.Printf("  %s:\n", name)
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if line == "" {
+			fmt.Println()
+			continue
+		}
+		fmt.Println("    " + line)
+	}
+	return nil
+}
+
+// findConfigFile returns the config file Load would use, or "" if none.
+func findConfigFile() string {
+	if configPath != "" {
+		return configPath
+	}
+	for _, path := range config.GetConfigPaths() {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// redactSecrets masks API keys and proxy credentials in place.
+func redactSecrets(cfg *config.Config) {
+	redactProxy(&cfg.Proxy)
+	if cfg.Server.Token != "" {
+		cfg.Server.Token = "***"
+	}
+	for name, p := range cfg.Providers {
+		if p.APIKey != "" {
+			p.APIKey = "***"
+		}
+		redactProxy(&p.Proxy)
+		for key := range p.HookOptions {
+			if strings.Contains(key, "secret") || strings.Contains(key, "token") || strings.Contains(key, "key") {
+				p.HookOptions[key] = "***"
+			}
+		}
+		for key := range p.Headers {
+			lower := strings.ToLower(key)
+			if strings.Contains(lower, "auth") || strings.Contains(lower, "key") || strings.Contains(lower, "token") {
+				p.Headers[key] = "***"
+			}
+		}
+		cfg.Providers[name] = p
+	}
+}
+
+func redactProxy(p *config.ProxyConfig) {
+	if p.Password != "" {
+		p.Password = "***"
+	}
+	if u, err := url.Parse(p.URL); err == nil && u.User != nil {
+		if password, ok := u.User.Password(); ok && password != "" {
+			p.URL = strings.Replace(p.URL, ":"+password+"@", ":***@", 1)
+		}
+	}
+}
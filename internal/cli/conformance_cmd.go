@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	llmprovider "github.com/foxzi/llm-translate/internal/provider"
+	"github.com/spf13/cobra"
+)
+
+var (
+	conformanceProvider string
+	conformanceModel    string
+)
+
+// conformanceText has something for every analysis: entities, amounts,
+// dates, a forecast and a promotional tone.
+const conformanceText = `Acme Corp said on Monday that its revenue rose 12% to $4.5 million in 2024. Chief executive Jane Doe expects further growth next year as the company opens a factory in Berlin. Customers can order the new model today at a 20% discount.`
+
+// conformanceCheck is one capability of a provider. run returns a short
+// summary of the answer.
+type conformanceCheck struct {
+	name string
+	run  func(ctx context.Context, p llmprovider.Provider) (string, error)
+}
+
+var conformanceChecks = []conformanceCheck{
+	{"translate", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		resp, err := p.Translate(ctx, llmprovider.TranslateRequest{Text: "Good morning, how are you?", SourceLang: "en", TargetLang: "de", MaxTokens: 64})
+		if err == nil && strings.TrimSpace(resp.Text) == "" {
+			err = fmt.Errorf("empty translation")
+		}
+		return resp.Text, err
+	}},
+	{"sentiment", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		resp, err := p.AnalyzeSentiment(ctx, conformanceText)
+		return fmt.Sprintf("%s (%.1f)", resp.Sentiment, resp.Score), err
+	}},
+	{"tags", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		resp, err := p.ExtractTags(ctx, conformanceText, 3)
+		return strings.Join(resp.Tags, ", "), err
+	}},
+	{"classify", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		resp, err := p.Classify(ctx, conformanceText)
+		return strings.Join(resp.Topics, ", "), err
+	}},
+	{"emotions", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		resp, err := p.AnalyzeEmotions(ctx, conformanceText)
+		return fmt.Sprintf("%d emotions", len(resp.Emotions)), err
+	}},
+	{"factuality", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		resp, err := p.AnalyzeFactuality(ctx, conformanceText)
+		return resp.Type, err
+	}},
+	{"impact", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		resp, err := p.AnalyzeImpact(ctx, conformanceText)
+		return strings.Join(resp.Affected, ", "), err
+	}},
+	{"sensationalism", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		resp, err := p.AnalyzeSensationalism(ctx, conformanceText)
+		return resp.Type, err
+	}},
+	{"usefulness", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		resp, err := p.AnalyzeUsefulness(ctx, conformanceText)
+		return fmt.Sprintf("useful: %v", resp.IsUseful), err
+	}},
+	{"entities", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		resp, err := p.ExtractEntities(ctx, conformanceText)
+		return strings.Join(append(resp.Persons, resp.Organizations...), ", "), err
+	}},
+	{"events", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		resp, err := p.ExtractEvents(ctx, conformanceText)
+		return fmt.Sprintf("%d events", len(resp.Events)), err
+	}},
+	{"time_focus", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		resp, err := p.AnalyzeTimeFocus(ctx, conformanceText)
+		return resp.Focus, err
+	}},
+	{"ad_detect", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		resp, err := p.AnalyzeAdDetect(ctx, conformanceText)
+		return resp.AdType, err
+	}},
+	{"combined", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		return checkCombined(ctx, p, llmprovider.CombinedAnalysisRequest{})
+	}},
+	{"combined_json", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		return checkCombined(ctx, p, llmprovider.CombinedAnalysisRequest{JSON: true})
+	}},
+	{"structured_output", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		if !llmprovider.SupportsStructuredOutput(p) {
+			return "", errUnsupported
+		}
+		return checkCombined(ctx, p, llmprovider.CombinedAnalysisRequest{JSON: true, Schema: true})
+	}},
+	{"headline", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		resp, err := p.GenerateHeadline(ctx, conformanceText)
+		return resp.Title, err
+	}},
+	{"embeddings", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		resp, err := p.Embed(ctx, conformanceText)
+		return fmt.Sprintf("%d dimensions", len(resp.Vector)), err
+	}},
+	{"list_models", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		models, err := llmprovider.ListModels(ctx, p)
+		return fmt.Sprintf("%d models", len(models)), err
+	}},
+	{"continuation", func(ctx context.Context, p llmprovider.Provider) (string, error) {
+		if !llmprovider.SupportsContinuation(p) {
+			return "", errUnsupported
+		}
+		return "truncated output is continued", nil
+	}},
+}
+
+// errUnsupported marks a capability the provider does not implement, which
+// is not counted as a failure.
+var errUnsupported = errors.New("not supported by the provider")
+
+// isUnsupported reports whether err says the provider lacks a capability,
+// as opposed to a failed request.
+func isUnsupported(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return errors.Is(err, errUnsupported) || strings.Contains(msg, "does not support") || strings.Contains(msg, "cannot list models")
+}
+
+// checkCombined asks for all analyses in one request and reports the
+// sections that could not be parsed.
+func checkCombined(ctx context.Context, p llmprovider.Provider, req llmprovider.CombinedAnalysisRequest) (string, error) {
+	req.Text = conformanceText
+	req.Sentiment, req.TagsCount, req.Classify, req.Emotions = true, 3, true, true
+	req.Factuality, req.Impact, req.Sensationalism, req.Usefulness = true, true, true, true
+	req.Entities, req.Events, req.TimeFocus, req.AdDetect = true, true, true, true
+	resp, err := p.AnalyzeCombined(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Failed) > 0 {
+		return "", fmt.Errorf("unparsable sections: %s", strings.Join(resp.Failed, ", "))
+	}
+	return "all sections parsed", nil
+}
+
+// newConformanceCmd builds the "conformance" command, which calls every
+// capability of one provider with a small prompt.
+func newConformanceCmd() *cobra.Command {
+	conformanceCmd := &cobra.Command{
+		Use:          "conformance",
+		Short:        "Check which translation and analysis capabilities of a provider work",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConformance(cmd)
+		},
+	}
+	conformanceCmd.Flags().StringVarP(&configPath, "config", "c", "", "Config file path")
+	conformanceCmd.Flags().StringVarP(&conformanceProvider, "provider", "p", "", "Provider to check (default: default provider)")
+	conformanceCmd.Flags().StringVarP(&conformanceModel, "model", "m", "", "Model to check (default: provider model)")
+	return conformanceCmd
+}
+
+func runConformance(cmd *cobra.Command) error {
+	cfg, err := config.Load(findConfigFile())
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	name := conformanceProvider
+	if name == "" {
+		name = cfg.DefaultProvider
+	}
+	if providerCfg, ok := cfg.Providers[name]; ok && conformanceModel != "" {
+		providerCfg.Model = conformanceModel
+		cfg.Providers[name] = providerCfg
+	}
+
+	p, err := newCheckedProvider(cfg, name)
+	if err != nil {
+		if hint := doctorHint(err); hint != "" {
+			return fmt.Errorf("%s: %w (%s)", name, err, hint)
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	if model := cfg.Providers[name].Model; model != "" {
+		logInfo("Checking %s with model %s", name, model)
+	} else {
+		logInfo("Checking %s", name)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CAPABILITY\tRESULT\tTIME\tDETAIL")
+	failed := 0
+	for _, check := range conformanceChecks {
+		ctx, cancel := context.WithTimeout(cmd.Context(), time.Duration(cfg.Settings.Timeout)*time.Second)
+		start := time.Now()
+		detail, err := check.run(ctx, p)
+		elapsed := time.Since(start).Round(time.Millisecond)
+		cancel()
+
+		result := "ok"
+		switch {
+		case isUnsupported(err):
+			result, detail, elapsed = "unsupported", "", 0
+		case err != nil:
+			result, detail = "FAIL", err.Error()
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%v\t%s\n", check.name, result, elapsed, conformanceDetail(detail))
+	}
+	w.Flush()
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d capabilities failed", failed, len(conformanceChecks))
+	}
+	return nil
+}
+
+// conformanceDetail keeps a detail on one short line.
+func conformanceDetail(detail string) string {
+	detail = strings.Join(strings.Fields(detail), " ")
+	if runes := []rune(detail); len(runes) > 60 {
+		detail = string(runes[:60]) + "..."
+	}
+	return detail
+}
@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/translator"
+	"github.com/spf13/cobra"
+)
+
+var (
+	daemonSocket  string
+	serverWorkers int
+)
+
+// newDaemonCmd builds the "daemon" command that serves translations over a
+// Unix socket with config, glossary and provider clients loaded once. It
+// accepts all root flags as defaults for the requests.
+func newDaemonCmd(rootCmd *cobra.Command) *cobra.Command {
+	daemonCmd := &cobra.Command{
+		Use:          "daemon",
+		Short:        "Serve translation requests over a Unix socket, keeping providers and glossaries loaded",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDaemon(cmd.Context(), cmd)
+		},
+	}
+
+	daemonCmd.Flags().AddFlagSet(rootCmd.Flags())
+	daemonCmd.Flags().StringVar(&daemonSocket, "socket", "", "Unix socket path to listen on")
+	daemonCmd.Flags().IntVar(&serverWorkers, "workers", 4, "Number of requests translated at once")
+	daemonCmd.MarkFlagRequired("socket")
+
+	return daemonCmd
+}
+
+// runDaemon accepts connections until ctx is done. Each line a client
+// sends is a JSON record as in --jsonl mode and is answered with a JSON
+// result line. Requests of one connection are answered in order.
+func runDaemon(ctx context.Context, cmd *cobra.Command) error {
+	srv, err := newTranslationServer(cmd)
+	if err != nil {
+		return err
+	}
+
+	if conn, err := net.Dial("unix", daemonSocket); err == nil {
+		conn.Close()
+		return fmt.Errorf("a daemon is already listening on %s", daemonSocket)
+	}
+	// A socket left over from a daemon that did not shut down is removed,
+	// anything else at the path is not ours to delete
+	if info, err := os.Lstat(daemonSocket); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return fmt.Errorf("%s exists and is not a socket", daemonSocket)
+		}
+		os.Remove(daemonSocket)
+	}
+
+	// Requests spend API credits, only the owner may connect
+	listener, err := listenPrivate(daemonSocket)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", daemonSocket, err)
+	}
+	defer listener.Close()
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	logInfo("Listening on %s (provider %s, model %s)", daemonSocket, srv.cfg.DefaultProvider, getModelForProvider(srv.cfg))
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+		go serveDaemonConn(ctx, conn, srv)
+	}
+}
+
+// translationServer holds what the daemon and serve commands load once
+// for all requests.
+type translationServer struct {
+	cfg      *config.Config
+	glossary []config.GlossaryEntry
+	// Translators are not safe for concurrent use, a request takes one
+	// from the pool for its duration
+	pool chan *translator.Translator
+}
+
+func newTranslationServer(cmd *cobra.Command) (*translationServer, error) {
+	if serverWorkers < 1 {
+		return nil, fmt.Errorf("--workers must be at least 1")
+	}
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+	glossary, err := loadRunGlossary()
+	if err != nil {
+		return nil, err
+	}
+	providerCfg := cfg.Providers[cfg.DefaultProvider]
+	if err := providerCfg.ResolveAPIKey(); err != nil {
+		return nil, err
+	}
+	cfg.Providers[cfg.DefaultProvider] = providerCfg
+
+	pool := make(chan *translator.Translator, serverWorkers)
+	for i := 0; i < serverWorkers; i++ {
+		pool <- translator.New(compareConfig(cfg, cfg.DefaultProvider, ""), verbose)
+	}
+	return &translationServer{cfg: cfg, glossary: glossary, pool: pool}, nil
+}
+
+// acquire waits for a free translator.
+func (s *translationServer) acquire(ctx context.Context) (*translator.Translator, error) {
+	select {
+	case t := <-s.pool:
+		return t, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *translationServer) release(t *translator.Translator) {
+	s.pool <- t
+}
+
+// serveDaemonConn answers the request lines of conn until the client
+// closes it.
+func serveDaemonConn(ctx context.Context, conn net.Conn, srv *translationServer) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		t, err := srv.acquire(ctx)
+		if err != nil {
+			return
+		}
+		result := translateLine(ctx, t, srv.cfg, srv.glossary, line)
+		srv.release(t)
+
+		if verbose {
+			if result.Error != "" {
+				logWarn("Request %s failed: %s", result.ID, result.Error)
+			} else {
+				logInfo("Request %s translated to %s, %d tokens", result.ID, result.TargetLang, result.TokensUsed)
+			}
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			logError("Failed to encode result: %v", err)
+			return
+		}
+		if _, err := conn.Write(append(data, '\n')); err != nil {
+			return
+		}
+	}
+}
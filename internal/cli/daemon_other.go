@@ -0,0 +1,11 @@
+//go:build !unix
+
+package cli
+
+import "net"
+
+// listenPrivate listens on a Unix socket. Access is left to the
+// permissions of the directory holding it.
+func listenPrivate(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
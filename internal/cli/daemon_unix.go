@@ -0,0 +1,17 @@
+//go:build unix
+
+package cli
+
+import (
+	"net"
+	"syscall"
+)
+
+// listenPrivate listens on a Unix socket only the owner may connect to.
+// The socket is created under a restrictive umask, so unlike a chmod after
+// the bind it is never open to others.
+func listenPrivate(path string) (net.Listener, error) {
+	old := syscall.Umask(0077)
+	defer syscall.Umask(old)
+	return net.Listen("unix", path)
+}
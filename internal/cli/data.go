@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/translator"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultDataKeys are translated in data files and menus unless data_keys
+// is set.
+var defaultDataKeys = []string{"name", "title", "description", "summary", "label", "caption", "text"}
+
+// isDataFile reports whether path is a YAML, JSON or TOML data file, whose
+// values are translated by key instead of as text.
+func isDataFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json", ".toml":
+		return true
+	}
+	return false
+}
+
+// dataKeys returns the set of keys whose values are translated.
+func dataKeys(cfg *config.Config) map[string]bool {
+	names := cfg.Settings.DataKeys
+	if len(names) == 0 {
+		names = defaultDataKeys
+	}
+	keys := make(map[string]bool)
+	for _, name := range names {
+		keys[name] = true
+	}
+	return keys
+}
+
+// translateDataFile translates the string values of the allowed keys in a
+// data file, at any depth, and writes the result to outputPath. Everything
+// else is kept: numbers, URLs, identifiers and key order. A file without
+// any of the keys is copied unchanged.
+func translateDataFile(ctx context.Context, t *translator.Translator, inputPath, outputPath string, keys map[string]bool, where string) error {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var out []byte
+	if strings.ToLower(filepath.Ext(inputPath)) == ".toml" {
+		out, err = translateTOML(ctx, t, data, keys, where)
+	} else {
+		out, err = translateYAMLOrJSON(ctx, t, data, keys, where, strings.ToLower(filepath.Ext(inputPath)) == ".json")
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputPath, out, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// translateYAMLOrJSON edits the YAML tree, so comments stay in YAML files.
+// JSON is parsed as YAML, which it is a subset of, and written back as
+// indented JSON.
+func translateYAMLOrJSON(ctx context.Context, t *translator.Translator, data []byte, keys map[string]bool, where string, isJSON bool) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid data file: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return data, nil
+	}
+
+	changed, err := translateFieldNodes(ctx, t, doc.Content[0], keys, where)
+	if err != nil || !changed {
+		return data, err
+	}
+
+	var buf bytes.Buffer
+	if isJSON {
+		writeJSONNode(&buf, doc.Content[0], "")
+		buf.WriteByte('\n')
+		return buf.Bytes(), nil
+	}
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	enc.Close()
+	return buf.Bytes(), nil
+}
+
+// writeJSONNode writes a node parsed from JSON with two space indentation,
+// keeping the key order of the source.
+func writeJSONNode(buf *bytes.Buffer, node *yaml.Node, indent string) {
+	inner := indent + "  "
+	switch node.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		start, end, step := "{", "}", 2
+		if node.Kind == yaml.SequenceNode {
+			start, end, step = "[", "]", 1
+		}
+		if len(node.Content) == 0 {
+			buf.WriteString(start + end)
+			return
+		}
+		buf.WriteString(start + "\n")
+		for i := 0; i < len(node.Content); i += step {
+			if i > 0 {
+				buf.WriteString(",\n")
+			}
+			buf.WriteString(inner)
+			if step == 2 {
+				buf.WriteString(jsonString(node.Content[i].Value) + ": ")
+			}
+			writeJSONNode(buf, node.Content[i+step-1], inner)
+		}
+		buf.WriteString("\n" + indent + end)
+	default:
+		if node.Tag == "!!str" {
+			buf.WriteString(jsonString(node.Value))
+		} else {
+			// Numbers, booleans and null are valid JSON as written
+			buf.WriteString(node.Value)
+		}
+	}
+}
+
+// jsonString quotes s for JSON without escaping HTML characters.
+func jsonString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// tomlStringLine matches a line setting a bare key to a one-line basic or
+// literal string.
+var tomlStringLine = regexp.MustCompile(`^(\s*)([A-Za-z0-9_-]+)(\s*=\s*)("(?:[^"\\]|\\.)*"|'[^']*')(.*)$`)
+
+// translateTOML translates TOML line by line, as there is no TOML parser in
+// the dependencies: only allowed keys set to a one-line string are
+// translated, multi-line strings and inline tables are left as they are.
+func translateTOML(ctx context.Context, t *translator.Translator, data []byte, keys map[string]bool, where string) ([]byte, error) {
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		m := tomlStringLine.FindStringSubmatch(line)
+		if m == nil || !keys[m[2]] {
+			continue
+		}
+
+		value := strings.Trim(m[4], "'")
+		if strings.HasPrefix(m[4], `"`) {
+			unquoted, err := strconv.Unquote(m[4])
+			if err != nil {
+				continue
+			}
+			value = unquoted
+		}
+		if value == "" {
+			continue
+		}
+
+		text, err := translateFieldText(ctx, t, m[2], value, where)
+		if err != nil {
+			return nil, err
+		}
+		// JSON escapes are valid in TOML basic strings
+		lines[i] = m[1] + m[2] + m[3] + jsonString(text) + m[5]
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
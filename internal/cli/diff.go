@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/metering"
+	"github.com/foxzi/llm-translate/internal/translator"
+)
+
+// translationManifestName is the file in the input directory recording the
+// source each translation was made from.
+const translationManifestName = ".llm-translate-manifest.json"
+
+// translationManifest maps output paths to the source they were translated
+// from, so --diff can tell changed sources from unchanged ones. Paths are
+// relative to the input directory, which can be moved with them.
+type translationManifest struct {
+	Files map[string]manifestEntry `json:"files"`
+	dir   string
+	found bool // read from the input directory, not new
+}
+
+type manifestEntry struct {
+	Source       string    `json:"source"`
+	SourceHash   string    `json:"source_hash"`
+	TranslatedAt time.Time `json:"translated_at"`
+}
+
+func loadTranslationManifest(dir string) (*translationManifest, error) {
+	m := &translationManifest{Files: make(map[string]manifestEntry), dir: dir}
+	data, err := os.ReadFile(filepath.Join(dir, translationManifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid translation manifest: %w", err)
+	}
+	m.found = true
+	if m.Files == nil {
+		m.Files = make(map[string]manifestEntry)
+	}
+	return m, nil
+}
+
+// save writes the manifest atomically, like the run report.
+func (m *translationManifest) save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(m.dir, translationManifestName)
+	if err := os.WriteFile(path+".tmp", append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// record notes that output was translated from the current source.
+func (m *translationManifest) record(source, output string) error {
+	hash, err := hashFile(source)
+	if err != nil {
+		return err
+	}
+	m.Files[m.rel(output)] = manifestEntry{Source: m.rel(source), SourceHash: hash, TranslatedAt: time.Now()}
+	return nil
+}
+
+// invalidate records output without a source hash, so --diff translates
+// source again whatever the age of the output.
+func (m *translationManifest) invalidate(source, output string) {
+	m.Files[m.rel(output)] = manifestEntry{Source: m.rel(source)}
+}
+
+func (m *translationManifest) rel(path string) string {
+	if rel, err := filepath.Rel(m.dir, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// change tells whether the translation of source to output is "new" (no
+// output), "changed" or "unchanged". Sources are compared with the hash
+// recorded for the output; an output missing from an existing manifest was
+// not made by a recorded run and counts as changed. Only without a
+// manifest, a source newer than its output counts as changed.
+func (m *translationManifest) change(source, output string) (string, error) {
+	outputInfo, err := os.Stat(output)
+	if errors.Is(err, os.ErrNotExist) {
+		return "new", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	entry, ok := m.Files[m.rel(output)]
+	if !ok && m.found {
+		return "changed", nil
+	}
+	if !ok {
+		sourceInfo, err := os.Stat(source)
+		if err != nil {
+			return "", err
+		}
+		if outputInfo.ModTime().Before(sourceInfo.ModTime()) {
+			return "changed", nil
+		}
+		return "unchanged", nil
+	}
+
+	hash, err := hashFile(source)
+	if err != nil {
+		return "", err
+	}
+	if hash != entry.SourceHash {
+		return "changed", nil
+	}
+	return "unchanged", nil
+}
+
+// recordFileTranslation records a single-file translation from -i to -o in
+// the manifest next to the input file, so --check and --diff see when the
+// source changes. Staged remote files and fetched pages are not recorded.
+func recordFileTranslation(remote *remoteRun) {
+	if inputFile == "" || outputFile == "" || remote != nil || isWebURL(inputFile) {
+		return
+	}
+	manifest, err := loadTranslationManifest(filepath.Dir(inputFile))
+	if err == nil {
+		if err = manifest.record(inputFile, outputFile); err == nil {
+			err = manifest.save()
+		}
+	}
+	if err != nil {
+		logWarn("Failed to record %s in the translation manifest: %v", inputFile, err)
+	}
+}
+
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// changedFiles keeps the files whose translation is new or changed.
+// Without a manifest, the unchanged translations are recorded as they are,
+// so later runs compare hashes.
+func changedFiles(files []string, m *translationManifest) ([]string, error) {
+	adopt := !m.found
+	var changed []string
+	for _, f := range files {
+		output := generateOutputPath(f, outSuffix, outPrefix, targetLang)
+		state, err := m.change(f, output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare %s: %w", f, err)
+		}
+		if state != "unchanged" {
+			changed = append(changed, f)
+			continue
+		}
+		if adopt {
+			if err := m.record(f, output); err != nil {
+				return nil, fmt.Errorf("failed to record %s: %w", f, err)
+			}
+		}
+	}
+	return changed, nil
+}
+
+// runDiffPreview is --dry-run in directory mode. It lists the files a run
+// would translate with their chunks, tokens and cost, estimated locally
+// from the prices of the provider. With --diff, unchanged translations are
+// listed as skipped. No provider is called and nothing is written.
+func runDiffPreview(cfg *config.Config, files []string, m *translationManifest) error {
+	t := translator.New(cfg, verbose)
+	prices := cfg.Providers[cfg.DefaultProvider]
+
+	var pending, chunks, tokens int
+	var cost float64
+	for _, source := range files {
+		output := generateOutputPath(source, outSuffix, outPrefix, targetLang)
+		state, err := m.change(source, output)
+		if err != nil {
+			return fmt.Errorf("failed to compare %s: %w", source, err)
+		}
+		if state == "unchanged" && diffMode {
+			logInfo("%s: unchanged, skipped", source)
+			continue
+		}
+
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", source, err)
+		}
+		_, content := extractFrontmatter(string(data))
+
+		// The output is assumed to be about as long as the input, as in --check
+		n := len(t.PlanChunks(content))
+		input := metering.EstimateTokens(content)
+		fileCost := prices.Cost(input, input)
+		pending++
+		chunks += n
+		tokens += 2 * input
+		cost += fileCost
+		logInfo("%s: %s -> %s, %d chunks, ~%d tokens, ~%.4f", source, state, output, n, 2*input, fileCost)
+	}
+
+	logInfo("Dry run: %d of %d files would be translated, ~%d requests, ~%d tokens, ~%.4f (estimated, analyses not included)",
+		pending, len(files), chunks, tokens, cost)
+	return nil
+}
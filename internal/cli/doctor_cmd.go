@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	llmprovider "github.com/foxzi/llm-translate/internal/provider"
+	"github.com/foxzi/llm-translate/internal/proxy"
+	"github.com/spf13/cobra"
+)
+
+var doctorProvider string
+
+// newDoctorCmd builds the "doctor" command, which checks a setup end to end.
+func newDoctorCmd() *cobra.Command {
+	doctorCmd := &cobra.Command{
+		Use:          "doctor",
+		Short:        "Check config, proxies and providers with a tiny request each",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDoctor(cmd)
+		},
+	}
+	doctorCmd.Flags().StringVarP(&configPath, "config", "c", "", "Config file path")
+	doctorCmd.Flags().StringVarP(&doctorProvider, "provider", "p", "", "Check only this provider")
+	return doctorCmd
+}
+
+// runDoctor reports every problem it finds instead of stopping at the first
+// one, and fails when there was any.
+func runDoctor(cmd *cobra.Command) error {
+	failures := 0
+
+	path := findConfigFile()
+	if path == "" {
+		logWarn("No config file found, using defaults and environment variables")
+	} else {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read config: %w", err)
+		}
+		for _, p := range config.CheckUnknownKeys(data) {
+			logError("config: %s", p)
+			failures++
+		}
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	for _, p := range cfg.Validate() {
+		logError("config: %s", p)
+		failures++
+	}
+	if path != "" && failures == 0 {
+		logInfo("config: %s is valid", path)
+	}
+
+	names := make([]string, 0, len(cfg.Providers))
+	for name := range cfg.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if doctorProvider != "" {
+		names = []string{doctorProvider}
+	}
+	if len(names) == 0 {
+		logError("No providers configured: add one under providers or set an API key variable such as OPENAI_API_KEY")
+		failures++
+	}
+
+	for _, name := range names {
+		if err := checkProvider(cmd.Context(), cfg, name); err != nil {
+			logError("%s: %v", name, err)
+			if hint := doctorHint(err); hint != "" {
+				logError("%s: %s", name, hint)
+			}
+			failures++
+		}
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d check(s) failed", failures)
+	}
+	logInfo("All checks passed")
+	return nil
+}
+
+// checkProvider creates a provider and sends a tiny request.
+func checkProvider(ctx context.Context, cfg *config.Config, name string) error {
+	p, err := newCheckedProvider(cfg, name)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Settings.Timeout)*time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err = p.Translate(ctx, llmprovider.TranslateRequest{
+		Text:       "Hello",
+		SourceLang: "en",
+		TargetLang: "es",
+		MaxTokens:  16,
+	})
+	if err != nil {
+		return fmt.Errorf("test request: %w", err)
+	}
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if model := cfg.Providers[name].Model; model != "" {
+		logInfo("%s: ok, model %s answered in %v", name, model, elapsed)
+	} else {
+		logInfo("%s: ok, answered in %v", name, elapsed)
+	}
+	return nil
+}
+
+// newCheckedProvider resolves the API key and proxy of a provider, checks
+// that the proxy accepts connections and creates the provider, which looks
+// up the binary of CLI providers.
+func newCheckedProvider(cfg *config.Config, name string) (llmprovider.Provider, error) {
+	providerCfg, ok := cfg.Providers[name]
+	if !ok {
+		return nil, fmt.Errorf("provider %s not configured", name)
+	}
+	if err := providerCfg.ResolveAPIKey(); err != nil {
+		return nil, fmt.Errorf("API key: %w", err)
+	}
+
+	// Same precedence as translation: the provider proxy, then the global one
+	proxyCfg := providerCfg.Proxy
+	if proxyCfg.URL == "" {
+		proxyCfg = cfg.Proxy
+	}
+	if proxyCfg.URL != "" {
+		if err := checkProxy(proxyCfg.URL); err != nil {
+			return nil, err
+		}
+	}
+	client, err := proxy.NewHTTPClient(proxyCfg, cfg.Settings.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("proxy: %w", err)
+	}
+	return llmprovider.Get(name, providerCfg, client)
+}
+
+// checkProxy tells whether the proxy server accepts connections. Credentials
+// are left out of the messages.
+func checkProxy(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("proxy: invalid URL")
+	}
+	conn, err := net.DialTimeout("tcp", u.Host, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("proxy %s://%s is unreachable: %w", u.Scheme, u.Host, err)
+	}
+	conn.Close()
+	logInfo("proxy: %s://%s is reachable", u.Scheme, u.Host)
+	return nil
+}
+
+// doctorHint suggests a fix for common provider errors.
+func doctorHint(err error) string {
+	msg := err.Error()
+	var netErr net.Error
+	switch {
+	case strings.HasPrefix(msg, "API key"), strings.Contains(msg, "API key is required"):
+		return "set api_key, api_key_cmd or api_key_keychain, or the provider's API key variable"
+	case strings.HasPrefix(msg, "proxy"):
+		return "check proxy.url and that the proxy is running, or unset HTTPS_PROXY/ALL_PROXY"
+	case strings.Contains(msg, "CLI not found"), strings.Contains(msg, "executable file not found"):
+		return "install the CLI or set base_url to the path of its binary"
+	case strings.Contains(msg, "status code: 401"), strings.Contains(msg, "status code: 403"):
+		return "the API key was rejected, check that it is valid for this provider"
+	case strings.Contains(msg, "status code: 404"):
+		return "check base_url and model"
+	case strings.Contains(msg, "status code: 429"):
+		return "rate limited or out of credits, check the account"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "no answer in time, check the network and proxy or raise settings.timeout"
+	case strings.Contains(msg, "connection refused"), strings.Contains(msg, "no such host"):
+		return "check base_url and that the server is running"
+	}
+	return ""
+}
@@ -0,0 +1,297 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/foxzi/llm-translate/internal/article"
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/feed"
+	"github.com/foxzi/llm-translate/internal/translator"
+	"github.com/spf13/cobra"
+)
+
+var (
+	feedItems int
+	feedDir   string
+)
+
+// feedDateLayouts are the date formats of RSS and Atom feeds.
+var feedDateLayouts = []string{
+	time.RFC1123Z, time.RFC1123, time.RFC3339, time.RFC822Z, time.RFC822,
+	"Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST", "2006-01-02",
+}
+
+// newFeedCmd builds the "feed" command that translates the items of an RSS
+// or Atom feed into a feed or Markdown files. It accepts all translation
+// and analysis flags.
+func newFeedCmd(rootCmd *cobra.Command) *cobra.Command {
+	feedCmd := &cobra.Command{
+		Use:          "feed <url or file>",
+		Short:        "Translate an RSS or Atom feed into a feed or Markdown files",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFeedTranslate(cmd.Context(), cmd, args[0])
+		},
+	}
+
+	feedCmd.Flags().AddFlagSet(rootCmd.Flags())
+	feedCmd.Flags().IntVar(&feedItems, "items", 0, "Translate only the first N items (0 for all)")
+	feedCmd.Flags().StringVar(&feedDir, "markdown-dir", "", "Write each item as a Markdown file with frontmatter to this directory instead of the feed")
+
+	return feedCmd
+}
+
+func runFeedTranslate(ctx context.Context, cmd *cobra.Command, source string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	var base *url.URL
+	if isWebURL(source) {
+		body, resp, err := fetchURL(ctx, cfg, source, "application/rss+xml,application/atom+xml,application/xml;q=0.9,*/*;q=0.8")
+		if err != nil {
+			return err
+		}
+		data, base = body, resp.Request.URL
+	} else if data, err = os.ReadFile(source); err != nil {
+		return fmt.Errorf("failed to read feed: %w", err)
+	}
+
+	f, err := feed.Parse(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	items := f.Items
+	if feedItems > 0 && len(items) > feedItems {
+		items = items[:feedItems]
+	}
+	logInfo("Found %d items in %s", len(f.Items), source)
+
+	glossary, err := loadRunGlossary()
+	if err != nil {
+		return err
+	}
+
+	t := translator.New(cfg, verbose)
+	report := newUsageReport(cfg)
+	defer report.write(cfg, t)
+
+	var translate translateFunc = func(text string, formatted bool) (translator.TranslateResponse, error) {
+		return t.Translate(ctx, translator.TranslateRequest{
+			Text:           text,
+			SourceLang:     sourceLang,
+			TargetLang:     targetLang,
+			Style:          style,
+			Context:        contextStr,
+			Temperature:    temperature,
+			MaxTokens:      maxTokens,
+			PreserveFormat: preserveFormat || formatted,
+			StrongMode:     strongMode,
+			StrongRetries:  strongRetries,
+			ReadingLevel:   cfg.Settings.ReadingLevel,
+			ReadingRetries: cfg.Settings.ReadingRetries,
+			MaxLength:      cfg.Settings.MaxLength,
+			MaxLenRatio:    cfg.Settings.MaxLenRatio,
+			LengthRetries:  cfg.Settings.LengthRetries,
+			Glossary:       glossary,
+		})
+	}
+
+	failed := 0
+	for i, item := range items {
+		name := feedItemName(item, i)
+		chunksBefore, start := t.Chunks(), time.Now()
+		var result translator.TranslateResponse
+		if feedDir != "" {
+			path := feedItemPath(item)
+			if _, err := os.Stat(path); err == nil {
+				if verbose {
+					logInfo("Skipping %s: already translated", path)
+				}
+				continue
+			}
+			result, err = writeFeedItem(ctx, t, cfg, item, path, itemBase(item, base), translate)
+		} else {
+			result, err = translateFeedItem(item, translate)
+		}
+		report.addFile(t, name, chunksBefore, start, result, err)
+		if err != nil {
+			logError("Failed to translate %s: %v", name, err)
+			failed++
+		} else if verbose {
+			logInfo("Translated %s", name)
+		}
+	}
+
+	if feedDir == "" {
+		// The channel texts, with the target language
+		for _, text := range []*feed.Text{f.Title, f.Description} {
+			if text == nil {
+				continue
+			}
+			if err := translateText(text, translate); err != nil {
+				return fmt.Errorf("translation of the feed title failed: %w", err)
+			}
+		}
+		if f.Language != nil {
+			f.Language.Value = targetLang
+		}
+		if err := writeOutput(string(f.Bytes())); err != nil {
+			return err
+		}
+	}
+
+	if verbose {
+		logUsage(t.Meter().Total())
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d items failed", failed, len(items))
+	}
+	return nil
+}
+
+// translateFunc translates text, keeping its formatting when formatted.
+type translateFunc func(text string, formatted bool) (translator.TranslateResponse, error)
+
+// translateText replaces the value of text with its translation.
+func translateText(text *feed.Text, translate translateFunc) error {
+	result, err := translate(text.Value, text.HTML)
+	if err == nil {
+		text.Value = result.Text
+	}
+	return err
+}
+
+// translateFeedItem translates the title, summary and content of item in
+// place and returns the result of the last.
+func translateFeedItem(item *feed.Item, translate translateFunc) (translator.TranslateResponse, error) {
+	var result translator.TranslateResponse
+	for _, text := range []*feed.Text{item.Title, item.Summary, item.Content} {
+		if text == nil {
+			continue
+		}
+		var err error
+		if result, err = translate(text.Value, text.HTML); err != nil {
+			return result, err
+		}
+		text.Value = result.Text
+	}
+	return result, nil
+}
+
+// feedItemPath returns the Markdown file of item in --markdown-dir, named
+// after its date and title.
+func feedItemPath(item *feed.Item) string {
+	slug := ""
+	if item.Title != nil {
+		slug = slugify(item.Title.Value)
+	}
+	if len(slug) > 80 {
+		slug = strings.TrimRight(slug[:80], "-")
+	}
+	if slug == "" {
+		sum := sha256.Sum256([]byte(item.ID + item.Link))
+		slug = hex.EncodeToString(sum[:6])
+	}
+	if date, ok := parseFeedDate(item.Published); ok {
+		slug = date.Format("2006-01-02") + "-" + slug
+	}
+	return filepath.Join(feedDir, slug+".md")
+}
+
+// writeFeedItem translates item and writes it as a Markdown file with
+// frontmatter to path, with the enabled analyses added to the
+// frontmatter. HTML content is converted to Markdown first.
+func writeFeedItem(ctx context.Context, t *translator.Translator, cfg *config.Config, item *feed.Item, path string, base *url.URL, translate translateFunc) (translator.TranslateResponse, error) {
+	var result translator.TranslateResponse
+	fields := map[string]interface{}{}
+	if item.Title != nil {
+		if err := translateText(item.Title, translate); err != nil {
+			return result, err
+		}
+		fields["title"] = strings.TrimSpace(item.Title.Value)
+	}
+	if date, ok := parseFeedDate(item.Published); ok {
+		fields["date"] = date.Format(time.RFC3339)
+	} else if item.Published != "" {
+		fields["date"] = item.Published
+	}
+	if item.Link != "" {
+		fields["link"] = item.Link
+	}
+	if item.Author != "" {
+		fields["author"] = item.Author
+	}
+	if len(item.Categories) > 0 {
+		fields["categories"] = item.Categories
+	}
+
+	content := ""
+	if body := item.Body(); body != nil {
+		source := body.Value
+		if body.HTML {
+			page, err := article.Fragment(body.Value, base)
+			if err != nil {
+				return result, err
+			}
+			source = page.Markdown()
+		}
+		var err error
+		if result, err = translate(source, true); err != nil {
+			return result, err
+		}
+		content = result.Text
+		for k, v := range analyzeTranslation(ctx, t, cfg, source, result, targetLang, path) {
+			fields[k] = v
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return result, err
+	}
+	output := buildFrontmatter(fields) + "\n" + strings.TrimSpace(content) + "\n"
+	if err := os.WriteFile(path, []byte(output), 0644); err != nil {
+		return result, fmt.Errorf("failed to write output file: %w", err)
+	}
+	return result, nil
+}
+
+// feedItemName names item in logs and the usage report.
+func feedItemName(item *feed.Item, index int) string {
+	switch {
+	case item.Link != "":
+		return item.Link
+	case item.ID != "":
+		return item.ID
+	}
+	return fmt.Sprintf("item %d", index+1)
+}
+
+// itemBase returns the URL relative links of item resolve against: its
+// link, or the feed URL.
+func itemBase(item *feed.Item, feedURL *url.URL) *url.URL {
+	if u, err := url.Parse(item.Link); err == nil && u.IsAbs() {
+		return u
+	}
+	return feedURL
+}
+
+func parseFeedDate(value string) (time.Time, bool) {
+	for _, layout := range feedDateLayouts {
+		if date, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,402 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Job states. Queued and running jobs are resumed when the server
+// restarts.
+const (
+	jobQueued    = "queued"
+	jobRunning   = "running"
+	jobDone      = "done"
+	jobFailed    = "failed"
+	jobCancelled = "cancelled"
+)
+
+// queuedJob is a translation submitted to the job queue.
+type queuedJob struct {
+	ID        string       `json:"id"`
+	Status    string       `json:"status"`
+	Record    jsonlRecord  `json:"request"`
+	Result    *jsonlResult `json:"result,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// copy returns a copy of the job that later changes of the queue do not
+// touch.
+func (j *queuedJob) copy() queuedJob {
+	c := *j
+	if j.Result != nil {
+		result := *j.Result
+		c.Result = &result
+	}
+	return c
+}
+
+// jobQueue runs submitted translations in the background and keeps their
+// state in a journal, so jobs survive restarts of the server. Each change
+// appends the changed job as one JSON line; the last line of a job wins.
+// The journal is compacted when it has grown to several times the jobs it
+// holds, dropping finished jobs older than the retention.
+type jobQueue struct {
+	path      string
+	retention time.Duration // 0 keeps finished jobs
+	srv       *translationServer
+
+	mu      sync.Mutex
+	jobs    []*queuedJob
+	file    *os.File
+	written int // lines in the journal
+	// cancels stops the running jobs by ID
+	cancels map[string]context.CancelFunc
+	// wake tells idle workers that jobs were queued
+	wake chan struct{}
+}
+
+// openJobQueue loads the jobs of the journal at path, if it exists, and
+// compacts it. Jobs that were running when the server stopped are queued
+// again.
+func openJobQueue(path string, retention time.Duration, srv *translationServer) (*jobQueue, error) {
+	q := &jobQueue{
+		path:      path,
+		retention: retention,
+		srv:       srv,
+		cancels:   make(map[string]context.CancelFunc),
+		wake:      make(chan struct{}, cap(srv.pool)),
+	}
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	for _, job := range q.jobs {
+		if job.Status == jobRunning {
+			job.Status = jobQueued
+		}
+	}
+	if err := q.compact(); err != nil {
+		return nil, fmt.Errorf("failed to write jobs file: %w", err)
+	}
+	return q, nil
+}
+
+// load replays the journal into the jobs, in the order of submission. A
+// last line that does not parse was torn by a crash while it was appended;
+// it is dropped with a warning and the compaction after loading removes it.
+func (q *jobQueue) load() error {
+	file, err := os.Open(q.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read jobs file: %w", err)
+	}
+	defer file.Close()
+
+	byID := make(map[string]*queuedJob)
+	scanner := bufio.NewScanner(file)
+	// A line holds a whole document and its translation
+	scanner.Buffer(nil, 256<<20)
+	var torn error
+	for line := 1; scanner.Scan(); line++ {
+		if torn != nil {
+			return torn
+		}
+		job := &queuedJob{}
+		if err := json.Unmarshal(scanner.Bytes(), job); err != nil {
+			torn = fmt.Errorf("failed to parse jobs file %s, line %d: %w", q.path, line, err)
+			continue
+		}
+		if prev, ok := byID[job.ID]; ok {
+			*prev = *job
+			continue
+		}
+		byID[job.ID] = job
+		q.jobs = append(q.jobs, job)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read jobs file: %w", err)
+	}
+	if torn != nil {
+		logWarn("Dropping the last line of the jobs file, torn by a crash: %v", torn)
+	}
+	return nil
+}
+
+// run processes queued jobs with one worker per translator of the server
+// until ctx is done.
+func (q *jobQueue) run(ctx context.Context) {
+	for i := 0; i < cap(q.srv.pool); i++ {
+		go q.work(ctx)
+	}
+}
+
+func (q *jobQueue) work(ctx context.Context) {
+	for {
+		job, jobCtx := q.next(ctx)
+		if job == nil {
+			select {
+			case <-q.wake:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		q.process(ctx, jobCtx, job)
+	}
+}
+
+// next marks the oldest queued job as running and returns it with the
+// context that cancels it, or nil when no job is queued.
+func (q *jobQueue) next(ctx context.Context) (*queuedJob, context.Context) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, job := range q.jobs {
+		if job.Status != jobQueued {
+			continue
+		}
+		jobCtx, cancel := context.WithCancel(ctx)
+		q.cancels[job.ID] = cancel
+		q.setStatus(job, jobRunning)
+		return job, jobCtx
+	}
+	return nil, nil
+}
+
+// process translates job and records the outcome, unless the job was
+// cancelled meanwhile. A job interrupted by the shutdown stays queued.
+func (q *jobQueue) process(ctx, jobCtx context.Context, job *queuedJob) {
+	record := job.Record
+	// The callback is sent for finished jobs only, not cancelled ones
+	record.Callback = ""
+
+	t, err := q.srv.acquire(jobCtx)
+	var result jsonlResult
+	if err == nil {
+		result = translateRecord(jobCtx, t, q.srv.cfg, q.srv.glossary, record)
+		q.srv.release(t)
+	}
+
+	q.mu.Lock()
+	q.cancels[job.ID]()
+	delete(q.cancels, job.ID)
+	status := ""
+	switch {
+	case job.Status == jobCancelled:
+		// Cancelled by the user, also when the server is stopping
+	case ctx.Err() != nil:
+		q.setStatus(job, jobQueued)
+	default:
+		status = jobDone
+		if result.Error != "" {
+			status = jobFailed
+		}
+		job.Result = &result
+		q.setStatus(job, status)
+	}
+	q.mu.Unlock()
+
+	if status == "" {
+		return
+	}
+	if verbose {
+		logInfo("Job %s %s, %d tokens", job.ID, status, result.TokensUsed)
+	}
+	if job.Record.Callback == "" {
+		return
+	}
+	if err := sendCallback(ctx, q.srv.cfg, job.Record.Callback, result); err != nil {
+		logWarn("Callback of job %s failed: %v", job.ID, err)
+		q.mu.Lock()
+		job.Result.CallbackError = err.Error()
+		q.save(job)
+		q.mu.Unlock()
+	}
+}
+
+// submit queues a translation of record and returns its job.
+func (q *jobQueue) submit(record jsonlRecord) (queuedJob, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return queuedJob{}, err
+	}
+	now := time.Now()
+	job := &queuedJob{
+		ID:        hex.EncodeToString(id),
+		Status:    jobQueued,
+		Record:    record,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.jobs = append(q.jobs, job)
+	if err := q.save(job); err != nil {
+		q.jobs = q.jobs[:len(q.jobs)-1]
+		return queuedJob{}, err
+	}
+	q.notify()
+	return job.copy(), nil
+}
+
+// get returns a copy of the job with id.
+func (q *jobQueue) get(id string) (queuedJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job := q.find(id)
+	if job == nil {
+		return queuedJob{}, false
+	}
+	return job.copy(), true
+}
+
+// cancel stops a queued or running job. Finished jobs are returned
+// unchanged with ok false.
+func (q *jobQueue) cancel(id string) (job queuedJob, found, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j := q.find(id)
+	if j == nil {
+		return queuedJob{}, false, false
+	}
+	if j.Status != jobQueued && j.Status != jobRunning {
+		return j.copy(), true, false
+	}
+	if cancel := q.cancels[j.ID]; cancel != nil {
+		cancel()
+	}
+	q.setStatus(j, jobCancelled)
+	return j.copy(), true, true
+}
+
+// retry queues a failed or cancelled job again. Other jobs are returned
+// unchanged with ok false.
+func (q *jobQueue) retry(id string) (job queuedJob, found, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j := q.find(id)
+	if j == nil {
+		return queuedJob{}, false, false
+	}
+	// A cancelled job may still be running until its worker notices
+	if (j.Status != jobFailed && j.Status != jobCancelled) || q.cancels[j.ID] != nil {
+		return j.copy(), true, false
+	}
+	j.Result = nil
+	q.setStatus(j, jobQueued)
+	q.notify()
+	return j.copy(), true, true
+}
+
+func (q *jobQueue) find(id string) *queuedJob {
+	for _, job := range q.jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
+
+// setStatus changes the status of job and saves it. Failures to save are
+// logged, the jobs stay in memory. Must be called with mu held.
+func (q *jobQueue) setStatus(job *queuedJob, status string) {
+	job.Status = status
+	job.UpdatedAt = time.Now()
+	q.save(job)
+}
+
+// save appends job to the journal and syncs it to disk, compacting the
+// journal when it has grown to several times the jobs it holds. Must be
+// called with mu held.
+func (q *jobQueue) save(job *queuedJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	if _, err := q.file.Write(append(data, '\n')); err != nil {
+		logWarn("Failed to save jobs file: %v", err)
+		return err
+	}
+	if err := q.file.Sync(); err != nil {
+		logWarn("Failed to sync jobs file: %v", err)
+		return err
+	}
+	q.written++
+	if q.written > 4*len(q.jobs)+64 {
+		if err := q.compact(); err != nil {
+			logWarn("Failed to compact jobs file: %v", err)
+		}
+	}
+	return nil
+}
+
+// compact drops finished jobs older than the retention and rewrites the
+// journal with one line per job. Must be called with mu held, or before
+// the workers start.
+func (q *jobQueue) compact() error {
+	kept := q.jobs[:0]
+	for _, job := range q.jobs {
+		finished := job.Status != jobQueued && job.Status != jobRunning
+		if finished && q.retention > 0 && time.Since(job.UpdatedAt) > q.retention && q.cancels[job.ID] == nil {
+			continue
+		}
+		kept = append(kept, job)
+	}
+	q.jobs = kept
+
+	tmp, err := os.OpenFile(q.path+".tmp", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	for _, job := range q.jobs {
+		data, err := json.Marshal(job)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		w.Write(append(data, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	// The journal is replaced only once the new one is on disk
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(q.path+".tmp", q.path); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(q.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if q.file != nil {
+		q.file.Close()
+	}
+	q.file, q.written = file, len(q.jobs)
+	return nil
+}
+
+// notify wakes an idle worker, if there is one.
+func (q *jobQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/translator"
+	"github.com/foxzi/llm-translate/internal/webhook"
+)
+
+// jsonlRecord is one input line of --jsonl mode. To and From default to
+// --to and --from. The result is also posted to Callback, if given.
+type jsonlRecord struct {
+	ID       json.RawMessage `json:"id"`
+	Text     string          `json:"text"`
+	To       string          `json:"to,omitempty"`
+	From     string          `json:"from,omitempty"`
+	Callback string          `json:"callback,omitempty"`
+}
+
+// jsonlResult is one output line of --jsonl mode.
+type jsonlResult struct {
+	ID         json.RawMessage        `json:"id,omitempty"`
+	Text       string                 `json:"text,omitempty"`
+	SourceLang string                 `json:"source_lang,omitempty"`
+	TargetLang string                 `json:"target_lang,omitempty"`
+	TokensUsed int                    `json:"tokens_used"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	// CallbackError is why the result could not be posted to the callback
+	CallbackError string `json:"callback_error,omitempty"`
+}
+
+// runJSONL translates the JSON records of the input lines with
+// --jsonl-concurrency workers and writes one JSON result per record, in
+// input order, as soon as it and the records before it are done.
+func runJSONL(ctx context.Context, cfg *config.Config) error {
+	if jsonlWorkers < 1 {
+		return fmt.Errorf("--jsonl-concurrency must be at least 1")
+	}
+	glossary, err := loadRunGlossary()
+	if err != nil {
+		return err
+	}
+
+	// Resolve the key once instead of in every worker
+	providerCfg := cfg.Providers[cfg.DefaultProvider]
+	if err := providerCfg.ResolveAPIKey(); err != nil {
+		return err
+	}
+	cfg.Providers[cfg.DefaultProvider] = providerCfg
+
+	var input io.Reader = os.Stdin
+	if inputFile != "" {
+		file, err := os.Open(inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to open input file: %w", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	var output io.Writer = os.Stdout
+	if outputFile != "" {
+		file, err := os.Create(outputFile)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer file.Close()
+		output = file
+	}
+
+	type job struct {
+		index int
+		line  string
+	}
+	type done struct {
+		index  int
+		result jsonlResult
+	}
+	jobs := make(chan job)
+	results := make(chan done)
+
+	var wg sync.WaitGroup
+	for w := 0; w < jsonlWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Translators are not safe for concurrent use
+			t := translator.New(compareConfig(cfg, cfg.DefaultProvider, ""), verbose)
+			for j := range jobs {
+				results <- done{j.index, translateLine(ctx, t, cfg, glossary, j.line)}
+			}
+		}()
+	}
+
+	var readErr error
+	go func() {
+		defer close(jobs)
+		scanner := bufio.NewScanner(input)
+		scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+		index := 0
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			jobs <- job{index, line}
+			index++
+		}
+		readErr = scanner.Err()
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := make(map[int]jsonlResult)
+	next, failed := 0, 0
+	for d := range results {
+		pending[d.index] = d.result
+		for {
+			result, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			if result.Error != "" {
+				failed++
+			}
+			data, err := json.Marshal(result)
+			if err != nil {
+				return fmt.Errorf("failed to encode result: %w", err)
+			}
+			if _, err := output.Write(append(data, '\n')); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+		}
+	}
+
+	if readErr != nil {
+		return fmt.Errorf("failed to read input: %w", readErr)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d records failed", failed, next)
+	}
+	return nil
+}
+
+// translateLine translates the JSON record on line, see translateRecord.
+func translateLine(ctx context.Context, t *translator.Translator, cfg *config.Config, glossary []config.GlossaryEntry, line string) jsonlResult {
+	var record jsonlRecord
+	if err := json.Unmarshal([]byte(line), &record); err != nil {
+		return jsonlResult{Error: fmt.Sprintf("invalid record: %v", err)}
+	}
+	return translateRecord(ctx, t, cfg, glossary, record)
+}
+
+// translateRecord translates record with t and runs the enabled analyses.
+// Failures are reported in the result.
+func translateRecord(ctx context.Context, t *translator.Translator, cfg *config.Config, glossary []config.GlossaryEntry, record jsonlRecord) (result jsonlResult) {
+	if record.Callback != "" {
+		defer func() {
+			if err := sendCallback(ctx, cfg, record.Callback, result); err != nil {
+				logWarn("Callback of record %s failed: %v", record.ID, err)
+				result.CallbackError = err.Error()
+			}
+		}()
+	}
+
+	result = jsonlResult{
+		ID:         record.ID,
+		SourceLang: record.From,
+		TargetLang: record.To,
+	}
+	if result.SourceLang == "" {
+		result.SourceLang = sourceLang
+	}
+	if result.TargetLang == "" {
+		result.TargetLang = targetLang
+	}
+	from, to, err := normalizeLangs(result.SourceLang, result.TargetLang)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.SourceLang, result.TargetLang = from, to
+	if strings.TrimSpace(record.Text) == "" {
+		result.Error = "record has no text"
+		return result
+	}
+
+	before := t.Meter().Total()
+	translated, err := t.Translate(ctx, translator.TranslateRequest{
+		Text:           record.Text,
+		SourceLang:     result.SourceLang,
+		TargetLang:     result.TargetLang,
+		Style:          style,
+		Context:        contextStr,
+		Temperature:    temperature,
+		MaxTokens:      maxTokens,
+		PreserveFormat: preserveFormat,
+		StrongMode:     strongMode,
+		StrongRetries:  strongRetries,
+		ReadingLevel:   cfg.Settings.ReadingLevel,
+		ReadingRetries: cfg.Settings.ReadingRetries,
+		MaxLength:      cfg.Settings.MaxLength,
+		MaxLenRatio:    cfg.Settings.MaxLenRatio,
+		LengthRetries:  cfg.Settings.LengthRetries,
+		Glossary:       glossary,
+	})
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Text = translated.Text
+	if translated.DetectedLang != "" {
+		result.SourceLang = translated.DetectedLang
+	}
+	result.Metadata = analyzeTranslation(ctx, t, cfg, record.Text, translated, result.TargetLang, "")
+	result.TokensUsed = t.Meter().Total().Tokens() - before.Tokens()
+	return result
+}
+
+// sendCallback posts result to the callback URL of its record, signed
+// with the webhooks secret.
+func sendCallback(ctx context.Context, cfg *config.Config, callback string, result jsonlResult) error {
+	payload, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{
+		Timeout: time.Duration(cfg.Settings.Timeout) * time.Second,
+		// A redirect could lead to a host the callback was not allowed to reach
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	return webhook.Send(ctx, client, callback, cfg.Webhooks, payload)
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/foxzi/llm-translate/internal/languages"
+	"github.com/spf13/cobra"
+)
+
+// newLanguagesCmd builds the "languages" command that lists the language
+// codes --from and --to accept.
+func newLanguagesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "languages",
+		Short: "List supported language codes and names",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "CODE\tNAME\tNATIVE")
+			for _, l := range languages.All() {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", l.Code, l.Name, l.Native)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// normalizeLangs returns the canonical tags of a source and a target
+// language, such as "pt-BR" for "pt_br" or "ru" for "Russian". The source
+// may be auto.
+func normalizeLangs(from, to string) (string, string, error) {
+	if from != "auto" {
+		var err error
+		if from, err = languages.Normalize(from); err != nil {
+			return "", "", fmt.Errorf("source language: %w", err)
+		}
+	}
+	to, err := languages.Normalize(to)
+	if err != nil {
+		return "", "", fmt.Errorf("target language: %w", err)
+	}
+	return from, to, nil
+}
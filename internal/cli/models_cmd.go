@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	llmprovider "github.com/foxzi/llm-translate/internal/provider"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pullProvider string
+	listProvider string
+)
+
+// newModelsCmd builds the "models" command group for local model servers.
+func newModelsCmd() *cobra.Command {
+	modelsCmd := &cobra.Command{
+		Use:   "models",
+		Short: "Manage models of local providers",
+	}
+
+	pullCmd := &cobra.Command{
+		Use:          "pull <model>",
+		Short:        "Download a model to the Ollama server",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runModelsPull(cmd, args[0])
+		},
+	}
+	pullCmd.Flags().StringVarP(&configPath, "config", "c", "", "Config file path")
+	pullCmd.Flags().StringVarP(&pullProvider, "provider", "p", "ollama", "Provider to pull the model with")
+	pullCmd.Flags().StringVarP(&baseURL, "base-url", "u", "", "Base URL for API")
+
+	listCmd := &cobra.Command{
+		Use:          "list",
+		Short:        "List the models of a provider with context sizes and prices",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runModelsList(cmd)
+		},
+	}
+	listCmd.Flags().StringVarP(&configPath, "config", "c", "", "Config file path")
+	listCmd.Flags().StringVarP(&listProvider, "provider", "p", "", "Provider (default: default_provider from config)")
+	listCmd.Flags().StringVarP(&baseURL, "base-url", "u", "", "Base URL for API")
+
+	modelsCmd.AddCommand(pullCmd, listCmd)
+	return modelsCmd
+}
+
+func runModelsPull(cmd *cobra.Command, model string) error {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	providerCfg := cfg.Providers[pullProvider]
+	if baseURL != "" {
+		providerCfg.BaseURL = baseURL
+	}
+	// Downloads take minutes, the command context cancels them
+	p, err := llmprovider.Get(pullProvider, providerCfg, &http.Client{})
+	if err != nil {
+		return err
+	}
+
+	lastStatus, lastStep := "", -1
+	err = llmprovider.PullModel(cmd.Context(), p, model, func(progress llmprovider.PullProgress) {
+		if progress.Total <= 0 {
+			if progress.Status != lastStatus {
+				logInfo("%s", progress.Status)
+			}
+			lastStatus = progress.Status
+			return
+		}
+		// Layers report often, log every 10%
+		step := int(progress.Completed * 10 / progress.Total)
+		if progress.Status != lastStatus || step != lastStep {
+			logInfo("%s: %d%% of %d MB", progress.Status, step*10, progress.Total>>20)
+		}
+		lastStatus, lastStep = progress.Status, step
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %w", model, err)
+	}
+	logInfo("Pulled %s", model)
+	return nil
+}
+
+func runModelsList(cmd *cobra.Command) error {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	name := listProvider
+	if name == "" {
+		name = cfg.DefaultProvider
+	}
+	providerCfg := cfg.Providers[name]
+	if baseURL != "" {
+		providerCfg.BaseURL = baseURL
+	}
+	if err := providerCfg.ResolveAPIKey(); err != nil {
+		return fmt.Errorf("provider %s: %w", name, err)
+	}
+	p, err := llmprovider.Get(name, providerCfg, &http.Client{Timeout: 30 * time.Second})
+	if err != nil {
+		return err
+	}
+
+	models, err := llmprovider.ListModels(cmd.Context(), p)
+	if err != nil {
+		return fmt.Errorf("failed to list models of %s: %w", name, err)
+	}
+
+	// Prices are per million tokens, like input_price and output_price
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MODEL\tCONTEXT\tINPUT\tOUTPUT")
+	for _, m := range models {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", m.ID, orDash(float64(m.ContextWindow), "%.0f"), orDash(m.InputPrice, "%g"), orDash(m.OutputPrice, "%g"))
+	}
+	return w.Flush()
+}
+
+// orDash formats an unknown (zero) value as "-".
+func orDash(v float64, format string) string {
+	if v == 0 {
+		return "-"
+	}
+	return fmt.Sprintf(format, v)
+}
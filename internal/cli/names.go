@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/foxzi/llm-translate/internal/translator"
+)
+
+// nameMap translates the file and directory names of site paths into
+// slugs in the target language. Paths maps source paths to target paths,
+// relative to the content directories, and is saved for rewriting links.
+// Names are translated once: a directory gets the same slug for all its
+// files and later runs reuse the saved names.
+type nameMap struct {
+	path    string
+	Paths   map[string]string
+	dirs    map[string]string // source directory -> target directory
+	targets map[string]string // target path or directory -> source
+}
+
+// loadNameMap reads the name map saved at path by a previous run, if any.
+func loadNameMap(path string) (*nameMap, error) {
+	m := &nameMap{
+		path:    path,
+		Paths:   make(map[string]string),
+		dirs:    make(map[string]string),
+		targets: make(map[string]string),
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read name map: %w", err)
+	}
+	var paths map[string]string
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return nil, fmt.Errorf("invalid name map %s: %w", path, err)
+	}
+	for src, dst := range paths {
+		m.add(src, dst)
+	}
+	return m, nil
+}
+
+// add records that file src is written to dst, along with the
+// directories of both.
+func (m *nameMap) add(src, dst string) {
+	m.Paths[src] = dst
+	m.targets[dst] = src
+	for srcDir, dstDir := path.Dir(src), path.Dir(dst); srcDir != "." && dstDir != "."; srcDir, dstDir = path.Dir(srcDir), path.Dir(dstDir) {
+		m.dirs[srcDir] = dstDir
+		m.targets[dstDir] = srcDir
+	}
+}
+
+// translate returns the target path of the source path rel, in slash
+// form. Directory names and page names are translated; resources and
+// names Hugo gives a meaning to (index.md, _index.md, names starting with
+// an underscore) keep their names.
+func (m *nameMap) translate(ctx context.Context, t *translator.Translator, rel string, page bool) (string, error) {
+	if dst, ok := m.Paths[rel]; ok {
+		return dst, nil
+	}
+
+	dir := "."
+	for _, name := range strings.Split(path.Dir(rel), "/") {
+		if name == "." {
+			break
+		}
+		srcDir := path.Join(m.sourceDir(dir), name)
+		dstDir, ok := m.dirs[srcDir]
+		if !ok {
+			slug, err := translateName(ctx, t, name)
+			if err != nil {
+				return "", err
+			}
+			dstDir = m.unique(path.Join(dir, slug), path.Join(dir, name), srcDir)
+			m.dirs[srcDir] = dstDir
+			m.targets[dstDir] = srcDir
+		}
+		dir = dstDir
+	}
+
+	base := path.Base(rel)
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	dst := path.Join(dir, base)
+	if page && stem != "index" && !strings.HasPrefix(stem, "_") {
+		slug, err := translateName(ctx, t, stem)
+		if err != nil {
+			return "", err
+		}
+		dst = m.unique(path.Join(dir, slug+ext), dst, rel)
+	}
+	m.Paths[rel] = dst
+	m.targets[dst] = rel
+	return dst, nil
+}
+
+// sourceDir returns the source directory written to target directory dir.
+func (m *nameMap) sourceDir(dir string) string {
+	if dir == "." {
+		return ""
+	}
+	return m.targets[dir]
+}
+
+// unique returns dst, or fallback when dst is taken by another source
+// whose name translated to the same slug.
+func (m *nameMap) unique(dst, fallback, src string) string {
+	if other, ok := m.targets[dst]; ok && other != src {
+		logWarn("%s and %s translate to the same name %s, keeping %s", other, src, dst, fallback)
+		return fallback
+	}
+	return dst
+}
+
+// save writes the name map as a JSON object of source to target paths.
+func (m *nameMap) save() error {
+	data, err := json.MarshalIndent(m.Paths, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.path, append(data, '\n'), 0644)
+}
+
+// translateName translates a file or directory name and makes a slug of
+// it. The name is kept when the translation gives no usable slug.
+func translateName(ctx context.Context, t *translator.Translator, name string) (string, error) {
+	words := strings.NewReplacer("-", " ", "_", " ").Replace(name)
+	result, err := t.Translate(ctx, translator.TranslateRequest{
+		Text:        words,
+		SourceLang:  sourceLang,
+		TargetLang:  targetLang,
+		Context:     "Name of a file or directory of a web site, used in its URLs. Output only the translated name on a single line.",
+		Temperature: temperature,
+		MaxTokens:   maxTokens,
+	})
+	if err != nil {
+		return "", fmt.Errorf("name %s: %w", name, err)
+	}
+	if slug := slugify(result.Text); slug != "" {
+		return slug, nil
+	}
+	return name, nil
+}
+
+// translit spells Cyrillic and accented Latin letters with ASCII letters.
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g", 'ў': "u",
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "ae", 'å': "a", 'æ': "ae",
+	'ç': "c", 'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ì': "i", 'í': "i",
+	'î': "i", 'ï': "i", 'ñ': "n", 'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o",
+	'ö': "oe", 'ø': "o", 'ù': "u", 'ú': "u", 'û': "u", 'ü': "ue", 'ý': "y",
+	'ÿ': "y", 'ß': "ss", 'ą': "a", 'ć': "c", 'č': "c", 'ď': "d", 'ę': "e",
+	'ě': "e", 'ğ': "g", 'ı': "i", 'ł': "l", 'ń': "n", 'ň': "n", 'ő': "o",
+	'œ': "oe", 'ř': "r", 'ś': "s", 'ş': "s", 'š': "s", 'ť': "t", 'ů': "u",
+	'ű': "u", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// slugify lowercases s, transliterates what translit knows and joins the
+// remaining ASCII letters and digits with hyphens. Other letters are
+// dropped.
+func slugify(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		spelled, ok := translit[r]
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			spelled = string(r)
+		case ok:
+		case unicode.IsLetter(r):
+			continue
+		default:
+			hyphen = b.Len() > 0
+			continue
+		}
+		if spelled == "" {
+			continue
+		}
+		if hyphen {
+			b.WriteByte('-')
+			hyphen = false
+		}
+		b.WriteString(spelled)
+	}
+	return b.String()
+}
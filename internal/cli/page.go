@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/foxzi/llm-translate/internal/article"
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/proxy"
+)
+
+// isWebURL reports whether --input names a web page rather than a file.
+func isWebURL(name string) bool {
+	return strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://")
+}
+
+// fetchURL downloads rawURL through the configured proxy. The response is
+// returned for its headers and final URL, its body is read.
+func fetchURL(ctx context.Context, cfg *config.Config, rawURL, accept string) ([]byte, *http.Response, error) {
+	client, err := proxy.NewHTTPClient(cfg.Proxy, cfg.Settings.Timeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("proxy: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	req.Header.Set("User-Agent", "llm-translate/"+Version)
+	req.Header.Set("Accept", accept)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("failed to fetch %s: %s", rawURL, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+	return body, resp, nil
+}
+
+// fetchArticle downloads the page at rawURL and returns its main article
+// as Markdown, or as HTML with --keep-html. Other content types, such as
+// plain text or Markdown, are returned as they are.
+func fetchArticle(ctx context.Context, cfg *config.Config, rawURL string) (string, error) {
+	body, resp, err := fetchURL(ctx, cfg, rawURL, "text/html,application/xhtml+xml,text/plain;q=0.9,*/*;q=0.8")
+	if err != nil {
+		return "", err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		if strings.TrimSpace(string(body)) == "" {
+			return "", fmt.Errorf("input is empty")
+		}
+		return string(body), nil
+	}
+
+	// Links are resolved against the URL after redirects
+	page, err := article.Extract(body, resp.Request.URL)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", rawURL, err)
+	}
+	if verbose {
+		logInfo("Extracted article %q from %s", page.Title, rawURL)
+	}
+	if keepHTML {
+		return page.HTML(), nil
+	}
+	return page.Markdown(), nil
+}
@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/foxzi/llm-translate/internal/storage"
+)
+
+// remoteRun holds local copies of the object storage URLs given to -i, -o
+// and -d. The run works on the copies; finish uploads what it wrote.
+type remoteRun struct {
+	tmp string
+
+	output    storage.Bucket
+	outputURL string
+	outputKey string
+
+	dir       storage.Bucket
+	dirURL    string
+	dirPrefix string
+	// hashes of the downloaded files of the directory, by relative path
+	hashes map[string]string
+}
+
+// stageRemote downloads the objects of the storage URLs among --input,
+// --output and --dir to a temporary directory and points the flags at the
+// copies. It returns nil when no URL is given.
+func stageRemote(ctx context.Context) (*remoteRun, error) {
+	if !storage.IsURL(inputFile) && !storage.IsURL(outputFile) && !storage.IsURL(inputDir) {
+		return nil, nil
+	}
+	tmp, err := os.MkdirTemp("", "llm-translate-")
+	if err != nil {
+		return nil, err
+	}
+	r := &remoteRun{tmp: tmp}
+	if err := r.stage(ctx); err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *remoteRun) stage(ctx context.Context) error {
+	if storage.IsURL(inputFile) {
+		bucket, key, err := storage.Open(inputFile)
+		if err != nil {
+			return err
+		}
+		data, err := bucket.Get(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to read input file: %w", err)
+		}
+		local := filepath.Join(r.tmp, "input", path.Base(key))
+		if err := writeStaged(local, data); err != nil {
+			return err
+		}
+		inputFile = local
+	}
+
+	if storage.IsURL(outputFile) {
+		bucket, key, err := storage.Open(outputFile)
+		if err != nil {
+			return err
+		}
+		if key == "" || strings.HasSuffix(key, "/") {
+			return fmt.Errorf("output URL %s names no object", outputFile)
+		}
+		local := filepath.Join(r.tmp, "output", path.Base(key))
+		if err := os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+			return err
+		}
+		if checkMode {
+			// --check compares with the existing translation
+			data, err := bucket.Get(ctx, key)
+			if err != nil {
+				return fmt.Errorf("failed to read output file: %w", err)
+			}
+			if err := writeStaged(local, data); err != nil {
+				return err
+			}
+		}
+		r.output, r.outputURL, r.outputKey = bucket, outputFile, key
+		outputFile = local
+	}
+
+	if storage.IsURL(inputDir) {
+		bucket, prefix, err := storage.Open(inputDir)
+		if err != nil {
+			return err
+		}
+		if prefix != "" && !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+		keys, err := bucket.List(ctx, prefix)
+		if err != nil {
+			return fmt.Errorf("failed to scan directory: %w", err)
+		}
+
+		// Sources, their translations and the manifest of --diff
+		exts := make(map[string]bool)
+		for _, ext := range parseExtensions(extensions) {
+			exts[ext] = true
+		}
+		local := filepath.Join(r.tmp, "dir")
+		r.hashes = make(map[string]string)
+		for _, key := range keys {
+			rel := strings.TrimPrefix(key, prefix)
+			if rel == "" || strings.HasSuffix(rel, "/") {
+				continue
+			}
+			if !exts[strings.ToLower(path.Ext(rel))] && path.Base(rel) != translationManifestName {
+				continue
+			}
+			data, err := bucket.Get(ctx, key)
+			if err != nil {
+				return fmt.Errorf("failed to download %s: %w", key, err)
+			}
+			file := filepath.Join(local, filepath.FromSlash(rel))
+			if err := writeStaged(file, data); err != nil {
+				return err
+			}
+			if r.hashes[rel], err = hashFile(file); err != nil {
+				return err
+			}
+		}
+		if err := os.MkdirAll(local, 0755); err != nil {
+			return err
+		}
+		if verbose {
+			logInfo("Downloaded %d files from %s", len(r.hashes), inputDir)
+		}
+		r.dir, r.dirURL, r.dirPrefix = bucket, inputDir, prefix
+		inputDir = local
+	}
+	return nil
+}
+
+// finish uploads the files the run created or changed and removes the
+// local copies. The directory is uploaded even after runErr, so a rerun
+// resumes; a single output only after success. Uploads are not cancelled
+// with ctx.
+func (r *remoteRun) finish(ctx context.Context, runErr error) error {
+	defer os.RemoveAll(r.tmp)
+	ctx = context.WithoutCancel(ctx)
+
+	var errs []error
+	if r.dir != nil {
+		uploaded := 0
+		local := filepath.Join(r.tmp, "dir")
+		err := filepath.Walk(local, func(file string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(local, file)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			hash, err := hashFile(file)
+			if err != nil || hash == r.hashes[rel] {
+				return err
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			if err := r.dir.Put(ctx, r.dirPrefix+rel, data); err != nil {
+				return err
+			}
+			uploaded++
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to upload to %s: %w", r.dirURL, err))
+		}
+		if verbose {
+			logInfo("Uploaded %d files to %s", uploaded, r.dirURL)
+		}
+	}
+
+	if r.output != nil && runErr == nil && !checkMode && !dryRun {
+		// The output with its sidecars, such as the embedding file
+		dir := path.Dir(r.outputKey)
+		entries, err := os.ReadDir(filepath.Join(r.tmp, "output"))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, entry := range entries {
+			data, err := os.ReadFile(filepath.Join(r.tmp, "output", entry.Name()))
+			if err == nil {
+				key := entry.Name()
+				if dir != "." {
+					key = dir + "/" + key
+				}
+				err = r.output.Put(ctx, key, data)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to upload to %s: %w", r.outputURL, err))
+				break
+			}
+		}
+	}
+
+	if runErr != nil {
+		for _, err := range errs {
+			logError("%v", err)
+		}
+		return runErr
+	}
+	return errors.Join(errs...)
+}
+
+// writeStaged writes a downloaded object, creating its directory.
+func writeStaged(file string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/translator"
+	"github.com/spf13/cobra"
+)
+
+// replHistory is the number of previous exchanges sent as context.
+const replHistory = 5
+
+const replHelp = `Paste text and press Enter on an empty line to translate it.
+  /to <lang>       translate into lang
+  /from <lang>     source language, auto to detect
+  /style <style>   translation style, none to clear
+  /clear           forget the previous exchanges
+  /help            show this help
+  /quit            exit`
+
+// newReplCmd builds the "repl" command that translates snippets typed or
+// pasted interactively. It accepts all translation flags.
+func newReplCmd(rootCmd *cobra.Command) *cobra.Command {
+	replCmd := &cobra.Command{
+		Use:          "repl",
+		Short:        "Translate snippets interactively, keeping the conversation as context",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRepl(cmd.Context(), cmd)
+		},
+	}
+
+	replCmd.Flags().AddFlagSet(rootCmd.Flags())
+
+	return replCmd
+}
+
+// replExchange is a translated snippet.
+type replExchange struct {
+	source, translation string
+}
+
+func runRepl(ctx context.Context, cmd *cobra.Command) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+	glossary, err := loadRunGlossary()
+	if err != nil {
+		return err
+	}
+	t := translator.New(cfg, verbose)
+
+	// Prompts go to stderr, so stdout holds only translations
+	stat, _ := os.Stdin.Stat()
+	interactive := stat.Mode()&os.ModeCharDevice != 0
+	prompt := func(p string) {
+		if interactive {
+			fmt.Fprint(os.Stderr, p)
+		}
+	}
+	if interactive {
+		fmt.Fprintf(os.Stderr, "Translating from %s to %s, /help for commands.\n", sourceLang, targetLang)
+	}
+
+	// Lines are read in the background, so an interrupt ends the session
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(os.Stdin)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	var history []replExchange
+	var snippet []string
+	translate := func() {
+		source := strings.Join(snippet, "\n")
+		snippet = nil
+		result, err := t.Translate(ctx, translator.TranslateRequest{
+			Text:           source,
+			SourceLang:     sourceLang,
+			TargetLang:     targetLang,
+			Style:          style,
+			Context:        replContext(history),
+			Temperature:    temperature,
+			MaxTokens:      maxTokens,
+			PreserveFormat: preserveFormat,
+			StrongMode:     strongMode,
+			StrongRetries:  strongRetries,
+			ReadingLevel:   cfg.Settings.ReadingLevel,
+			ReadingRetries: cfg.Settings.ReadingRetries,
+			MaxLength:      cfg.Settings.MaxLength,
+			MaxLenRatio:    cfg.Settings.MaxLenRatio,
+			LengthRetries:  cfg.Settings.LengthRetries,
+			Glossary:       glossary,
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				logError("Translation failed: %v", err)
+			}
+			return
+		}
+		fmt.Println(strings.TrimSpace(result.Text))
+		history = append(history, replExchange{source, strings.TrimSpace(result.Text)})
+		if len(history) > replHistory {
+			history = history[1:]
+		}
+	}
+
+	for {
+		if len(snippet) == 0 {
+			prompt(targetLang + "> ")
+		} else {
+			prompt("... ")
+		}
+
+		var line string
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return nil
+		case line, ok = <-lines:
+		}
+		if !ok {
+			break
+		}
+
+		switch {
+		case len(snippet) == 0 && strings.HasPrefix(line, "/"):
+			if !replCommand(line, cfg.Prompts.Styles, &history) {
+				return nil
+			}
+		case strings.TrimSpace(line) != "":
+			snippet = append(snippet, line)
+		case len(snippet) > 0:
+			translate()
+		}
+	}
+
+	// Text after the last empty line
+	if len(snippet) > 0 {
+		translate()
+	}
+	if verbose {
+		logUsage(t.Meter().Total())
+	}
+	return nil
+}
+
+// replCommand runs a slash command of the session, styles are the known
+// styles. It returns false to end the session.
+func replCommand(line string, styles map[string]string, history *[]replExchange) bool {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case "/to", "/from":
+		if arg == "" {
+			fmt.Fprintf(os.Stderr, "Translating from %s to %s\n", sourceLang, targetLang)
+			break
+		}
+		from, to := sourceLang, targetLang
+		if name == "/to" {
+			to = arg
+		} else {
+			from = arg
+		}
+		from, to, err := normalizeLangs(from, to)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			break
+		}
+		sourceLang, targetLang = from, to
+		// Earlier translations are in another language now
+		*history = nil
+	case "/style":
+		switch arg {
+		case "":
+			fmt.Fprintf(os.Stderr, "Style: %s\n", style)
+		case "none":
+			style = ""
+		default:
+			if _, ok := styles[arg]; !ok {
+				fmt.Fprintf(os.Stderr, "Unknown style %s, use %s\n", arg, config.Choices(styles))
+				break
+			}
+			style = arg
+		}
+	case "/clear":
+		*history = nil
+	case "/help":
+		fmt.Fprintln(os.Stderr, replHelp)
+	case "/quit", "/exit":
+		return false
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %s, /help lists the commands\n", name)
+	}
+	return true
+}
+
+// replContext returns the --context text with the previous exchanges of
+// the session, which keep terms and tone consistent.
+func replContext(history []replExchange) string {
+	var b strings.Builder
+	b.WriteString(contextStr)
+	if len(history) > 0 {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString("Earlier in this session, translated the same way:")
+		for _, e := range history {
+			fmt.Fprintf(&b, "\nSource: %s\nTranslation: %s", e.source, e.translation)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,336 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultReviewDir is used by --review-dir without a value and by the
+// review command.
+const defaultReviewDir = "pending-review"
+
+// reviewManifestName is the manifest file inside the review directory.
+const reviewManifestName = "manifest.json"
+
+// qaKeys are the frontmatter keys set by the checks. A translation with any
+// of them goes to review.
+var qaKeys = []string{
+	"numeric_issues",
+	"missing_links",
+	"injection_suspected",
+	"capitalization_issues",
+	"term_issues",
+}
+
+var reviewAll bool
+
+// reviewManifest lists the translations in the review directory. Approving
+// one moves it to its output path, rejecting one deletes it so the next run
+// translates the source again.
+type reviewManifest struct {
+	Files []reviewEntry `json:"files"`
+}
+
+type reviewEntry struct {
+	Pending  string    `json:"pending"` // translation waiting for review
+	Output   string    `json:"output"`  // where it is moved on approval
+	Source   string    `json:"source"`
+	Reasons  []string  `json:"reasons"`
+	Status   string    `json:"status"` // pending or rejected
+	QueuedAt time.Time `json:"queued_at"`
+	InputDir string    `json:"input_dir,omitempty"` // holds the translation manifest, in directory mode
+}
+
+// newReviewCmd builds the "review" command group for translations held
+// back by --review-dir.
+func newReviewCmd() *cobra.Command {
+	reviewCmd := &cobra.Command{
+		Use:   "review",
+		Short: "List, approve or reject translations that failed checks",
+	}
+
+	listCmd := &cobra.Command{
+		Use:          "list",
+		Short:        "List translations waiting for review",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReviewList()
+		},
+	}
+
+	approveCmd := &cobra.Command{
+		Use:          "approve [file...]",
+		Short:        "Move reviewed translations to their output paths",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReviewDecision(args, true)
+		},
+	}
+
+	rejectCmd := &cobra.Command{
+		Use:          "reject [file...]",
+		Short:        "Discard translations so the next run translates them again",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReviewDecision(args, false)
+		},
+	}
+
+	for _, cmd := range []*cobra.Command{listCmd, approveCmd, rejectCmd} {
+		cmd.Flags().StringVar(&reviewDir, "review-dir", "", "Review directory (default: "+defaultReviewDir+")")
+	}
+	approveCmd.Flags().BoolVar(&reviewAll, "all", false, "Approve all pending translations")
+	rejectCmd.Flags().BoolVar(&reviewAll, "all", false, "Reject all pending translations")
+
+	reviewCmd.AddCommand(listCmd, approveCmd, rejectCmd)
+	return reviewCmd
+}
+
+// qaFailures returns the checks a translation failed, including chunks
+// taken from the fallback cache.
+func qaFailures(fmUpdates map[string]interface{}, stale int) []string {
+	var reasons []string
+	for _, key := range qaKeys {
+		if _, ok := fmUpdates[key]; ok {
+			reasons = append(reasons, key)
+		}
+	}
+	if stale > 0 {
+		reasons = append(reasons, "stale_chunks")
+	}
+	return reasons
+}
+
+// pendingPath places the translation for output inside dir, keeping its
+// path so files with the same name do not collide.
+func pendingPath(dir, output string) string {
+	if !filepath.IsLocal(output) {
+		if abs, err := filepath.Abs(output); err == nil {
+			output = abs
+		}
+		output = strings.TrimPrefix(output, filepath.VolumeName(output))
+	}
+	return filepath.Join(dir, output)
+}
+
+func loadReviewManifest(dir string) (*reviewManifest, error) {
+	m := &reviewManifest{}
+	data, err := os.ReadFile(filepath.Join(dir, reviewManifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid review manifest: %w", err)
+	}
+	return m, nil
+}
+
+func (m *reviewManifest) save(dir string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, reviewManifestName), append(data, '\n'), 0644)
+}
+
+// remove drops the entry for output, reporting whether there was one.
+func (m *reviewManifest) remove(output string) bool {
+	for i, e := range m.Files {
+		if e.Output == output {
+			m.Files = append(m.Files[:i], m.Files[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// queueForReview writes a translation that failed checks to the review
+// directory instead of its output path and records it in the manifest.
+func queueForReview(dir, source, output, text string, reasons []string) error {
+	m, err := loadReviewManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	pending := pendingPath(dir, output)
+	if err := os.MkdirAll(filepath.Dir(pending), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(pending, []byte(text), 0644); err != nil {
+		return err
+	}
+
+	m.remove(output)
+	m.Files = append(m.Files, reviewEntry{
+		Pending:  pending,
+		Output:   output,
+		Source:   source,
+		Reasons:  reasons,
+		Status:   "pending",
+		QueuedAt: time.Now(),
+		InputDir: inputDir,
+	})
+	return m.save(dir)
+}
+
+// clearReview drops an earlier queued translation of output after a new
+// one passed the checks, so approving cannot overwrite it.
+func clearReview(dir, output string) error {
+	m, err := loadReviewManifest(dir)
+	if err != nil {
+		return err
+	}
+	if !m.remove(output) {
+		return nil
+	}
+	if err := os.Remove(pendingPath(dir, output)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return m.save(dir)
+}
+
+func runReviewList() error {
+	dir := reviewDirOrDefault()
+	m, err := loadReviewManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	if len(m.Files) == 0 {
+		logInfo("No translations waiting for review in %s", dir)
+		return nil
+	}
+	for _, e := range m.Files {
+		fmt.Printf("%s\t%s -> %s\t%s\n", e.Status, e.Pending, e.Output, strings.Join(e.Reasons, ", "))
+	}
+	return nil
+}
+
+// runReviewDecision approves or rejects the pending translations named by
+// their pending path, output path or source, or all of them with --all.
+func runReviewDecision(args []string, approve bool) error {
+	if len(args) == 0 && !reviewAll {
+		return fmt.Errorf("name the files to review, or use --all")
+	}
+
+	dir := reviewDirOrDefault()
+	m, err := loadReviewManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	matched := make(map[string]bool)
+	var failed []string
+	kept := m.Files[:0]
+	for _, e := range m.Files {
+		arg := matchReviewEntry(e, args)
+		if e.Status != "pending" || (!reviewAll && arg == "") {
+			kept = append(kept, e)
+			continue
+		}
+		matched[arg] = true
+
+		if approve {
+			if err := moveFile(e.Pending, e.Output); err != nil {
+				logError("Failed to approve %s: %v", e.Pending, err)
+				failed = append(failed, e.Pending)
+				kept = append(kept, e)
+				continue
+			}
+			logInfo("Approved %s -> %s", e.Pending, e.Output)
+			continue
+		}
+
+		if err := os.Remove(e.Pending); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logError("Failed to reject %s: %v", e.Pending, err)
+			failed = append(failed, e.Pending)
+			kept = append(kept, e)
+			continue
+		}
+		if err := invalidateTranslation(e); err != nil {
+			logWarn("Failed to update translation manifest for %s: %v", e.Output, err)
+		}
+		// Kept until the next run translates the source again
+		e.Status = "rejected"
+		kept = append(kept, e)
+		logInfo("Rejected %s, %s will be translated again on the next run", e.Pending, e.Source)
+	}
+	m.Files = kept
+
+	for _, arg := range args {
+		if !matched[arg] {
+			logWarn("No pending translation for %s", arg)
+		}
+	}
+
+	if err := m.save(dir); err != nil {
+		return fmt.Errorf("failed to write review manifest: %w", err)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d translations could not be processed", len(failed))
+	}
+	return nil
+}
+
+// invalidateTranslation marks the output of a rejected entry as outdated
+// in the translation manifest, so --diff translates its source again even
+// when an older translation is in place.
+func invalidateTranslation(e reviewEntry) error {
+	if e.InputDir == "" {
+		return nil
+	}
+	if _, err := os.Stat(e.InputDir); err != nil {
+		return err
+	}
+	m, err := loadTranslationManifest(e.InputDir)
+	if err != nil {
+		return err
+	}
+	m.invalidate(e.Source, e.Output)
+	return m.save()
+}
+
+func reviewDirOrDefault() string {
+	if reviewDir == "" {
+		return defaultReviewDir
+	}
+	return reviewDir
+}
+
+// matchReviewEntry returns the argument naming the entry, or "".
+func matchReviewEntry(e reviewEntry, args []string) string {
+	for _, arg := range args {
+		clean := filepath.Clean(arg)
+		if clean == filepath.Clean(e.Pending) || clean == filepath.Clean(e.Output) || clean == filepath.Clean(e.Source) {
+			return arg
+		}
+	}
+	return ""
+}
+
+// moveFile renames src to dst, copying when they are on different file
+// systems.
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"os"
+	"strings"
+
+	"github.com/foxzi/llm-translate/api/schema"
+	"github.com/spf13/cobra"
+)
+
+// newSchemaCmd builds the "schema" command group that publishes the JSON
+// Schemas of the machine-readable output.
+func newSchemaCmd() *cobra.Command {
+	schemaCmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schemas of the JSON output, results and reports",
+	}
+
+	printCmd := &cobra.Command{
+		Use:          "print <name>",
+		Short:        "Print a JSON Schema: " + strings.Join(schema.Names(), ", "),
+		Args:         cobra.ExactArgs(1),
+		ValidArgs:    schema.Names(),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := schema.Get(args[0])
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(data)
+			return err
+		},
+	}
+
+	schemaCmd.AddCommand(printCmd)
+	return schemaCmd
+}
@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/translator"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// selftestFiles holds the sample corpus, its glossary and the expected
+// translations of the mock provider.
+//
+//go:embed selftest
+var selftestFiles embed.FS
+
+var selftestOut string
+
+// selftestCase translates one corpus file. chunks is the expected number of
+// chunks, 0 when not checked.
+type selftestCase struct {
+	file     string
+	from, to string
+	strong   bool
+	glossary bool
+	chunks   int
+}
+
+// selftestCases cover chunking, YAML and TOML frontmatter, frontmatter-only
+// files, data files, the glossary and strong validation.
+var selftestCases = []selftestCase{
+	{file: "guide.en.md", from: "en", to: "de", strong: true, glossary: true, chunks: 3},
+	{file: "notes.de.md", from: "de", to: "en", strong: true, chunks: 1},
+	{file: "news.ru.md", from: "ru", to: "en", chunks: 1},
+	{file: "intro.ja.md", from: "ja", to: "en", chunks: 1},
+	{file: "about.en.md", from: "en", to: "fr", chunks: 2},
+	{file: "team.en.yaml", from: "en", to: "es", chunks: 4},
+}
+
+// selftestChunkSize splits the guide into several chunks.
+const selftestChunkSize = 300
+
+// newSelftestCmd builds the "selftest" command, which runs the bundled
+// corpus through the offline mock provider and compares the output with
+// the expected files.
+func newSelftestCmd() *cobra.Command {
+	selftestCmd := &cobra.Command{
+		Use:          "selftest",
+		Short:        "Translate a bundled sample corpus offline and compare with expected output",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSelftest(cmd)
+		},
+	}
+	selftestCmd.Flags().StringVarP(&selftestOut, "output", "o", "", "Keep the translations in this directory")
+	return selftestCmd
+}
+
+func runSelftest(cmd *cobra.Command) error {
+	data, err := selftestFiles.ReadFile("selftest/glossary.yaml")
+	if err != nil {
+		return err
+	}
+	var glossaryFile struct {
+		Terms []config.GlossaryEntry `yaml:"terms"`
+	}
+	if err := yaml.Unmarshal(data, &glossaryFile); err != nil {
+		return fmt.Errorf("invalid selftest glossary: %w", err)
+	}
+
+	dir := selftestOut
+	if dir == "" {
+		if dir, err = os.MkdirTemp("", "llm-translate-selftest-"); err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+	} else if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	failures := 0
+	for _, c := range selftestCases {
+		if err := runSelftestCase(cmd, c, dir, glossaryFile.Terms); err != nil {
+			logError("%s (%s -> %s): %v", c.file, c.from, c.to, err)
+			failures++
+			continue
+		}
+		logInfo("%s (%s -> %s): ok", c.file, c.from, c.to)
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d of %d selftest cases failed", failures, len(selftestCases))
+	}
+	logInfo("All %d selftest cases passed", len(selftestCases))
+	return nil
+}
+
+// runSelftestCase translates a corpus file into dir with a clean default
+// config, so neither the user config nor flags change the result.
+func runSelftestCase(cmd *cobra.Command, c selftestCase, dir string, glossary []config.GlossaryEntry) error {
+	source, err := selftestFiles.ReadFile(path.Join("selftest/corpus", c.file))
+	if err != nil {
+		return err
+	}
+	ext := filepath.Ext(c.file)
+	outName := strings.TrimSuffix(c.file, ext) + "." + c.to + ext
+	inputPath := filepath.Join(dir, c.file)
+	outputPath := filepath.Join(dir, outName)
+	if err := os.WriteFile(inputPath, source, 0644); err != nil {
+		return err
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.DefaultProvider = "mock"
+	cfg.Providers["mock"] = config.ProviderConfig{}
+	cfg.Settings.ChunkSize = selftestChunkSize
+	cfg.Settings.CheckNumbers = true
+	cfg.Settings.CheckLinks = true
+	cfg.StrongValidation.Enabled = c.strong
+
+	// translateFileContent reads the request options from the flags
+	sourceLang, targetLang = c.from, c.to
+	style, contextStr, preserveFormat = "", "", false
+	temperature, maxTokens = cfg.Settings.Temperature, cfg.Settings.MaxTokens
+	strongMode, strongRetries = c.strong, cfg.StrongValidation.MaxRetries
+	if !c.glossary {
+		glossary = nil
+	}
+
+	t := translator.New(cfg, verbose)
+	fmUpdates, result, _, err := translateFileContent(cmd.Context(), t, cfg, inputPath, outputPath, glossary)
+	if err != nil {
+		return err
+	}
+	if reasons := qaFailures(fmUpdates, result.StaleChunks); len(reasons) > 0 {
+		return fmt.Errorf("checks failed: %s", strings.Join(reasons, ", "))
+	}
+	if c.chunks > 0 && t.Chunks() != c.chunks {
+		return fmt.Errorf("split into %d chunks, expected %d", t.Chunks(), c.chunks)
+	}
+
+	got, err := os.ReadFile(outputPath)
+	if err != nil {
+		return err
+	}
+	want, err := selftestFiles.ReadFile(path.Join("selftest/golden", outName))
+	if err != nil {
+		return fmt.Errorf("no expected output: %w", err)
+	}
+	return compareGolden(string(got), string(want))
+}
+
+// compareGolden reports the first line where got differs from want.
+func compareGolden(got, want string) error {
+	if got == want {
+		return nil
+	}
+	gotLines, wantLines := strings.Split(got, "\n"), strings.Split(want, "\n")
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w {
+			return fmt.Errorf("output differs at line %d:\n  got:  %q\n  want: %q", i+1, g, w)
+		}
+	}
+	return fmt.Errorf("output differs")
+}
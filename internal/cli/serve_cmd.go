@@ -0,0 +1,379 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/languages"
+	"github.com/foxzi/llm-translate/internal/rpc"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveGRPC      string
+	serveJobs      string
+	serveRetention time.Duration // finished jobs are dropped from the jobs file after this long
+)
+
+// newServeCmd builds the "serve" command that exposes translation,
+// language detection and analysis over gRPC. It accepts all root flags as
+// defaults for the requests.
+func newServeCmd(rootCmd *cobra.Command) *cobra.Command {
+	serveCmd := &cobra.Command{
+		Use:          "serve",
+		Short:        "Serve translation, language detection and analysis over gRPC",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServe(cmd.Context(), cmd)
+		},
+	}
+
+	serveCmd.Flags().AddFlagSet(rootCmd.Flags())
+	serveCmd.Flags().StringVar(&serveGRPC, "grpc", "", "Address to serve the gRPC API on (e.g. :50051)")
+	serveCmd.Flags().IntVar(&serverWorkers, "workers", 4, "Number of requests translated at once")
+	serveCmd.Flags().StringVar(&serveJobs, "jobs-file", "", "File keeping the state of queued jobs; enables the job RPCs")
+	serveCmd.Flags().DurationVar(&serveRetention, "jobs-retention", 7*24*time.Hour, "How long finished jobs are kept in the jobs file (0 = forever)")
+	serveCmd.MarkFlagRequired("grpc")
+
+	return serveCmd
+}
+
+// runServe serves the API of api/llmtranslate/v1/translator.proto over
+// plaintext HTTP/2 until ctx is done.
+func runServe(ctx context.Context, cmd *cobra.Command) error {
+	srv, err := newTranslationServer(cmd)
+	if err != nil {
+		return err
+	}
+	service := &grpcService{srv: srv}
+	if serveJobs != "" {
+		if service.jobs, err = openJobQueue(serveJobs, serveRetention, srv); err != nil {
+			return err
+		}
+		service.jobs.run(ctx)
+	}
+
+	addr, err := serveAddress(serveGRPC, srv.cfg.Server.Token)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	server := &http.Server{
+		Handler:   rpc.Handler(service, srv.cfg.Server.Token),
+		Protocols: &protocols,
+	}
+	go func() {
+		<-ctx.Done()
+		// Lets running requests finish, the second signal forces the exit
+		server.Shutdown(context.Background())
+	}()
+
+	logInfo("Serving gRPC on %s (provider %s, model %s)", listener.Addr(), srv.cfg.DefaultProvider, getModelForProvider(srv.cfg))
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("gRPC server failed: %w", err)
+	}
+	return nil
+}
+
+// serveAddress returns the address to listen on. Requests spend API
+// credits, so without a token the server stays on loopback: an address
+// without host becomes 127.0.0.1, other hosts are refused.
+func serveAddress(addr, token string) (string, error) {
+	if token != "" {
+		return addr, nil
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid --grpc address %s: %w", addr, err)
+	}
+	if host == "" {
+		return net.JoinHostPort("127.0.0.1", port), nil
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return "", fmt.Errorf("serving on %s needs server.token in config, clients then authenticate with it", addr)
+	}
+	return addr, nil
+}
+
+// grpcService implements the Translator service with the translators of
+// the server. Jobs is nil without --jobs-file.
+type grpcService struct {
+	srv  *translationServer
+	jobs *jobQueue
+}
+
+func (s *grpcService) Translate(ctx context.Context, req *rpc.TranslateRequest) (*rpc.TranslateResponse, error) {
+	if strings.TrimSpace(req.Text) == "" {
+		return nil, rpc.Errorf(rpc.CodeInvalidArgument, "request has no text")
+	}
+	if err := s.checkCallback(req.Callback); err != nil {
+		return nil, err
+	}
+	t, err := s.srv.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.srv.release(t)
+
+	id, _ := json.Marshal(req.ID)
+	result := translateRecord(ctx, t, s.srv.cfg, s.srv.glossary, jsonlRecord{
+		ID:       id,
+		Text:     req.Text,
+		From:     req.SourceLang,
+		To:       req.TargetLang,
+		Callback: req.Callback,
+	})
+	if result.Error != "" {
+		return nil, errors.New(result.Error)
+	}
+	metadata, err := encodeMetadata(result.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return &rpc.TranslateResponse{
+		ID:            req.ID,
+		Text:          result.Text,
+		SourceLang:    result.SourceLang,
+		TargetLang:    result.TargetLang,
+		TokensUsed:    int32(result.TokensUsed),
+		MetadataJSON:  metadata,
+		CallbackError: result.CallbackError,
+	}, nil
+}
+
+func (s *grpcService) Detect(ctx context.Context, req *rpc.DetectRequest) (*rpc.DetectResponse, error) {
+	if strings.TrimSpace(req.Text) == "" {
+		return nil, rpc.Errorf(rpc.CodeInvalidArgument, "request has no text")
+	}
+	t, err := s.srv.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.srv.release(t)
+
+	lang, err := t.DetectLanguage(ctx, req.Text)
+	if err != nil {
+		return nil, err
+	}
+	return &rpc.DetectResponse{Language: lang}, nil
+}
+
+// Analyze runs the analyses of the analyze command on the text. The
+// request may name the analyses to run and the language of the text, which
+// default to those of the server.
+func (s *grpcService) Analyze(ctx context.Context, req *rpc.AnalyzeRequest) (*rpc.AnalyzeResponse, error) {
+	if strings.TrimSpace(req.Text) == "" {
+		return nil, rpc.Errorf(rpc.CodeInvalidArgument, "request has no text")
+	}
+	lang := s.srv.cfg.DefaultTargetLanguage
+	if req.TargetLang != "" {
+		var err error
+		if lang, err = languages.Normalize(req.TargetLang); err != nil {
+			return nil, rpc.Errorf(rpc.CodeInvalidArgument, "target language: %v", err)
+		}
+	}
+	cfg, err := requestAnalyses(s.srv.cfg, req.Analyses)
+	if err != nil {
+		return nil, rpc.Errorf(rpc.CodeInvalidArgument, "%v", err)
+	}
+
+	t, err := s.srv.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.srv.release(t)
+
+	before := t.Meter().Total()
+	analysisText := redactForAnalysis(cfg, req.Text)
+	fmUpdates := runAnalysis(ctx, t, cfg, analysisText, lang, verbose)
+	runHeadline(ctx, t, cfg, analysisText, fmUpdates, verbose)
+	runReadability(cfg, req.Text, lang, fmUpdates)
+
+	metadata, err := encodeMetadata(fmUpdates)
+	if err != nil {
+		return nil, err
+	}
+	return &rpc.AnalyzeResponse{
+		MetadataJSON: metadata,
+		TokensUsed:   int32(t.Meter().Total().Tokens() - before.Tokens()),
+	}, nil
+}
+
+// requestAnalyses returns a copy of cfg with only the named analyses
+// enabled, or cfg itself when no names are given. Names are those of the
+// analyses in the settings section and of the custom analyzers; tags keep
+// the server's count, or 5 without one.
+func requestAnalyses(cfg *config.Config, names []string) (*config.Config, error) {
+	if len(names) == 0 {
+		return cfg, nil
+	}
+	builtin := map[string]bool{
+		"sentiment": true, "tags": true, "classify": true, "emotions": true,
+		"factuality": true, "impact": true, "sensationalism": true, "entities": true,
+		"events": true, "usefulness": true, "time_focus": true, "ad_detect": true,
+	}
+	var analyzers []string
+	headline, readability := false, false
+	for _, name := range names {
+		switch {
+		case builtin[name]:
+		case name == "headline":
+			headline = true
+		case name == "readability":
+			readability = true
+		default:
+			if _, ok := cfg.Analyzers[name]; !ok {
+				return nil, fmt.Errorf("unknown analysis %q", name)
+			}
+			analyzers = append(analyzers, name)
+		}
+	}
+
+	selected := onlyAnalyses(cfg, names)
+	s := &selected.Settings
+	if slices.Contains(names, "tags") && s.TagsCount == 0 {
+		s.TagsCount = 5
+	}
+	s.Headline = headline
+	s.Readability = readability
+	s.RunAnalyzers = analyzers
+	return selected, nil
+}
+
+// checkCallback refuses callback URLs whose host is not listed in
+// server.callback_hosts, so clients cannot make the server post to
+// internal services.
+func (s *grpcService) checkCallback(callback string) error {
+	if callback == "" {
+		return nil
+	}
+	target, err := url.Parse(callback)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+		return rpc.Errorf(rpc.CodeInvalidArgument, "invalid callback URL %q", callback)
+	}
+	host := strings.ToLower(target.Hostname())
+	if !slices.ContainsFunc(s.srv.cfg.Server.CallbackHosts, func(h string) bool { return strings.ToLower(h) == host }) {
+		return rpc.Errorf(rpc.CodeInvalidArgument, "callback host %q is not in server.callback_hosts", host)
+	}
+	return nil
+}
+
+// SubmitJob queues the translation and returns without waiting for it.
+func (s *grpcService) SubmitJob(ctx context.Context, req *rpc.TranslateRequest) (*rpc.Job, error) {
+	if s.jobs == nil {
+		return nil, errNoJobs
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		return nil, rpc.Errorf(rpc.CodeInvalidArgument, "request has no text")
+	}
+	if err := s.checkCallback(req.Callback); err != nil {
+		return nil, err
+	}
+	id, _ := json.Marshal(req.ID)
+	job, err := s.jobs.submit(jsonlRecord{
+		ID:       id,
+		Text:     req.Text,
+		From:     req.SourceLang,
+		To:       req.TargetLang,
+		Callback: req.Callback,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue job: %w", err)
+	}
+	return rpcJob(job)
+}
+
+func (s *grpcService) GetJob(ctx context.Context, req *rpc.JobRequest) (*rpc.Job, error) {
+	if s.jobs == nil {
+		return nil, errNoJobs
+	}
+	job, found := s.jobs.get(req.JobID)
+	if !found {
+		return nil, rpc.Errorf(rpc.CodeNotFound, "no job %q", req.JobID)
+	}
+	return rpcJob(job)
+}
+
+func (s *grpcService) CancelJob(ctx context.Context, req *rpc.JobRequest) (*rpc.Job, error) {
+	if s.jobs == nil {
+		return nil, errNoJobs
+	}
+	job, found, ok := s.jobs.cancel(req.JobID)
+	if !found {
+		return nil, rpc.Errorf(rpc.CodeNotFound, "no job %q", req.JobID)
+	}
+	if !ok {
+		return nil, rpc.Errorf(rpc.CodeFailedPrecondition, "job %s is %s", job.ID, job.Status)
+	}
+	return rpcJob(job)
+}
+
+func (s *grpcService) RetryJob(ctx context.Context, req *rpc.JobRequest) (*rpc.Job, error) {
+	if s.jobs == nil {
+		return nil, errNoJobs
+	}
+	job, found, ok := s.jobs.retry(req.JobID)
+	if !found {
+		return nil, rpc.Errorf(rpc.CodeNotFound, "no job %q", req.JobID)
+	}
+	if !ok {
+		return nil, rpc.Errorf(rpc.CodeFailedPrecondition, "job %s is %s", job.ID, job.Status)
+	}
+	return rpcJob(job)
+}
+
+var errNoJobs = rpc.Errorf(rpc.CodeFailedPrecondition, "jobs are disabled, start the server with --jobs-file")
+
+// rpcJob converts a job of the queue to its message.
+func rpcJob(job queuedJob) (*rpc.Job, error) {
+	msg := &rpc.Job{JobID: job.ID, Status: job.Status}
+	if job.Result == nil {
+		return msg, nil
+	}
+	metadata, err := encodeMetadata(job.Result.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	var id string
+	json.Unmarshal(job.Record.ID, &id)
+	msg.Result = &rpc.TranslateResponse{
+		ID:            id,
+		Text:          job.Result.Text,
+		SourceLang:    job.Result.SourceLang,
+		TargetLang:    job.Result.TargetLang,
+		TokensUsed:    int32(job.Result.TokensUsed),
+		MetadataJSON:  metadata,
+		Error:         job.Result.Error,
+		CallbackError: job.Result.CallbackError,
+	}
+	return msg, nil
+}
+
+// encodeMetadata returns analysis results as a JSON object, or "" when
+// there are none.
+func encodeMetadata(fields map[string]interface{}) (string, error) {
+	if len(fields) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	return string(data), nil
+}
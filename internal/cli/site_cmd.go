@@ -0,0 +1,409 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/translator"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	siteRoot   string
+	siteFields string
+	siteNames  bool
+)
+
+// newSiteCmd builds the "site" command for Hugo multilingual sites that keep
+// each language in content/<lang>/. It accepts all translation flags.
+func newSiteCmd(rootCmd *cobra.Command) *cobra.Command {
+	siteCmd := &cobra.Command{
+		Use:          "site",
+		Short:        "Translate a Hugo multilingual site (content/<lang>/ layout)",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSiteTranslate(cmd.Context(), cmd)
+		},
+	}
+
+	siteCmd.Flags().AddFlagSet(rootCmd.Flags())
+	siteCmd.Flags().StringVar(&siteRoot, "root", ".", "Site root containing the content directory")
+	siteCmd.Flags().StringVar(&siteFields, "fields", strings.Join(defaultFrontmatterFields, ","), "Frontmatter fields to translate (comma-separated)")
+	siteCmd.Flags().BoolVar(&siteNames, "translate-names", false, "Translate page and directory names into slugs of the target language")
+
+	return siteCmd
+}
+
+func runSiteTranslate(ctx context.Context, cmd *cobra.Command) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	if sourceLang == "" || sourceLang == "auto" {
+		return fmt.Errorf("site mode requires --from with the source language directory name (e.g. --from en)")
+	}
+
+	srcDir := filepath.Join(siteRoot, "content", sourceLang)
+	dstDir := filepath.Join(siteRoot, "content", targetLang)
+	if info, err := os.Stat(srcDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("source content directory not found: %s", srcDir)
+	}
+
+	extMap := make(map[string]bool)
+	for _, ext := range parseExtensions(extensions) {
+		extMap[ext] = true
+	}
+
+	var pages, resources []string
+	err = filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if extMap[strings.ToLower(filepath.Ext(path))] {
+			pages = append(pages, path)
+		} else {
+			resources = append(resources, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan content: %w", err)
+	}
+
+	glossary, err := loadRunGlossary()
+	if err != nil {
+		return err
+	}
+
+	// frontmatter_fields from config applies unless --fields is given
+	names := cfg.Settings.FrontmatterFields
+	if len(names) == 0 || cmd.Flags().Changed("fields") {
+		names = strings.Split(siteFields, ",")
+	}
+	fields := make(map[string]bool)
+	cfg.Settings.FrontmatterFields = nil
+	for _, f := range names {
+		if f = strings.TrimSpace(f); f != "" {
+			fields[f] = true
+			cfg.Settings.FrontmatterFields = append(cfg.Settings.FrontmatterFields, f)
+		}
+	}
+
+	logInfo("Found %d pages in %s", len(pages), srcDir)
+
+	t := translator.New(cfg, verbose)
+	report := newUsageReport(cfg)
+	defer report.write(cfg, t)
+
+	var pathNames *nameMap
+	if siteNames {
+		mapPath := filepath.Join(siteRoot, fmt.Sprintf("names.%s-%s.json", sourceLang, targetLang))
+		if pathNames, err = loadNameMap(mapPath); err != nil {
+			return err
+		}
+		defer func() {
+			if err := pathNames.save(); err != nil {
+				logError("Failed to write name map: %v", err)
+			}
+		}()
+	}
+
+	for i, inputPath := range pages {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		relPath, _ := filepath.Rel(srcDir, inputPath)
+		outputPath, err := siteOutputPath(ctx, t, pathNames, dstDir, relPath, true)
+		if err != nil {
+			logError("Failed to translate the name of %s: %v", relPath, err)
+			continue
+		}
+
+		// Existing pages may be edited by hand, never overwrite them
+		if _, err := os.Stat(outputPath); err == nil {
+			if verbose {
+				logInfo("[%d/%d] %s already translated, skipping", i+1, len(pages), relPath)
+			}
+			continue
+		}
+
+		logInfo("[%d/%d] %s", i+1, len(pages), relPath)
+
+		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+			logError("Failed to create directory for %s: %v", relPath, err)
+			continue
+		}
+
+		chunksBefore, start := t.Chunks(), time.Now()
+		_, result, _, err := translateFile(ctx, t, cfg, inputPath, outputPath, glossary)
+		if errors.Is(err, errSkipped) {
+			continue
+		}
+		report.addFile(t, relPath, chunksBefore, start, result, err)
+		if err != nil {
+			logError("Failed to translate %s: %v", relPath, err)
+			continue
+		}
+		if result.StaleChunks > 0 {
+			logWarn("%s: %d chunks used cached translations", relPath, result.StaleChunks)
+		}
+
+		// Frontmatter-only pages had their fields translated already
+		if result.Text == "" {
+			continue
+		}
+		if err := translatePageFields(ctx, t, outputPath, fields); err != nil {
+			logWarn("Frontmatter of %s not translated: %v", relPath, err)
+		}
+	}
+
+	// Page bundle resources (images, data files) are copied as is
+	for _, inputPath := range resources {
+		relPath, _ := filepath.Rel(srcDir, inputPath)
+		outputPath, err := siteOutputPath(ctx, t, pathNames, dstDir, relPath, false)
+		if err != nil {
+			logError("Failed to translate the name of %s: %v", relPath, err)
+			continue
+		}
+		if _, err := os.Stat(outputPath); err == nil {
+			continue
+		}
+		if err := copyFile(inputPath, outputPath); err != nil {
+			logError("Failed to copy %s: %v", relPath, err)
+		}
+	}
+
+	translateSiteData(ctx, t, cfg, report)
+
+	logInfo("Site translation complete: %s", dstDir)
+	return nil
+}
+
+// siteOutputPath returns where the page or resource at relPath is written
+// in dstDir, with its names translated when names is set.
+func siteOutputPath(ctx context.Context, t *translator.Translator, names *nameMap, dstDir, relPath string, page bool) (string, error) {
+	if names == nil {
+		return filepath.Join(dstDir, relPath), nil
+	}
+	rel, err := names.translate(ctx, t, filepath.ToSlash(relPath), page)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dstDir, filepath.FromSlash(rel)), nil
+}
+
+// translateSiteData translates the data files in data/<from>/ to
+// data/<to>/ and the menus in config/_default/menus.<from>.* to
+// menus.<to>.*, the layouts Hugo reads per language. Only values of the
+// data keys are translated. Existing files are never overwritten.
+func translateSiteData(ctx context.Context, t *translator.Translator, cfg *config.Config, report *usageReport) {
+	type pair struct{ src, dst, where string }
+	var pairs []pair
+
+	dataSrc := filepath.Join(siteRoot, "data", sourceLang)
+	dataDst := filepath.Join(siteRoot, "data", targetLang)
+	filepath.Walk(dataSrc, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && isDataFile(path) {
+			rel, _ := filepath.Rel(dataSrc, path)
+			pairs = append(pairs, pair{path, filepath.Join(dataDst, rel), "data file of a web site"})
+		}
+		return nil
+	})
+
+	menus, _ := filepath.Glob(filepath.Join(siteRoot, "config", "_default", "menus."+sourceLang+".*"))
+	for _, path := range menus {
+		if isDataFile(path) {
+			dst := filepath.Join(filepath.Dir(path), "menus."+targetLang+filepath.Ext(path))
+			pairs = append(pairs, pair{path, dst, "navigation menu of a web site"})
+		}
+	}
+
+	keys := dataKeys(cfg)
+	for _, p := range pairs {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := os.Stat(p.dst); err == nil {
+			continue
+		}
+		rel, _ := filepath.Rel(siteRoot, p.src)
+		logInfo("%s", rel)
+
+		chunksBefore, start := t.Chunks(), time.Now()
+		err := translateDataFile(ctx, t, p.src, p.dst, keys, p.where)
+		report.addFile(t, rel, chunksBefore, start, translator.TranslateResponse{}, err)
+		if err != nil {
+			logError("Failed to translate %s: %v", rel, err)
+		}
+	}
+}
+
+// translatePageFields translates the selected frontmatter fields of a page
+// in place, see translateFrontmatter.
+func translatePageFields(ctx context.Context, t *translator.Translator, path string, fields map[string]bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	text := string(data)
+	if strings.HasPrefix(text, "+++") {
+		return fmt.Errorf("TOML frontmatter is not supported, only YAML")
+	}
+
+	frontmatter, content := extractFrontmatter(text)
+	if frontmatter == "" {
+		return nil
+	}
+
+	translated, err := translateFrontmatter(ctx, t, frontmatter, fields)
+	if err != nil || translated == frontmatter {
+		return err
+	}
+	return os.WriteFile(path, []byte(translated+content), 0644)
+}
+
+// translateFrontmatter translates the string values of the selected fields
+// of YAML frontmatter at any depth, including lists of strings and fields of
+// list items. The YAML tree is edited directly so key order, comments,
+// dates, slugs and taxonomies stay exactly as written. The frontmatter is
+// returned unchanged when it has none of the fields.
+func translateFrontmatter(ctx context.Context, t *translator.Translator, frontmatter string, fields map[string]bool) (string, error) {
+	raw := strings.TrimPrefix(frontmatter, "---")
+	if idx := strings.Index(raw, "\n---"); idx != -1 {
+		raw = raw[:idx]
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(raw), &doc); err != nil {
+		return "", fmt.Errorf("invalid frontmatter: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return frontmatter, nil
+	}
+
+	changed, err := translateFieldNodes(ctx, t, doc.Content[0], fields, "frontmatter of a web page")
+	if err != nil || !changed {
+		return frontmatter, err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return "", err
+	}
+	enc.Close()
+
+	return "---\n" + buf.String() + "---\n", nil
+}
+
+// translateFieldNodes walks node and translates the values of the selected
+// fields, reporting whether any was translated. where describes the
+// document to the model.
+func translateFieldNodes(ctx context.Context, t *translator.Translator, node *yaml.Node, fields map[string]bool, where string) (bool, error) {
+	changed := false
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			var c bool
+			var err error
+			if fields[key.Value] {
+				c, err = translateFieldValue(ctx, t, key.Value, value, where)
+			} else {
+				c, err = translateFieldNodes(ctx, t, value, fields, where)
+			}
+			if err != nil {
+				return false, err
+			}
+			changed = changed || c
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			c, err := translateFieldNodes(ctx, t, item, fields, where)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || c
+		}
+	}
+	return changed, nil
+}
+
+// translateFieldValue translates a string field or each string of a list.
+func translateFieldValue(ctx context.Context, t *translator.Translator, name string, value *yaml.Node, where string) (bool, error) {
+	values := []*yaml.Node{value}
+	if value.Kind == yaml.SequenceNode {
+		values = value.Content
+	}
+
+	changed := false
+	for _, v := range values {
+		if v.Kind != yaml.ScalarNode || v.Tag != "!!str" || v.Value == "" {
+			continue
+		}
+
+		text, err := translateFieldText(ctx, t, name, v.Value, where)
+		if err != nil {
+			return false, err
+		}
+		v.Value = text
+		changed = true
+	}
+	return changed, nil
+}
+
+// translateFieldText translates the value of one field.
+func translateFieldText(ctx context.Context, t *translator.Translator, name, text, where string) (string, error) {
+	result, err := t.Translate(ctx, translator.TranslateRequest{
+		Text:        text,
+		SourceLang:  sourceLang,
+		TargetLang:  targetLang,
+		Style:       style,
+		Context:     fmt.Sprintf("Field %q of the %s. Output a single line.", name, where),
+		Temperature: temperature,
+		MaxTokens:   maxTokens,
+	})
+	if err != nil {
+		return "", fmt.Errorf("field %s: %w", name, err)
+	}
+	return strings.TrimSpace(result.Text), nil
+}
+
+func copyFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/translator"
+)
+
+// splitDocuments splits text at lines consisting of delimiter. seps holds
+// the delimiter lines with their line breaks and the blank lines after
+// them, one fewer than docs.
+func splitDocuments(text, delimiter string) (docs, seps []string) {
+	var doc strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		switch {
+		case strings.TrimSpace(line) == delimiter:
+			docs = append(docs, doc.String())
+			seps = append(seps, line)
+			doc.Reset()
+		case len(seps) > 0 && doc.Len() == 0 && strings.TrimSpace(line) == "":
+			// A document starts at its first line, where frontmatter is found
+			seps[len(seps)-1] += line
+		default:
+			doc.WriteString(line)
+		}
+	}
+	return append(docs, doc.String()), seps
+}
+
+// runSplitTranslate translates the documents of inputText separated by
+// --split-on independently, each with its own analyses, and writes them
+// joined by the original delimiter lines. Documents without text are kept
+// as they are. In JSON format the output is an array of documents.
+func runSplitTranslate(ctx context.Context, cfg *config.Config, inputText string) error {
+	glossary, err := loadRunGlossary()
+	if err != nil {
+		return err
+	}
+
+	docs, seps := splitDocuments(inputText, splitOn)
+	if verbose {
+		logInfo("Input split into %d documents", len(docs))
+	}
+
+	reportName := inputFile
+	if reportName == "" {
+		reportName = "stdin"
+	}
+	t := translator.New(cfg, verbose)
+	report := newUsageReport(cfg)
+	defer report.write(cfg, t)
+
+	var out strings.Builder
+	metas := []outputDocument{}
+	for i, doc := range docs {
+		translated := doc
+		frontmatter, content := extractFrontmatter(doc)
+		switch {
+		case translationDisabled(frontmatter):
+			// Kept as it is
+		case strings.TrimSpace(content) != "":
+			chunksBefore, start := t.Chunks(), time.Now()
+			result, err := t.Translate(ctx, translator.TranslateRequest{
+				Text:           content,
+				SourceLang:     sourceLang,
+				TargetLang:     targetLang,
+				Style:          style,
+				Context:        contextStr,
+				Temperature:    temperature,
+				MaxTokens:      maxTokens,
+				PreserveFormat: preserveFormat,
+				StrongMode:     strongMode,
+				StrongRetries:  strongRetries,
+				ReadingLevel:   cfg.Settings.ReadingLevel,
+				ReadingRetries: cfg.Settings.ReadingRetries,
+				MaxLength:      cfg.Settings.MaxLength,
+				MaxLenRatio:    cfg.Settings.MaxLenRatio,
+				LengthRetries:  cfg.Settings.LengthRetries,
+				Glossary:       glossary,
+			})
+			name := fmt.Sprintf("%s#%d", reportName, i+1)
+			report.addFile(t, name, chunksBefore, start, result, err)
+			if err != nil {
+				return fmt.Errorf("translation of document %d failed: %w", i+1, err)
+			}
+			file := report.Files[len(report.Files)-1]
+
+			fmUpdates := analyzeTranslation(ctx, t, cfg, content, result, targetLang, "")
+			if len(fmUpdates) > 0 {
+				frontmatter = updateFrontmatter(frontmatter, fmUpdates)
+			}
+			translated = outputText(cfg, frontmatter, content, result, fmUpdates)
+			exportAlignment(name, content, result)
+
+			detectedLang := sourceLang
+			if result.DetectedLang != "" {
+				detectedLang = result.DetectedLang
+			}
+			metas = append(metas, outputDocument{
+				Text:        result.Text,
+				Frontmatter: frontmatter,
+				SourceLang:  detectedLang,
+				TargetLang:  targetLang,
+				Provider:    cfg.DefaultProvider,
+				Model:       getModelForProvider(cfg),
+				TokensUsed:  file.InputTokens + file.OutputTokens,
+				StaleChunks: result.StaleChunks,
+				Responses:   result.Responses,
+				Metadata:    fmUpdates,
+			})
+
+		case strings.HasPrefix(frontmatter, "---"):
+			if translated, err = translateFrontmatterOnly(ctx, t, cfg, frontmatter); err != nil {
+				return fmt.Errorf("translation of document %d failed: %w", i+1, err)
+			}
+		}
+
+		out.WriteString(translated)
+		if i < len(seps) {
+			out.WriteString(seps[i])
+		}
+	}
+
+	if outputMeta != "" {
+		data, err := json.MarshalIndent(metas, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode metadata: %w", err)
+		}
+		if err := os.WriteFile(outputMeta, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write metadata file: %w", err)
+		}
+	}
+
+	output := out.String()
+	if outputFormat == "json" {
+		data, err := json.MarshalIndent(metas, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+		output = string(data) + "\n"
+	}
+	if err := writeOutput(output); err != nil {
+		return err
+	}
+
+	if verbose {
+		logUsage(t.Meter().Total())
+		logHealth(t.Health().Snapshot())
+	}
+	return nil
+}
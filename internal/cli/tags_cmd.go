@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/foxzi/llm-translate/internal/digest"
+	"github.com/spf13/cobra"
+)
+
+var tagsTop int
+
+// newTagsCmd builds the "tags" command, which reports tag frequencies and
+// co-occurrence from the tags stored in frontmatter of translated files.
+func newTagsCmd() *cobra.Command {
+	tagsCmd := &cobra.Command{
+		Use:          "tags",
+		Short:        "Report tag frequency, co-occurrence and near-duplicates across files",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTagsReport()
+		},
+	}
+
+	tagsCmd.Flags().StringVarP(&inputDir, "dir", "d", "", "Directory with translated files")
+	tagsCmd.Flags().StringVar(&extensions, "ext", ".md,.txt", "File extensions to scan (comma-separated)")
+	tagsCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file (default: stdout)")
+	tagsCmd.Flags().IntVar(&tagsTop, "top", 50, "Number of entries per list (0 = all)")
+	_ = tagsCmd.MarkFlagRequired("dir")
+
+	return tagsCmd
+}
+
+func runTagsReport() error {
+	files, err := findFiles(inputDir, parseExtensions(extensions))
+	if err != nil {
+		return fmt.Errorf("failed to scan directory: %w", err)
+	}
+
+	report := digest.NewTagReport()
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			logWarn("Failed to read %s: %v", path, err)
+			continue
+		}
+
+		frontmatter, _ := extractFrontmatter(string(data))
+		fm := parseFrontmatter(frontmatter)
+		if fm == nil {
+			continue
+		}
+
+		list, ok := fm["tags"].([]interface{})
+		if !ok {
+			continue
+		}
+		var tags []string
+		for _, tag := range list {
+			if s, ok := tag.(string); ok {
+				tags = append(tags, s)
+			}
+		}
+		report.Add(tags)
+	}
+
+	output := report.Render(tagsTop)
+	if outputFile == "" {
+		fmt.Print(output)
+		return nil
+	}
+
+	if err := os.WriteFile(outputFile, []byte(output), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	logInfo("Tag report written to %s", outputFile)
+	return nil
+}
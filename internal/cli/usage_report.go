@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/metering"
+	"github.com/foxzi/llm-translate/internal/translator"
+)
+
+// usageReport is written with --report for chargeback: the files a run
+// processed, the tokens it used per provider and model, per language pair
+// and document type, and their cost estimated from the prices in the
+// provider config.
+type usageReport struct {
+	StartedAt     time.Time    `json:"started_at"`
+	FinishedAt    time.Time    `json:"finished_at"`
+	DurationMs    int64        `json:"duration_ms"`
+	Processed     int          `json:"files_processed"`
+	Failed        int          `json:"files_failed"`
+	Chunks        int          `json:"chunks"`
+	Retries       int          `json:"retries"`
+	InputTokens   int          `json:"input_tokens"`
+	OutputTokens  int          `json:"output_tokens"`
+	EstimatedCost float64      `json:"estimated_cost"`
+	Usage         []usageCost  `json:"usage"`
+	Pairs         []pairUsage  `json:"pairs"`
+	Files         []reportFile `json:"files"`
+
+	cfg      *config.Config
+	lastUse  metering.Usage // meter totals when the previous file was added
+	lastCost float64
+}
+
+// usageCost is the usage of one provider and model with its cost, zero
+// when the provider has no prices configured.
+type usageCost struct {
+	metering.Usage
+	Cost float64 `json:"cost"`
+}
+
+// pairUsage is the usage of the files of one language pair and document
+// type.
+type pairUsage struct {
+	SourceLang   string  `json:"source_lang"`
+	TargetLang   string  `json:"target_lang"`
+	Type         string  `json:"type"`
+	Files        int     `json:"files"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+}
+
+type reportFile struct {
+	Path         string                     `json:"path"`
+	SourceLang   string                     `json:"source_lang"`
+	TargetLang   string                     `json:"target_lang"`
+	Type         string                     `json:"type"`
+	Chunks       int                        `json:"chunks"`
+	InputTokens  int                        `json:"input_tokens"`
+	OutputTokens int                        `json:"output_tokens"`
+	Cost         float64                    `json:"cost"`
+	DurationMs   int64                      `json:"duration_ms"`
+	Error        string                     `json:"error,omitempty"`
+	Responses    []translator.ChunkResponse `json:"responses,omitempty"`
+}
+
+func newUsageReport(cfg *config.Config) *usageReport {
+	return &usageReport{StartedAt: time.Now(), Files: []reportFile{}, cfg: cfg}
+}
+
+// addFile records a file that took the chunks since chunksBefore and the
+// time since start, with the provider responses of its chunks. Files are
+// translated one after another, so the tokens used since the previous file
+// are its own.
+func (r *usageReport) addFile(t *translator.Translator, path string, chunksBefore int, start time.Time, result translator.TranslateResponse, err error) {
+	source := sourceLang
+	if source == "auto" && result.DetectedLang != "" {
+		source = result.DetectedLang
+	}
+	use, cost := meterCost(r.cfg, t)
+	file := reportFile{
+		Path:         path,
+		SourceLang:   source,
+		TargetLang:   targetLang,
+		Type:         documentType(path),
+		Chunks:       t.Chunks() - chunksBefore,
+		InputTokens:  use.InputTokens - r.lastUse.InputTokens,
+		OutputTokens: use.OutputTokens - r.lastUse.OutputTokens,
+		Cost:         roundCost(cost - r.lastCost),
+		DurationMs:   time.Since(start).Milliseconds(),
+		Responses:    result.Responses,
+	}
+	r.lastUse, r.lastCost = use, cost
+	if err != nil {
+		file.Error = err.Error()
+		r.Failed++
+	} else {
+		r.Processed++
+	}
+	r.Files = append(r.Files, file)
+}
+
+// write completes the report from the translator stats and saves it. Does
+// nothing without --report.
+func (r *usageReport) write(cfg *config.Config, t *translator.Translator) {
+	if reportPath == "" {
+		return
+	}
+
+	r.FinishedAt = time.Now()
+	r.DurationMs = r.FinishedAt.Sub(r.StartedAt).Milliseconds()
+	r.Chunks = t.Chunks()
+	r.Retries = t.Retries()
+	r.Usage = []usageCost{}
+	for _, u := range t.Meter().Snapshot() {
+		cost := roundCost(cfg.Providers[u.Provider].Cost(u.InputTokens, u.OutputTokens))
+		r.Usage = append(r.Usage, usageCost{Usage: u, Cost: cost})
+		r.InputTokens += u.InputTokens
+		r.OutputTokens += u.OutputTokens
+		r.EstimatedCost += cost
+	}
+	r.EstimatedCost = roundCost(r.EstimatedCost)
+	r.Pairs = groupPairs(r.Files)
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err == nil {
+		err = os.WriteFile(reportPath, append(data, '\n'), 0644)
+	}
+	if err != nil {
+		logError("Failed to write usage report: %v", err)
+	}
+}
+
+// meterCost returns the usage of all providers so far and its cost.
+func meterCost(cfg *config.Config, t *translator.Translator) (metering.Usage, float64) {
+	var cost float64
+	for _, u := range t.Meter().Snapshot() {
+		cost += cfg.Providers[u.Provider].Cost(u.InputTokens, u.OutputTokens)
+	}
+	return t.Meter().Total(), cost
+}
+
+// groupPairs sums the usage of files by language pair and document type.
+func groupPairs(files []reportFile) []pairUsage {
+	pairs := []pairUsage{}
+	index := make(map[[3]string]int)
+	for _, f := range files {
+		key := [3]string{f.SourceLang, f.TargetLang, f.Type}
+		i, ok := index[key]
+		if !ok {
+			i = len(pairs)
+			index[key] = i
+			pairs = append(pairs, pairUsage{SourceLang: f.SourceLang, TargetLang: f.TargetLang, Type: f.Type})
+		}
+		pairs[i].Files++
+		pairs[i].InputTokens += f.InputTokens
+		pairs[i].OutputTokens += f.OutputTokens
+		pairs[i].Cost = roundCost(pairs[i].Cost + f.Cost)
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Cost > pairs[j].Cost })
+	return pairs
+}
+
+// documentType is the extension of path without the dot, text for files
+// without one.
+func documentType(path string) string {
+	if ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")); ext != "" {
+		return ext
+	}
+	return "text"
+}
+
+// roundCost drops float noise below a millionth of the currency unit.
+func roundCost(cost float64) float64 {
+	return math.Round(cost*1e6) / 1e6
+}
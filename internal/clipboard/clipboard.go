@@ -0,0 +1,86 @@
+// Package clipboard reads and writes the system clipboard with the tools of
+// the platform: pbpaste and pbcopy on macOS, PowerShell on Windows, and
+// wl-clipboard, xclip, xsel or Termux on other systems.
+package clipboard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// tool is a clipboard program with the arguments that read and write the
+// clipboard through stdout and stdin.
+type tool struct {
+	read, write []string
+}
+
+func tools() []tool {
+	switch runtime.GOOS {
+	case "darwin":
+		return []tool{{[]string{"pbpaste"}, []string{"pbcopy"}}}
+	case "windows":
+		// UTF-8 on the pipes, consoles default to the OEM code page
+		return []tool{{
+			[]string{"powershell", "-NoProfile", "-Command", "[Console]::OutputEncoding = [Text.Encoding]::UTF8; Get-Clipboard -Raw"},
+			[]string{"powershell", "-NoProfile", "-Command", "[Console]::InputEncoding = [Text.Encoding]::UTF8; Set-Clipboard -Value ([Console]::In.ReadToEnd())"},
+		}}
+	}
+	var found []tool
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		found = append(found, tool{[]string{"wl-paste", "--no-newline"}, []string{"wl-copy"}})
+	}
+	return append(found,
+		tool{[]string{"xclip", "-selection", "clipboard", "-o"}, []string{"xclip", "-selection", "clipboard", "-i"}},
+		tool{[]string{"xsel", "--clipboard", "--output"}, []string{"xsel", "--clipboard", "--input"}},
+		tool{[]string{"termux-clipboard-get"}, []string{"termux-clipboard-set"}},
+	)
+}
+
+// find returns the first installed clipboard tool.
+func find() (tool, error) {
+	for _, t := range tools() {
+		if _, err := exec.LookPath(t.read[0]); err == nil {
+			return t, nil
+		}
+	}
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		return tool{}, fmt.Errorf("clipboard tool %s not found", tools()[0].read[0])
+	}
+	return tool{}, fmt.Errorf("no clipboard tool found: install wl-clipboard, xclip or xsel")
+}
+
+// Read returns the text in the clipboard.
+func Read() (string, error) {
+	t, err := find()
+	if err != nil {
+		return "", err
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(t.read[0], t.read[1:]...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w: %s", t.read[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.ReplaceAll(string(out), "\r\n", "\n"), nil
+}
+
+// Write replaces the clipboard content with text.
+func Write(text string) error {
+	t, err := find()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(t.write[0], t.write[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	// Not a pipe: xclip and wl-copy stay in the background holding it
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", t.write[0], err)
+	}
+	return nil
+}
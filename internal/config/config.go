@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -11,32 +13,158 @@ type Config struct {
 	DefaultTargetLanguage string                    `yaml:"default_target_language"`
 	Settings              Settings                  `yaml:"settings"`
 	StrongValidation      StrongValidation          `yaml:"strong_validation"`
+	Redaction             Redaction                 `yaml:"redaction"`
+	Currency              Currency                  `yaml:"currency"`
+	Ensemble              Ensemble                  `yaml:"ensemble"`
+	Webhooks              Webhooks                  `yaml:"webhooks"`
+	Server                Server                    `yaml:"server"`
 	Proxy                 ProxyConfig               `yaml:"proxy"`
 	Providers             map[string]ProviderConfig `yaml:"providers"`
 	Prompts               Prompts                   `yaml:"prompts"`
 	Glossary              []GlossaryEntry           `yaml:"glossary"`
+	Profiles              map[string]Profile        `yaml:"profiles"`
+	Domains               map[string]Domain         `yaml:"domains"`
+	Analyzers             map[string]Analyzer       `yaml:"analyzers"`
+	Postprocess           []PostprocessRule         `yaml:"postprocess"`
 }
 
 type Settings struct {
-	Temperature    float64 `yaml:"temperature"`
-	MaxTokens      int     `yaml:"max_tokens"`
-	Timeout        int     `yaml:"timeout"`
-	ChunkSize      int     `yaml:"chunk_size"`
-	PreserveFormat bool    `yaml:"preserve_format"`
-	RetryCount     int     `yaml:"retry_count"`
-	RetryDelay     int     `yaml:"retry_delay"`
-	Sentiment      bool    `yaml:"sentiment"`
-	TagsCount      int     `yaml:"tags_count"`
-	Classify       bool    `yaml:"classify"`
-	Emotions       bool    `yaml:"emotions"`
-	Factuality     bool    `yaml:"factuality"`
-	Impact         bool    `yaml:"impact"`
-	Sensationalism bool    `yaml:"sensationalism"`
-	Entities       bool    `yaml:"entities"`
-	Events         bool    `yaml:"events"`
-	Usefulness     bool    `yaml:"usefulness"`
-	TimeFocus      bool    `yaml:"time_focus"`
-	AdDetect       bool    `yaml:"ad_detect"`
+	Temperature      float64   `yaml:"temperature"`
+	MaxTokens        int       `yaml:"max_tokens"`
+	Timeout          int       `yaml:"timeout"`
+	ChunkSize        int       `yaml:"chunk_size"`
+	ChunkConcurrency int       `yaml:"chunk_concurrency"`
+	ChunkOverlap     int       `yaml:"chunk_overlap"`   // sentences of the previous chunk translated again
+	RateLimit        int       `yaml:"rate_limit"`      // requests per minute, 0 = unlimited
+	CarrySentences   int       `yaml:"carry_sentences"` // last translated sentences passed to the next chunk
+	CarrySummary     bool      `yaml:"carry_summary"`   // pass a rolling summary to the next chunk
+	PreserveFormat   bool      `yaml:"preserve_format"`
+	RetryCount       int       `yaml:"retry_count"`
+	RetryDelay       int       `yaml:"retry_delay"`
+	FallbackCache    string    `yaml:"fallback_cache"` // file of last good translations used when a chunk fails
+	OTLPEndpoint     string    `yaml:"otlp_endpoint"`  // OpenTelemetry collector for traces and metrics
+	Sentiment        bool      `yaml:"sentiment"`
+	TagsCount        int       `yaml:"tags_count"`
+	Classify         bool      `yaml:"classify"`
+	Emotions         bool      `yaml:"emotions"`
+	Factuality       bool      `yaml:"factuality"`
+	Impact           bool      `yaml:"impact"`
+	Sensationalism   bool      `yaml:"sensationalism"`
+	Entities         bool      `yaml:"entities"`
+	Events           bool      `yaml:"events"`
+	Usefulness       bool      `yaml:"usefulness"`
+	TimeFocus        bool      `yaml:"time_focus"`
+	AdDetect         bool      `yaml:"ad_detect"`
+	AnalysisJSON     bool      `yaml:"analysis_json"`     // combined analysis as one JSON response
+	StructuredOutput bool      `yaml:"structured_output"` // JSON schema for analyses where supported
+	RunAnalyzers     []string  `yaml:"run_analyzers"`     // custom analyzers from the analyzers section
+	Headline         bool      `yaml:"headline"`
+	Readability      bool      `yaml:"readability"`
+	Embeddings       string    `yaml:"embeddings"` // "sidecar" or "frontmatter", empty disables
+	Dedupe           float64   `yaml:"dedupe"`     // similarity for skipping near-duplicate sources, 0 disables
+	ReadingLevel     string    `yaml:"reading_level"`
+	ReadingRetries   int       `yaml:"reading_retries"`
+	Tone             string    `yaml:"tone"`      // see Tones
+	Audience         string    `yaml:"audience"`  // see Audiences
+	Formality        string    `yaml:"formality"` // see Formalities
+	MaxLength        int       `yaml:"max_len"`
+	MaxLenRatio      float64   `yaml:"max_len_ratio"`
+	LengthRetries    int       `yaml:"length_retries"`
+	BannedTerms      []string  `yaml:"banned_terms"` // words and phrases that must not appear in translations
+	BannedRetries    int       `yaml:"banned_retries"`
+	Protect          []string  `yaml:"protect"`          // placeholder syntaxes kept out of translation, see PlaceholderSyntaxes
+	ProtectPatterns  []string  `yaml:"protect_patterns"` // regular expressions of other placeholders
+	ProtectRetries   int       `yaml:"protect_retries"`
+	CheckNumbers     bool      `yaml:"check_numbers"`
+	CheckLinks       bool      `yaml:"check_links"`
+	InjectionGuard   bool      `yaml:"injection_guard"` // fence document text off from instructions
+	CheckTerms       bool      `yaml:"check_terms"`
+	FixTerms         bool      `yaml:"fix_terms"`
+	TrimGlossary     bool      `yaml:"trim_glossary"`        // send only glossary terms found in the chunk
+	CheckCaps        bool      `yaml:"check_capitalization"` // target language capitalization conventions
+	FixCaps          bool      `yaml:"fix_capitalization"`
+	LocalizeNumbers  bool      `yaml:"localize_numbers"` // number separators of the target language
+	LocalizeDates    bool      `yaml:"localize_dates"`   // numeric date order and separators of the target language
+	ConvertUnits     string    `yaml:"convert_units"`    // "metric" or "imperial" values appended to measurements, empty disables
+	Diagrams         bool      `yaml:"translate_diagrams"`
+	ReviewDir        string    `yaml:"review_dir"`    // translations failing checks are held here for review
+	Annotate         bool      `yaml:"annotate"`      // mark problems with HTML comments in the output
+	Bilingual        string    `yaml:"bilingual"`     // "inline", "table" or "interleaved" layout with the source, empty disables
+	MaxCost          float64   `yaml:"max_cost"`      // stop once the estimated cost reaches this, 0 = unlimited
+	BudgetAlerts     []float64 `yaml:"budget_alerts"` // percentages of max_cost to warn at
+	BudgetPace       string    `yaml:"budget_pace"`   // spread max_cost over this duration, e.g. 8h
+	EmptyFiles       string    `yaml:"empty_files"`   // files without text: "fail" (default), "skip" or "copy"
+	// FrontmatterFields are translated in files that are YAML frontmatter only
+	FrontmatterFields []string `yaml:"frontmatter_fields"`
+	// DataKeys are translated in YAML, JSON and TOML data files and menus
+	DataKeys []string `yaml:"data_keys"`
+}
+
+// PlaceholderSyntaxes are the patterns of the placeholder syntaxes that
+// protect can name: interpolation tokens the model must copy unchanged.
+var PlaceholderSyntaxes = map[string]string{
+	"mustache": `\{\{\{?[^{}]+\}?\}\}`,
+	"jinja":    `\{%-?.*?-?%\}|\{#.*?#\}`,
+	"printf":   `%(?:\d+\$|\([A-Za-z_]\w*\))?[-+0#]*(?:\d+|\*)?(?:\.(?:\d+|\*))?[hlLqjzt]*[diouxXeEfFgGcsqvT%]`,
+	"shell":    `\$\{[^{}\s]+\}|\$[A-Za-z_]\w*`,
+	"emoji":    `:[a-z0-9_+-]*[a-z][a-z0-9_+-]*:`,
+}
+
+// Tones, Audiences and Formalities map the values of tone, audience and
+// formality to the instructions they add to the translation prompt,
+// after the style.
+var (
+	Tones = map[string]string{
+		"neutral":      "Keep a neutral, matter-of-fact tone.",
+		"friendly":     "Use a warm, friendly tone.",
+		"professional": "Use a polished, professional tone.",
+		"enthusiastic": "Use an energetic, enthusiastic tone.",
+		"serious":      "Use a serious, restrained tone.",
+		"playful":      "Use a light, playful tone.",
+		"empathetic":   "Use a caring, empathetic tone.",
+	}
+	Audiences = map[string]string{
+		"general":    "Write for a general audience, in wording a non-specialist understands.",
+		"experts":    "Write for domain experts, with the established terminology of the field.",
+		"beginners":  "Write for beginners, preferring plain wording to jargon where the meaning allows.",
+		"children":   "Write for children, with simple words and short sentences.",
+		"business":   "Write for business readers, concise and to the point.",
+		"developers": "Write for software developers, keeping programming terms in their usual form.",
+	}
+	Formalities = map[string]string{
+		"formal":   "Address the reader formally, with the polite form of address where the target language has one (Sie, vous, Вы).",
+		"informal": "Address the reader informally, with the familiar form of address where the target language has one (du, tu, ты).",
+		"neutral":  "Avoid forms of address that mark formality where the target language allows it.",
+	}
+)
+
+// Choices returns the keys of values, sorted and comma separated, for
+// error messages.
+func Choices(values map[string]string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+// CheckVoice reports a tone, audience or formality of s that is not one of
+// the known values.
+func (s Settings) CheckVoice() error {
+	for _, field := range []struct {
+		name, value string
+		values      map[string]string
+	}{
+		{"tone", s.Tone, Tones},
+		{"audience", s.Audience, Audiences},
+		{"formality", s.Formality, Formalities},
+	} {
+		if _, ok := field.values[field.value]; field.value != "" && !ok {
+			return fmt.Errorf("unknown %s %s (use %s)", field.name, field.value, Choices(field.values))
+		}
+	}
+	return nil
 }
 
 type StrongValidation struct {
@@ -46,6 +174,53 @@ type StrongValidation struct {
 	AllowedTerms    []string `yaml:"allowed_terms"`
 }
 
+type Redaction struct {
+	Enabled bool     `yaml:"enabled"`
+	Types   []string `yaml:"types"`
+	Terms   []string `yaml:"terms"`
+}
+
+// Currency configures annotation of amounts with converted values.
+// Rates map a currency code to the value of one unit in Target currency.
+type Currency struct {
+	Target string             `yaml:"target"`
+	Rates  map[string]float64 `yaml:"rates"`
+}
+
+// Ensemble translates each chunk with all Members and has Judge pick the
+// best translation, or write a merged one with Merge. Entries are
+// provider[:model]; the judge defaults to the default provider.
+type Ensemble struct {
+	Members []string `yaml:"members"`
+	Judge   string   `yaml:"judge"`
+	Merge   bool     `yaml:"merge"`
+}
+
+// Webhooks signs the callbacks of batch and server requests with
+// HMAC-SHA256 of "timestamp\nPOST\npath\nbody" using Secret. The headers
+// default to X-Signature and X-Timestamp.
+type Webhooks struct {
+	Secret          string `yaml:"secret"`
+	Header          string `yaml:"header"`
+	TimestampHeader string `yaml:"timestamp_header"`
+}
+
+// Server guards the gRPC API of the serve command. Clients send Token as
+// "authorization: Bearer <token>"; without one the server listens on
+// loopback addresses only. Callback URLs of requests must point to one of
+// CallbackHosts.
+type Server struct {
+	Token         string   `yaml:"token"`
+	CallbackHosts []string `yaml:"callback_hosts"`
+}
+
+// SplitProviderSpec splits "provider:model" at the first colon, so Ollama
+// tags like "ollama:llama3:8b" keep theirs. The model is optional.
+func SplitProviderSpec(spec string) (string, string) {
+	name, model, _ := strings.Cut(strings.TrimSpace(spec), ":")
+	return name, model
+}
+
 type ProxyConfig struct {
 	URL      string   `yaml:"url"`
 	Username string   `yaml:"username"`
@@ -54,15 +229,81 @@ type ProxyConfig struct {
 }
 
 type ProviderConfig struct {
-	APIKey  string      `yaml:"api_key"`
-	BaseURL string      `yaml:"base_url"`
-	Model   string      `yaml:"model"`
-	Proxy   ProxyConfig `yaml:"proxy"`
+	APIKey          string                 `yaml:"api_key"`
+	APIKeyCmd       string                 `yaml:"api_key_cmd"`      // command whose stdout is the API key
+	APIKeyKeychain  string                 `yaml:"api_key_keychain"` // OS keychain service holding the API key
+	BaseURL         string                 `yaml:"base_url"`
+	Model           string                 `yaml:"model"`
+	EmbeddingModel  string                 `yaml:"embedding_model"` // model for --embeddings (openai, ollama, google)
+	Stream          bool                   `yaml:"stream"`          // stream translations (anthropic only)
+	Proxy           ProxyConfig            `yaml:"proxy"`
+	RequestHooks    []string               `yaml:"request_hooks"` // names of request mutation hooks, e.g. hmac
+	HookOptions     map[string]string      `yaml:"hook_options"`
+	Organization    string                 `yaml:"organization"`      // OpenAI-Organization header (openai only)
+	Project         string                 `yaml:"project"`           // OpenAI-Project header (openai only)
+	Headers         map[string]string      `yaml:"headers"`           // extra headers sent with every request
+	InputPrice      float64                `yaml:"input_price"`       // per million input tokens, for cost estimates
+	OutputPrice     float64                `yaml:"output_price"`      // per million output tokens
+	ContextWindow   int                    `yaml:"context_window"`    // tokens, for models missing from the built-in table
+	MaxOutputTokens int                    `yaml:"max_output_tokens"` // tokens the model can generate
+	KeepAlive       string                 `yaml:"keep_alive"`        // how long Ollama keeps the model loaded, e.g. 30m (ollama only)
+	NumCtx          int                    `yaml:"num_ctx"`           // context window Ollama loads the model with (ollama only)
+	Options         map[string]interface{} `yaml:"options"`           // model options passed to Ollama as is (ollama only)
+	Fixtures        string                 `yaml:"fixtures"`          // YAML file of source texts and their translations (mock only)
+	Plugin          PluginConfig           `yaml:"plugin"`            // external command translating for a provider of this name
+	Template        TemplateConfig         `yaml:"template"`          // HTTP API of a gateway translating for a provider of this name
+}
+
+// PluginConfig runs an external command as a translation provider. The
+// command reads the request on stdin and writes the translation to stdout.
+type PluginConfig struct {
+	Command  string `yaml:"command"`  // shell command
+	Request  string `yaml:"request"`  // stdin template with JSON quoted placeholders, a JSON object of all fields by default
+	Response string `yaml:"response"` // dotted path of the translation in JSON output, the whole output by default
+}
+
+// TemplateConfig describes the HTTP API of an LLM gateway. The request is
+// sent to base_url with the headers of the provider.
+type TemplateConfig struct {
+	Method   string `yaml:"method"`   // POST by default
+	Body     string `yaml:"body"`     // JSON body with JSON quoted placeholders, like plugin requests
+	Response string `yaml:"response"` // dotted path of the translation in the JSON response
+}
+
+// Cost estimates the cost of input and output tokens from the prices of
+// the provider, zero without prices.
+func (p ProviderConfig) Cost(input, output int) float64 {
+	return (float64(input)*p.InputPrice + float64(output)*p.OutputPrice) / 1e6
 }
 
 type Prompts struct {
-	System string            `yaml:"system"`
-	Styles map[string]string `yaml:"styles"`
+	System         string            `yaml:"system"`
+	SystemOverride map[string]string `yaml:"system_override"` // per provider, replaces System
+	Styles         map[string]string `yaml:"styles"`
+}
+
+// Analyzer is a custom analysis run like the built-in ones. Prompt is the
+// system prompt, the analyzed text is sent as the message. The answer is
+// parsed with Pattern, or as JSON when Schema is set, and stored in
+// frontmatter under Key (the analyzer name by default).
+type Analyzer struct {
+	Prompt  string                 `yaml:"prompt"`
+	Pattern string                 `yaml:"pattern"`
+	Schema  map[string]interface{} `yaml:"schema"`
+	Key     string                 `yaml:"key"`
+}
+
+// PostprocessRule is a deterministic cleanup of every translated chunk,
+// applied in config order before validation. A rule sets one of Pattern
+// (regex replaced with Replace), Quotes (straight double quotes to "curly",
+// "guillemets" or "german" ones) or Words (banned word -> substitute).
+// Langs limits the rule to these target languages.
+type PostprocessRule struct {
+	Pattern string            `yaml:"pattern"`
+	Replace string            `yaml:"replace"`
+	Quotes  string            `yaml:"quotes"`
+	Words   map[string]string `yaml:"words"`
+	Langs   []string          `yaml:"langs"`
 }
 
 type GlossaryEntry struct {
@@ -80,25 +321,35 @@ func DefaultConfig() *Config {
 		DefaultProvider:       "openai",
 		DefaultTargetLanguage: "en",
 		Settings: Settings{
-			Temperature:    0.3,
-			MaxTokens:      4096,
-			Timeout:        60,
-			ChunkSize:      3000,
-			PreserveFormat: false,
-			RetryCount:     3,
-			RetryDelay:     1,
-			Sentiment:      false,
-			TagsCount:      0,
-			Classify:       false,
-			Emotions:       false,
-			Factuality:     false,
-			Impact:         false,
-			Sensationalism: false,
-			Entities:       false,
-			Events:         false,
-			Usefulness:     false,
-			TimeFocus:      false,
-			AdDetect:       false,
+			Temperature:      0.3,
+			MaxTokens:        4096,
+			Timeout:          60,
+			ChunkSize:        3000,
+			ChunkConcurrency: 1,
+			PreserveFormat:   false,
+			RetryCount:       3,
+			RetryDelay:       1,
+			Sentiment:        false,
+			TagsCount:        0,
+			Classify:         false,
+			Emotions:         false,
+			Factuality:       false,
+			Impact:           false,
+			Sensationalism:   false,
+			Entities:         false,
+			Events:           false,
+			Usefulness:       false,
+			TimeFocus:        false,
+			AdDetect:         false,
+			Headline:         false,
+			Readability:      false,
+			ReadingRetries:   2,
+			LengthRetries:    2,
+			BannedRetries:    2,
+			ProtectRetries:   2,
+			CheckNumbers:     false,
+			CheckLinks:       false,
+			TrimGlossary:     true,
 		},
 		StrongValidation: StrongValidation{
 			Enabled:    false,
@@ -122,6 +373,10 @@ func DefaultConfig() *Config {
 				"Windows", "macOS",
 			},
 		},
+		Redaction: Redaction{
+			Enabled: false,
+			Types:   []string{"email", "phone", "card"},
+		},
 		Providers: make(map[string]ProviderConfig),
 		Prompts: Prompts{
 			System: `You are a professional translator. Translate the following text from {source_lang} to {target_lang}. Preserve the original formatting and structure. Output only the translation without explanations.`,
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed domains/*.yaml
+var domainFiles embed.FS
+
+// Domain is a preset for a subject area, selected with --domain. Prompt is
+// added to the system prompt as a style, Glossary entries are added to the
+// run's glossary. Settings and StrongValidation hold partial blocks: only
+// keys present in the preset override the loaded configuration.
+type Domain struct {
+	Description      string          `yaml:"description"`
+	Prompt           string          `yaml:"prompt"`
+	Glossary         []GlossaryEntry `yaml:"glossary"`
+	Settings         yaml.Node       `yaml:"settings,omitempty"`
+	StrongValidation yaml.Node       `yaml:"strong_validation,omitempty"`
+}
+
+// DomainNames returns the names of the built-in and configured domains.
+func (c *Config) DomainNames() []string {
+	seen := make(map[string]bool)
+	entries, _ := fs.ReadDir(domainFiles, "domains")
+	for _, entry := range entries {
+		seen[strings.TrimSuffix(entry.Name(), ".yaml")] = true
+	}
+	for name := range c.Domains {
+		seen[name] = true
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DomainPreset returns the YAML source of a built-in domain, to be copied
+// into the config and customized.
+func DomainPreset(name string) ([]byte, error) {
+	data, err := domainFiles.ReadFile("domains/" + name + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("built-in domain %s not found", name)
+	}
+	return data, nil
+}
+
+// ApplyDomain merges the named domain into the config and returns it so the
+// caller can apply the glossary. A domain defined in the config replaces the
+// built-in one of the same name. The prompt is registered as a style under
+// the domain name.
+func (c *Config) ApplyDomain(name string) (*Domain, error) {
+	domain, ok := c.Domains[name]
+	if !ok {
+		data, err := DomainPreset(name)
+		if err != nil {
+			return nil, fmt.Errorf("domain %s not found (available: %s)", name, strings.Join(c.DomainNames(), ", "))
+		}
+		if err := yaml.Unmarshal(data, &domain); err != nil {
+			return nil, fmt.Errorf("invalid built-in domain %s: %w", name, err)
+		}
+	}
+
+	if !domain.Settings.IsZero() {
+		if err := domain.Settings.Decode(&c.Settings); err != nil {
+			return nil, fmt.Errorf("invalid settings in domain %s: %w", name, err)
+		}
+	}
+
+	if !domain.StrongValidation.IsZero() {
+		if err := domain.StrongValidation.Decode(&c.StrongValidation); err != nil {
+			return nil, fmt.Errorf("invalid strong_validation in domain %s: %w", name, err)
+		}
+	}
+
+	if prompt := strings.TrimSpace(domain.Prompt); prompt != "" {
+		if c.Prompts.Styles == nil {
+			c.Prompts.Styles = make(map[string]string)
+		}
+		c.Prompts.Styles[name] = prompt
+	}
+
+	return &domain, nil
+}
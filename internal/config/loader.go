@@ -57,6 +57,8 @@ func expandEnvVarsInConfig(cfg *Config) {
 		provider.APIKey = ExpandEnvVars(provider.APIKey)
 		provider.BaseURL = ExpandEnvVars(provider.BaseURL)
 		provider.Model = ExpandEnvVars(provider.Model)
+		provider.Organization = ExpandEnvVars(provider.Organization)
+		provider.Project = ExpandEnvVars(provider.Project)
 		provider.Proxy.URL = ExpandEnvVars(provider.Proxy.URL)
 		provider.Proxy.Username = ExpandEnvVars(provider.Proxy.Username)
 		provider.Proxy.Password = ExpandEnvVars(provider.Proxy.Password)
@@ -66,6 +68,7 @@ func expandEnvVarsInConfig(cfg *Config) {
 	cfg.Proxy.URL = ExpandEnvVars(cfg.Proxy.URL)
 	cfg.Proxy.Username = ExpandEnvVars(cfg.Proxy.Username)
 	cfg.Proxy.Password = ExpandEnvVars(cfg.Proxy.Password)
+	cfg.Server.Token = ExpandEnvVars(cfg.Server.Token)
 }
 
 func applyEnvironmentOverrides(cfg *Config) {
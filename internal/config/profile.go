@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profile bundles options for a recurring translation job, selected with
+// --profile. Settings holds a partial settings block: only keys present in
+// the profile override the loaded configuration.
+type Profile struct {
+	Provider   string    `yaml:"provider"`
+	Model      string    `yaml:"model"`
+	TargetLang string    `yaml:"target_language"`
+	Style      string    `yaml:"style"`
+	Glossary   string    `yaml:"glossary"`
+	Context    string    `yaml:"context"`
+	Settings   yaml.Node `yaml:"settings,omitempty"`
+}
+
+// ApplyProfile merges the named profile into the config and returns it so
+// the caller can apply per-run options (style, glossary, context).
+func (c *Config) ApplyProfile(name string) (*Profile, error) {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile %s not found in config", name)
+	}
+
+	if profile.Provider != "" {
+		c.DefaultProvider = profile.Provider
+	}
+
+	if profile.Model != "" {
+		providerCfg := c.Providers[c.DefaultProvider]
+		providerCfg.Model = profile.Model
+		c.Providers[c.DefaultProvider] = providerCfg
+	}
+
+	if profile.TargetLang != "" {
+		c.DefaultTargetLanguage = profile.TargetLang
+	}
+
+	if !profile.Settings.IsZero() {
+		if err := profile.Settings.Decode(&c.Settings); err != nil {
+			return nil, fmt.Errorf("invalid settings in profile %s: %w", name, err)
+		}
+	}
+
+	return &profile, nil
+}
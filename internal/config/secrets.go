@@ -0,0 +1,102 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ResolveAPIKey fills APIKey from api_key_cmd or the OS keychain when it is
+// not set directly. Called only for the provider in use, so commands like
+// "pass show" do not run (and prompt) for providers that are not needed.
+func (p *ProviderConfig) ResolveAPIKey() error {
+	if p.APIKey != "" {
+		return nil
+	}
+
+	switch {
+	case p.APIKeyCmd != "":
+		key, err := runSecretCommand(ShellCommand(p.APIKeyCmd))
+		if err != nil {
+			return fmt.Errorf("api_key_cmd failed: %w", err)
+		}
+		p.APIKey = key
+
+	case p.APIKeyKeychain != "":
+		args, err := keychainCommand(p.APIKeyKeychain)
+		if err != nil {
+			return err
+		}
+		key, err := runSecretCommand(args)
+		if err != nil {
+			return fmt.Errorf("keychain lookup for %s failed: %w", p.APIKeyKeychain, err)
+		}
+		p.APIKey = key
+	}
+
+	return nil
+}
+
+// RunCommand runs a shell command and returns its trimmed stdout, for
+// options that read secrets or tokens from external tools.
+func RunCommand(command string) (string, error) {
+	return runSecretCommand(ShellCommand(command))
+}
+
+// HasAPIKeySource reports whether the key is set, can be resolved, or is
+// replaced by an OAuth token from the oauth request hook.
+func (p ProviderConfig) HasAPIKeySource() bool {
+	if p.APIKey != "" || p.APIKeyCmd != "" || p.APIKeyKeychain != "" {
+		return true
+	}
+	for _, hook := range p.RequestHooks {
+		if hook == "oauth" {
+			return true
+		}
+	}
+	return false
+}
+
+// ShellCommand returns the arguments running command with the system shell.
+func ShellCommand(command string) []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/C", command}
+	}
+	return []string{"sh", "-c", command}
+}
+
+// keychainCommand returns the lookup command for a keychain service name:
+// macOS Keychain via "security", Linux Secret Service via "secret-tool".
+func keychainCommand(service string) ([]string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"security", "find-generic-password", "-s", service, "-w"}, nil
+	case "linux":
+		return []string{"secret-tool", "lookup", "service", service}, nil
+	default:
+		return nil, fmt.Errorf("keychain is not supported on %s, use api_key_cmd", runtime.GOOS)
+	}
+}
+
+func runSecretCommand(args []string) (string, error) {
+	cmd := exec.Command(args[0], args[1:]...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+
+	key := strings.TrimSpace(stdout.String())
+	if key == "" {
+		return "", fmt.Errorf("command returned empty output")
+	}
+	return key, nil
+}
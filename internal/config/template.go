@@ -0,0 +1,52 @@
+package config
+
+// Template is the commented starter config written by "config init".
+// See config.example.yaml for the full list of options.
+const Template = `# llm-translate configuration
+# Full reference: config.example.yaml in the project repository
+
+# Default provider: openai, anthropic, google, ollama, openrouter,
+# claude-cli, codex-cli, qwen-cli
+default_provider: openai
+
+# Default target language for translations
+default_target_language: en
+
+settings:
+  temperature: 0.3
+  max_tokens: 4096
+  timeout: 60          # Request timeout in seconds
+  chunk_size: 3000     # Split long texts into chunks of this size
+  preserve_format: false
+  retry_count: 3
+  retry_delay: 1
+
+# Proxy for all providers (http, https or socks5)
+proxy:
+  url: ""
+  no_proxy:
+    - localhost
+    - 127.0.0.1
+
+providers:
+  openai:
+    api_key: ${OPENAI_API_KEY}
+    base_url: https://api.openai.com/v1
+    model: gpt-4o-mini
+
+  anthropic:
+    api_key: ${ANTHROPIC_API_KEY}
+    base_url: https://api.anthropic.com
+    model: claude-3-5-sonnet-20241022
+
+  ollama:
+    # No API key needed for local Ollama
+    base_url: http://localhost:11434
+    model: llama3.2
+
+# Default glossary (applied to all translations)
+# glossary:
+#   - term: "API"
+#     translation: "API"
+#     note: "do not translate"
+`
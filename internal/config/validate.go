@@ -0,0 +1,224 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/foxzi/llm-translate/internal/languages"
+	"gopkg.in/yaml.v3"
+)
+
+// apiKeyProviders are providers that cannot work without an API key.
+var apiKeyProviders = map[string]bool{
+	"openai":     true,
+	"anthropic":  true,
+	"google":     true,
+	"openrouter": true,
+}
+
+// CheckUnknownKeys reports keys in config data that do not map to any
+// config field, usually typos like "chunk_sise".
+func CheckUnknownKeys(data []byte) []string {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	err := dec.Decode(DefaultConfig())
+	if err == nil || errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		return typeErr.Errors
+	}
+	return []string{err.Error()}
+}
+
+// Validate checks the effective config for missing API keys, unknown
+// provider references and malformed proxy URLs.
+func (c *Config) Validate() []string {
+	var problems []string
+
+	if _, ok := c.Providers[c.DefaultProvider]; !ok {
+		problems = append(problems, fmt.Sprintf("default provider %s is not configured", c.DefaultProvider))
+	}
+	if _, err := languages.Normalize(c.DefaultTargetLanguage); err != nil {
+		problems = append(problems, fmt.Sprintf("default_target_language: %v", err))
+	}
+
+	if err := validateProxyURL(c.Proxy.URL); err != nil {
+		problems = append(problems, fmt.Sprintf("proxy: %v", err))
+	}
+
+	names := make([]string, 0, len(c.Providers))
+	for name := range c.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := c.Providers[name]
+		if apiKeyProviders[name] && !p.HasAPIKeySource() {
+			problems = append(problems, fmt.Sprintf("provider %s: api_key is empty and no api_key_cmd or api_key_keychain set", name))
+		}
+		if err := validateProxyURL(p.Proxy.URL); err != nil {
+			problems = append(problems, fmt.Sprintf("provider %s: proxy: %v", name, err))
+		}
+		if name != "openai" && (p.Organization != "" || p.Project != "") {
+			problems = append(problems, fmt.Sprintf("provider %s: organization and project apply to openai only, use headers", name))
+		}
+	}
+
+	profileNames := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		profileNames = append(profileNames, name)
+	}
+	sort.Strings(profileNames)
+
+	for _, name := range profileNames {
+		profile := c.Profiles[name]
+		if profile.TargetLang != "" {
+			if _, err := languages.Normalize(profile.TargetLang); err != nil {
+				problems = append(problems, fmt.Sprintf("profile %s: target_language: %v", name, err))
+			}
+		}
+		if profile.Provider == "" {
+			continue
+		}
+		if _, ok := c.Providers[profile.Provider]; !ok {
+			problems = append(problems, fmt.Sprintf("profile %s: provider %s is not configured", name, profile.Provider))
+		}
+	}
+
+	analyzerNames := make([]string, 0, len(c.Analyzers))
+	for name := range c.Analyzers {
+		analyzerNames = append(analyzerNames, name)
+	}
+	sort.Strings(analyzerNames)
+
+	for _, name := range analyzerNames {
+		a := c.Analyzers[name]
+		if strings.TrimSpace(a.Prompt) == "" {
+			problems = append(problems, fmt.Sprintf("analyzer %s: prompt is empty", name))
+		}
+		if _, err := regexp.Compile(a.Pattern); err != nil {
+			problems = append(problems, fmt.Sprintf("analyzer %s: invalid pattern: %v", name, err))
+		}
+	}
+
+	for _, name := range c.Settings.RunAnalyzers {
+		if _, ok := c.Analyzers[name]; !ok {
+			problems = append(problems, fmt.Sprintf("run_analyzers: analyzer %s is not defined", name))
+		}
+	}
+
+	for i, rule := range c.Postprocess {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			problems = append(problems, fmt.Sprintf("postprocess rule %d: invalid pattern: %v", i+1, err))
+		}
+		switch rule.Quotes {
+		case "", "curly", "guillemets", "german":
+		default:
+			problems = append(problems, fmt.Sprintf("postprocess rule %d: unknown quotes style %s (use curly, guillemets or german)", i+1, rule.Quotes))
+		}
+	}
+
+	if len(c.Ensemble.Members) == 1 {
+		problems = append(problems, "ensemble: members needs at least two entries")
+	}
+	for _, spec := range append(c.Ensemble.Members, c.Ensemble.Judge) {
+		if name, _ := SplitProviderSpec(spec); name != "" {
+			if _, ok := c.Providers[name]; !ok {
+				problems = append(problems, fmt.Sprintf("ensemble: provider %s is not configured", name))
+			}
+		}
+	}
+
+	switch c.Settings.Embeddings {
+	case "", "sidecar", "frontmatter":
+	default:
+		problems = append(problems, fmt.Sprintf("embeddings: unknown mode %s (use sidecar or frontmatter)", c.Settings.Embeddings))
+	}
+
+	switch c.Settings.Bilingual {
+	case "", "inline", "table", "interleaved":
+	default:
+		problems = append(problems, fmt.Sprintf("bilingual: unknown layout %s (use inline, table or interleaved)", c.Settings.Bilingual))
+	}
+
+	for _, name := range c.Settings.Protect {
+		if _, ok := PlaceholderSyntaxes[name]; !ok {
+			problems = append(problems, fmt.Sprintf("protect: unknown placeholder syntax %s (use mustache, jinja, printf, shell or emoji)", name))
+		}
+	}
+	for i, pattern := range c.Settings.ProtectPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			problems = append(problems, fmt.Sprintf("protect_patterns %d: invalid pattern: %v", i+1, err))
+		}
+	}
+
+	if err := c.Settings.CheckVoice(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	switch c.Settings.ConvertUnits {
+	case "", "metric", "imperial":
+	default:
+		problems = append(problems, fmt.Sprintf("convert_units: unknown unit system %s (use metric or imperial)", c.Settings.ConvertUnits))
+	}
+
+	switch c.Settings.EmptyFiles {
+	case "", "fail", "skip", "copy":
+	default:
+		problems = append(problems, fmt.Sprintf("empty_files: unknown policy %s (use fail, skip or copy)", c.Settings.EmptyFiles))
+	}
+
+	if c.Settings.Dedupe < 0 || c.Settings.Dedupe > 1 {
+		problems = append(problems, fmt.Sprintf("dedupe: similarity %g is not between 0 and 1", c.Settings.Dedupe))
+	}
+
+	if c.Settings.MaxCost == 0 && (len(c.Settings.BudgetAlerts) > 0 || c.Settings.BudgetPace != "") {
+		problems = append(problems, "budget_alerts and budget_pace need max_cost")
+	}
+	for _, pct := range c.Settings.BudgetAlerts {
+		if pct <= 0 || pct >= 100 {
+			problems = append(problems, fmt.Sprintf("budget_alerts: %g is not a percentage between 0 and 100", pct))
+		}
+	}
+	if c.Settings.BudgetPace != "" {
+		if d, err := time.ParseDuration(c.Settings.BudgetPace); err != nil || d <= 0 {
+			problems = append(problems, fmt.Sprintf("budget_pace: invalid duration %s (use e.g. 8h)", c.Settings.BudgetPace))
+		}
+	}
+
+	return problems
+}
+
+func validateProxyURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", raw, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("unsupported scheme in %q (use http, https or socks5)", raw)
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("missing host in %q", raw)
+	}
+	return nil
+}
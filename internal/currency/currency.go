@@ -0,0 +1,173 @@
+package currency
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/foxzi/llm-translate/internal/config"
+)
+
+// Converter annotates money amounts with their approximate value in the
+// target currency, e.g. "$100" -> "$100 (≈ 9 300 ₽)".
+type Converter struct {
+	target   string
+	rates    map[string]float64
+	amountRe *regexp.Regexp
+}
+
+var symbols = map[string]string{
+	"$": "USD",
+	"€": "EUR",
+	"£": "GBP",
+	"¥": "JPY",
+	"₽": "RUB",
+	"₴": "UAH",
+	"₹": "INR",
+}
+
+// prefixSymbols are written before the number, others after it.
+var prefixSymbols = map[string]bool{"USD": true, "GBP": true, "JPY": true, "INR": true}
+
+const numberPattern = `\d{1,3}(?:[,.\x{00A0}\x{202F} ]\d{3})+(?:[.,]\d+)?|\d+(?:[.,]\d+)?`
+
+// scaleRe matches magnitude words after an amount ("$1.5 million"); such
+// amounts are left as is to avoid misleading conversions.
+var scaleRe = regexp.MustCompile(`^\s?(?i:million|billion|trillion|thousand|mln|bn|k\b|млн|млрд|трлн|тыс|миллион|миллиард)`)
+
+func New(cfg config.Currency) *Converter {
+	rates := make(map[string]float64)
+	for code, rate := range cfg.Rates {
+		rates[strings.ToUpper(code)] = rate
+	}
+
+	var units []string
+	for sym := range symbols {
+		units = append(units, regexp.QuoteMeta(sym))
+	}
+	for code := range rates {
+		units = append(units, regexp.QuoteMeta(code))
+	}
+	// Longest first so multi-letter codes are not cut short
+	sort.Slice(units, func(i, j int) bool { return len(units[i]) > len(units[j]) })
+	unit := strings.Join(units, "|")
+
+	return &Converter{
+		target: strings.ToUpper(cfg.Target),
+		rates:  rates,
+		amountRe: regexp.MustCompile(
+			`(?:(` + unit + `)\s?(` + numberPattern + `))|(?:(` + numberPattern + `)\s?(` + unit + `))`,
+		),
+	}
+}
+
+// Annotate appends converted amounts next to original figures. Amounts in
+// the target currency, without a known rate or already annotated are kept.
+func (c *Converter) Annotate(text string) string {
+	var b strings.Builder
+	last := 0
+
+	for _, m := range c.amountRe.FindAllStringSubmatchIndex(text, -1) {
+		unit, number := "", ""
+		if m[2] >= 0 {
+			unit, number = text[m[2]:m[3]], text[m[4]:m[5]]
+		} else {
+			number, unit = text[m[6]:m[7]], text[m[8]:m[9]]
+		}
+
+		end := m[1]
+		rest := text[end:]
+		if scaleRe.MatchString(rest) || strings.HasPrefix(strings.TrimLeft(rest, " "), "(≈") {
+			continue
+		}
+
+		code := unit
+		if symCode, ok := symbols[unit]; ok {
+			code = symCode
+		}
+		rate, ok := c.rates[code]
+		if !ok || code == c.target {
+			continue
+		}
+
+		amount, ok := parseAmount(number)
+		if !ok {
+			continue
+		}
+
+		b.WriteString(text[last:end])
+		b.WriteString(" (≈ " + c.format(amount*rate) + ")")
+		last = end
+	}
+
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+func (c *Converter) format(amount float64) string {
+	symbol := c.target
+	for sym, code := range symbols {
+		if code == c.target {
+			symbol = sym
+			break
+		}
+	}
+
+	var number string
+	if amount >= 100 {
+		number = groupThousands(strconv.FormatFloat(math.Round(amount), 'f', 0, 64))
+	} else {
+		number = strconv.FormatFloat(math.Round(amount*100)/100, 'f', -1, 64)
+	}
+
+	if prefixSymbols[c.target] && symbol != c.target {
+		return symbol + number
+	}
+	return number + " " + symbol
+}
+
+// parseAmount handles both "1,500.50" and "1 500,50" style numbers.
+func parseAmount(s string) (float64, bool) {
+	s = strings.NewReplacer(" ", "", "\u00a0", "", "\u202f", "").Replace(s)
+
+	lastComma := strings.LastIndex(s, ",")
+	lastDot := strings.LastIndex(s, ".")
+	switch {
+	case lastComma >= 0 && lastDot >= 0:
+		if lastComma > lastDot {
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.Replace(s, ",", ".", 1)
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case lastComma >= 0:
+		s = normalizeSeparator(s, ",")
+	case lastDot >= 0:
+		s = normalizeSeparator(s, ".")
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	return v, err == nil
+}
+
+// normalizeSeparator treats a single separator followed by exactly three
+// digits, or any repeated separator, as thousands grouping.
+func normalizeSeparator(s, sep string) string {
+	if strings.Count(s, sep) > 1 || len(s)-strings.LastIndex(s, sep)-1 == 3 {
+		return strings.ReplaceAll(s, sep, "")
+	}
+	return strings.Replace(s, sep, ".", 1)
+}
+
+func groupThousands(digits string) string {
+	var b strings.Builder
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteRune(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,114 @@
+package dedupe
+
+import (
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	// shingleSize is the number of consecutive words in a shingle.
+	shingleSize = 5
+	// numHashes is the MinHash signature length, the similarity estimate
+	// has a standard error of about 1/sqrt(numHashes).
+	numHashes = 128
+)
+
+// Duplicate reports that document Index is a near-duplicate of document Of.
+type Duplicate struct {
+	Index      int
+	Of         int
+	Similarity float64 // estimated Jaccard similarity of word shingles
+}
+
+// Find returns the documents whose similarity to an earlier document is at
+// least threshold. Each duplicate points to the first document of its
+// group, which is the one to keep. Documents without words are never
+// duplicates.
+func Find(texts []string, threshold float64) []Duplicate {
+	signatures := make([][]uint64, len(texts))
+	for i, text := range texts {
+		signatures[i] = signature(text)
+	}
+
+	var duplicates []Duplicate
+	var kept []int
+	for i, sig := range signatures {
+		best, of := 0.0, -1
+		if sig != nil {
+			for _, j := range kept {
+				if s := similarity(sig, signatures[j]); s >= threshold && s > best {
+					best, of = s, j
+				}
+			}
+		}
+		if of < 0 {
+			kept = append(kept, i)
+			continue
+		}
+		duplicates = append(duplicates, Duplicate{Index: i, Of: of, Similarity: best})
+	}
+	return duplicates
+}
+
+// signature computes the MinHash signature of the word shingles of text,
+// or nil if text has no words.
+func signature(text string) []uint64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return nil
+	}
+
+	sig := make([]uint64, numHashes)
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+
+	n := len(words) - shingleSize + 1
+	if n < 1 {
+		n = 1
+	}
+	for i := 0; i < n; i++ {
+		end := i + shingleSize
+		if end > len(words) {
+			end = len(words)
+		}
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:end], " ")))
+		base := h.Sum64()
+
+		for k := range sig {
+			if v := mix(base + uint64(k)*0x9e3779b97f4a7c15); v < sig[k] {
+				sig[k] = v
+			}
+		}
+	}
+	return sig
+}
+
+// mix is the splitmix64 finalizer, used to derive independent hash
+// functions from one shingle hash.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+func similarity(a, b []uint64) float64 {
+	if a == nil || b == nil {
+		return 0
+	}
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return float64(same) / float64(len(a))
+}
@@ -0,0 +1,177 @@
+package digest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// topN limits each ranked list in the digest.
+const topN = 10
+
+// Digest aggregates per-file analysis results of a directory run into a
+// single overview document.
+type Digest struct {
+	files      []fileEntry
+	topics     *counter
+	tags       *counter
+	sentiments *counter
+	entities   map[string]*counter
+}
+
+type fileEntry struct {
+	path  string
+	title string
+}
+
+// entityKeys are frontmatter keys produced by entity extraction, in the
+// order they appear in the digest.
+var entityKeys = []string{"persons", "organizations", "locations"}
+
+func New() *Digest {
+	d := &Digest{
+		topics:     newCounter(),
+		tags:       newCounter(),
+		sentiments: newCounter(),
+		entities:   make(map[string]*counter),
+	}
+	for _, key := range entityKeys {
+		d.entities[key] = newCounter()
+	}
+	return d
+}
+
+// Add records the frontmatter updates computed for one translated file.
+func (d *Digest) Add(path string, meta map[string]interface{}) {
+	entry := fileEntry{path: path}
+	if title, ok := meta["title"].(string); ok {
+		entry.title = title
+	}
+	d.files = append(d.files, entry)
+
+	d.topics.addAll(meta["topics"])
+	d.tags.addAll(meta["tags"])
+	if sentiment, ok := meta["sentiment"].(string); ok && sentiment != "" {
+		d.sentiments.add(sentiment)
+	}
+	for _, key := range entityKeys {
+		d.entities[key].addAll(meta[key])
+	}
+}
+
+// Render returns the digest as a markdown document.
+func (d *Digest) Render() string {
+	var b strings.Builder
+
+	b.WriteString("# Translation Digest\n\n")
+	fmt.Fprintf(&b, "Files translated: %d\n", len(d.files))
+
+	writeRanked(&b, "Top Topics", d.topics)
+	writeRanked(&b, "Top Tags", d.tags)
+
+	if d.sentiments.total > 0 {
+		b.WriteString("\n## Sentiment\n\n")
+		for _, item := range d.sentiments.ranked(0) {
+			fmt.Fprintf(&b, "- %s: %d (%.0f%%)\n", item.name, item.count,
+				float64(item.count)*100/float64(d.sentiments.total))
+		}
+	}
+
+	var entities strings.Builder
+	for _, key := range entityKeys {
+		items := d.entities[key].ranked(topN)
+		if len(items) == 0 {
+			continue
+		}
+		fmt.Fprintf(&entities, "\n### %s\n\n", strings.ToUpper(key[:1])+key[1:])
+		for _, item := range items {
+			fmt.Fprintf(&entities, "- %s (%d)\n", item.name, item.count)
+		}
+	}
+	if entities.Len() > 0 {
+		b.WriteString("\n## Notable Entities\n")
+		b.WriteString(entities.String())
+	}
+
+	b.WriteString("\n## Files\n\n")
+	for _, f := range d.files {
+		if f.title != "" {
+			fmt.Fprintf(&b, "- %s: %s\n", f.path, f.title)
+		} else {
+			fmt.Fprintf(&b, "- %s\n", f.path)
+		}
+	}
+
+	return b.String()
+}
+
+func writeRanked(b *strings.Builder, heading string, c *counter) {
+	items := c.ranked(topN)
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n\n", heading)
+	for _, item := range items {
+		fmt.Fprintf(b, "- %s (%d)\n", item.name, item.count)
+	}
+}
+
+// counter counts values case-insensitively, keeping the first spelling seen.
+type counter struct {
+	counts map[string]int
+	names  map[string]string
+	total  int
+}
+
+type rankedItem struct {
+	name  string
+	count int
+}
+
+func newCounter() *counter {
+	return &counter{
+		counts: make(map[string]int),
+		names:  make(map[string]string),
+	}
+}
+
+func (c *counter) add(value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	key := strings.ToLower(value)
+	if _, ok := c.names[key]; !ok {
+		c.names[key] = value
+	}
+	c.counts[key]++
+	c.total++
+}
+
+func (c *counter) addAll(values interface{}) {
+	list, ok := values.([]string)
+	if !ok {
+		return
+	}
+	for _, v := range list {
+		c.add(v)
+	}
+}
+
+// ranked returns items by descending count; limit 0 returns all.
+func (c *counter) ranked(limit int) []rankedItem {
+	items := make([]rankedItem, 0, len(c.counts))
+	for key, count := range c.counts {
+		items = append(items, rankedItem{name: c.names[key], count: count})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].count != items[j].count {
+			return items[i].count > items[j].count
+		}
+		return items[i].name < items[j].name
+	})
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
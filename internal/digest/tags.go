@@ -0,0 +1,203 @@
+package digest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// TagReport collects tag frequencies and co-occurrence across files to help
+// consolidate near-duplicate tags in a corpus.
+type TagReport struct {
+	files int
+	tags  *counter
+	pairs map[[2]string]int
+}
+
+func NewTagReport() *TagReport {
+	return &TagReport{
+		tags:  newCounter(),
+		pairs: make(map[[2]string]int),
+	}
+}
+
+// Add records the tags of one file.
+func (r *TagReport) Add(tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+	r.files++
+
+	seen := make(map[string]bool)
+	var keys []string
+	for _, tag := range tags {
+		key := strings.ToLower(strings.TrimSpace(tag))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+		r.tags.add(tag)
+	}
+
+	sort.Strings(keys)
+	for i := 0; i < len(keys); i++ {
+		for j := i + 1; j < len(keys); j++ {
+			r.pairs[[2]string{keys[i], keys[j]}]++
+		}
+	}
+}
+
+// Render returns the report as markdown, limiting each list to top entries.
+func (r *TagReport) Render(top int) string {
+	var b strings.Builder
+
+	b.WriteString("# Tag Report\n\n")
+	fmt.Fprintf(&b, "Files with tags: %d\n", r.files)
+	fmt.Fprintf(&b, "Distinct tags: %d\n", len(r.tags.counts))
+
+	if items := r.tags.ranked(top); len(items) > 0 {
+		b.WriteString("\n## Frequency\n\n")
+		for _, item := range items {
+			fmt.Fprintf(&b, "- %s (%d)\n", item.name, item.count)
+		}
+	}
+
+	if pairs := r.rankedPairs(top); len(pairs) > 0 {
+		b.WriteString("\n## Co-occurrence\n\n")
+		for _, p := range pairs {
+			fmt.Fprintf(&b, "- %s + %s (%d)\n", r.tags.names[p.a], r.tags.names[p.b], p.count)
+		}
+	}
+
+	if groups := r.nearDuplicates(); len(groups) > 0 {
+		b.WriteString("\n## Possible Duplicates\n\n")
+		for _, group := range groups {
+			var parts []string
+			for _, key := range group {
+				parts = append(parts, fmt.Sprintf("%s (%d)", r.tags.names[key], r.tags.counts[key]))
+			}
+			fmt.Fprintf(&b, "- %s\n", strings.Join(parts, ", "))
+		}
+	}
+
+	return b.String()
+}
+
+type tagPair struct {
+	a, b  string
+	count int
+}
+
+func (r *TagReport) rankedPairs(limit int) []tagPair {
+	pairs := make([]tagPair, 0, len(r.pairs))
+	for key, count := range r.pairs {
+		if count < 2 {
+			continue
+		}
+		pairs = append(pairs, tagPair{a: key[0], b: key[1], count: count})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].count != pairs[j].count {
+			return pairs[i].count > pairs[j].count
+		}
+		if pairs[i].a != pairs[j].a {
+			return pairs[i].a < pairs[j].a
+		}
+		return pairs[i].b < pairs[j].b
+	})
+	if limit > 0 && len(pairs) > limit {
+		pairs = pairs[:limit]
+	}
+	return pairs
+}
+
+// nearDuplicates groups tags that differ only in punctuation, spacing,
+// a plural suffix or a single typo ("machine-learning", "machine learning").
+func (r *TagReport) nearDuplicates() [][]string {
+	keys := make([]string, 0, len(r.tags.counts))
+	for key := range r.tags.counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parent := make(map[string]string)
+	var find func(string) string
+	find = func(k string) string {
+		if parent[k] == "" || parent[k] == k {
+			return k
+		}
+		parent[k] = find(parent[k])
+		return parent[k]
+	}
+
+	for i := 0; i < len(keys); i++ {
+		for j := i + 1; j < len(keys); j++ {
+			if similarTags(keys[i], keys[j]) {
+				parent[find(keys[j])] = find(keys[i])
+			}
+		}
+	}
+
+	grouped := make(map[string][]string)
+	for _, key := range keys {
+		root := find(key)
+		grouped[root] = append(grouped[root], key)
+	}
+
+	var groups [][]string
+	for _, key := range keys {
+		if group, ok := grouped[key]; ok && len(group) > 1 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+func similarTags(a, b string) bool {
+	na, nb := normalizeTag(a), normalizeTag(b)
+	if na == nb {
+		return true
+	}
+	// Single-letter typos only for longer tags, "ai" and "ar" are distinct
+	if len([]rune(na)) < 5 || len([]rune(nb)) < 5 {
+		return false
+	}
+	return editDistance(na, nb) <= 1
+}
+
+func normalizeTag(tag string) string {
+	var b strings.Builder
+	for _, r := range tag {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	s := b.String()
+	if len(s) > 3 && strings.HasSuffix(s, "s") && !strings.HasSuffix(s, "ss") {
+		s = strings.TrimSuffix(s, "s")
+	}
+	return s
+}
+
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
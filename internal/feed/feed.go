@@ -0,0 +1,325 @@
+// Package feed reads RSS and Atom feeds and writes them back with
+// translated texts. Only the texts are replaced, the rest of the document
+// is kept byte for byte, so extensions and formatting survive.
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Namespaces of the elements read, besides RSS 2.0 without one
+var namespaces = map[string]string{
+	"http://www.w3.org/2005/Atom":              "",
+	"http://purl.org/rss/1.0/":                 "",
+	"http://purl.org/rss/1.0/modules/content/": "content:",
+	"http://purl.org/dc/elements/1.1/":         "dc:",
+}
+
+// Feed is a parsed RSS 2.0, RSS 1.0 or Atom feed.
+type Feed struct {
+	// Title, Description and Language of the channel; nil when absent
+	Title       *Text
+	Description *Text
+	Language    *Text
+	Items       []*Item
+
+	data  []byte
+	texts []*Text // in document order
+}
+
+// Item is an RSS item or Atom entry.
+type Item struct {
+	ID         string
+	Link       string
+	Published  string
+	Author     string
+	Categories []string
+
+	// Title, Summary (RSS description) and Content (content:encoded);
+	// nil when absent
+	Title   *Text
+	Summary *Text
+	Content *Text
+}
+
+// Body returns the full text of the item: the content, or the summary
+// when there is none.
+func (it *Item) Body() *Text {
+	if it.Content != nil {
+		return it.Content
+	}
+	return it.Summary
+}
+
+// Text is a translatable element text. Setting Value replaces it in the
+// output of Bytes.
+type Text struct {
+	Value string
+	// HTML tells whether Value is HTML rather than plain text
+	HTML bool
+
+	orig       string
+	start, end int
+	markup     bool // Atom xhtml content, written without escaping
+}
+
+// Parse reads an RSS or Atom feed. HTML entities undefined in XML, which
+// many feeds use, are accepted.
+func Parse(data []byte) (*Feed, error) {
+	f := &Feed{data: data}
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+
+	var (
+		stack []string // local names of the open elements
+		item  *Item
+		root  string
+	)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("invalid feed: %w", err)
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			name := el.Name.Local
+			parent := ""
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			if root == "" {
+				root = name
+			}
+
+			switch {
+			case name == "item" || name == "entry":
+				item = &Item{}
+				f.Items = append(f.Items, item)
+			case item == nil:
+				// Channel elements
+				if parent != "channel" && !(root == "feed" && parent == "feed") {
+					break
+				}
+				var target **Text
+				switch name {
+				case "title":
+					target = &f.Title
+				case "description", "subtitle":
+					target = &f.Description
+				case "language":
+					target = &f.Language
+				}
+				if target != nil {
+					text, err := f.readText(d, el)
+					if err != nil {
+						return nil, err
+					}
+					*target = text
+					continue
+				}
+			case parent == "author" && name == "name":
+				value, err := readString(d, el)
+				if err != nil {
+					return nil, err
+				}
+				item.Author = value
+				continue
+			case parent == "item" || parent == "entry":
+				text, done, err := f.itemElement(d, el, item)
+				if err != nil {
+					return nil, err
+				}
+				if done {
+					if text != nil {
+						f.texts = append(f.texts, text)
+					}
+					continue
+				}
+			}
+			stack = append(stack, name)
+
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			if el.Name.Local == "item" || el.Name.Local == "entry" {
+				item = nil
+			}
+		}
+	}
+
+	if root != "rss" && root != "feed" && root != "RDF" {
+		return nil, fmt.Errorf("not an RSS or Atom feed")
+	}
+	for _, text := range []*Text{f.Title, f.Description, f.Language} {
+		if text != nil {
+			f.texts = append(f.texts, text)
+		}
+	}
+	sort.Slice(f.texts, func(i, j int) bool { return f.texts[i].start < f.texts[j].start })
+	return f, nil
+}
+
+// itemElement reads the child el of an item. done reports whether el was
+// consumed; text is the translatable text it holds, if any.
+func (f *Feed) itemElement(d *xml.Decoder, el xml.StartElement, item *Item) (text *Text, done bool, err error) {
+	prefix, known := namespaces[el.Name.Space]
+	if !known && el.Name.Space != "" {
+		// Extensions such as media:title or itunes:summary
+		return nil, false, nil
+	}
+	rss := el.Name.Space != "http://www.w3.org/2005/Atom"
+
+	switch prefix + el.Name.Local {
+	case "title":
+		item.Title, err = f.readText(d, el)
+		return item.Title, true, err
+	case "description", "summary":
+		item.Summary, err = f.readText(d, el)
+		if item.Summary != nil && rss {
+			item.Summary.HTML = true
+		}
+		return item.Summary, true, err
+	case "content:encoded", "content":
+		if attr(el, "src") != "" {
+			return nil, false, nil
+		}
+		item.Content, err = f.readText(d, el)
+		if item.Content != nil && rss {
+			item.Content.HTML = true
+		}
+		return item.Content, true, err
+	case "link":
+		if href := attr(el, "href"); href != "" {
+			if rel := attr(el, "rel"); (rel == "" || rel == "alternate") && item.Link == "" {
+				item.Link = href
+			}
+			return nil, false, nil
+		}
+		item.Link, err = readString(d, el)
+		return nil, true, err
+	case "guid", "id":
+		item.ID, err = readString(d, el)
+		return nil, true, err
+	case "pubDate", "published", "dc:date", "updated":
+		value, err := readString(d, el)
+		if item.Published == "" || el.Name.Local != "updated" {
+			item.Published = value
+		}
+		return nil, true, err
+	case "author", "dc:creator":
+		if !rss {
+			// The name is read from the child element
+			return nil, false, nil
+		}
+		item.Author, err = readString(d, el)
+		return nil, true, err
+	case "category", "dc:subject":
+		if term := attr(el, "term"); term != "" {
+			item.Categories = append(item.Categories, term)
+			return nil, false, nil
+		}
+		value, err := readString(d, el)
+		if value != "" {
+			item.Categories = append(item.Categories, value)
+		}
+		return nil, true, err
+	}
+	return nil, false, nil
+}
+
+// readText reads the content of el, which has just been read, with its
+// position in the document. It returns nil for empty elements.
+func (f *Feed) readText(d *xml.Decoder, el xml.StartElement) (*Text, error) {
+	text := &Text{start: int(d.InputOffset())}
+	switch attr(el, "type") {
+	case "html", "text/html":
+		text.HTML = true
+	case "xhtml", "application/xhtml+xml":
+		text.HTML, text.markup = true, true
+	}
+
+	var b strings.Builder
+	depth := 0
+	for {
+		offset := int(d.InputOffset())
+		tok, err := d.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid feed: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			if depth == 0 {
+				text.end = offset
+				if text.markup {
+					text.Value = string(f.data[text.start:text.end])
+				} else {
+					text.Value = b.String()
+				}
+				text.orig = text.Value
+				if text.end <= text.start || strings.TrimSpace(text.Value) == "" {
+					return nil, nil
+				}
+				return text, nil
+			}
+			depth--
+		case xml.CharData:
+			b.Write(t)
+		}
+	}
+}
+
+// readString reads the text content of el, which has just been read.
+func readString(d *xml.Decoder, el xml.StartElement) (string, error) {
+	var value string
+	if err := d.DecodeElement(&value, &el); err != nil {
+		return "", fmt.Errorf("invalid feed: %w", err)
+	}
+	return strings.TrimSpace(value), nil
+}
+
+// Bytes returns the feed with the changed texts written in place.
+func (f *Feed) Bytes() []byte {
+	var b bytes.Buffer
+	last := 0
+	for _, text := range f.texts {
+		if text.Value == text.orig {
+			continue
+		}
+		b.Write(f.data[last:text.start])
+		switch {
+		case text.markup:
+			b.WriteString(text.Value)
+		case text.HTML && !strings.Contains(text.Value, "]]>"):
+			b.WriteString("<![CDATA[" + text.Value + "]]>")
+		default:
+			b.WriteString(escaper.Replace(text.Value))
+		}
+		last = text.end
+	}
+	b.Write(f.data[last:])
+	return b.Bytes()
+}
+
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
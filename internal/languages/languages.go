@@ -0,0 +1,263 @@
+// Package languages validates language values such as "ru", "pt_br" or
+// "Russian" and turns them into canonical BCP-47 tags.
+package languages
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Language is a supported language with its English and native names.
+type Language struct {
+	Code   string
+	Name   string
+	Native string
+}
+
+// all lists the supported languages by ISO 639-1 code, and ISO 639-3 where
+// there is none.
+var all = []Language{
+	{"af", "Afrikaans", "Afrikaans"},
+	{"am", "Amharic", "አማርኛ"},
+	{"ar", "Arabic", "العربية"},
+	{"az", "Azerbaijani", "Azərbaycanca"},
+	{"be", "Belarusian", "Беларуская"},
+	{"bg", "Bulgarian", "Български"},
+	{"bn", "Bengali", "বাংলা"},
+	{"bs", "Bosnian", "Bosanski"},
+	{"ca", "Catalan", "Català"},
+	{"cs", "Czech", "Čeština"},
+	{"cy", "Welsh", "Cymraeg"},
+	{"da", "Danish", "Dansk"},
+	{"de", "German", "Deutsch"},
+	{"el", "Greek", "Ελληνικά"},
+	{"en", "English", "English"},
+	{"eo", "Esperanto", "Esperanto"},
+	{"es", "Spanish", "Español"},
+	{"et", "Estonian", "Eesti"},
+	{"eu", "Basque", "Euskara"},
+	{"fa", "Persian", "فارسی"},
+	{"fi", "Finnish", "Suomi"},
+	{"fil", "Filipino", "Filipino"},
+	{"fr", "French", "Français"},
+	{"ga", "Irish", "Gaeilge"},
+	{"gl", "Galician", "Galego"},
+	{"gu", "Gujarati", "ગુજરાતી"},
+	{"ha", "Hausa", "Hausa"},
+	{"he", "Hebrew", "עברית"},
+	{"hi", "Hindi", "हिन्दी"},
+	{"hr", "Croatian", "Hrvatski"},
+	{"hu", "Hungarian", "Magyar"},
+	{"hy", "Armenian", "Հայերեն"},
+	{"id", "Indonesian", "Bahasa Indonesia"},
+	{"is", "Icelandic", "Íslenska"},
+	{"it", "Italian", "Italiano"},
+	{"ja", "Japanese", "日本語"},
+	{"jv", "Javanese", "Basa Jawa"},
+	{"ka", "Georgian", "ქართული"},
+	{"kk", "Kazakh", "Қазақша"},
+	{"km", "Khmer", "ខ្មែរ"},
+	{"kn", "Kannada", "ಕನ್ನಡ"},
+	{"ko", "Korean", "한국어"},
+	{"ky", "Kyrgyz", "Кыргызча"},
+	{"la", "Latin", "Latina"},
+	{"lo", "Lao", "ລາວ"},
+	{"lt", "Lithuanian", "Lietuvių"},
+	{"lv", "Latvian", "Latviešu"},
+	{"mk", "Macedonian", "Македонски"},
+	{"ml", "Malayalam", "മലയാളം"},
+	{"mn", "Mongolian", "Монгол"},
+	{"mr", "Marathi", "मराठी"},
+	{"ms", "Malay", "Bahasa Melayu"},
+	{"my", "Burmese", "မြန်မာ"},
+	{"nb", "Norwegian Bokmål", "Norsk bokmål"},
+	{"ne", "Nepali", "नेपाली"},
+	{"nl", "Dutch", "Nederlands"},
+	{"nn", "Norwegian Nynorsk", "Norsk nynorsk"},
+	{"no", "Norwegian", "Norsk"},
+	{"pa", "Punjabi", "ਪੰਜਾਬੀ"},
+	{"pl", "Polish", "Polski"},
+	{"ps", "Pashto", "پښتو"},
+	{"pt", "Portuguese", "Português"},
+	{"ro", "Romanian", "Română"},
+	{"ru", "Russian", "Русский"},
+	{"si", "Sinhala", "සිංහල"},
+	{"sk", "Slovak", "Slovenčina"},
+	{"sl", "Slovenian", "Slovenščina"},
+	{"so", "Somali", "Soomaali"},
+	{"sq", "Albanian", "Shqip"},
+	{"sr", "Serbian", "Српски"},
+	{"sv", "Swedish", "Svenska"},
+	{"sw", "Swahili", "Kiswahili"},
+	{"ta", "Tamil", "தமிழ்"},
+	{"te", "Telugu", "తెలుగు"},
+	{"tg", "Tajik", "Тоҷикӣ"},
+	{"th", "Thai", "ไทย"},
+	{"tk", "Turkmen", "Türkmençe"},
+	{"tl", "Tagalog", "Tagalog"},
+	{"tr", "Turkish", "Türkçe"},
+	{"tt", "Tatar", "Татарча"},
+	{"uk", "Ukrainian", "Українська"},
+	{"ur", "Urdu", "اردو"},
+	{"uz", "Uzbek", "Oʻzbekcha"},
+	{"vi", "Vietnamese", "Tiếng Việt"},
+	{"yi", "Yiddish", "ייִדיש"},
+	{"yo", "Yoruba", "Yorùbá"},
+	{"yue", "Cantonese", "粵語"},
+	{"zh", "Chinese", "中文"},
+	{"zu", "Zulu", "isiZulu"},
+}
+
+// aliases are other names of languages and tags, in lower case. Deprecated
+// codes map to their replacements.
+var aliases = map[string]string{
+	"iw": "he", "in": "id", "ji": "yi", "jw": "jv", "mo": "ro",
+	"farsi": "fa", "mandarin": "zh", "bokmål": "nb", "bokmal": "nb", "nynorsk": "nn",
+	"simplified chinese": "zh-Hans", "chinese simplified": "zh-Hans",
+	"traditional chinese": "zh-Hant", "chinese traditional": "zh-Hant",
+	"brazilian portuguese": "pt-BR", "portuguese brazil": "pt-BR",
+	"european portuguese": "pt-PT", "portuguese portugal": "pt-PT",
+	"british english": "en-GB", "american english": "en-US",
+	"latin american spanish": "es-419", "mexican spanish": "es-MX",
+	"canadian french": "fr-CA", "swiss german": "de-CH",
+}
+
+var (
+	byCode = make(map[string]Language)
+	byName = make(map[string]string)
+	// tagRe matches language-script-region tags like "sr-Latn-RS", with
+	// hyphens or underscores.
+	tagRe = regexp.MustCompile(`^([a-zA-Z]{2,3})(?:[-_]([a-zA-Z]{4}))?(?:[-_]([a-zA-Z]{2}|\d{3}))?$`)
+)
+
+func init() {
+	for _, l := range all {
+		byCode[l.Code] = l
+		byName[strings.ToLower(l.Name)] = l.Code
+		byName[strings.ToLower(l.Native)] = l.Code
+	}
+	for name, tag := range aliases {
+		byName[name] = tag
+	}
+}
+
+// All returns the supported languages ordered by code.
+func All() []Language {
+	return all
+}
+
+// Lookup returns the language of a tag like "pt-BR".
+func Lookup(tag string) (Language, bool) {
+	m := tagRe.FindStringSubmatch(tag)
+	if m == nil {
+		return Language{}, false
+	}
+	l, ok := byCode[strings.ToLower(m[1])]
+	return l, ok
+}
+
+// Normalize returns the canonical tag of value: a code in any case, a
+// language-region tag with an underscore, or an English or native name.
+// Unknown values fail with suggestions of similar languages.
+func Normalize(value string) (string, error) {
+	v := strings.TrimSpace(value)
+	if tag, ok := byName[strings.ToLower(v)]; ok {
+		return tag, nil
+	}
+	if m := tagRe.FindStringSubmatch(v); m != nil {
+		code := strings.ToLower(m[1])
+		if alias, ok := aliases[code]; ok {
+			code = alias
+		}
+		if _, ok := byCode[code]; ok {
+			tag := code
+			if m[2] != "" {
+				tag += "-" + strings.ToUpper(m[2][:1]) + strings.ToLower(m[2][1:])
+			}
+			if m[3] != "" {
+				tag += "-" + strings.ToUpper(m[3])
+			}
+			return tag, nil
+		}
+	}
+
+	if suggestions := suggest(strings.ToLower(v)); len(suggestions) > 0 {
+		return "", fmt.Errorf("unknown language %q, did you mean %s?", value, strings.Join(suggestions, " or "))
+	}
+	return "", fmt.Errorf("unknown language %q, see llm-translate languages for the codes", value)
+}
+
+// suggest returns up to three languages whose code or name is within a
+// few typos of value, closest first.
+func suggest(value string) []string {
+	type candidate struct {
+		code     string
+		distance int
+	}
+	best := make(map[string]int)
+	for name, code := range byName {
+		limit := 1
+		if len([]rune(name)) > 4 {
+			limit = 2
+		}
+		if d := distance(value, name); d <= limit {
+			if prev, ok := best[code]; !ok || d < prev {
+				best[code] = d
+			}
+		}
+	}
+	for code := range byCode {
+		if d := distance(value, code); d <= 1 && len(value) <= 3 {
+			if prev, ok := best[code]; !ok || d < prev {
+				best[code] = d
+			}
+		}
+	}
+
+	var candidates []candidate
+	for code, d := range best {
+		candidates = append(candidates, candidate{code, d})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].code < candidates[j].code
+	})
+	var result []string
+	for i, c := range candidates {
+		if i == 3 {
+			break
+		}
+		name := c.code
+		if l, ok := Lookup(c.code); ok {
+			name = fmt.Sprintf("%s (%s)", c.code, l.Name)
+		}
+		result = append(result, name)
+	}
+	return result
+}
+
+// distance is the Levenshtein distance of a and b.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,355 @@
+// Package localize rewrites numbers and dates of translated text in the
+// conventions of the target locale, such as decimal commas and
+// DD.MM.YYYY dates, and annotates measurements with their value in the
+// metric or imperial system.
+package localize
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// locale holds the number and date conventions of a language.
+type locale struct {
+	decimal, group string
+	// order of day, month and year in numeric dates: "dmy", "mdy" or "ymd"
+	order   string
+	dateSep string
+}
+
+const (
+	nbsp       = "\u00a0"
+	narrowNbsp = "\u202f"
+)
+
+// locales are keyed by language code, regional variants by the full code.
+var locales = map[string]locale{
+	"en":    {".", ",", "mdy", "/"},
+	"en-gb": {".", ",", "dmy", "/"},
+	"en-au": {".", ",", "dmy", "/"},
+	"en-nz": {".", ",", "dmy", "/"},
+	"en-ie": {".", ",", "dmy", "/"},
+	"en-in": {".", ",", "dmy", "/"},
+	"ja":    {".", ",", "ymd", "/"},
+	"zh":    {".", ",", "ymd", "/"},
+	"ko":    {".", ",", "ymd", "."},
+	"he":    {".", ",", "dmy", "."},
+	"hi":    {".", ",", "dmy", "/"},
+	"th":    {".", ",", "dmy", "/"},
+	"de":    {",", ".", "dmy", "."},
+	"de-ch": {".", "’", "dmy", "."},
+	"es":    {",", ".", "dmy", "/"},
+	"it":    {",", ".", "dmy", "/"},
+	"pt":    {",", ".", "dmy", "/"},
+	"nl":    {",", ".", "dmy", "-"},
+	"id":    {",", ".", "dmy", "/"},
+	"tr":    {",", ".", "dmy", "."},
+	"da":    {",", ".", "dmy", "."},
+	"el":    {",", ".", "dmy", "/"},
+	"ro":    {",", ".", "dmy", "."},
+	"hr":    {",", ".", "dmy", "."},
+	"sl":    {",", ".", "dmy", "."},
+	"sr":    {",", ".", "dmy", "."},
+	"vi":    {",", ".", "dmy", "/"},
+	"ru":    {",", nbsp, "dmy", "."},
+	"uk":    {",", nbsp, "dmy", "."},
+	"be":    {",", nbsp, "dmy", "."},
+	"bg":    {",", nbsp, "dmy", "."},
+	"kk":    {",", nbsp, "dmy", "."},
+	"pl":    {",", nbsp, "dmy", "."},
+	"cs":    {",", nbsp, "dmy", "."},
+	"sk":    {",", nbsp, "dmy", "."},
+	"fi":    {",", nbsp, "dmy", "."},
+	"no":    {",", nbsp, "dmy", "."},
+	"nb":    {",", nbsp, "dmy", "."},
+	"et":    {",", nbsp, "dmy", "."},
+	"lv":    {",", nbsp, "dmy", "."},
+	"lt":    {",", nbsp, "ymd", "-"},
+	"sv":    {",", nbsp, "ymd", "-"},
+	"hu":    {",", nbsp, "ymd", "."},
+	"fr":    {",", narrowNbsp, "dmy", "/"},
+}
+
+// lookup returns the conventions of lang, such as "pt-BR" or "RU".
+func lookup(lang string) (locale, bool) {
+	lang = strings.ReplaceAll(strings.ToLower(lang), "_", "-")
+	if loc, ok := locales[lang]; ok {
+		return loc, true
+	}
+	if i := strings.Index(lang, "-"); i >= 0 {
+		lang = lang[:i]
+	}
+	loc, ok := locales[lang]
+	return loc, ok
+}
+
+var (
+	// numberRe matches digits with separators between them.
+	numberRe = regexp.MustCompile(`\d+(?:[.,’\x{00A0}\x{202F}]\d+)*`)
+	// dateRe matches numeric dates with the same separator twice.
+	dateRe = regexp.MustCompile(`\d{1,4}[./-]\d{1,2}[./-]\d{1,4}`)
+	// skipRe matches Markdown code and URLs, which are left as they are.
+	skipRe = regexp.MustCompile("(?s)```.*?```|`[^`\n]+`|https?://[^\\s)\\]>]+")
+)
+
+// Numbers rewrites the decimal and thousands separators of the numbers of
+// text that appear unchanged in source into the conventions of to. The
+// numbers are read with the conventions of from; with an unknown source
+// language, only numbers whose reading is unambiguous are rewritten.
+// Numbers without separators are kept, so years and IDs stay intact.
+func Numbers(source, text, from, to string) string {
+	target, ok := lookup(to)
+	if !ok {
+		return text
+	}
+	src, known := lookup(from)
+	inSource := tokens(numberRe, source)
+
+	return outsideSkipped(text, func(s string) string {
+		return replaceTokens(numberRe, s, func(token string) string {
+			if !inSource[token] {
+				return token
+			}
+			whole, frac, grouped, ok := parseNumber(token, src, known)
+			if !ok {
+				return token
+			}
+			return formatNumber(whole, frac, grouped, target)
+		})
+	})
+}
+
+// Dates rewrites the numeric dates of text that appear unchanged in source
+// into the order and separator of to, e.g. 03/14/2024 to 14.03.2024. ISO
+// dates and dates with two-digit years are kept.
+func Dates(source, text, from, to string) string {
+	target, ok := lookup(to)
+	if !ok {
+		return text
+	}
+	src, known := lookup(from)
+	inSource := tokens(dateRe, source)
+
+	return outsideSkipped(text, func(s string) string {
+		return replaceTokens(dateRe, s, func(token string) string {
+			if !inSource[token] {
+				return token
+			}
+			day, month, year, ok := parseDate(token, src, known)
+			if !ok {
+				return token
+			}
+			var parts []string
+			for _, c := range target.order {
+				switch c {
+				case 'd':
+					parts = append(parts, day)
+				case 'm':
+					parts = append(parts, month)
+				case 'y':
+					parts = append(parts, year)
+				}
+			}
+			return strings.Join(parts, target.dateSep)
+		})
+	})
+}
+
+// parseNumber splits token into the digits of its whole and fractional
+// part, read with loc when known. grouped reports thousands separators.
+func parseNumber(token string, loc locale, known bool) (whole, frac string, grouped, ok bool) {
+	parts := splitNumber(token)
+	if len(parts) == 1 {
+		return "", "", false, false
+	}
+
+	decimal, group := loc.decimal, loc.group
+	if !known {
+		// Without the source conventions: two kinds of separators, one
+		// used more than once, or one not followed by three digits
+		last := parts[len(parts)-1].sep
+		mixed := false
+		for _, p := range parts[1:] {
+			mixed = mixed || p.sep != last
+		}
+		switch {
+		case mixed:
+			decimal, group = last, parts[1].sep
+		case len(parts) > 2:
+			decimal, group = "", last
+		case len(parts[1].digits) != 3:
+			decimal, group = last, ""
+		default:
+			return "", "", false, false
+		}
+	}
+
+	for i, p := range parts[1:] {
+		switch {
+		case sameSeparator(p.sep, decimal) && i == len(parts)-2:
+			frac = p.digits
+		case sameSeparator(p.sep, group) && len(p.digits) == 3 && len(parts[0].digits) <= 3:
+			grouped = true
+		default:
+			return "", "", false, false
+		}
+	}
+	for _, p := range parts {
+		whole += p.digits
+	}
+	whole = whole[:len(whole)-len(frac)]
+	return whole, frac, grouped, true
+}
+
+// numberPart is a run of digits and the separator before it.
+type numberPart struct {
+	sep, digits string
+}
+
+func splitNumber(token string) []numberPart {
+	var parts []numberPart
+	sep, start := "", 0
+	for i, r := range token {
+		if unicode.IsDigit(r) {
+			continue
+		}
+		parts = append(parts, numberPart{sep, token[start:i]})
+		sep, start = string(r), i+utf8.RuneLen(r)
+	}
+	return append(parts, numberPart{sep, token[start:]})
+}
+
+// sameSeparator treats the no-break spaces as one group separator.
+func sameSeparator(a, b string) bool {
+	if a == narrowNbsp {
+		a = nbsp
+	}
+	if b == narrowNbsp {
+		b = nbsp
+	}
+	return a != "" && a == b
+}
+
+// formatNumber writes a number in the conventions of loc, grouping the
+// thousands when the source did.
+func formatNumber(whole, frac string, grouped bool, loc locale) string {
+	if grouped {
+		var b strings.Builder
+		for i, r := range whole {
+			if i > 0 && (len(whole)-i)%3 == 0 {
+				b.WriteString(loc.group)
+			}
+			b.WriteRune(r)
+		}
+		whole = b.String()
+	}
+	if frac != "" {
+		return whole + loc.decimal + frac
+	}
+	return whole
+}
+
+// parseDate reads a numeric date in the order of loc when known, otherwise
+// only when the order is clear from the values.
+func parseDate(token string, loc locale, known bool) (day, month, year string, ok bool) {
+	sep := token[strings.IndexAny(token, "./-")]
+	parts := strings.Split(token, string(sep))
+	if len(parts) != 3 {
+		return "", "", "", false
+	}
+	var order string
+	switch {
+	case len(parts[0]) == 4 && sep == '-':
+		// ISO dates read the same everywhere
+		return "", "", "", false
+	case len(parts[0]) == 4:
+		order = "ymd"
+	case len(parts[2]) != 4:
+		return "", "", "", false
+	case known && loc.order != "ymd":
+		order = loc.order
+	case atoi(parts[0]) > 12:
+		order = "dmy"
+	case atoi(parts[1]) > 12:
+		order = "mdy"
+	case parts[0] == parts[1]:
+		order = "dmy"
+	default:
+		return "", "", "", false
+	}
+
+	for i, c := range order {
+		switch c {
+		case 'd':
+			day = parts[i]
+		case 'm':
+			month = parts[i]
+		case 'y':
+			year = parts[i]
+		}
+	}
+	if len(year) != 4 || atoi(month) < 1 || atoi(month) > 12 || atoi(day) < 1 || atoi(day) > 31 {
+		return "", "", "", false
+	}
+	return day, month, year, true
+}
+
+func atoi(s string) int {
+	n := 0
+	for _, r := range s {
+		n = n*10 + int(r-'0')
+	}
+	return n
+}
+
+// tokens returns the matches of re in text outside code and URLs.
+func tokens(re *regexp.Regexp, text string) map[string]bool {
+	found := make(map[string]bool)
+	outsideSkipped(text, func(s string) string {
+		replaceTokens(re, s, func(token string) string {
+			found[token] = true
+			return token
+		})
+		return s
+	})
+	return found
+}
+
+// replaceTokens replaces the matches of re in text that stand on their
+// own, not inside words, versions or other numbers.
+func replaceTokens(re *regexp.Regexp, text string, fn func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		before, _ := utf8.DecodeLastRuneInString(text[:loc[0]])
+		after, _ := utf8.DecodeRuneInString(text[loc[1]:])
+		if loc[0] > 0 && (isWordRune(before) || strings.ContainsRune("._/:,", before)) ||
+			loc[1] < len(text) && (isWordRune(after) || after == '/') {
+			continue
+		}
+		b.WriteString(text[last:loc[0]])
+		b.WriteString(fn(text[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// outsideSkipped applies fn to the text between code and URLs.
+func outsideSkipped(text string, fn func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range skipRe.FindAllStringIndex(text, -1) {
+		b.WriteString(fn(text[last:loc[0]]))
+		b.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(fn(text[last:]))
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
@@ -0,0 +1,136 @@
+package localize
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// unit converts a measurement into the other system.
+type unit struct {
+	to      string
+	convert func(float64) float64
+}
+
+func factor(f float64) func(float64) float64 {
+	return func(v float64) float64 { return v * f }
+}
+
+// units maps the names and symbols of imperial and metric units, written
+// after the number, to their counterpart. Names of other languages are not
+// matched, the symbols usually survive translation.
+var units = map[string]map[string]unit{
+	"metric": {
+		"mi": {"km", factor(1.609344)}, "mile": {"km", factor(1.609344)}, "miles": {"km", factor(1.609344)},
+		"yd": {"m", factor(0.9144)}, "yard": {"m", factor(0.9144)}, "yards": {"m", factor(0.9144)},
+		"ft": {"m", factor(0.3048)}, "foot": {"m", factor(0.3048)}, "feet": {"m", factor(0.3048)},
+		"inch": {"cm", factor(2.54)}, "inches": {"cm", factor(2.54)},
+		"lb": {"kg", factor(0.45359237)}, "lbs": {"kg", factor(0.45359237)},
+		"oz": {"g", factor(28.349523125)}, "ounce": {"g", factor(28.349523125)}, "ounces": {"g", factor(28.349523125)},
+		"gal": {"l", factor(3.785411784)}, "gallon": {"l", factor(3.785411784)}, "gallons": {"l", factor(3.785411784)},
+		"mph":  {"km/h", factor(1.609344)},
+		"acre": {"ha", factor(0.40468564)}, "acres": {"ha", factor(0.40468564)},
+		"°F": {"°C", func(v float64) float64 { return (v - 32) * 5 / 9 }},
+	},
+	"imperial": {
+		"km": {"mi", factor(1 / 1.609344)}, "kilometers": {"mi", factor(1 / 1.609344)}, "kilometres": {"mi", factor(1 / 1.609344)},
+		"m": {"ft", factor(1 / 0.3048)}, "meters": {"ft", factor(1 / 0.3048)}, "metres": {"ft", factor(1 / 0.3048)},
+		"cm": {"in", factor(1 / 2.54)}, "mm": {"in", factor(1 / 25.4)},
+		"kg": {"lb", factor(1 / 0.45359237)}, "kilograms": {"lb", factor(1 / 0.45359237)},
+		"g": {"oz", factor(1 / 28.349523125)}, "grams": {"oz", factor(1 / 28.349523125)},
+		"l": {"gal", factor(1 / 3.785411784)}, "L": {"gal", factor(1 / 3.785411784)}, "liters": {"gal", factor(1 / 3.785411784)}, "litres": {"gal", factor(1 / 3.785411784)},
+		"km/h": {"mph", factor(1 / 1.609344)},
+		"ha":   {"acres", factor(1 / 0.40468564)},
+		"°C":   {"°F", func(v float64) float64 { return v*9/5 + 32 }},
+		"км":   {"mi", factor(1 / 1.609344)}, "м": {"ft", factor(1 / 0.3048)}, "см": {"in", factor(1 / 2.54)},
+		"кг": {"lb", factor(1 / 0.45359237)}, "л": {"gal", factor(1 / 3.785411784)}, "км/ч": {"mph", factor(1 / 1.609344)},
+	},
+}
+
+// Units appends the value in system, "metric" or "imperial", to the
+// measurements of text in the other system, e.g. "5 miles" becomes
+// "5 miles (≈ 8 km)". Numbers are read and written in the conventions of
+// lang. Measurements already followed by a conversion are kept.
+func Units(text, system, lang string) string {
+	table := units[system]
+	if len(table) == 0 {
+		return text
+	}
+	loc, ok := lookup(lang)
+	if !ok {
+		loc = locales["en"]
+	}
+
+	names := make([]string, 0, len(table))
+	for name := range table {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	// Longest first, so km/h is not read as km
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	re := regexp.MustCompile(`(` + numberRe.String() + `)[ \x{00A0}\x{202F}]?(` + strings.Join(names, "|") + `)`)
+
+	return outsideSkipped(text, func(s string) string {
+		var b strings.Builder
+		last := 0
+		for _, m := range re.FindAllStringSubmatchIndex(s, -1) {
+			number, name := s[m[2]:m[3]], s[m[4]:m[5]]
+			before, _ := utf8.DecodeLastRuneInString(s[:m[2]])
+			after, _ := utf8.DecodeRuneInString(s[m[1]:])
+			// Amounts of money, such as "$5 m", are not measurements
+			if m[2] > 0 && (isWordRune(before) || unicode.Is(unicode.Sc, before) || before == '.' || before == ',') ||
+				m[1] < len(s) && isWordRune(after) ||
+				strings.HasPrefix(strings.TrimLeft(s[m[1]:], " "), "(≈") {
+				continue
+			}
+			value, ok := parseValue(number, loc)
+			if !ok {
+				continue
+			}
+			if before == '-' || before == '−' {
+				value = -value
+			}
+			u := table[name]
+			b.WriteString(s[last:m[1]])
+			b.WriteString(" (≈ " + formatValue(u.convert(value), loc) + " " + u.to + ")")
+			last = m[1]
+		}
+		b.WriteString(s[last:])
+		return b.String()
+	})
+}
+
+// parseValue reads a number written in the conventions of loc, or without
+// separators.
+func parseValue(number string, loc locale) (float64, bool) {
+	whole, frac := number, ""
+	if strings.ContainsAny(number, ".,’\u00a0\u202f") {
+		var ok bool
+		if whole, frac, _, ok = parseNumber(number, loc, true); !ok {
+			return 0, false
+		}
+	}
+	if frac != "" {
+		whole += "." + frac
+	}
+	value, err := strconv.ParseFloat(whole, 64)
+	return value, err == nil
+}
+
+// formatValue rounds a converted value, to one decimal below 100, and
+// writes it in the conventions of loc.
+func formatValue(v float64, loc locale) string {
+	s := strconv.FormatFloat(math.Round(v), 'f', 0, 64)
+	if math.Abs(v) < 100 {
+		s = strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+	}
+	whole, frac, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	number := formatNumber(whole, frac, len(whole) > 4, loc)
+	if strings.HasPrefix(s, "-") {
+		return "-" + number
+	}
+	return number
+}
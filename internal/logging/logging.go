@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// logger is used by Info, Warn and Error. Until Setup is called it writes
+// info and above as text to stderr.
+var logger = slog.New(&textHandler{w: os.Stderr, level: slog.LevelInfo})
+
+// Setup configures the log level (info, warn or error), the format (text
+// or json) and the destination: file, or stderr when empty. The file is
+// appended to and stays open for the life of the process.
+func Setup(level, format, file string) error {
+	var lvl slog.Level
+	switch level {
+	case "info":
+		lvl = slog.LevelInfo
+	case "warn":
+		lvl = slog.LevelWarn
+	case "error":
+		lvl = slog.LevelError
+	default:
+		return fmt.Errorf("invalid log level %q: use info, warn or error", level)
+	}
+
+	var w io.Writer = os.Stderr
+	if file != "" {
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+		w = f
+	}
+
+	switch format {
+	case "text":
+		logger = slog.New(&textHandler{w: w, level: lvl})
+	case "json":
+		logger = slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: lvl}))
+	default:
+		return fmt.Errorf("invalid log format %q: use text or json", format)
+	}
+	return nil
+}
+
+func Info(format string, args ...interface{}) {
+	logger.Info(fmt.Sprintf(format, args...))
+}
+
+func Warn(format string, args ...interface{}) {
+	logger.Warn(fmt.Sprintf(format, args...))
+}
+
+func Error(format string, args ...interface{}) {
+	logger.Error(fmt.Sprintf(format, args...))
+}
+
+// textHandler writes "[LEVEL] message" lines, the format of the tool
+// before structured logging.
+type textHandler struct {
+	w     io.Writer
+	level slog.Level
+}
+
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	_, err := fmt.Fprintf(h.w, "[%s] %s\n", strings.ToUpper(r.Level.String()), r.Message)
+	return err
+}
+
+func (h *textHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *textHandler) WithGroup(string) slog.Handler      { return h }
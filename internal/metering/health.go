@@ -0,0 +1,124 @@
+package metering
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// window is the number of latest requests the recent stats cover.
+const window = 20
+
+// Health tracks latency and failures of provider requests during a run, so
+// a degraded endpoint can be noticed while the run is still going. It is
+// safe for concurrent use.
+type Health struct {
+	mu        sync.Mutex
+	providers map[string]*providerHealth
+}
+
+type providerHealth struct {
+	requests int
+	errors   int
+	total    time.Duration
+	recent   []sample // last window requests, oldest first
+}
+
+type sample struct {
+	latency time.Duration
+	failed  bool
+}
+
+// ProviderHealth summarizes the requests to one provider. Recent values
+// cover the last 20 requests.
+type ProviderHealth struct {
+	Provider        string  `json:"provider"`
+	Requests        int     `json:"requests"`
+	Errors          int     `json:"errors"`
+	AvgLatencyMs    int64   `json:"avg_latency_ms"`
+	RecentLatencyMs int64   `json:"recent_latency_ms"`
+	RecentErrorRate float64 `json:"recent_error_rate"`
+}
+
+// Degraded reports whether recent requests fail half of the time or are
+// more than twice as slow as the run average. A few requests are not
+// enough to tell.
+func (s ProviderHealth) Degraded() bool {
+	if s.Requests < 4 {
+		return false
+	}
+	if s.RecentErrorRate >= 0.5 {
+		return true
+	}
+	return s.Requests > window && s.RecentLatencyMs > 2*s.AvgLatencyMs
+}
+
+func NewHealth() *Health {
+	return &Health{providers: make(map[string]*providerHealth)}
+}
+
+// Record adds one request and returns the updated stats of its provider.
+// A nil Health ignores it.
+func (h *Health) Record(provider string, latency time.Duration, failed bool) ProviderHealth {
+	if h == nil {
+		return ProviderHealth{}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	p, ok := h.providers[provider]
+	if !ok {
+		p = &providerHealth{}
+		h.providers[provider] = p
+	}
+	p.requests++
+	p.total += latency
+	if failed {
+		p.errors++
+	}
+	p.recent = append(p.recent, sample{latency: latency, failed: failed})
+	if len(p.recent) > window {
+		p.recent = p.recent[1:]
+	}
+	return p.stats(provider)
+}
+
+// Snapshot returns the stats of every provider, sorted by name.
+func (h *Health) Snapshot() []ProviderHealth {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	list := make([]ProviderHealth, 0, len(h.providers))
+	for name, p := range h.providers {
+		list = append(list, p.stats(name))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Provider < list[j].Provider
+	})
+	return list
+}
+
+func (p *providerHealth) stats(name string) ProviderHealth {
+	var recent time.Duration
+	failed := 0
+	for _, s := range p.recent {
+		recent += s.latency
+		if s.failed {
+			failed++
+		}
+	}
+
+	return ProviderHealth{
+		Provider:        name,
+		Requests:        p.requests,
+		Errors:          p.errors,
+		AvgLatencyMs:    (p.total / time.Duration(p.requests)).Milliseconds(),
+		RecentLatencyMs: (recent / time.Duration(len(p.recent))).Milliseconds(),
+		RecentErrorRate: float64(failed) / float64(len(p.recent)),
+	}
+}
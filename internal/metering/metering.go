@@ -0,0 +1,121 @@
+package metering
+
+import (
+	"sort"
+	"sync"
+	"unicode"
+
+	"github.com/foxzi/llm-translate/internal/telemetry"
+)
+
+// Meter accumulates token usage reported by providers. It is safe for
+// concurrent use, so parallel chunk and file workers can share one meter.
+type Meter struct {
+	mu    sync.Mutex
+	usage map[[2]string]*Usage
+}
+
+// Usage is the token usage of one provider and model.
+type Usage struct {
+	Provider     string `json:"provider,omitempty"`
+	Model        string `json:"model,omitempty"`
+	Requests     int    `json:"requests"`
+	InputTokens  int    `json:"input_tokens"`
+	OutputTokens int    `json:"output_tokens"`
+	Estimated    bool   `json:"estimated,omitempty"` // some counts are local estimates
+}
+
+// Tokens returns input and output tokens together.
+func (u Usage) Tokens() int {
+	return u.InputTokens + u.OutputTokens
+}
+
+func New() *Meter {
+	return &Meter{usage: make(map[[2]string]*Usage)}
+}
+
+// Record adds the usage of one request. A nil meter ignores it.
+func (m *Meter) Record(provider, model string, input, output int) {
+	m.record(provider, model, input, output, false)
+}
+
+// RecordEstimate adds a request whose usage the provider did not report,
+// estimating tokens from the prompt and response text.
+func (m *Meter) RecordEstimate(provider, model, input, output string) {
+	m.record(provider, model, EstimateTokens(input), EstimateTokens(output), true)
+}
+
+func (m *Meter) record(provider, model string, input, output int, estimated bool) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := [2]string{provider, model}
+	u, ok := m.usage[key]
+	if !ok {
+		u = &Usage{Provider: provider, Model: model}
+		m.usage[key] = u
+	}
+	u.Requests++
+	u.InputTokens += input
+	u.OutputTokens += output
+	u.Estimated = u.Estimated || estimated
+
+	telemetry.Count("llm_translate.tokens", input, telemetry.String("provider", provider), telemetry.String("model", model), telemetry.String("type", "input"))
+	telemetry.Count("llm_translate.tokens", output, telemetry.String("provider", provider), telemetry.String("model", model), telemetry.String("type", "output"))
+}
+
+// Total returns usage summed over all providers and models.
+func (m *Meter) Total() Usage {
+	var total Usage
+	for _, u := range m.Snapshot() {
+		total.Requests += u.Requests
+		total.InputTokens += u.InputTokens
+		total.OutputTokens += u.OutputTokens
+		total.Estimated = total.Estimated || u.Estimated
+	}
+	return total
+}
+
+// Snapshot returns usage per provider and model, sorted by provider.
+func (m *Meter) Snapshot() []Usage {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := make([]Usage, 0, len(m.usage))
+	for _, u := range m.usage {
+		list = append(list, *u)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Provider != list[j].Provider {
+			return list[i].Provider < list[j].Provider
+		}
+		return list[i].Model < list[j].Model
+	})
+	return list
+}
+
+// EstimateTokens approximates the token count of text without a tokenizer:
+// about 4 characters per token for ASCII, 2 for other alphabets (Cyrillic,
+// Greek, Arabic) and 1 per CJK character.
+func EstimateTokens(text string) int {
+	var ascii, other, cjk int
+	for _, r := range text {
+		switch {
+		case r < 0x80:
+			ascii++
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			cjk++
+		default:
+			other++
+		}
+	}
+	return (ascii+3)/4 + (other+1)/2 + cjk
+}
@@ -0,0 +1,191 @@
+package postprocess
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/foxzi/llm-translate/internal/config"
+)
+
+// Pipeline applies the configured postprocess rules to translated text.
+// A nil Pipeline leaves text unchanged.
+type Pipeline struct {
+	rules []rule
+}
+
+type rule struct {
+	langs     map[string]bool
+	apply     func(string) string
+	skipsCode bool // markdown code is left as is
+}
+
+// quoteStyles maps a quotes style to its opening and closing quote.
+var quoteStyles = map[string][2]string{
+	"curly":      {"“", "”"},
+	"guillemets": {"«", "»"},
+	"german":     {"„", "“"},
+}
+
+// quotedRe matches a pair of straight double quotes on one line. The
+// opening quote must start a word, so HTML attributes (href="...") and
+// inch marks stay as they are.
+var quotedRe = regexp.MustCompile(`(^|[\s(\[{—–-])"([^"\s][^"\n]*?)"`)
+
+// codeRe matches fenced code blocks and inline code spans.
+var codeRe = regexp.MustCompile("(?s)```.*?```|`[^`\n]+`")
+
+// New compiles the rules. Returns nil without rules.
+func New(cfg []config.PostprocessRule) (*Pipeline, error) {
+	if len(cfg) == 0 {
+		return nil, nil
+	}
+
+	p := &Pipeline{}
+	for i, rc := range cfg {
+		r, err := compile(rc)
+		if err != nil {
+			return nil, fmt.Errorf("postprocess rule %d: %w", i+1, err)
+		}
+		p.rules = append(p.rules, r)
+	}
+	return p, nil
+}
+
+func compile(rc config.PostprocessRule) (rule, error) {
+	r := rule{}
+	if len(rc.Langs) > 0 {
+		r.langs = make(map[string]bool)
+		for _, lang := range rc.Langs {
+			r.langs[strings.ToLower(lang)] = true
+		}
+	}
+
+	set := 0
+	if rc.Pattern != "" {
+		set++
+		re, err := regexp.Compile(rc.Pattern)
+		if err != nil {
+			return r, fmt.Errorf("invalid pattern: %w", err)
+		}
+		r.apply = func(text string) string {
+			return re.ReplaceAllString(text, rc.Replace)
+		}
+	}
+	if rc.Quotes != "" {
+		set++
+		quotes, ok := quoteStyles[rc.Quotes]
+		if !ok {
+			return r, fmt.Errorf("unknown quotes style %q: use curly, guillemets or german", rc.Quotes)
+		}
+		r.apply = func(text string) string {
+			return quotedRe.ReplaceAllString(text, "${1}"+quotes[0]+"${2}"+quotes[1])
+		}
+		r.skipsCode = true
+	}
+	if len(rc.Words) > 0 {
+		set++
+		if _, ok := rc.Words[""]; ok {
+			return r, fmt.Errorf("empty word")
+		}
+		r.apply = wordReplacer(rc.Words)
+		r.skipsCode = true
+	}
+
+	if set != 1 {
+		return r, fmt.Errorf("set exactly one of pattern, quotes or words")
+	}
+	return r, nil
+}
+
+// Apply runs the rules for targetLang in order.
+func (p *Pipeline) Apply(text, targetLang string) string {
+	if p == nil {
+		return text
+	}
+
+	targetLang = strings.ToLower(targetLang)
+	for _, r := range p.rules {
+		if r.langs != nil && !r.langs[targetLang] {
+			continue
+		}
+		if r.skipsCode {
+			text = outsideCode(text, r.apply)
+		} else {
+			text = r.apply(text)
+		}
+	}
+	return text
+}
+
+// outsideCode applies fn to the text between markdown code blocks and spans.
+func outsideCode(text string, fn func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range codeRe.FindAllStringIndex(text, -1) {
+		b.WriteString(fn(text[last:loc[0]]))
+		b.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(fn(text[last:]))
+	return b.String()
+}
+
+// wordReplacer replaces whole words, ignoring case. A capitalized or
+// upper case word gets a substitute in the same case. Longer words are matched first so a
+// phrase wins over a word it contains.
+func wordReplacer(words map[string]string) func(string) string {
+	banned := make([]string, 0, len(words))
+	subs := make(map[string]string)
+	for word, sub := range words {
+		banned = append(banned, regexp.QuoteMeta(word))
+		subs[strings.ToLower(word)] = sub
+	}
+	sort.Slice(banned, func(i, j int) bool { return len(banned[i]) > len(banned[j]) })
+	re := regexp.MustCompile(`(?i)` + strings.Join(banned, "|"))
+
+	return func(text string) string {
+		var b strings.Builder
+		last := 0
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			if !isWordBoundary(text, loc[0], loc[1]) {
+				continue
+			}
+			match := text[loc[0]:loc[1]]
+			b.WriteString(text[last:loc[0]])
+			b.WriteString(matchCase(subs[strings.ToLower(match)], match))
+			last = loc[1]
+		}
+		b.WriteString(text[last:])
+		return b.String()
+	}
+}
+
+func isWordBoundary(text string, start, end int) bool {
+	if before, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(before) {
+		return false
+	}
+	if after, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isWordRune(after) {
+		return false
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func matchCase(sub, match string) string {
+	first, _ := utf8.DecodeRuneInString(match)
+	if !unicode.IsUpper(first) || sub == "" {
+		return sub
+	}
+	if utf8.RuneCountInString(match) > 1 && strings.ToUpper(match) == match {
+		return strings.ToUpper(sub)
+	}
+	r, size := utf8.DecodeRuneInString(sub)
+	return string(unicode.ToUpper(r)) + sub[size:]
+}
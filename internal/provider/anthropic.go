@@ -1,6 +1,7 @@
 package provider
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -22,6 +23,22 @@ type anthropicRequest struct {
 	MaxTokens   int                `json:"max_tokens"`
 	Temperature float64            `json:"temperature,omitempty"`
 	System      string             `json:"system,omitempty"`
+	Stream      bool               `json:"stream,omitempty"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	ToolChoice  *anthropicChoice   `json:"tool_choice,omitempty"`
+}
+
+// anthropicTool is used for structured output: the model is forced to call
+// the tool, and its input is the JSON answer.
+type anthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+type anthropicChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
 }
 
 type anthropicMessage struct {
@@ -42,8 +59,9 @@ type anthropicResponse struct {
 }
 
 type anthropicContent struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type  string          `json:"type"`
+	Text  string          `json:"text"`
+	Input json.RawMessage `json:"input,omitempty"` // tool_use arguments
 }
 
 type anthropicUsage struct {
@@ -74,21 +92,7 @@ func NewAnthropicProvider(cfg config.ProviderConfig, client *http.Client) Provid
 }
 
 func (p *AnthropicProvider) Translate(ctx context.Context, req TranslateRequest) (TranslateResponse, error) {
-	systemPrompt := fmt.Sprintf(
-		"You are a professional translator. Translate the following text from %s to %s. "+
-			"Preserve the original formatting and structure. "+
-			"Output only the translation without explanations.",
-		req.SourceLang, req.TargetLang,
-	)
-
-	if req.SourceLang == "auto" {
-		systemPrompt = fmt.Sprintf(
-			"You are a professional translator. Detect the source language and translate the text to %s. "+
-				"Preserve the original formatting and structure. "+
-				"Output only the translation without explanations.",
-			req.TargetLang,
-		)
-	}
+	systemPrompt := p.systemPrompt(req)
 
 	fullPrompt := p.buildPrompt(req, systemPrompt)
 
@@ -97,6 +101,7 @@ func (p *AnthropicProvider) Translate(ctx context.Context, req TranslateRequest)
 		System:      fullPrompt,
 		MaxTokens:   req.MaxTokens,
 		Temperature: req.Temperature,
+		Stream:      p.config.Stream,
 		Messages: []anthropicMessage{
 			{
 				Role:    "user",
@@ -105,6 +110,12 @@ func (p *AnthropicProvider) Translate(ctx context.Context, req TranslateRequest)
 		},
 	}
 
+	// The model continues a prefilled assistant turn
+	prefill, trailing := splitPrefill(req.Partial)
+	if prefill != "" {
+		anthropicReq.Messages = append(anthropicReq.Messages, anthropicMessage{Role: "assistant", Content: prefill})
+	}
+
 	jsonData, err := json.Marshal(anthropicReq)
 	if err != nil {
 		return TranslateResponse{}, fmt.Errorf("failed to marshal request: %w", err)
@@ -126,16 +137,24 @@ func (p *AnthropicProvider) Translate(ctx context.Context, req TranslateRequest)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return TranslateResponse{}, fmt.Errorf("failed to read response: %w", err)
-	}
-
 	var anthropicResp anthropicResponse
-	if err := json.Unmarshal(body, &anthropicResp); err != nil {
-		return TranslateResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
+	if anthropicReq.Stream && resp.StatusCode == http.StatusOK {
+		anthropicResp, err = readAnthropicStream(resp.Body)
+		if err != nil {
+			return TranslateResponse{}, fmt.Errorf("failed to read stream: %w", err)
+		}
+	} else {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return TranslateResponse{}, fmt.Errorf("failed to read response: %w", err)
+		}
+		if err := json.Unmarshal(body, &anthropicResp); err != nil {
+			return TranslateResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
+		}
 	}
 
+	p.record(anthropicResp.Usage.InputTokens, anthropicResp.Usage.OutputTokens)
+
 	if anthropicResp.Error != nil {
 		return TranslateResponse{}, fmt.Errorf("Anthropic API error: %s", anthropicResp.Error.Message)
 	}
@@ -155,12 +174,81 @@ func (p *AnthropicProvider) Translate(ctx context.Context, req TranslateRequest)
 		}
 	}
 
+	// Text continues Partial, which still has the whitespace cut from the prefill
+	translatedText = strings.TrimPrefix(translatedText, trailing)
+
 	return TranslateResponse{
 		Text:       translatedText,
 		TokensUsed: anthropicResp.Usage.InputTokens + anthropicResp.Usage.OutputTokens,
+		Truncated:  anthropicResp.StopReason == "max_tokens",
+		Meta: ResponseMeta{
+			ID:           anthropicResp.ID,
+			Model:        anthropicResp.Model,
+			FinishReason: anthropicResp.StopReason,
+		},
 	}, nil
 }
 
+// anthropicEvent is a server-sent event of a streamed message.
+type anthropicEvent struct {
+	Type    string             `json:"type"`
+	Message *anthropicResponse `json:"message"` // message_start
+	Delta   struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"` // content_block_delta, message_delta
+	Usage *anthropicUsage `json:"usage"` // message_delta
+	Error *anthropicError `json:"error"`
+}
+
+// readAnthropicStream assembles a streamed message into the response a
+// non-streaming request returns.
+func readAnthropicStream(body io.Reader) (anthropicResponse, error) {
+	var resp anthropicResponse
+	var text strings.Builder
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+
+		var event anthropicEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return resp, fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+
+		switch event.Type {
+		case "message_start":
+			if event.Message != nil {
+				resp.ID = event.Message.ID
+				resp.Model = event.Message.Model
+				resp.Usage = event.Message.Usage
+			}
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" {
+				text.WriteString(event.Delta.Text)
+			}
+		case "message_delta":
+			resp.StopReason = event.Delta.StopReason
+			if event.Usage != nil {
+				resp.Usage.OutputTokens = event.Usage.OutputTokens
+			}
+		case "error":
+			resp.Error = event.Error
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return resp, err
+	}
+
+	resp.Content = []anthropicContent{{Type: "text", Text: text.String()}}
+	return resp, nil
+}
+
 func (p *AnthropicProvider) AnalyzeSentiment(ctx context.Context, text string) (SentimentResponse, error) {
 	anthropicReq := anthropicRequest{
 		Model:       p.config.Model,
@@ -206,6 +294,8 @@ func (p *AnthropicProvider) AnalyzeSentiment(ctx context.Context, text string) (
 		return SentimentResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(anthropicResp.Usage.InputTokens, anthropicResp.Usage.OutputTokens)
+
 	if anthropicResp.Error != nil {
 		return SentimentResponse{}, fmt.Errorf("Anthropic API error: %s", anthropicResp.Error.Message)
 	}
@@ -271,6 +361,8 @@ func (p *AnthropicProvider) ExtractTags(ctx context.Context, text string, count
 		return TagsResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(anthropicResp.Usage.InputTokens, anthropicResp.Usage.OutputTokens)
+
 	if anthropicResp.Error != nil {
 		return TagsResponse{}, fmt.Errorf("Anthropic API error: %s", anthropicResp.Error.Message)
 	}
@@ -334,6 +426,8 @@ func (p *AnthropicProvider) Classify(ctx context.Context, text string) (Classify
 		return ClassifyResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(anthropicResp.Usage.InputTokens, anthropicResp.Usage.OutputTokens)
+
 	if anthropicResp.Error != nil {
 		return ClassifyResponse{}, fmt.Errorf("Anthropic API error: %s", anthropicResp.Error.Message)
 	}
@@ -397,6 +491,8 @@ func (p *AnthropicProvider) AnalyzeEmotions(ctx context.Context, text string) (E
 		return EmotionsResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(anthropicResp.Usage.InputTokens, anthropicResp.Usage.OutputTokens)
+
 	if anthropicResp.Error != nil {
 		return EmotionsResponse{}, fmt.Errorf("Anthropic API error: %s", anthropicResp.Error.Message)
 	}
@@ -460,6 +556,8 @@ func (p *AnthropicProvider) AnalyzeFactuality(ctx context.Context, text string)
 		return FactualityResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(anthropicResp.Usage.InputTokens, anthropicResp.Usage.OutputTokens)
+
 	if anthropicResp.Error != nil {
 		return FactualityResponse{}, fmt.Errorf("Anthropic API error: %s", anthropicResp.Error.Message)
 	}
@@ -523,6 +621,8 @@ func (p *AnthropicProvider) AnalyzeImpact(ctx context.Context, text string) (Imp
 		return ImpactResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(anthropicResp.Usage.InputTokens, anthropicResp.Usage.OutputTokens)
+
 	if anthropicResp.Error != nil {
 		return ImpactResponse{}, fmt.Errorf("Anthropic API error: %s", anthropicResp.Error.Message)
 	}
@@ -586,6 +686,8 @@ func (p *AnthropicProvider) AnalyzeSensationalism(ctx context.Context, text stri
 		return SensationalismResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(anthropicResp.Usage.InputTokens, anthropicResp.Usage.OutputTokens)
+
 	if anthropicResp.Error != nil {
 		return SensationalismResponse{}, fmt.Errorf("Anthropic API error: %s", anthropicResp.Error.Message)
 	}
@@ -649,6 +751,8 @@ func (p *AnthropicProvider) ExtractEntities(ctx context.Context, text string) (E
 		return EntitiesResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(anthropicResp.Usage.InputTokens, anthropicResp.Usage.OutputTokens)
+
 	if anthropicResp.Error != nil {
 		return EntitiesResponse{}, fmt.Errorf("Anthropic API error: %s", anthropicResp.Error.Message)
 	}
@@ -712,6 +816,8 @@ func (p *AnthropicProvider) ExtractEvents(ctx context.Context, text string) (Eve
 		return EventsResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(anthropicResp.Usage.InputTokens, anthropicResp.Usage.OutputTokens)
+
 	if anthropicResp.Error != nil {
 		return EventsResponse{}, fmt.Errorf("Anthropic API error: %s", anthropicResp.Error.Message)
 	}
@@ -775,6 +881,8 @@ func (p *AnthropicProvider) AnalyzeUsefulness(ctx context.Context, text string)
 		return UsefulnessResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(anthropicResp.Usage.InputTokens, anthropicResp.Usage.OutputTokens)
+
 	if anthropicResp.Error != nil {
 		return UsefulnessResponse{}, fmt.Errorf("Anthropic API error: %s", anthropicResp.Error.Message)
 	}
@@ -838,6 +946,8 @@ func (p *AnthropicProvider) AnalyzeAdDetect(ctx context.Context, text string) (A
 		return AdDetectResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(anthropicResp.Usage.InputTokens, anthropicResp.Usage.OutputTokens)
+
 	if anthropicResp.Error != nil {
 		return AdDetectResponse{}, fmt.Errorf("Anthropic API error: %s", anthropicResp.Error.Message)
 	}
@@ -901,6 +1011,8 @@ func (p *AnthropicProvider) AnalyzeTimeFocus(ctx context.Context, text string) (
 		return TimeFocusResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(anthropicResp.Usage.InputTokens, anthropicResp.Usage.OutputTokens)
+
 	if anthropicResp.Error != nil {
 		return TimeFocusResponse{}, fmt.Errorf("Anthropic API error: %s", anthropicResp.Error.Message)
 	}
@@ -935,6 +1047,15 @@ func (p *AnthropicProvider) AnalyzeCombined(ctx context.Context, req CombinedAna
 		},
 	}
 
+	if req.Schema {
+		anthropicReq.Tools = []anthropicTool{{
+			Name:        "record_analysis",
+			Description: "Record the analysis of the text.",
+			InputSchema: combinedJSONSchema(req),
+		}}
+		anthropicReq.ToolChoice = &anthropicChoice{Type: "tool", Name: "record_analysis"}
+	}
+
 	jsonData, err := json.Marshal(anthropicReq)
 	if err != nil {
 		return CombinedAnalysisResponse{}, fmt.Errorf("failed to marshal request: %w", err)
@@ -966,6 +1087,8 @@ func (p *AnthropicProvider) AnalyzeCombined(ctx context.Context, req CombinedAna
 		return CombinedAnalysisResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(anthropicResp.Usage.InputTokens, anthropicResp.Usage.OutputTokens)
+
 	if anthropicResp.Error != nil {
 		return CombinedAnalysisResponse{}, fmt.Errorf("Anthropic API error: %s", anthropicResp.Error.Message)
 	}
@@ -976,14 +1099,82 @@ func (p *AnthropicProvider) AnalyzeCombined(ctx context.Context, req CombinedAna
 
 	var responseText string
 	for _, content := range anthropicResp.Content {
-		if content.Type == "text" {
+		switch content.Type {
+		case "text":
 			responseText += content.Text
+		case "tool_use":
+			responseText += string(content.Input)
 		}
 	}
 
 	return ParseCombinedResponse(responseText, req), nil
 }
 
+func (p *AnthropicProvider) GenerateHeadline(ctx context.Context, text string) (HeadlineResponse, error) {
+	anthropicReq := anthropicRequest{
+		Model:       p.config.Model,
+		System:      HeadlinePrompt,
+		MaxTokens:   200,
+		Temperature: 0.3,
+		Messages: []anthropicMessage{
+			{
+				Role:    "user",
+				Content: text,
+			},
+		},
+	}
+
+	jsonData, err := json.Marshal(anthropicReq)
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/v1/messages"
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", p.config.APIKey)
+	httpReq.Header.Set("anthropic-version", "2023-06-01")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var anthropicResp anthropicResponse
+	if err := json.Unmarshal(body, &anthropicResp); err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	p.record(anthropicResp.Usage.InputTokens, anthropicResp.Usage.OutputTokens)
+
+	if anthropicResp.Error != nil {
+		return HeadlineResponse{}, fmt.Errorf("Anthropic API error: %s", anthropicResp.Error.Message)
+	}
+
+	if len(anthropicResp.Content) == 0 {
+		return HeadlineResponse{}, fmt.Errorf("no content in response")
+	}
+
+	var responseText string
+	for _, content := range anthropicResp.Content {
+		if content.Type == "text" {
+			responseText += content.Text
+		}
+	}
+
+	return ParseHeadlineResponse(responseText)
+}
+
 func init() {
 	Register("anthropic", NewAnthropicProvider)
 }
@@ -0,0 +1,184 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// replayAPIKey stands in for the API key of providers replayed without one.
+const replayAPIKey = "replay"
+
+// Cassette records the HTTP traffic of providers to a file, or replays the
+// recorded responses instead of sending requests. Requests are matched by
+// method, URL and body; API keys in headers are never stored, and the key
+// query parameter is removed from URLs.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+
+	path   string
+	replay bool
+	mu     sync.Mutex
+	used   map[int]bool
+}
+
+// Interaction is a request and the response it got.
+type Interaction struct {
+	Method   string `json:"method"`
+	URL      string `json:"url"`
+	Request  string `json:"request,omitempty"`
+	Status   int    `json:"status"`
+	Response string `json:"response"`
+}
+
+// cassette is used by all providers created by Get once set.
+var cassette *Cassette
+
+// RecordCassette makes providers record their traffic to path, which is
+// overwritten.
+func RecordCassette(path string) {
+	cassette = &Cassette{path: path}
+}
+
+// ReplayCassette makes providers answer from the traffic recorded in path.
+func ReplayCassette(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read cassette: %w", err)
+	}
+	c := &Cassette{path: path, replay: true, used: make(map[int]bool)}
+	if err := json.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("invalid cassette %s: %w", path, err)
+	}
+	cassette = c
+	return nil
+}
+
+type cassetteTransport struct {
+	base     http.RoundTripper
+	cassette *Cassette
+}
+
+func (t cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+	url := cassetteURL(req)
+
+	if t.cassette.replay {
+		in, ok := t.cassette.find(req.Method, url, string(body))
+		if !ok {
+			return nil, fmt.Errorf("no recorded response in cassette %s for %s %s", t.cassette.path, req.Method, url)
+		}
+		return &http.Response{
+			Status:     strconv.Itoa(in.Status) + " " + http.StatusText(in.Status),
+			StatusCode: in.Status,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(in.Response)),
+			Request:    req,
+		}, nil
+	}
+
+	req.Body = io.NopCloser(strings.NewReader(string(body)))
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(strings.NewReader(string(respBody)))
+
+	err = t.cassette.add(Interaction{
+		Method:   req.Method,
+		URL:      url,
+		Request:  string(body),
+		Status:   resp.StatusCode,
+		Response: string(respBody),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// cassetteURL returns the URL of req without the key query parameter.
+func cassetteURL(req *http.Request) string {
+	u := *req.URL
+	query := u.Query()
+	if query.Has("key") {
+		query.Del("key")
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// find returns the first unused recording of a request. When all were used,
+// the last one is returned again, so repeated requests keep working.
+func (c *Cassette) find(method, url, body string) (Interaction, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	last := -1
+	for i, in := range c.Interactions {
+		if in.Method != method || in.URL != url || in.Request != body {
+			continue
+		}
+		if !c.used[i] {
+			c.used[i] = true
+			return in, true
+		}
+		last = i
+	}
+	if last < 0 {
+		return Interaction{}, false
+	}
+	return c.Interactions[last], true
+}
+
+// add records an interaction and saves the cassette, so the recording
+// survives an interrupted run.
+func (c *Cassette) add(in Interaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Interactions = append(c.Interactions, in)
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// wrap returns a copy of client going through the cassette.
+func (c *Cassette) wrap(client *http.Client) *http.Client {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped := *client
+	wrapped.Transport = cassetteTransport{base: base, cassette: c}
+	return &wrapped
+}
@@ -0,0 +1,159 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ModelInfo is a model offered by a provider. Zero values are unknown.
+type ModelInfo struct {
+	ID            string
+	ContextWindow int
+	InputPrice    float64 // per million input tokens
+	OutputPrice   float64 // per million output tokens
+}
+
+// modelLister is implemented by providers with a model listing endpoint:
+// OpenAI and compatible servers, OpenRouter and Ollama.
+type modelLister interface {
+	listModels(ctx context.Context) ([]ModelInfo, error)
+}
+
+// ListModels returns the models of provider p sorted by name. Context
+// windows the endpoint does not report are taken from the built-in table.
+func ListModels(ctx context.Context, p Provider) ([]ModelInfo, error) {
+	lister, ok := p.(modelLister)
+	if !ok {
+		return nil, fmt.Errorf("provider %s cannot list models", p.Name())
+	}
+	models, err := lister.listModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range models {
+		if models[i].ContextWindow == 0 {
+			models[i].ContextWindow = LookupModelLimits(models[i].ID).ContextWindow
+		}
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models, nil
+}
+
+// getJSON sends a GET request to url and decodes the answer into out.
+func (b *BaseProvider) getJSON(ctx context.Context, url string, out interface{}, header map[string]string) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, value := range header {
+		httpReq.Header.Set(key, value)
+	}
+
+	resp, err := b.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+type openAIModelList struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+}
+
+func (p *OpenAIProvider) listModels(ctx context.Context) ([]ModelInfo, error) {
+	header := map[string]string{}
+	if p.config.APIKey != "" {
+		header["Authorization"] = "Bearer " + p.config.APIKey
+	}
+
+	var list openAIModelList
+	if err := p.getJSON(ctx, strings.TrimRight(p.config.BaseURL, "/")+"/models", &list, header); err != nil {
+		return nil, err
+	}
+	models := make([]ModelInfo, 0, len(list.Data))
+	for _, m := range list.Data {
+		models = append(models, ModelInfo{ID: m.ID})
+	}
+	return models, nil
+}
+
+// openRouterModelList carries prices as strings in currency units per
+// token.
+type openRouterModelList struct {
+	Data []struct {
+		ID            string `json:"id"`
+		ContextLength int    `json:"context_length"`
+		Pricing       struct {
+			Prompt     string `json:"prompt"`
+			Completion string `json:"completion"`
+		} `json:"pricing"`
+	} `json:"data"`
+}
+
+func (p *OpenRouterProvider) listModels(ctx context.Context) ([]ModelInfo, error) {
+	var list openRouterModelList
+	err := p.getJSON(ctx, strings.TrimRight(p.config.BaseURL, "/")+"/models", &list,
+		map[string]string{"Authorization": "Bearer " + p.config.APIKey})
+	if err != nil {
+		return nil, err
+	}
+	models := make([]ModelInfo, 0, len(list.Data))
+	for _, m := range list.Data {
+		models = append(models, ModelInfo{
+			ID:            m.ID,
+			ContextWindow: m.ContextLength,
+			InputPrice:    perMillion(m.Pricing.Prompt),
+			OutputPrice:   perMillion(m.Pricing.Completion),
+		})
+	}
+	return models, nil
+}
+
+// perMillion converts a price per token to a price per million tokens, the
+// unit of input_price and output_price.
+func perMillion(perToken string) float64 {
+	price, err := strconv.ParseFloat(perToken, 64)
+	if err != nil {
+		return 0
+	}
+	return price * 1e6
+}
+
+type ollamaModelList struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+// listModels returns the models pulled to the Ollama server.
+func (p *OllamaProvider) listModels(ctx context.Context) ([]ModelInfo, error) {
+	var list ollamaModelList
+	if err := p.getJSON(ctx, strings.TrimRight(p.config.BaseURL, "/")+"/api/tags", &list, nil); err != nil {
+		return nil, err
+	}
+	models := make([]ModelInfo, 0, len(list.Models))
+	for _, m := range list.Models {
+		models = append(models, ModelInfo{ID: m.Name})
+	}
+	return models, nil
+}
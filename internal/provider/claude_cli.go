@@ -64,7 +64,9 @@ func (p *ClaudeCLIProvider) runCLI(ctx context.Context, prompt string, input str
 		return "", fmt.Errorf("claude CLI error: %w, stderr: %s", err, stderr.String())
 	}
 
-	return strings.TrimSpace(stdout.String()), nil
+	result := strings.TrimSpace(stdout.String())
+	p.recordEstimate(prompt+input, result)
+	return result, nil
 }
 
 func (p *ClaudeCLIProvider) runCLIJSON(ctx context.Context, prompt string, input string) (string, error) {
@@ -87,28 +89,17 @@ func (p *ClaudeCLIProvider) runCLIJSON(ctx context.Context, prompt string, input
 
 	var resp claudeCLIResponse
 	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
-		return strings.TrimSpace(stdout.String()), nil
+		result := strings.TrimSpace(stdout.String())
+		p.recordEstimate(prompt+input, result)
+		return result, nil
 	}
 
+	p.recordEstimate(prompt+input, resp.Result)
 	return resp.Result, nil
 }
 
 func (p *ClaudeCLIProvider) Translate(ctx context.Context, req TranslateRequest) (TranslateResponse, error) {
-	prompt := fmt.Sprintf(
-		"You are a professional translator. Translate the following text from %s to %s. "+
-			"Preserve the original formatting and structure. "+
-			"Output only the translation without explanations.",
-		req.SourceLang, req.TargetLang,
-	)
-
-	if req.SourceLang == "auto" {
-		prompt = fmt.Sprintf(
-			"You are a professional translator. Detect the source language and translate the text to %s. "+
-				"Preserve the original formatting and structure. "+
-				"Output only the translation without explanations.",
-			req.TargetLang,
-		)
-	}
+	prompt := p.systemPrompt(req)
 
 	fullPrompt := p.buildPrompt(req, prompt)
 
@@ -245,6 +236,15 @@ func (p *ClaudeCLIProvider) AnalyzeCombined(ctx context.Context, req CombinedAna
 	return ParseCombinedResponse(result, req), nil
 }
 
+func (p *ClaudeCLIProvider) GenerateHeadline(ctx context.Context, text string) (HeadlineResponse, error) {
+	result, err := p.runCLI(ctx, HeadlinePrompt, text)
+	if err != nil {
+		return HeadlineResponse{}, err
+	}
+
+	return ParseHeadlineResponse(result)
+}
+
 func init() {
 	Register("claude-cli", NewClaudeCLIProvider)
 }
@@ -74,7 +74,9 @@ func (p *CodexCLIProvider) runCLI(ctx context.Context, prompt string) (string, e
 		return "", fmt.Errorf("codex CLI error: %w, stderr: %s", err, stderr.String())
 	}
 
-	return strings.TrimSpace(stdout.String()), nil
+	result := strings.TrimSpace(stdout.String())
+	p.recordEstimate(prompt, result)
+	return result, nil
 }
 
 func (p *CodexCLIProvider) runCLIJSON(ctx context.Context, prompt string) (string, int, error) {
@@ -114,43 +116,25 @@ func (p *CodexCLIProvider) runCLIJSON(ctx context.Context, prompt string) (strin
 
 		if event.Type == "turn.completed" && event.Usage != nil {
 			tokensUsed = event.Usage.InputTokens + event.Usage.OutputTokens
+			p.record(event.Usage.InputTokens, event.Usage.OutputTokens)
 		}
 	}
 
 	if lastMessage == "" {
-		return strings.TrimSpace(stdout.String()), tokensUsed, nil
+		lastMessage = strings.TrimSpace(stdout.String())
+	}
+	if tokensUsed == 0 {
+		p.recordEstimate(prompt, lastMessage)
 	}
 
 	return lastMessage, tokensUsed, nil
 }
 
 func (p *CodexCLIProvider) Translate(ctx context.Context, req TranslateRequest) (TranslateResponse, error) {
-	prompt := fmt.Sprintf(
-		"You are a professional translator. Translate the following text from %s to %s. "+
-			"Preserve the original formatting and structure. "+
-			"Output only the translation without explanations.\n\nText to translate:\n%s",
-		req.SourceLang, req.TargetLang, req.Text,
-	)
-
-	if req.SourceLang == "auto" {
-		prompt = fmt.Sprintf(
-			"You are a professional translator. Detect the source language and translate the text to %s. "+
-				"Preserve the original formatting and structure. "+
-				"Output only the translation without explanations.\n\nText to translate:\n%s",
-			req.TargetLang, req.Text,
-		)
-	}
+	prompt := p.systemPrompt(req) + "\n\nText to translate:\n" + req.Text
 
-	if req.Style != "" {
-		stylePrompts := map[string]string{
-			"formal":    "Use formal language appropriate for official documents.",
-			"informal":  "Use casual, conversational language.",
-			"technical": "Preserve technical terminology accurately.",
-			"literary":  "Maintain literary style and artistic expression.",
-		}
-		if stylePrompt, ok := stylePrompts[req.Style]; ok {
-			prompt = stylePrompt + " " + prompt
-		}
+	if req.Style != "" && req.SystemPrompt == "" {
+		prompt = req.Style + " " + prompt
 	}
 
 	result, tokensUsed, err := p.runCLIJSON(ctx, prompt)
@@ -349,6 +333,20 @@ func (p *CodexCLIProvider) AnalyzeCombined(ctx context.Context, req CombinedAnal
 	return ParseCombinedResponse(result, req), nil
 }
 
+func (p *CodexCLIProvider) GenerateHeadline(ctx context.Context, text string) (HeadlineResponse, error) {
+	prompt := HeadlinePrompt + "\n\n" + text
+
+	result, _, err := p.runCLIJSON(ctx, prompt)
+	if err != nil {
+		result, err = p.runCLI(ctx, prompt)
+		if err != nil {
+			return HeadlineResponse{}, err
+		}
+	}
+
+	return ParseHeadlineResponse(result)
+}
+
 func init() {
 	Register("codex-cli", NewCodexCLIProvider)
 }
@@ -0,0 +1,318 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// combinedJSON is the response schema of the JSON combined analysis. Every
+// section is a pointer so a missing section can be told from an empty one.
+type combinedJSON struct {
+	Sentiment *struct {
+		Label string  `json:"label"`
+		Score float64 `json:"score"`
+	} `json:"sentiment"`
+	Tags     []string `json:"tags"`
+	Classify *struct {
+		Topics []string `json:"topics"`
+		Scope  []string `json:"scope"`
+		Type   []string `json:"type"`
+	} `json:"classify"`
+	Emotions   map[string]float64 `json:"emotions"`
+	Factuality *struct {
+		Type       string   `json:"type"`
+		Confidence float64  `json:"confidence"`
+		Evidence   []string `json:"evidence"`
+	} `json:"factuality"`
+	Impact *struct {
+		Affected []string `json:"affected"`
+	} `json:"impact"`
+	Sensationalism *struct {
+		Type       string   `json:"type"`
+		Confidence float64  `json:"confidence"`
+		Markers    []string `json:"markers"`
+	} `json:"sensationalism"`
+	Usefulness *struct {
+		Useful     *bool    `json:"useful"`
+		Confidence float64  `json:"confidence"`
+		Reasons    []string `json:"reasons"`
+	} `json:"usefulness"`
+	Entities *struct {
+		Persons       []string `json:"persons"`
+		Organizations []string `json:"organizations"`
+		Locations     []string `json:"locations"`
+		Dates         []string `json:"dates"`
+		Amounts       []string `json:"amounts"`
+	} `json:"entities"`
+	Events    []string `json:"events"`
+	TimeFocus *struct {
+		Focus        string   `json:"focus"`
+		Confidence   float64  `json:"confidence"`
+		IsPrediction bool     `json:"is_prediction"`
+		Indicators   []string `json:"indicators"`
+	} `json:"time_focus"`
+	AdDetect *struct {
+		Type       string   `json:"type"`
+		Confidence float64  `json:"confidence"`
+		Markers    []string `json:"markers"`
+	} `json:"ad_detect"`
+}
+
+// buildCombinedJSONPrompt asks for all requested sections as one JSON object.
+func buildCombinedJSONPrompt(req CombinedAnalysisRequest) string {
+	var fields, rules []string
+
+	if req.Sentiment {
+		fields = append(fields, `"sentiment": {"label": "positive|negative|neutral", "score": <-1.0 to 1.0>}`)
+		rules = append(rules, "sentiment: round score to 1 decimal place. Factual reporting = neutral (near 0.0).")
+	}
+	if req.TagsCount > 0 {
+		fields = append(fields, `"tags": [<strings>]`)
+		rules = append(rules, fmt.Sprintf("tags: the %d most important tags, same language as text, lowercase, nominative case, nouns/noun phrases, no duplicates.", req.TagsCount))
+	}
+	if req.Classify {
+		fields = append(fields, `"classify": {"topics": [politics|economics|technology|medicine|incidents], "scope": [regional|international], "type": [corporate|regulatory|macro]}`)
+	}
+	if req.Emotions {
+		fields = append(fields, `"emotions": {"fear": 0.0, "anger": 0.0, "hope": 0.0, "uncertainty": 0.0, "optimism": 0.0, "panic": 0.0}`)
+		rules = append(rules, "emotions: only emotions > 0.1, round to 1 decimal. Be conservative for factual news.")
+	}
+	if req.Factuality {
+		fields = append(fields, `"factuality": {"type": "confirmed|rumors|forecasts|unsourced", "confidence": <0.0-1.0>, "evidence": [official_source|statistics|quotes|documents|expert_opinion|anonymous_source|speculation|prediction]}`)
+	}
+	if req.Impact {
+		fields = append(fields, `"impact": {"affected": [individuals|business|government|investors|consumers]}`)
+	}
+	if req.Sensationalism {
+		fields = append(fields, `"sensationalism": {"type": "neutral|emotional|clickbait|manipulative", "confidence": <0.0-1.0>, "markers": [<strings>]}`)
+	}
+	if req.Usefulness {
+		fields = append(fields, `"usefulness": {"useful": true|false, "confidence": <0.0-1.0>, "reasons": [<strings>]}`)
+	}
+	if req.Entities {
+		fields = append(fields, `"entities": {"persons": [], "organizations": [], "locations": [], "dates": [], "amounts": []}`)
+		rules = append(rules, "entities: full person names and proper organization names only (no common nouns, countries or blockchains), geographic places only, bare dates without prepositions, amounts as complete numbers with currency; nominative case; empty list if none.")
+	}
+	if req.Events {
+		fields = append(fields, `"events": [<3-10 words each, max 5, same language as text>]`)
+	}
+	if req.TimeFocus {
+		fields = append(fields, `"time_focus": {"focus": "past|present|future|mixed", "confidence": <0.0-1.0>, "is_prediction": true|false, "indicators": [<strings>]}`)
+		rules = append(rules, "time_focus: is_prediction=true only for explicit predictions/forecasts/speculations about future outcomes.")
+	}
+	if req.AdDetect {
+		fields = append(fields, `"ad_detect": {"type": "none|direct|native|sponsored|pr", "confidence": <0.0-1.0>, "markers": [<strings>]}`)
+		rules = append(rules, "ad_detect: genuine news about companies is NOT advertising. Be strict.")
+	}
+
+	var b strings.Builder
+	b.WriteString("Analyze the following text and respond with a single JSON object with exactly these keys:\n{\n  ")
+	b.WriteString(strings.Join(fields, ",\n  "))
+	b.WriteString("\n}\n")
+	if len(rules) > 0 {
+		b.WriteString("\nRules:\n- ")
+		b.WriteString(strings.Join(rules, "\n- "))
+		b.WriteString("\n")
+	}
+	b.WriteString("\nOutput only the JSON object, no markdown, no explanations.\n\nText to analyze:")
+	return b.String()
+}
+
+// parseCombinedJSONResponse maps the JSON answer onto the regular response
+// types. Sections that are missing or invalid are listed in Failed.
+func parseCombinedJSONResponse(response string, req CombinedAnalysisRequest) CombinedAnalysisResponse {
+	var data combinedJSON
+	if err := json.Unmarshal([]byte(ExtractJSONObject(response)), &data); err != nil {
+		// Not JSON after all, the model may have used the line format
+		req.JSON, req.Schema = false, false
+		return ParseCombinedResponse(response, req)
+	}
+
+	result := CombinedAnalysisResponse{Raw: response}
+	fail := func(name string) {
+		result.Failed = append(result.Failed, name)
+	}
+
+	if req.Sentiment {
+		if s := data.Sentiment; s != nil && oneOf(s.Label, "positive", "negative", "neutral") {
+			score := s.Score
+			result.Sentiment = &SentimentResponse{
+				Sentiment:  strings.ToLower(strings.TrimSpace(s.Label)),
+				Score:      score,
+				Confidence: 1.0 - (1.0-abs(score))*0.5,
+			}
+		} else {
+			fail("sentiment")
+		}
+	}
+
+	if req.TagsCount > 0 {
+		if tags := cleanList(data.Tags, false); len(tags) > 0 {
+			result.Tags = &TagsResponse{Tags: tags}
+		} else {
+			fail("tags")
+		}
+	}
+
+	if req.Classify {
+		if c := data.Classify; c != nil && len(c.Topics)+len(c.Scope)+len(c.Type) > 0 {
+			result.Classify = &ClassifyResponse{
+				Topics:   cleanList(c.Topics, true),
+				Scope:    cleanList(c.Scope, true),
+				NewsType: cleanList(c.Type, true),
+			}
+		} else {
+			fail("classify")
+		}
+	}
+
+	if req.Emotions {
+		emotions := make(map[string]float64)
+		for name, score := range data.Emotions {
+			if score > 0 {
+				emotions[strings.ToLower(name)] = score
+			}
+		}
+		if len(emotions) > 0 {
+			result.Emotions = &EmotionsResponse{Emotions: emotions}
+		} else {
+			fail("emotions")
+		}
+	}
+
+	if req.Factuality {
+		if f := data.Factuality; f != nil && f.Type != "" {
+			result.Factuality = &FactualityResponse{
+				Type:       strings.ToLower(f.Type),
+				Confidence: f.Confidence,
+				Evidence:   cleanList(f.Evidence, true),
+			}
+		} else {
+			fail("factuality")
+		}
+	}
+
+	if req.Impact {
+		if data.Impact != nil {
+			result.Impact = &ImpactResponse{Affected: cleanList(data.Impact.Affected, true)}
+		} else {
+			fail("impact")
+		}
+	}
+
+	if req.Sensationalism {
+		if s := data.Sensationalism; s != nil && s.Type != "" {
+			result.Sensationalism = &SensationalismResponse{
+				Type:       strings.ToLower(s.Type),
+				Confidence: s.Confidence,
+				Markers:    cleanList(s.Markers, true),
+			}
+		} else {
+			fail("sensationalism")
+		}
+	}
+
+	if req.Usefulness {
+		if u := data.Usefulness; u != nil && u.Useful != nil {
+			result.Usefulness = &UsefulnessResponse{
+				IsUseful:   *u.Useful,
+				Confidence: u.Confidence,
+				Reasons:    cleanList(u.Reasons, true),
+			}
+		} else {
+			fail("usefulness")
+		}
+	}
+
+	if req.Entities {
+		if e := data.Entities; e != nil {
+			entities := EntitiesResponse{
+				Persons:       filterEntityGarbage(cleanList(e.Persons, false)),
+				Organizations: filterEntityGarbage(cleanList(e.Organizations, false)),
+				Locations:     filterEntityGarbage(cleanList(e.Locations, false)),
+				Dates:         filterEntityGarbage(cleanList(e.Dates, false)),
+				Amounts:       filterAmounts(filterEntityGarbage(cleanList(e.Amounts, false))),
+			}
+			if len(entities.Persons)+len(entities.Organizations)+len(entities.Locations)+len(entities.Dates)+len(entities.Amounts) > 0 {
+				result.Entities = &entities
+			} else {
+				fail("entities")
+			}
+		} else {
+			fail("entities")
+		}
+	}
+
+	if req.Events {
+		if events := cleanList(data.Events, false); len(events) > 0 {
+			result.Events = &EventsResponse{Events: events}
+		} else {
+			fail("events")
+		}
+	}
+
+	if req.TimeFocus {
+		if tf := data.TimeFocus; tf != nil && oneOf(tf.Focus, "past", "present", "future", "mixed") {
+			result.TimeFocus = &TimeFocusResponse{
+				Focus:        strings.ToLower(strings.TrimSpace(tf.Focus)),
+				Confidence:   tf.Confidence,
+				IsPrediction: tf.IsPrediction,
+				Indicators:   cleanList(tf.Indicators, true),
+			}
+		} else {
+			fail("time_focus")
+		}
+	}
+
+	if req.AdDetect {
+		if ad := data.AdDetect; ad != nil && oneOf(ad.Type, "none", "direct", "native", "sponsored", "pr") {
+			result.AdDetect = &AdDetectResponse{
+				AdType:     strings.ToLower(strings.TrimSpace(ad.Type)),
+				Confidence: ad.Confidence,
+				Markers:    cleanList(ad.Markers, true),
+			}
+		} else {
+			fail("ad_detect")
+		}
+	}
+
+	return result
+}
+
+// ExtractJSONObject strips markdown fences and text around the outermost
+// JSON object, which some models add even in JSON mode.
+func ExtractJSONObject(response string) string {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start == -1 || end < start {
+		return response
+	}
+	return response[start : end+1]
+}
+
+// cleanList trims values and drops empty and "none" entries.
+func cleanList(items []string, lower bool) []string {
+	var result []string
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if lower {
+			item = strings.ToLower(item)
+		}
+		if item != "" && !strings.EqualFold(item, "none") {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// oneOf reports whether value is one of the allowed labels, ignoring case.
+func oneOf(value string, allowed ...string) bool {
+	value = strings.TrimSpace(value)
+	for _, a := range allowed {
+		if strings.EqualFold(value, a) {
+			return true
+		}
+	}
+	return false
+}
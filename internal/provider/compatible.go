@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/foxzi/llm-translate/internal/config"
+)
+
+// compatPreset describes a local server speaking the OpenAI chat
+// completions API.
+type compatPreset struct {
+	baseURL string
+	// listsLoaded is set when GET /models returns the served model, so it
+	// can be used when no model is configured. text-generation-webui lists
+	// every model on disk instead and answers with the loaded one whatever
+	// the request names.
+	listsLoaded bool
+}
+
+var compatPresets = map[string]compatPreset{
+	"openai-compatible": {listsLoaded: true},
+	"vllm":              {baseURL: "http://localhost:8000/v1", listsLoaded: true},
+	"lmstudio":          {baseURL: "http://localhost:1234/v1", listsLoaded: true},
+	"llamacpp":          {baseURL: "http://localhost:8080/v1", listsLoaded: true},
+	"textgen":           {baseURL: "http://127.0.0.1:5000/v1"},
+}
+
+// leakedStopTokens are end of turn tokens that servers with a mismatched
+// chat template return as text instead of stopping on them.
+var leakedStopTokens = []string{"<|im_end|>", "<|eot_id|>", "<|end|>", "<end_of_turn>", "</s>"}
+
+// CompatibleProvider talks to a self-hosted OpenAI-compatible server. An
+// API key is optional: vLLM checks it only when started with --api-key,
+// the others ignore it.
+type CompatibleProvider struct {
+	OpenAIProvider
+	listsLoaded bool
+}
+
+func newCompatibleFactory(name string, preset compatPreset) func(config.ProviderConfig, *http.Client) Provider {
+	return func(cfg config.ProviderConfig, client *http.Client) Provider {
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = preset.baseURL
+		}
+		return &CompatibleProvider{
+			OpenAIProvider: OpenAIProvider{
+				BaseProvider: BaseProvider{
+					name:       name,
+					config:     cfg,
+					httpClient: client,
+				},
+			},
+			listsLoaded: preset.listsLoaded,
+		}
+	}
+}
+
+func (p *CompatibleProvider) Translate(ctx context.Context, req TranslateRequest) (TranslateResponse, error) {
+	resp, err := p.OpenAIProvider.Translate(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	for _, token := range leakedStopTokens {
+		if i := strings.Index(resp.Text, token); i >= 0 {
+			resp.Text = strings.TrimRight(resp.Text[:i], " \t\n")
+		}
+	}
+	return resp, nil
+}
+
+// ValidateConfig requires a base URL only. Without a model, the one the
+// server reports is used.
+func (p *CompatibleProvider) ValidateConfig() error {
+	if p.config.BaseURL == "" {
+		return fmt.Errorf("base URL is required for provider %s", p.name)
+	}
+	if p.config.Model != "" {
+		return nil
+	}
+	if !p.listsLoaded {
+		return fmt.Errorf("model is required for provider %s", p.name)
+	}
+
+	model, err := p.servedModel()
+	if err != nil {
+		return fmt.Errorf("model is not set and could not be read from %s: %w", p.name, err)
+	}
+	p.config.Model = model
+	return nil
+}
+
+// servedModel returns the first model listed by the server, in its order.
+func (p *CompatibleProvider) servedModel() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	models, err := p.listModels(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(models) == 0 || models[0].ID == "" {
+		return "", fmt.Errorf("no models loaded")
+	}
+	return models[0].ID, nil
+}
+
+func init() {
+	for name, preset := range compatPresets {
+		Register(name, newCompatibleFactory(name, preset))
+	}
+}
@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"strings"
+	"unicode"
+)
+
+// continuePrompt follows the partial translation in chat APIs without
+// assistant prefill.
+const continuePrompt = "Your translation was cut off. Continue it exactly where it stopped. Output only the rest, without repeating anything."
+
+// continuationProvider is implemented by providers that honor
+// TranslateRequest.Partial: Anthropic prefills the assistant turn with it,
+// OpenAI, OpenRouter and Ollama send it as an assistant message followed by
+// a request to continue.
+type continuationProvider interface {
+	continuation()
+}
+
+// SupportsContinuation reports whether a translation cut at the max tokens
+// limit can be continued with TranslateRequest.Partial.
+func SupportsContinuation(p Provider) bool {
+	_, ok := p.(continuationProvider)
+	return ok
+}
+
+func (p *OpenAIProvider) continuation()     {}
+func (p *OpenRouterProvider) continuation() {}
+func (p *AnthropicProvider) continuation()  {}
+func (p *OllamaProvider) continuation()     {}
+
+// continuationMessages appends the partial translation and the request to
+// continue it to a chat.
+func continuationMessages(messages []message, partial string) []message {
+	if partial == "" {
+		return messages
+	}
+	return append(messages,
+		message{Role: "assistant", Content: partial},
+		message{Role: "user", Content: continuePrompt},
+	)
+}
+
+// splitPrefill splits a partial translation into the prefill Anthropic
+// accepts, which must not end with whitespace, and that whitespace.
+func splitPrefill(partial string) (string, string) {
+	prefill := strings.TrimRightFunc(partial, unicode.IsSpace)
+	return prefill, partial[len(prefill):]
+}
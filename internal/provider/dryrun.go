@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ErrNotSent is returned by providers using a client from DryRunClient.
+var ErrNotSent = errors.New("request not sent (dry run)")
+
+// Payload is a request recorded by a dry run client.
+type Payload struct {
+	Method string
+	URL    string
+	Body   []byte
+}
+
+// PayloadLog collects the requests of a dry run client.
+type PayloadLog struct {
+	mu       sync.Mutex
+	payloads []Payload
+}
+
+// Take returns the recorded requests and empties the log.
+func (l *PayloadLog) Take() []Payload {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	payloads := l.payloads
+	l.payloads = nil
+	return payloads
+}
+
+type dryRunTransport struct {
+	log *PayloadLog
+}
+
+func (d dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+	}
+	d.log.mu.Lock()
+	d.log.payloads = append(d.log.payloads, Payload{Method: req.Method, URL: req.URL.String(), Body: body})
+	d.log.mu.Unlock()
+	return nil, ErrNotSent
+}
+
+// DryRunClient returns a client that records requests in log instead of
+// sending them, so the exact body a provider builds can be shown.
+func DryRunClient(log *PayloadLog) *http.Client {
+	return &http.Client{Transport: dryRunTransport{log: log}}
+}
+
+// commandProvider is implemented by providers that run a local CLI or a
+// function instead of sending HTTP requests.
+type commandProvider interface {
+	command()
+}
+
+// RunsCommand reports whether p runs a local command, which a dry run
+// client cannot intercept.
+func RunsCommand(p Provider) bool {
+	_, ok := p.(commandProvider)
+	return ok
+}
+
+func (p *ClaudeCLIProvider) command() {}
+func (p *CodexCLIProvider) command()  {}
+func (p *QwenCLIProvider) command()   {}
+func (p *funcProvider) command()      {}
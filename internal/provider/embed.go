@@ -0,0 +1,178 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type EmbedResponse struct {
+	Vector []float64
+	Model  string // embedding model that produced the vector
+}
+
+// Embed is used by providers without an embeddings endpoint.
+func (b *BaseProvider) Embed(ctx context.Context, text string) (EmbedResponse, error) {
+	return EmbedResponse{}, fmt.Errorf("provider %s does not support embeddings", b.name)
+}
+
+// embeddingModel returns the configured embedding_model or def.
+func (b *BaseProvider) embeddingModel(def string) string {
+	if b.config.EmbeddingModel != "" {
+		return b.config.EmbeddingModel
+	}
+	return def
+}
+
+// postJSON sends payload to url and decodes the answer into out. The status
+// code is returned so callers can report API errors from the body first.
+func (b *BaseProvider) postJSON(ctx context.Context, url string, payload, out interface{}, header map[string]string) (int, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	for key, value := range header {
+		httpReq.Header.Set(key, value)
+	}
+
+	resp, err := b.httpClient.Do(httpReq)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
+
+type openAIEmbedRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+type openAIEmbedResponse struct {
+	Data []struct {
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+	Usage usage        `json:"usage"`
+	Error *openAIError `json:"error,omitempty"`
+}
+
+func (p *OpenAIProvider) Embed(ctx context.Context, text string) (EmbedResponse, error) {
+	model := p.embeddingModel("text-embedding-3-small")
+
+	var embedResp openAIEmbedResponse
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/embeddings"
+	status, err := p.postJSON(ctx, url, openAIEmbedRequest{Model: model, Input: text}, &embedResp,
+		map[string]string{"Authorization": "Bearer " + p.config.APIKey})
+	if err != nil {
+		return EmbedResponse{}, err
+	}
+
+	p.meter.Record(p.name, model, embedResp.Usage.PromptTokens, 0)
+
+	if embedResp.Error != nil {
+		return EmbedResponse{}, fmt.Errorf("OpenAI API error: %s", embedResp.Error.Message)
+	}
+	if status != http.StatusOK {
+		return EmbedResponse{}, fmt.Errorf("unexpected status code: %d", status)
+	}
+	if len(embedResp.Data) == 0 {
+		return EmbedResponse{}, fmt.Errorf("no embedding in response")
+	}
+
+	return EmbedResponse{Vector: embedResp.Data[0].Embedding, Model: model}, nil
+}
+
+type ollamaEmbedRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Embeddings      [][]float64 `json:"embeddings"`
+	PromptEvalCount int         `json:"prompt_eval_count,omitempty"`
+	Error           string      `json:"error,omitempty"`
+}
+
+func (p *OllamaProvider) Embed(ctx context.Context, text string) (EmbedResponse, error) {
+	model := p.embeddingModel("nomic-embed-text")
+
+	var embedResp ollamaEmbedResponse
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/embed"
+	status, err := p.postJSON(ctx, url, ollamaEmbedRequest{Model: model, Input: text}, &embedResp, nil)
+	if err != nil {
+		return EmbedResponse{}, err
+	}
+
+	p.meter.Record(p.name, model, embedResp.PromptEvalCount, 0)
+
+	if embedResp.Error != "" {
+		return EmbedResponse{}, fmt.Errorf("Ollama API error: %s", embedResp.Error)
+	}
+	if status != http.StatusOK {
+		return EmbedResponse{}, fmt.Errorf("unexpected status code: %d", status)
+	}
+	if len(embedResp.Embeddings) == 0 {
+		return EmbedResponse{}, fmt.Errorf("no embedding in response")
+	}
+
+	return EmbedResponse{Vector: embedResp.Embeddings[0], Model: model}, nil
+}
+
+type googleEmbedRequest struct {
+	Content googleContent `json:"content"`
+}
+
+type googleEmbedResponse struct {
+	Embedding struct {
+		Values []float64 `json:"values"`
+	} `json:"embedding"`
+	Error *googleError `json:"error,omitempty"`
+}
+
+func (p *GoogleProvider) Embed(ctx context.Context, text string) (EmbedResponse, error) {
+	model := p.embeddingModel("text-embedding-004")
+
+	var embedResp googleEmbedResponse
+	url := fmt.Sprintf("%s/models/%s:embedContent?key=%s",
+		strings.TrimRight(p.config.BaseURL, "/"),
+		model,
+		p.config.APIKey,
+	)
+	req := googleEmbedRequest{Content: googleContent{Parts: []googlePart{{Text: text}}}}
+	status, err := p.postJSON(ctx, url, req, &embedResp, nil)
+	if err != nil {
+		return EmbedResponse{}, err
+	}
+
+	if embedResp.Error != nil {
+		return EmbedResponse{}, fmt.Errorf("Google API error: %s", embedResp.Error.Message)
+	}
+	if status != http.StatusOK {
+		return EmbedResponse{}, fmt.Errorf("unexpected status code: %d", status)
+	}
+	if len(embedResp.Embedding.Values) == 0 {
+		return EmbedResponse{}, fmt.Errorf("no embedding in response")
+	}
+
+	return EmbedResponse{Vector: embedResp.Embedding.Values, Model: model}, nil
+}
@@ -0,0 +1,119 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/foxzi/llm-translate/internal/config"
+)
+
+// TranslateFunc translates req.Text following systemPrompt, the complete
+// instructions built from the request.
+type TranslateFunc func(ctx context.Context, systemPrompt string, req TranslateRequest) (string, error)
+
+// RegisterFunc registers a provider that translates by calling translate,
+// for backends of programs embedding the translator. Analyses are not
+// supported.
+func RegisterFunc(name string, translate TranslateFunc) {
+	Register(name, func(cfg config.ProviderConfig, client *http.Client) Provider {
+		return newFuncProvider(name, cfg, client, translate)
+	})
+}
+
+func newFuncProvider(name string, cfg config.ProviderConfig, client *http.Client, translate TranslateFunc) Provider {
+	return &funcProvider{
+		translateOnly: translateOnly{
+			BaseProvider: BaseProvider{
+				name:       name,
+				config:     cfg,
+				httpClient: client,
+			},
+		},
+		translate: translate,
+	}
+}
+
+type funcProvider struct {
+	translateOnly
+	translate TranslateFunc
+}
+
+func (p *funcProvider) ValidateConfig() error {
+	return nil
+}
+
+func (p *funcProvider) Translate(ctx context.Context, req TranslateRequest) (TranslateResponse, error) {
+	prompt := p.buildPrompt(req, p.systemPrompt(req))
+	text, err := p.translate(ctx, prompt, req)
+	if err != nil {
+		return TranslateResponse{}, err
+	}
+	p.recordEstimate(prompt+req.Text, text)
+	return TranslateResponse{Text: text}, nil
+}
+
+// translateOnly provides the analyses of providers that only translate.
+type translateOnly struct {
+	BaseProvider
+}
+
+func (p *translateOnly) unsupported() error {
+	return fmt.Errorf("provider %s does not support analyses", p.name)
+}
+
+func (p *translateOnly) AnalyzeSentiment(ctx context.Context, text string) (SentimentResponse, error) {
+	return SentimentResponse{}, p.unsupported()
+}
+
+func (p *translateOnly) ExtractTags(ctx context.Context, text string, count int) (TagsResponse, error) {
+	return TagsResponse{}, p.unsupported()
+}
+
+func (p *translateOnly) Classify(ctx context.Context, text string) (ClassifyResponse, error) {
+	return ClassifyResponse{}, p.unsupported()
+}
+
+func (p *translateOnly) AnalyzeEmotions(ctx context.Context, text string) (EmotionsResponse, error) {
+	return EmotionsResponse{}, p.unsupported()
+}
+
+func (p *translateOnly) AnalyzeFactuality(ctx context.Context, text string) (FactualityResponse, error) {
+	return FactualityResponse{}, p.unsupported()
+}
+
+func (p *translateOnly) AnalyzeImpact(ctx context.Context, text string) (ImpactResponse, error) {
+	return ImpactResponse{}, p.unsupported()
+}
+
+func (p *translateOnly) AnalyzeSensationalism(ctx context.Context, text string) (SensationalismResponse, error) {
+	return SensationalismResponse{}, p.unsupported()
+}
+
+func (p *translateOnly) AnalyzeUsefulness(ctx context.Context, text string) (UsefulnessResponse, error) {
+	return UsefulnessResponse{}, p.unsupported()
+}
+
+func (p *translateOnly) ExtractEntities(ctx context.Context, text string) (EntitiesResponse, error) {
+	return EntitiesResponse{}, p.unsupported()
+}
+
+func (p *translateOnly) ExtractEvents(ctx context.Context, text string) (EventsResponse, error) {
+	return EventsResponse{}, p.unsupported()
+}
+
+func (p *translateOnly) AnalyzeTimeFocus(ctx context.Context, text string) (TimeFocusResponse, error) {
+	return TimeFocusResponse{}, p.unsupported()
+}
+
+func (p *translateOnly) AnalyzeAdDetect(ctx context.Context, text string) (AdDetectResponse, error) {
+	return AdDetectResponse{}, p.unsupported()
+}
+
+func (p *translateOnly) AnalyzeCombined(ctx context.Context, req CombinedAnalysisRequest) (CombinedAnalysisResponse, error) {
+	return CombinedAnalysisResponse{}, p.unsupported()
+}
+
+func (p *translateOnly) GenerateHeadline(ctx context.Context, text string) (HeadlineResponse, error) {
+	return HeadlineResponse{}, p.unsupported()
+}
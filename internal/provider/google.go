@@ -32,15 +32,19 @@ type googlePart struct {
 }
 
 type googleGenConfig struct {
-	Temperature     float64 `json:"temperature,omitempty"`
-	MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
-	TopP            float64 `json:"topP,omitempty"`
-	TopK            int     `json:"topK,omitempty"`
+	Temperature      float64                `json:"temperature,omitempty"`
+	MaxOutputTokens  int                    `json:"maxOutputTokens,omitempty"`
+	TopP             float64                `json:"topP,omitempty"`
+	TopK             int                    `json:"topK,omitempty"`
+	ResponseMimeType string                 `json:"responseMimeType,omitempty"`
+	ResponseSchema   map[string]interface{} `json:"responseSchema,omitempty"`
 }
 
 type googleResponse struct {
 	Candidates    []googleCandidate `json:"candidates"`
 	UsageMetadata googleUsage       `json:"usageMetadata"`
+	ModelVersion  string            `json:"modelVersion"`
+	ResponseID    string            `json:"responseId"`
 	Error         *googleError      `json:"error,omitempty"`
 }
 
@@ -80,21 +84,7 @@ func NewGoogleProvider(cfg config.ProviderConfig, client *http.Client) Provider
 }
 
 func (p *GoogleProvider) Translate(ctx context.Context, req TranslateRequest) (TranslateResponse, error) {
-	systemPrompt := fmt.Sprintf(
-		"You are a professional translator. Translate the following text from %s to %s. "+
-			"Preserve the original formatting and structure. "+
-			"Output only the translation without explanations.",
-		req.SourceLang, req.TargetLang,
-	)
-
-	if req.SourceLang == "auto" {
-		systemPrompt = fmt.Sprintf(
-			"You are a professional translator. Detect the source language and translate the text to %s. "+
-				"Preserve the original formatting and structure. "+
-				"Output only the translation without explanations.",
-			req.TargetLang,
-		)
-	}
+	systemPrompt := p.systemPrompt(req)
 
 	fullPrompt := p.buildPrompt(req, systemPrompt)
 
@@ -152,6 +142,8 @@ func (p *GoogleProvider) Translate(ctx context.Context, req TranslateRequest) (T
 		return TranslateResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(googleResp.UsageMetadata.PromptTokenCount, googleResp.UsageMetadata.CandidatesTokenCount)
+
 	if googleResp.Error != nil {
 		return TranslateResponse{}, fmt.Errorf("Google API error: %s", googleResp.Error.Message)
 	}
@@ -172,6 +164,12 @@ func (p *GoogleProvider) Translate(ctx context.Context, req TranslateRequest) (T
 	return TranslateResponse{
 		Text:       translatedText,
 		TokensUsed: googleResp.UsageMetadata.TotalTokenCount,
+		Truncated:  googleResp.Candidates[0].FinishReason == "MAX_TOKENS",
+		Meta: ResponseMeta{
+			ID:           googleResp.ResponseID,
+			Model:        googleResp.ModelVersion,
+			FinishReason: googleResp.Candidates[0].FinishReason,
+		},
 	}, nil
 }
 
@@ -230,6 +228,8 @@ func (p *GoogleProvider) AnalyzeSentiment(ctx context.Context, text string) (Sen
 		return SentimentResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(googleResp.UsageMetadata.PromptTokenCount, googleResp.UsageMetadata.CandidatesTokenCount)
+
 	if googleResp.Error != nil {
 		return SentimentResponse{}, fmt.Errorf("Google API error: %s", googleResp.Error.Message)
 	}
@@ -303,6 +303,8 @@ func (p *GoogleProvider) ExtractTags(ctx context.Context, text string, count int
 		return TagsResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(googleResp.UsageMetadata.PromptTokenCount, googleResp.UsageMetadata.CandidatesTokenCount)
+
 	if googleResp.Error != nil {
 		return TagsResponse{}, fmt.Errorf("Google API error: %s", googleResp.Error.Message)
 	}
@@ -374,6 +376,8 @@ func (p *GoogleProvider) Classify(ctx context.Context, text string) (ClassifyRes
 		return ClassifyResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(googleResp.UsageMetadata.PromptTokenCount, googleResp.UsageMetadata.CandidatesTokenCount)
+
 	if googleResp.Error != nil {
 		return ClassifyResponse{}, fmt.Errorf("Google API error: %s", googleResp.Error.Message)
 	}
@@ -445,6 +449,8 @@ func (p *GoogleProvider) AnalyzeEmotions(ctx context.Context, text string) (Emot
 		return EmotionsResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(googleResp.UsageMetadata.PromptTokenCount, googleResp.UsageMetadata.CandidatesTokenCount)
+
 	if googleResp.Error != nil {
 		return EmotionsResponse{}, fmt.Errorf("Google API error: %s", googleResp.Error.Message)
 	}
@@ -516,6 +522,8 @@ func (p *GoogleProvider) AnalyzeFactuality(ctx context.Context, text string) (Fa
 		return FactualityResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(googleResp.UsageMetadata.PromptTokenCount, googleResp.UsageMetadata.CandidatesTokenCount)
+
 	if googleResp.Error != nil {
 		return FactualityResponse{}, fmt.Errorf("Google API error: %s", googleResp.Error.Message)
 	}
@@ -587,6 +595,8 @@ func (p *GoogleProvider) AnalyzeImpact(ctx context.Context, text string) (Impact
 		return ImpactResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(googleResp.UsageMetadata.PromptTokenCount, googleResp.UsageMetadata.CandidatesTokenCount)
+
 	if googleResp.Error != nil {
 		return ImpactResponse{}, fmt.Errorf("Google API error: %s", googleResp.Error.Message)
 	}
@@ -658,6 +668,8 @@ func (p *GoogleProvider) AnalyzeSensationalism(ctx context.Context, text string)
 		return SensationalismResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(googleResp.UsageMetadata.PromptTokenCount, googleResp.UsageMetadata.CandidatesTokenCount)
+
 	if googleResp.Error != nil {
 		return SensationalismResponse{}, fmt.Errorf("Google API error: %s", googleResp.Error.Message)
 	}
@@ -729,6 +741,8 @@ func (p *GoogleProvider) ExtractEntities(ctx context.Context, text string) (Enti
 		return EntitiesResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(googleResp.UsageMetadata.PromptTokenCount, googleResp.UsageMetadata.CandidatesTokenCount)
+
 	if googleResp.Error != nil {
 		return EntitiesResponse{}, fmt.Errorf("Google API error: %s", googleResp.Error.Message)
 	}
@@ -800,6 +814,8 @@ func (p *GoogleProvider) ExtractEvents(ctx context.Context, text string) (Events
 		return EventsResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(googleResp.UsageMetadata.PromptTokenCount, googleResp.UsageMetadata.CandidatesTokenCount)
+
 	if googleResp.Error != nil {
 		return EventsResponse{}, fmt.Errorf("Google API error: %s", googleResp.Error.Message)
 	}
@@ -871,6 +887,8 @@ func (p *GoogleProvider) AnalyzeUsefulness(ctx context.Context, text string) (Us
 		return UsefulnessResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(googleResp.UsageMetadata.PromptTokenCount, googleResp.UsageMetadata.CandidatesTokenCount)
+
 	if googleResp.Error != nil {
 		return UsefulnessResponse{}, fmt.Errorf("Google API error: %s", googleResp.Error.Message)
 	}
@@ -942,6 +960,8 @@ func (p *GoogleProvider) AnalyzeAdDetect(ctx context.Context, text string) (AdDe
 		return AdDetectResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(googleResp.UsageMetadata.PromptTokenCount, googleResp.UsageMetadata.CandidatesTokenCount)
+
 	if googleResp.Error != nil {
 		return AdDetectResponse{}, fmt.Errorf("Google API error: %s", googleResp.Error.Message)
 	}
@@ -1013,6 +1033,8 @@ func (p *GoogleProvider) AnalyzeTimeFocus(ctx context.Context, text string) (Tim
 		return TimeFocusResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(googleResp.UsageMetadata.PromptTokenCount, googleResp.UsageMetadata.CandidatesTokenCount)
+
 	if googleResp.Error != nil {
 		return TimeFocusResponse{}, fmt.Errorf("Google API error: %s", googleResp.Error.Message)
 	}
@@ -1052,6 +1074,13 @@ func (p *GoogleProvider) AnalyzeCombined(ctx context.Context, req CombinedAnalys
 		},
 	}
 
+	if req.JSON || req.Schema {
+		googleReq.GenerationConfig.ResponseMimeType = "application/json"
+	}
+	if req.Schema {
+		googleReq.GenerationConfig.ResponseSchema = googleSchema(combinedJSONSchema(req))
+	}
+
 	jsonData, err := json.Marshal(googleReq)
 	if err != nil {
 		return CombinedAnalysisResponse{}, fmt.Errorf("failed to marshal request: %w", err)
@@ -1086,6 +1115,8 @@ func (p *GoogleProvider) AnalyzeCombined(ctx context.Context, req CombinedAnalys
 		return CombinedAnalysisResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(googleResp.UsageMetadata.PromptTokenCount, googleResp.UsageMetadata.CandidatesTokenCount)
+
 	if googleResp.Error != nil {
 		return CombinedAnalysisResponse{}, fmt.Errorf("Google API error: %s", googleResp.Error.Message)
 	}
@@ -1102,6 +1133,79 @@ func (p *GoogleProvider) AnalyzeCombined(ctx context.Context, req CombinedAnalys
 	return ParseCombinedResponse(responseText, req), nil
 }
 
+func (p *GoogleProvider) GenerateHeadline(ctx context.Context, text string) (HeadlineResponse, error) {
+	googleReq := googleRequest{
+		Contents: []googleContent{
+			{
+				Parts: []googlePart{
+					{Text: text},
+				},
+				Role: "user",
+			},
+		},
+		GenerationConfig: googleGenConfig{
+			Temperature:     0.1,
+			MaxOutputTokens: 200,
+		},
+		SystemInstruction: &googleContent{
+			Parts: []googlePart{
+				{Text: HeadlinePrompt},
+			},
+		},
+	}
+
+	jsonData, err := json.Marshal(googleReq)
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/models/%s:generateContent?key=%s",
+		strings.TrimRight(p.config.BaseURL, "/"),
+		p.config.Model,
+		p.config.APIKey,
+	)
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var googleResp googleResponse
+	if err := json.Unmarshal(body, &googleResp); err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	p.record(googleResp.UsageMetadata.PromptTokenCount, googleResp.UsageMetadata.CandidatesTokenCount)
+
+	if googleResp.Error != nil {
+		return HeadlineResponse{}, fmt.Errorf("Google API error: %s", googleResp.Error.Message)
+	}
+
+	if len(googleResp.Candidates) == 0 {
+		return HeadlineResponse{}, fmt.Errorf("no candidates in response")
+	}
+
+	var responseText string
+	for _, part := range googleResp.Candidates[0].Content.Parts {
+		responseText += part.Text
+	}
+
+	return ParseHeadlineResponse(responseText)
+}
+
 func init() {
 	Register("google", NewGoogleProvider)
 }
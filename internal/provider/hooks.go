@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/foxzi/llm-translate/internal/config"
+)
+
+// RequestHook mutates an outgoing HTTP request before it is sent, e.g. to
+// add signatures or tokens required by an enterprise API gateway. Hooks are
+// enabled per provider with request_hooks and read their parameters from
+// hook_options. Client is the HTTP client of the provider without hooks,
+// for hooks that send requests of their own.
+type RequestHook func(req *http.Request, client *http.Client, cfg config.ProviderConfig) error
+
+var requestHooks = map[string]RequestHook{
+	"hmac":  hmacSigningHook,
+	"oauth": oauthHook,
+}
+
+// RegisterRequestHook makes a hook available to request_hooks by name.
+func RegisterRequestHook(name string, hook RequestHook) {
+	requestHooks[name] = hook
+}
+
+// staticHeaders returns the headers set on every request of a provider:
+// OpenAI organization and project scoping, so usage is billed to the right
+// project, and the headers from config.
+func staticHeaders(name string, cfg config.ProviderConfig) map[string]string {
+	headers := make(map[string]string)
+	if name == "openai" {
+		if cfg.Organization != "" {
+			headers["OpenAI-Organization"] = cfg.Organization
+		}
+		if cfg.Project != "" {
+			headers["OpenAI-Project"] = cfg.Project
+		}
+	}
+	for key, value := range cfg.Headers {
+		headers[key] = config.ExpandEnvVars(value)
+	}
+	return headers
+}
+
+// withRequestHooks returns a copy of client that sets headers and runs the
+// configured hooks on every request. Hooks send their own requests with
+// hookClient.
+func withRequestHooks(client, hookClient *http.Client, cfg config.ProviderConfig, headers map[string]string) (*http.Client, error) {
+	var hooks []RequestHook
+	for _, name := range cfg.RequestHooks {
+		hook, ok := requestHooks[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown request hook: %s", name)
+		}
+		hooks = append(hooks, hook)
+	}
+
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	hooked := *client
+	hooked.Transport = &hookTransport{
+		base:    base,
+		client:  hookClient,
+		hooks:   hooks,
+		headers: headers,
+		cfg:     cfg,
+		oauth:   slices.Contains(cfg.RequestHooks, "oauth"),
+	}
+	return &hooked, nil
+}
+
+type hookTransport struct {
+	base    http.RoundTripper
+	client  *http.Client
+	hooks   []RequestHook
+	headers map[string]string
+	cfg     config.ProviderConfig
+	oauth   bool // a rejected token is refreshed and the request sent again
+}
+
+func (t *hookTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.send(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !t.oauth || (req.Body != nil && req.GetBody == nil) {
+		return resp, err
+	}
+
+	// The cached token was revoked or expired early, fetch a new one once
+	resp.Body.Close()
+	tokens.invalidate(t.cfg.HookOptions)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = body
+	}
+	return t.send(req)
+}
+
+func (t *hookTransport) send(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the caller's request
+	req = req.Clone(req.Context())
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	for _, hook := range t.hooks {
+		if err := hook(req, t.client, t.cfg); err != nil {
+			return nil, fmt.Errorf("request hook failed: %w", err)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// hmacSigningHook signs "timestamp\nMETHOD\npath\nbody" with HMAC-SHA256.
+// Options: hmac_secret (required), hmac_header (default X-Signature),
+// hmac_timestamp_header (default X-Timestamp).
+func hmacSigningHook(req *http.Request, _ *http.Client, cfg config.ProviderConfig) error {
+	secret := config.ExpandEnvVars(cfg.HookOptions["hmac_secret"])
+	if secret == "" {
+		return fmt.Errorf("hmac: hook_options.hmac_secret is not set")
+	}
+
+	signatureHeader := cfg.HookOptions["hmac_header"]
+	if signatureHeader == "" {
+		signatureHeader = "X-Signature"
+	}
+	timestampHeader := cfg.HookOptions["hmac_timestamp_header"]
+	if timestampHeader == "" {
+		timestampHeader = "X-Timestamp"
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return fmt.Errorf("hmac: failed to read body: %w", err)
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n%s\n%s\n", timestamp, req.Method, req.URL.RequestURI())
+	mac.Write(body)
+
+	req.Header.Set(timestampHeader, timestamp)
+	req.Header.Set(signatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	return nil
+}
@@ -0,0 +1,346 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// MockProvider answers offline and deterministically, for the selftest
+// command and for trying scripts, chunking and output layout without a
+// network. Texts found in the fixtures file get their recorded translation;
+// otherwise the letters of every word are reversed, while markup, numbers,
+// URLs, code and glossary terms are kept. Analyses return synthetic results
+// derived from the text.
+type MockProvider struct {
+	BaseProvider
+	fixtures map[string]string
+}
+
+func NewMockProvider(cfg config.ProviderConfig, client *http.Client) Provider {
+	return &MockProvider{
+		BaseProvider: BaseProvider{
+			name:       "mock",
+			config:     cfg,
+			httpClient: client,
+		},
+	}
+}
+
+// ValidateConfig loads the fixtures file, a YAML map of source texts to
+// their translations.
+func (p *MockProvider) ValidateConfig() error {
+	if p.config.Fixtures == "" {
+		return nil
+	}
+	data, err := os.ReadFile(p.config.Fixtures)
+	if err != nil {
+		return fmt.Errorf("failed to read mock fixtures: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &p.fixtures); err != nil {
+		return fmt.Errorf("invalid mock fixtures %s: %w", p.config.Fixtures, err)
+	}
+	return nil
+}
+
+func (p *MockProvider) Translate(ctx context.Context, req TranslateRequest) (TranslateResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return TranslateResponse{}, err
+	}
+
+	text := req.Text
+	if req.Delimiter != "" {
+		text = strings.TrimPrefix(text, "<"+req.Delimiter+">\n")
+		text = strings.TrimSuffix(text, "\n</"+req.Delimiter+">")
+	}
+
+	translated := mockTranslate(text, req.Glossary)
+	if fixture, ok := p.fixtures[strings.TrimSpace(text)]; ok {
+		// Keep the whitespace around the text
+		translated = strings.Replace(text, strings.TrimSpace(text), fixture, 1)
+	}
+	p.recordEstimate(req.Text, translated)
+	return TranslateResponse{Text: translated}, nil
+}
+
+// mockTranslate reverses the letters of each word outside code blocks. Words
+// with a URL or inline code are kept, glossary sources become their targets.
+func mockTranslate(text string, glossary []config.GlossaryEntry) string {
+	var targets []string
+	for _, entry := range glossary {
+		source, target := entry.Source, entry.Target
+		if source == "" {
+			source, target = entry.Term, entry.Translation
+		}
+		if source == "" || target == "" {
+			continue
+		}
+		// A placeholder without letters is not reversed
+		placeholder := fmt.Sprintf("\x00%d\x00", len(targets))
+		text = replaceFold(text, source, placeholder)
+		targets = append(targets, target)
+	}
+
+	lines := strings.Split(text, "\n")
+	inCode := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+		words := strings.Split(line, " ")
+		for j, word := range words {
+			if strings.Contains(word, "://") || strings.Contains(word, "`") {
+				continue
+			}
+			words[j] = reverseLetters(word)
+		}
+		lines[i] = strings.Join(words, " ")
+	}
+	text = strings.Join(lines, "\n")
+
+	for i, target := range targets {
+		text = strings.ReplaceAll(text, fmt.Sprintf("\x00%d\x00", i), target)
+	}
+	return text
+}
+
+// reverseLetters reverses each run of letters in word. A capital first
+// letter stays in front.
+func reverseLetters(word string) string {
+	runes := []rune(word)
+	for start := 0; start < len(runes); {
+		if !unicode.IsLetter(runes[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(runes) && unicode.IsLetter(runes[end]) {
+			end++
+		}
+		run := runes[start:end]
+		capital := unicode.IsUpper(run[0]) && (len(run) == 1 || !unicode.IsUpper(run[1]))
+		for a, b := 0, len(run)-1; a < b; a, b = a+1, b-1 {
+			run[a], run[b] = run[b], run[a]
+		}
+		if capital {
+			run[len(run)-1] = unicode.ToLower(run[len(run)-1])
+			run[0] = unicode.ToUpper(run[0])
+		}
+		start = end
+	}
+	return string(runes)
+}
+
+// replaceFold replaces old in text with new_ ignoring case.
+func replaceFold(text, old, new_ string) string {
+	var b strings.Builder
+	lower, oldLower := strings.ToLower(text), strings.ToLower(old)
+	if len(lower) != len(text) {
+		// Case folding changed byte offsets, fall back to exact matches
+		return strings.ReplaceAll(text, old, new_)
+	}
+	for {
+		i := strings.Index(lower, oldLower)
+		if i < 0 {
+			b.WriteString(text)
+			return b.String()
+		}
+		b.WriteString(text[:i] + new_)
+		text, lower = text[i+len(old):], lower[i+len(old):]
+	}
+}
+
+func (p *MockProvider) AnalyzeSentiment(ctx context.Context, text string) (SentimentResponse, error) {
+	return SentimentResponse{Sentiment: "neutral", Score: 0, Confidence: 0.5}, nil
+}
+
+func (p *MockProvider) ExtractTags(ctx context.Context, text string, count int) (TagsResponse, error) {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, word := range mockWords(text) {
+		word = strings.ToLower(word)
+		if len([]rune(word)) < 5 || seen[word] {
+			continue
+		}
+		if len(tags) == count {
+			break
+		}
+		seen[word] = true
+		tags = append(tags, word)
+	}
+	return TagsResponse{Tags: tags}, nil
+}
+
+func (p *MockProvider) Classify(ctx context.Context, text string) (ClassifyResponse, error) {
+	return ClassifyResponse{Topics: []string{"technology"}, Scope: []string{"international"}, NewsType: []string{"corporate"}}, nil
+}
+
+func (p *MockProvider) AnalyzeEmotions(ctx context.Context, text string) (EmotionsResponse, error) {
+	return EmotionsResponse{Emotions: map[string]float64{"optimism": 0.2}}, nil
+}
+
+func (p *MockProvider) AnalyzeFactuality(ctx context.Context, text string) (FactualityResponse, error) {
+	return FactualityResponse{Type: "unsourced", Confidence: 0.5}, nil
+}
+
+func (p *MockProvider) AnalyzeImpact(ctx context.Context, text string) (ImpactResponse, error) {
+	return ImpactResponse{Affected: []string{"consumers"}}, nil
+}
+
+func (p *MockProvider) AnalyzeSensationalism(ctx context.Context, text string) (SensationalismResponse, error) {
+	return SensationalismResponse{Type: "neutral", Confidence: 0.5}, nil
+}
+
+func (p *MockProvider) AnalyzeUsefulness(ctx context.Context, text string) (UsefulnessResponse, error) {
+	return UsefulnessResponse{IsUseful: true, Confidence: 0.5, Reasons: []string{"mock result"}}, nil
+}
+
+// ExtractEntities reports capitalized words inside sentences as
+// organizations and words with digits as amounts.
+func (p *MockProvider) ExtractEntities(ctx context.Context, text string) (EntitiesResponse, error) {
+	var resp EntitiesResponse
+	words := mockWords(text)
+	for i, word := range words {
+		runes := []rune(word)
+		switch {
+		case strings.IndexFunc(word, unicode.IsDigit) >= 0:
+			resp.Amounts = append(resp.Amounts, word)
+		case i > 0 && unicode.IsUpper(runes[0]) && !strings.HasSuffix(words[i-1], "."):
+			resp.Organizations = append(resp.Organizations, strings.TrimRight(word, ".,;:!?"))
+		}
+	}
+	return resp, nil
+}
+
+func (p *MockProvider) ExtractEvents(ctx context.Context, text string) (EventsResponse, error) {
+	return EventsResponse{Events: []string{mockFirstWords(text, 12)}}, nil
+}
+
+func (p *MockProvider) AnalyzeTimeFocus(ctx context.Context, text string) (TimeFocusResponse, error) {
+	return TimeFocusResponse{Focus: "present", Confidence: 0.5}, nil
+}
+
+func (p *MockProvider) AnalyzeAdDetect(ctx context.Context, text string) (AdDetectResponse, error) {
+	return AdDetectResponse{AdType: "none", Confidence: 0.5}, nil
+}
+
+// AnalyzeCombined fills the requested sections with the results of the
+// single analyses.
+func (p *MockProvider) AnalyzeCombined(ctx context.Context, req CombinedAnalysisRequest) (CombinedAnalysisResponse, error) {
+	var resp CombinedAnalysisResponse
+	if req.Sentiment {
+		r, _ := p.AnalyzeSentiment(ctx, req.Text)
+		resp.Sentiment = &r
+	}
+	if req.TagsCount > 0 {
+		r, _ := p.ExtractTags(ctx, req.Text, req.TagsCount)
+		resp.Tags = &r
+	}
+	if req.Classify {
+		r, _ := p.Classify(ctx, req.Text)
+		resp.Classify = &r
+	}
+	if req.Emotions {
+		r, _ := p.AnalyzeEmotions(ctx, req.Text)
+		resp.Emotions = &r
+	}
+	if req.Factuality {
+		r, _ := p.AnalyzeFactuality(ctx, req.Text)
+		resp.Factuality = &r
+	}
+	if req.Impact {
+		r, _ := p.AnalyzeImpact(ctx, req.Text)
+		resp.Impact = &r
+	}
+	if req.Sensationalism {
+		r, _ := p.AnalyzeSensationalism(ctx, req.Text)
+		resp.Sensationalism = &r
+	}
+	if req.Usefulness {
+		r, _ := p.AnalyzeUsefulness(ctx, req.Text)
+		resp.Usefulness = &r
+	}
+	if req.Entities {
+		r, _ := p.ExtractEntities(ctx, req.Text)
+		resp.Entities = &r
+	}
+	if req.Events {
+		r, _ := p.ExtractEvents(ctx, req.Text)
+		resp.Events = &r
+	}
+	if req.TimeFocus {
+		r, _ := p.AnalyzeTimeFocus(ctx, req.Text)
+		resp.TimeFocus = &r
+	}
+	if req.AdDetect {
+		r, _ := p.AnalyzeAdDetect(ctx, req.Text)
+		resp.AdDetect = &r
+	}
+	return resp, nil
+}
+
+func (p *MockProvider) GenerateHeadline(ctx context.Context, text string) (HeadlineResponse, error) {
+	return HeadlineResponse{Title: mockFirstWords(text, 8), Description: mockFirstWords(text, 20)}, nil
+}
+
+// Embed hashes the words of text into a small normalized vector, so texts
+// sharing words are similar.
+func (p *MockProvider) Embed(ctx context.Context, text string) (EmbedResponse, error) {
+	vector := make([]float64, 16)
+	for _, word := range mockWords(text) {
+		h := fnv.New32a()
+		h.Write([]byte(strings.ToLower(strings.Trim(word, ".,;:!?"))))
+		vector[h.Sum32()%uint32(len(vector))]++
+	}
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range vector {
+			vector[i] /= norm
+		}
+	}
+	return EmbedResponse{Vector: vector, Model: "mock"}, nil
+}
+
+func (p *MockProvider) listModels(ctx context.Context) ([]ModelInfo, error) {
+	return []ModelInfo{{ID: "mock"}}, nil
+}
+
+// mockWords splits text into words, leaving out markup-only tokens.
+func mockWords(text string) []string {
+	var words []string
+	for _, word := range strings.Fields(text) {
+		if strings.IndexFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// mockFirstWords returns the first n words of text.
+func mockFirstWords(text string, n int) string {
+	words := mockWords(text)
+	if len(words) > n {
+		words = words[:n]
+	}
+	return strings.Join(words, " ")
+}
+
+func init() {
+	Register("mock", NewMockProvider)
+}
@@ -0,0 +1,72 @@
+package provider
+
+import "strings"
+
+// ModelLimits are the token limits of a model. Zero means unknown.
+type ModelLimits struct {
+	ContextWindow int // input and output tokens of one request
+	MaxOutput     int // tokens the model can generate
+}
+
+// modelLimits lists known models by name prefix. Dots are written as
+// hyphens, the way model names are normalized before lookup.
+var modelLimits = map[string]ModelLimits{
+	"gpt-3-5-turbo":     {16385, 4096},
+	"gpt-4":             {8192, 8192},
+	"gpt-4-turbo":       {128000, 4096},
+	"gpt-4o":            {128000, 16384},
+	"gpt-4-1":           {1047576, 32768},
+	"gpt-5":             {400000, 128000},
+	"o1":                {200000, 100000},
+	"o3":                {200000, 100000},
+	"o4-mini":           {200000, 100000},
+	"claude-3-haiku":    {200000, 4096},
+	"claude-3-opus":     {200000, 4096},
+	"claude-3-5-haiku":  {200000, 8192},
+	"claude-3-5-sonnet": {200000, 8192},
+	"claude-3-7-sonnet": {200000, 64000},
+	"claude-sonnet-4":   {200000, 64000},
+	"claude-opus-4":     {200000, 32000},
+	"gemini-1-5-flash":  {1048576, 8192},
+	"gemini-1-5-pro":    {2097152, 8192},
+	"gemini-2-0-flash":  {1048576, 8192},
+	"gemini-2-5":        {1048576, 65536},
+	"llama3":            {8192, 0},
+	"llama3-1":          {131072, 0},
+	"llama3-2":          {131072, 0},
+	"qwen2-5":           {32768, 8192},
+}
+
+// LookupModelLimits returns the limits of a model from the table, matching
+// the longest name prefix followed by a hyphen or the end of the name.
+// OpenRouter names such as anthropic/claude-3.5-sonnet are looked up
+// without the vendor.
+func LookupModelLimits(model string) ModelLimits {
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	model = strings.ReplaceAll(strings.ToLower(model), ".", "-")
+	model = strings.ReplaceAll(model, ":", "-")
+
+	best := ""
+	for prefix := range modelLimits {
+		rest, ok := strings.CutPrefix(model, prefix)
+		if ok && len(prefix) > len(best) && (rest == "" || rest[0] == '-') {
+			best = prefix
+		}
+	}
+	return modelLimits[best]
+}
+
+// Limits returns the limits of the configured model. context_window and
+// max_output_tokens in the provider config take precedence over the table.
+func (b *BaseProvider) Limits() ModelLimits {
+	limits := LookupModelLimits(b.config.Model)
+	if b.config.ContextWindow > 0 {
+		limits.ContextWindow = b.config.ContextWindow
+	}
+	if b.config.MaxOutputTokens > 0 {
+		limits.MaxOutput = b.config.MaxOutputTokens
+	}
+	return limits
+}
@@ -0,0 +1,196 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/foxzi/llm-translate/internal/config"
+)
+
+// tokenRefreshMargin refreshes tokens slightly before they expire so
+// long requests do not start with a token about to become invalid.
+const tokenRefreshMargin = 60 * time.Second
+
+// tokenManager caches short-lived OAuth tokens for the whole process, so
+// providers created per file and concurrent workers share one token.
+type tokenManager struct {
+	mu     sync.Mutex
+	tokens map[string]cachedToken
+}
+
+type cachedToken struct {
+	value   string
+	expires time.Time
+}
+
+var tokens = &tokenManager{
+	tokens: make(map[string]cachedToken),
+}
+
+// oauthHook sets a bearer token obtained with the OAuth client credentials
+// flow (Azure AD, GigaChat) or from a command (gcloud for Vertex AI).
+// Options: oauth_token_url, oauth_client_id, oauth_client_secret,
+// oauth_scope, oauth_auth_style (post or basic), oauth_token_cmd,
+// oauth_token_ttl (seconds, for oauth_token_cmd), oauth_header. Tokens
+// are requested with the proxy and timeout of the provider, within the
+// context of the request.
+func oauthHook(req *http.Request, client *http.Client, cfg config.ProviderConfig) error {
+	token, err := tokens.get(req.Context(), client, cfg.HookOptions)
+	if err != nil {
+		return fmt.Errorf("oauth: %w", err)
+	}
+
+	header := cfg.HookOptions["oauth_header"]
+	if header == "" {
+		header = "Authorization"
+	}
+	req.Header.Set(header, "Bearer "+token)
+	return nil
+}
+
+// tokenKey identifies the token of a set of hook options.
+func tokenKey(opts map[string]string) string {
+	return opts["oauth_token_url"] + "|" + opts["oauth_client_id"] + "|" + opts["oauth_scope"] + "|" + opts["oauth_token_cmd"]
+}
+
+// invalidate drops the cached token of opts, after the API rejected it.
+func (m *tokenManager) invalidate(opts map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tokens, tokenKey(opts))
+}
+
+func (m *tokenManager) get(ctx context.Context, client *http.Client, opts map[string]string) (string, error) {
+	key := tokenKey(opts)
+
+	// Holding the lock while fetching makes concurrent workers wait for a
+	// single refresh instead of all requesting new tokens at once
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cached, ok := m.tokens[key]; ok && time.Now().Add(tokenRefreshMargin).Before(cached.expires) {
+		return cached.value, nil
+	}
+
+	var token cachedToken
+	var err error
+	if opts["oauth_token_cmd"] != "" {
+		token, err = tokenFromCommand(opts)
+	} else {
+		token, err = fetchToken(ctx, client, opts)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	m.tokens[key] = token
+	return token.value, nil
+}
+
+func tokenFromCommand(opts map[string]string) (cachedToken, error) {
+	value, err := config.RunCommand(opts["oauth_token_cmd"])
+	if err != nil {
+		return cachedToken{}, fmt.Errorf("oauth_token_cmd failed: %w", err)
+	}
+
+	ttl := 3000
+	if raw := opts["oauth_token_ttl"]; raw != "" {
+		ttl, err = strconv.Atoi(raw)
+		if err != nil {
+			return cachedToken{}, fmt.Errorf("invalid oauth_token_ttl: %w", err)
+		}
+	}
+
+	return cachedToken{value: value, expires: time.Now().Add(time.Duration(ttl) * time.Second)}, nil
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"` // seconds, RFC 6749
+	ExpiresAt   int64  `json:"expires_at"` // unix milliseconds, GigaChat
+}
+
+func fetchToken(ctx context.Context, client *http.Client, opts map[string]string) (cachedToken, error) {
+	tokenURL := opts["oauth_token_url"]
+	if tokenURL == "" {
+		return cachedToken{}, fmt.Errorf("hook_options.oauth_token_url is not set")
+	}
+
+	clientID := config.ExpandEnvVars(opts["oauth_client_id"])
+	clientSecret := config.ExpandEnvVars(opts["oauth_client_secret"])
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if scope := opts["oauth_scope"]; scope != "" {
+		form.Set("scope", scope)
+	}
+
+	basicAuth := opts["oauth_auth_style"] == "basic"
+	if !basicAuth {
+		form.Set("client_id", clientID)
+		form.Set("client_secret", clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return cachedToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// GigaChat requires a unique request ID, other servers ignore it
+	req.Header.Set("RqUID", newRequestID())
+	if basicAuth {
+		req.SetBasicAuth(clientID, clientSecret)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return cachedToken{}, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return cachedToken{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return cachedToken{}, fmt.Errorf("token endpoint error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return cachedToken{}, fmt.Errorf("failed to parse token response: %w", err)
+	}
+	if tr.AccessToken == "" {
+		return cachedToken{}, fmt.Errorf("token response has no access_token")
+	}
+
+	expires := time.Now().Add(time.Hour)
+	switch {
+	case tr.ExpiresIn > 0:
+		expires = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	case tr.ExpiresAt > 0:
+		expires = time.UnixMilli(tr.ExpiresAt)
+	}
+
+	return cachedToken{value: tr.AccessToken, expires: expires}, nil
+}
+
+// newRequestID returns a random UUID v4.
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
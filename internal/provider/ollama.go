@@ -17,31 +17,54 @@ type OllamaProvider struct {
 }
 
 type ollamaRequest struct {
-	Model   string        `json:"model"`
-	Prompt  string        `json:"prompt"`
-	System  string        `json:"system,omitempty"`
-	Stream  bool          `json:"stream"`
-	Options ollamaOptions `json:"options,omitempty"`
+	Model     string        `json:"model"`
+	Messages  []message     `json:"messages"`
+	Stream    bool          `json:"stream"`
+	Format    string        `json:"format,omitempty"`
+	KeepAlive string        `json:"keep_alive,omitempty"`
+	Options   ollamaOptions `json:"options,omitempty"`
 }
 
 type ollamaOptions struct {
 	Temperature float64 `json:"temperature,omitempty"`
 	NumPredict  int     `json:"num_predict,omitempty"`
+	NumCtx      int     `json:"num_ctx,omitempty"`
+	// Extra holds the options from config, sent along with the ones above
+	Extra map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON merges the options from config with the per-request ones,
+// which take precedence.
+func (o ollamaOptions) MarshalJSON() ([]byte, error) {
+	merged := make(map[string]interface{}, len(o.Extra)+3)
+	for key, value := range o.Extra {
+		merged[key] = value
+	}
+	if o.Temperature != 0 {
+		merged["temperature"] = o.Temperature
+	}
+	if o.NumPredict != 0 {
+		merged["num_predict"] = o.NumPredict
+	}
+	if o.NumCtx != 0 {
+		merged["num_ctx"] = o.NumCtx
+	}
+	return json.Marshal(merged)
 }
 
 type ollamaResponse struct {
-	Model              string `json:"model"`
-	CreatedAt          string `json:"created_at"`
-	Response           string `json:"response"`
-	Done               bool   `json:"done"`
-	Context            []int  `json:"context,omitempty"`
-	TotalDuration      int64  `json:"total_duration,omitempty"`
-	LoadDuration       int64  `json:"load_duration,omitempty"`
-	PromptEvalCount    int    `json:"prompt_eval_count,omitempty"`
-	PromptEvalDuration int64  `json:"prompt_eval_duration,omitempty"`
-	EvalCount          int    `json:"eval_count,omitempty"`
-	EvalDuration       int64  `json:"eval_duration,omitempty"`
-	Error              string `json:"error,omitempty"`
+	Model              string  `json:"model"`
+	CreatedAt          string  `json:"created_at"`
+	Message            message `json:"message"`
+	Done               bool    `json:"done"`
+	DoneReason         string  `json:"done_reason,omitempty"`
+	TotalDuration      int64   `json:"total_duration,omitempty"`
+	LoadDuration       int64   `json:"load_duration,omitempty"`
+	PromptEvalCount    int     `json:"prompt_eval_count,omitempty"`
+	PromptEvalDuration int64   `json:"prompt_eval_duration,omitempty"`
+	EvalCount          int     `json:"eval_count,omitempty"`
+	EvalDuration       int64   `json:"eval_duration,omitempty"`
+	Error              string  `json:"error,omitempty"`
 }
 
 func NewOllamaProvider(cfg config.ProviderConfig, client *http.Client) Provider {
@@ -61,29 +84,32 @@ func NewOllamaProvider(cfg config.ProviderConfig, client *http.Client) Provider
 	}
 }
 
+// marshal encodes a chat request with keep_alive, num_ctx and the options
+// from config.
+func (p *OllamaProvider) marshal(req ollamaRequest) ([]byte, error) {
+	req.KeepAlive = p.config.KeepAlive
+	req.Options.NumCtx = p.config.NumCtx
+	req.Options.Extra = p.config.Options
+	return json.Marshal(req)
+}
+
 func (p *OllamaProvider) Translate(ctx context.Context, req TranslateRequest) (TranslateResponse, error) {
-	systemPrompt := fmt.Sprintf(
-		"You are a professional translator. Translate the following text from %s to %s. "+
-			"Preserve the original formatting and structure. "+
-			"Output only the translation without explanations.",
-		req.SourceLang, req.TargetLang,
-	)
-
-	if req.SourceLang == "auto" {
-		systemPrompt = fmt.Sprintf(
-			"You are a professional translator. Detect the source language and translate the text to %s. "+
-				"Preserve the original formatting and structure. "+
-				"Output only the translation without explanations.",
-			req.TargetLang,
-		)
-	}
+	systemPrompt := p.systemPrompt(req)
 
 	fullPrompt := p.buildPrompt(req, systemPrompt)
 
 	ollamaReq := ollamaRequest{
-		Model:  p.config.Model,
-		System: fullPrompt,
-		Prompt: req.Text,
+		Model: p.config.Model,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: fullPrompt,
+			},
+			{
+				Role:    "user",
+				Content: req.Text,
+			},
+		},
 		Stream: false,
 		Options: ollamaOptions{
 			Temperature: req.Temperature,
@@ -91,12 +117,14 @@ func (p *OllamaProvider) Translate(ctx context.Context, req TranslateRequest) (T
 		},
 	}
 
-	jsonData, err := json.Marshal(ollamaReq)
+	ollamaReq.Messages = continuationMessages(ollamaReq.Messages, req.Partial)
+
+	jsonData, err := p.marshal(ollamaReq)
 	if err != nil {
 		return TranslateResponse{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/generate"
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/chat"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return TranslateResponse{}, fmt.Errorf("failed to create request: %w", err)
@@ -120,6 +148,8 @@ func (p *OllamaProvider) Translate(ctx context.Context, req TranslateRequest) (T
 		return TranslateResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(ollamaResp.PromptEvalCount, ollamaResp.EvalCount)
+
 	if ollamaResp.Error != "" {
 		return TranslateResponse{}, fmt.Errorf("Ollama API error: %s", ollamaResp.Error)
 	}
@@ -134,8 +164,13 @@ func (p *OllamaProvider) Translate(ctx context.Context, req TranslateRequest) (T
 	}
 
 	return TranslateResponse{
-		Text:       ollamaResp.Response,
+		Text:       ollamaResp.Message.Content,
 		TokensUsed: tokensUsed,
+		Truncated:  ollamaResp.DoneReason == "length",
+		Meta: ResponseMeta{
+			Model:        ollamaResp.Model,
+			FinishReason: ollamaResp.DoneReason,
+		},
 	}, nil
 }
 
@@ -148,9 +183,17 @@ func (p *OllamaProvider) ValidateConfig() error {
 
 func (p *OllamaProvider) AnalyzeSentiment(ctx context.Context, text string) (SentimentResponse, error) {
 	ollamaReq := ollamaRequest{
-		Model:  p.config.Model,
-		System: SentimentPrompt,
-		Prompt: text,
+		Model: p.config.Model,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: SentimentPrompt,
+			},
+			{
+				Role:    "user",
+				Content: text,
+			},
+		},
 		Stream: false,
 		Options: ollamaOptions{
 			Temperature: 0.1,
@@ -158,12 +201,12 @@ func (p *OllamaProvider) AnalyzeSentiment(ctx context.Context, text string) (Sen
 		},
 	}
 
-	jsonData, err := json.Marshal(ollamaReq)
+	jsonData, err := p.marshal(ollamaReq)
 	if err != nil {
 		return SentimentResponse{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/generate"
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/chat"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return SentimentResponse{}, fmt.Errorf("failed to create request: %w", err)
@@ -187,20 +230,30 @@ func (p *OllamaProvider) AnalyzeSentiment(ctx context.Context, text string) (Sen
 		return SentimentResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(ollamaResp.PromptEvalCount, ollamaResp.EvalCount)
+
 	if ollamaResp.Error != "" {
 		return SentimentResponse{}, fmt.Errorf("Ollama API error: %s", ollamaResp.Error)
 	}
 
-	return ParseSentimentResponse(ollamaResp.Response)
+	return ParseSentimentResponse(ollamaResp.Message.Content)
 }
 
 func (p *OllamaProvider) ExtractTags(ctx context.Context, text string, count int) (TagsResponse, error) {
 	tagsPrompt := fmt.Sprintf(TagsPromptTemplate, count)
 
 	ollamaReq := ollamaRequest{
-		Model:  p.config.Model,
-		System: tagsPrompt,
-		Prompt: text,
+		Model: p.config.Model,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: tagsPrompt,
+			},
+			{
+				Role:    "user",
+				Content: text,
+			},
+		},
 		Stream: false,
 		Options: ollamaOptions{
 			Temperature: 0.3,
@@ -208,12 +261,12 @@ func (p *OllamaProvider) ExtractTags(ctx context.Context, text string, count int
 		},
 	}
 
-	jsonData, err := json.Marshal(ollamaReq)
+	jsonData, err := p.marshal(ollamaReq)
 	if err != nil {
 		return TagsResponse{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/generate"
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/chat"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return TagsResponse{}, fmt.Errorf("failed to create request: %w", err)
@@ -237,18 +290,28 @@ func (p *OllamaProvider) ExtractTags(ctx context.Context, text string, count int
 		return TagsResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(ollamaResp.PromptEvalCount, ollamaResp.EvalCount)
+
 	if ollamaResp.Error != "" {
 		return TagsResponse{}, fmt.Errorf("Ollama API error: %s", ollamaResp.Error)
 	}
 
-	return ParseTagsResponse(ollamaResp.Response)
+	return ParseTagsResponse(ollamaResp.Message.Content)
 }
 
 func (p *OllamaProvider) Classify(ctx context.Context, text string) (ClassifyResponse, error) {
 	ollamaReq := ollamaRequest{
-		Model:  p.config.Model,
-		System: ClassifyPrompt,
-		Prompt: text,
+		Model: p.config.Model,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: ClassifyPrompt,
+			},
+			{
+				Role:    "user",
+				Content: text,
+			},
+		},
 		Stream: false,
 		Options: ollamaOptions{
 			Temperature: 0.1,
@@ -256,12 +319,12 @@ func (p *OllamaProvider) Classify(ctx context.Context, text string) (ClassifyRes
 		},
 	}
 
-	jsonData, err := json.Marshal(ollamaReq)
+	jsonData, err := p.marshal(ollamaReq)
 	if err != nil {
 		return ClassifyResponse{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/generate"
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/chat"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return ClassifyResponse{}, fmt.Errorf("failed to create request: %w", err)
@@ -285,18 +348,28 @@ func (p *OllamaProvider) Classify(ctx context.Context, text string) (ClassifyRes
 		return ClassifyResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(ollamaResp.PromptEvalCount, ollamaResp.EvalCount)
+
 	if ollamaResp.Error != "" {
 		return ClassifyResponse{}, fmt.Errorf("Ollama API error: %s", ollamaResp.Error)
 	}
 
-	return ParseClassifyResponse(ollamaResp.Response)
+	return ParseClassifyResponse(ollamaResp.Message.Content)
 }
 
 func (p *OllamaProvider) AnalyzeEmotions(ctx context.Context, text string) (EmotionsResponse, error) {
 	ollamaReq := ollamaRequest{
-		Model:  p.config.Model,
-		System: EmotionsPrompt,
-		Prompt: text,
+		Model: p.config.Model,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: EmotionsPrompt,
+			},
+			{
+				Role:    "user",
+				Content: text,
+			},
+		},
 		Stream: false,
 		Options: ollamaOptions{
 			Temperature: 0.1,
@@ -304,12 +377,12 @@ func (p *OllamaProvider) AnalyzeEmotions(ctx context.Context, text string) (Emot
 		},
 	}
 
-	jsonData, err := json.Marshal(ollamaReq)
+	jsonData, err := p.marshal(ollamaReq)
 	if err != nil {
 		return EmotionsResponse{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/generate"
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/chat"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return EmotionsResponse{}, fmt.Errorf("failed to create request: %w", err)
@@ -333,18 +406,28 @@ func (p *OllamaProvider) AnalyzeEmotions(ctx context.Context, text string) (Emot
 		return EmotionsResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(ollamaResp.PromptEvalCount, ollamaResp.EvalCount)
+
 	if ollamaResp.Error != "" {
 		return EmotionsResponse{}, fmt.Errorf("Ollama API error: %s", ollamaResp.Error)
 	}
 
-	return ParseEmotionsResponse(ollamaResp.Response)
+	return ParseEmotionsResponse(ollamaResp.Message.Content)
 }
 
 func (p *OllamaProvider) AnalyzeFactuality(ctx context.Context, text string) (FactualityResponse, error) {
 	ollamaReq := ollamaRequest{
-		Model:  p.config.Model,
-		System: FactualityPrompt,
-		Prompt: text,
+		Model: p.config.Model,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: FactualityPrompt,
+			},
+			{
+				Role:    "user",
+				Content: text,
+			},
+		},
 		Stream: false,
 		Options: ollamaOptions{
 			Temperature: 0.1,
@@ -352,12 +435,12 @@ func (p *OllamaProvider) AnalyzeFactuality(ctx context.Context, text string) (Fa
 		},
 	}
 
-	jsonData, err := json.Marshal(ollamaReq)
+	jsonData, err := p.marshal(ollamaReq)
 	if err != nil {
 		return FactualityResponse{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/generate"
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/chat"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return FactualityResponse{}, fmt.Errorf("failed to create request: %w", err)
@@ -381,18 +464,28 @@ func (p *OllamaProvider) AnalyzeFactuality(ctx context.Context, text string) (Fa
 		return FactualityResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(ollamaResp.PromptEvalCount, ollamaResp.EvalCount)
+
 	if ollamaResp.Error != "" {
 		return FactualityResponse{}, fmt.Errorf("Ollama API error: %s", ollamaResp.Error)
 	}
 
-	return ParseFactualityResponse(ollamaResp.Response)
+	return ParseFactualityResponse(ollamaResp.Message.Content)
 }
 
 func (p *OllamaProvider) AnalyzeImpact(ctx context.Context, text string) (ImpactResponse, error) {
 	ollamaReq := ollamaRequest{
-		Model:  p.config.Model,
-		System: ImpactPrompt,
-		Prompt: text,
+		Model: p.config.Model,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: ImpactPrompt,
+			},
+			{
+				Role:    "user",
+				Content: text,
+			},
+		},
 		Stream: false,
 		Options: ollamaOptions{
 			Temperature: 0.1,
@@ -400,12 +493,12 @@ func (p *OllamaProvider) AnalyzeImpact(ctx context.Context, text string) (Impact
 		},
 	}
 
-	jsonData, err := json.Marshal(ollamaReq)
+	jsonData, err := p.marshal(ollamaReq)
 	if err != nil {
 		return ImpactResponse{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/generate"
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/chat"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return ImpactResponse{}, fmt.Errorf("failed to create request: %w", err)
@@ -429,18 +522,28 @@ func (p *OllamaProvider) AnalyzeImpact(ctx context.Context, text string) (Impact
 		return ImpactResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(ollamaResp.PromptEvalCount, ollamaResp.EvalCount)
+
 	if ollamaResp.Error != "" {
 		return ImpactResponse{}, fmt.Errorf("Ollama API error: %s", ollamaResp.Error)
 	}
 
-	return ParseImpactResponse(ollamaResp.Response)
+	return ParseImpactResponse(ollamaResp.Message.Content)
 }
 
 func (p *OllamaProvider) AnalyzeSensationalism(ctx context.Context, text string) (SensationalismResponse, error) {
 	ollamaReq := ollamaRequest{
-		Model:  p.config.Model,
-		System: SensationalismPrompt,
-		Prompt: text,
+		Model: p.config.Model,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: SensationalismPrompt,
+			},
+			{
+				Role:    "user",
+				Content: text,
+			},
+		},
 		Stream: false,
 		Options: ollamaOptions{
 			Temperature: 0.1,
@@ -448,12 +551,12 @@ func (p *OllamaProvider) AnalyzeSensationalism(ctx context.Context, text string)
 		},
 	}
 
-	jsonData, err := json.Marshal(ollamaReq)
+	jsonData, err := p.marshal(ollamaReq)
 	if err != nil {
 		return SensationalismResponse{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/generate"
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/chat"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return SensationalismResponse{}, fmt.Errorf("failed to create request: %w", err)
@@ -477,18 +580,28 @@ func (p *OllamaProvider) AnalyzeSensationalism(ctx context.Context, text string)
 		return SensationalismResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(ollamaResp.PromptEvalCount, ollamaResp.EvalCount)
+
 	if ollamaResp.Error != "" {
 		return SensationalismResponse{}, fmt.Errorf("Ollama API error: %s", ollamaResp.Error)
 	}
 
-	return ParseSensationalismResponse(ollamaResp.Response)
+	return ParseSensationalismResponse(ollamaResp.Message.Content)
 }
 
 func (p *OllamaProvider) ExtractEntities(ctx context.Context, text string) (EntitiesResponse, error) {
 	ollamaReq := ollamaRequest{
-		Model:  p.config.Model,
-		System: EntitiesPrompt,
-		Prompt: text,
+		Model: p.config.Model,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: EntitiesPrompt,
+			},
+			{
+				Role:    "user",
+				Content: text,
+			},
+		},
 		Stream: false,
 		Options: ollamaOptions{
 			Temperature: 0.1,
@@ -496,12 +609,12 @@ func (p *OllamaProvider) ExtractEntities(ctx context.Context, text string) (Enti
 		},
 	}
 
-	jsonData, err := json.Marshal(ollamaReq)
+	jsonData, err := p.marshal(ollamaReq)
 	if err != nil {
 		return EntitiesResponse{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/generate"
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/chat"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return EntitiesResponse{}, fmt.Errorf("failed to create request: %w", err)
@@ -525,18 +638,28 @@ func (p *OllamaProvider) ExtractEntities(ctx context.Context, text string) (Enti
 		return EntitiesResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(ollamaResp.PromptEvalCount, ollamaResp.EvalCount)
+
 	if ollamaResp.Error != "" {
 		return EntitiesResponse{}, fmt.Errorf("Ollama API error: %s", ollamaResp.Error)
 	}
 
-	return ParseEntitiesResponse(ollamaResp.Response)
+	return ParseEntitiesResponse(ollamaResp.Message.Content)
 }
 
 func (p *OllamaProvider) ExtractEvents(ctx context.Context, text string) (EventsResponse, error) {
 	ollamaReq := ollamaRequest{
-		Model:  p.config.Model,
-		System: EventsPrompt,
-		Prompt: text,
+		Model: p.config.Model,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: EventsPrompt,
+			},
+			{
+				Role:    "user",
+				Content: text,
+			},
+		},
 		Stream: false,
 		Options: ollamaOptions{
 			Temperature: 0.1,
@@ -544,12 +667,12 @@ func (p *OllamaProvider) ExtractEvents(ctx context.Context, text string) (Events
 		},
 	}
 
-	jsonData, err := json.Marshal(ollamaReq)
+	jsonData, err := p.marshal(ollamaReq)
 	if err != nil {
 		return EventsResponse{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/generate"
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/chat"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return EventsResponse{}, fmt.Errorf("failed to create request: %w", err)
@@ -573,18 +696,28 @@ func (p *OllamaProvider) ExtractEvents(ctx context.Context, text string) (Events
 		return EventsResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(ollamaResp.PromptEvalCount, ollamaResp.EvalCount)
+
 	if ollamaResp.Error != "" {
 		return EventsResponse{}, fmt.Errorf("Ollama API error: %s", ollamaResp.Error)
 	}
 
-	return ParseEventsResponse(ollamaResp.Response)
+	return ParseEventsResponse(ollamaResp.Message.Content)
 }
 
 func (p *OllamaProvider) AnalyzeUsefulness(ctx context.Context, text string) (UsefulnessResponse, error) {
 	ollamaReq := ollamaRequest{
-		Model:  p.config.Model,
-		System: UsefulnessPrompt,
-		Prompt: text,
+		Model: p.config.Model,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: UsefulnessPrompt,
+			},
+			{
+				Role:    "user",
+				Content: text,
+			},
+		},
 		Stream: false,
 		Options: ollamaOptions{
 			Temperature: 0.1,
@@ -592,12 +725,12 @@ func (p *OllamaProvider) AnalyzeUsefulness(ctx context.Context, text string) (Us
 		},
 	}
 
-	jsonData, err := json.Marshal(ollamaReq)
+	jsonData, err := p.marshal(ollamaReq)
 	if err != nil {
 		return UsefulnessResponse{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/generate"
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/chat"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return UsefulnessResponse{}, fmt.Errorf("failed to create request: %w", err)
@@ -621,18 +754,28 @@ func (p *OllamaProvider) AnalyzeUsefulness(ctx context.Context, text string) (Us
 		return UsefulnessResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(ollamaResp.PromptEvalCount, ollamaResp.EvalCount)
+
 	if ollamaResp.Error != "" {
 		return UsefulnessResponse{}, fmt.Errorf("Ollama API error: %s", ollamaResp.Error)
 	}
 
-	return ParseUsefulnessResponse(ollamaResp.Response)
+	return ParseUsefulnessResponse(ollamaResp.Message.Content)
 }
 
 func (p *OllamaProvider) AnalyzeAdDetect(ctx context.Context, text string) (AdDetectResponse, error) {
 	ollamaReq := ollamaRequest{
-		Model:  p.config.Model,
-		System: AdDetectPrompt,
-		Prompt: text,
+		Model: p.config.Model,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: AdDetectPrompt,
+			},
+			{
+				Role:    "user",
+				Content: text,
+			},
+		},
 		Stream: false,
 		Options: ollamaOptions{
 			Temperature: 0.1,
@@ -640,12 +783,12 @@ func (p *OllamaProvider) AnalyzeAdDetect(ctx context.Context, text string) (AdDe
 		},
 	}
 
-	jsonData, err := json.Marshal(ollamaReq)
+	jsonData, err := p.marshal(ollamaReq)
 	if err != nil {
 		return AdDetectResponse{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/generate"
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/chat"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return AdDetectResponse{}, fmt.Errorf("failed to create request: %w", err)
@@ -669,18 +812,28 @@ func (p *OllamaProvider) AnalyzeAdDetect(ctx context.Context, text string) (AdDe
 		return AdDetectResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(ollamaResp.PromptEvalCount, ollamaResp.EvalCount)
+
 	if ollamaResp.Error != "" {
 		return AdDetectResponse{}, fmt.Errorf("Ollama API error: %s", ollamaResp.Error)
 	}
 
-	return ParseAdDetectResponse(ollamaResp.Response)
+	return ParseAdDetectResponse(ollamaResp.Message.Content)
 }
 
 func (p *OllamaProvider) AnalyzeTimeFocus(ctx context.Context, text string) (TimeFocusResponse, error) {
 	ollamaReq := ollamaRequest{
-		Model:  p.config.Model,
-		System: TimeFocusPrompt,
-		Prompt: text,
+		Model: p.config.Model,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: TimeFocusPrompt,
+			},
+			{
+				Role:    "user",
+				Content: text,
+			},
+		},
 		Stream: false,
 		Options: ollamaOptions{
 			Temperature: 0.1,
@@ -688,12 +841,12 @@ func (p *OllamaProvider) AnalyzeTimeFocus(ctx context.Context, text string) (Tim
 		},
 	}
 
-	jsonData, err := json.Marshal(ollamaReq)
+	jsonData, err := p.marshal(ollamaReq)
 	if err != nil {
 		return TimeFocusResponse{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/generate"
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/chat"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return TimeFocusResponse{}, fmt.Errorf("failed to create request: %w", err)
@@ -717,20 +870,30 @@ func (p *OllamaProvider) AnalyzeTimeFocus(ctx context.Context, text string) (Tim
 		return TimeFocusResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(ollamaResp.PromptEvalCount, ollamaResp.EvalCount)
+
 	if ollamaResp.Error != "" {
 		return TimeFocusResponse{}, fmt.Errorf("Ollama API error: %s", ollamaResp.Error)
 	}
 
-	return ParseTimeFocusResponse(ollamaResp.Response)
+	return ParseTimeFocusResponse(ollamaResp.Message.Content)
 }
 
 func (p *OllamaProvider) AnalyzeCombined(ctx context.Context, req CombinedAnalysisRequest) (CombinedAnalysisResponse, error) {
 	prompt := BuildCombinedPrompt(req)
 
 	ollamaReq := ollamaRequest{
-		Model:  p.config.Model,
-		System: prompt,
-		Prompt: req.Text,
+		Model: p.config.Model,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: prompt,
+			},
+			{
+				Role:    "user",
+				Content: req.Text,
+			},
+		},
 		Stream: false,
 		Options: ollamaOptions{
 			Temperature: 0.2,
@@ -738,12 +901,16 @@ func (p *OllamaProvider) AnalyzeCombined(ctx context.Context, req CombinedAnalys
 		},
 	}
 
-	jsonData, err := json.Marshal(ollamaReq)
+	if req.JSON {
+		ollamaReq.Format = "json"
+	}
+
+	jsonData, err := p.marshal(ollamaReq)
 	if err != nil {
 		return CombinedAnalysisResponse{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/generate"
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/chat"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return CombinedAnalysisResponse{}, fmt.Errorf("failed to create request: %w", err)
@@ -767,11 +934,71 @@ func (p *OllamaProvider) AnalyzeCombined(ctx context.Context, req CombinedAnalys
 		return CombinedAnalysisResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(ollamaResp.PromptEvalCount, ollamaResp.EvalCount)
+
 	if ollamaResp.Error != "" {
 		return CombinedAnalysisResponse{}, fmt.Errorf("Ollama API error: %s", ollamaResp.Error)
 	}
 
-	return ParseCombinedResponse(ollamaResp.Response, req), nil
+	return ParseCombinedResponse(ollamaResp.Message.Content, req), nil
+}
+
+func (p *OllamaProvider) GenerateHeadline(ctx context.Context, text string) (HeadlineResponse, error) {
+	ollamaReq := ollamaRequest{
+		Model: p.config.Model,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: HeadlinePrompt,
+			},
+			{
+				Role:    "user",
+				Content: text,
+			},
+		},
+		Stream: false,
+		Options: ollamaOptions{
+			Temperature: 0.3,
+			NumPredict:  200,
+		},
+	}
+
+	jsonData, err := p.marshal(ollamaReq)
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/chat"
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var ollamaResp ollamaResponse
+	if err := json.Unmarshal(body, &ollamaResp); err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	p.record(ollamaResp.PromptEvalCount, ollamaResp.EvalCount)
+
+	if ollamaResp.Error != "" {
+		return HeadlineResponse{}, fmt.Errorf("Ollama API error: %s", ollamaResp.Error)
+	}
+
+	return ParseHeadlineResponse(ollamaResp.Message.Content)
 }
 
 func init() {
@@ -17,10 +17,69 @@ type OpenAIProvider struct {
 }
 
 type openAIRequest struct {
-	Model       string    `json:"model"`
-	Messages    []message `json:"messages"`
-	Temperature float64   `json:"temperature"`
-	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Model          string          `json:"model"`
+	Messages       []message       `json:"messages"`
+	Temperature    float64         `json:"temperature"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+// reasoningHeadroom is added to max tokens for reasoning models, where
+// max_completion_tokens also covers the hidden reasoning. Without it a small
+// limit can be used up before any answer is written.
+const reasoningHeadroom = 8192
+
+// isReasoningModel reports whether model is an o-series or GPT-5 model,
+// also with a vendor prefix like "openai/o3" used by gateways. These reject
+// temperature and max_tokens in chat completions.
+func isReasoningModel(model string) bool {
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	model = strings.ToLower(model)
+	for _, prefix := range []string{"o1", "o3", "o4", "gpt-5"} {
+		if rest, ok := strings.CutPrefix(model, prefix); ok && (rest == "" || rest[0] == '-') {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON sends max_completion_tokens instead of max_tokens and drops
+// temperature for reasoning models, so requests built for chat models work
+// with them unchanged.
+func (r openAIRequest) MarshalJSON() ([]byte, error) {
+	type plain openAIRequest
+	if !isReasoningModel(r.Model) {
+		return json.Marshal(plain(r))
+	}
+
+	completionTokens := 0
+	if r.MaxTokens > 0 {
+		completionTokens = r.MaxTokens + reasoningHeadroom
+		if limit := LookupModelLimits(r.Model).MaxOutput; limit > 0 {
+			completionTokens = min(completionTokens, limit)
+		}
+	}
+	return json.Marshal(struct {
+		plain
+		Temperature         *float64 `json:"temperature,omitempty"`
+		MaxTokens           int      `json:"max_tokens,omitempty"`
+		MaxCompletionTokens int      `json:"max_completion_tokens,omitempty"`
+	}{plain: plain(r), MaxCompletionTokens: completionTokens})
+}
+
+// responseFormat enables JSON mode in OpenAI-compatible APIs, or
+// structured output with a JSON schema.
+type responseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *jsonSchema `json:"json_schema,omitempty"`
+}
+
+type jsonSchema struct {
+	Name   string                 `json:"name"`
+	Strict bool                   `json:"strict"`
+	Schema map[string]interface{} `json:"schema"`
 }
 
 type message struct {
@@ -74,21 +133,7 @@ func NewOpenAIProvider(cfg config.ProviderConfig, client *http.Client) Provider
 }
 
 func (p *OpenAIProvider) Translate(ctx context.Context, req TranslateRequest) (TranslateResponse, error) {
-	systemPrompt := fmt.Sprintf(
-		"You are a professional translator. Translate the following text from %s to %s. "+
-			"Preserve the original formatting and structure. "+
-			"Output only the translation without explanations.",
-		req.SourceLang, req.TargetLang,
-	)
-
-	if req.SourceLang == "auto" {
-		systemPrompt = fmt.Sprintf(
-			"You are a professional translator. Detect the source language and translate the text to %s. "+
-				"Preserve the original formatting and structure. "+
-				"Output only the translation without explanations.",
-			req.TargetLang,
-		)
-	}
+	systemPrompt := p.systemPrompt(req)
 
 	fullPrompt := p.buildPrompt(req, systemPrompt)
 
@@ -108,6 +153,8 @@ func (p *OpenAIProvider) Translate(ctx context.Context, req TranslateRequest) (T
 		},
 	}
 
+	openAIReq.Messages = continuationMessages(openAIReq.Messages, req.Partial)
+
 	jsonData, err := json.Marshal(openAIReq)
 	if err != nil {
 		return TranslateResponse{}, fmt.Errorf("failed to marshal request: %w", err)
@@ -138,6 +185,8 @@ func (p *OpenAIProvider) Translate(ctx context.Context, req TranslateRequest) (T
 		return TranslateResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openAIResp.Usage.PromptTokens, openAIResp.Usage.CompletionTokens)
+
 	if openAIResp.Error != nil {
 		return TranslateResponse{}, fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
 	}
@@ -153,6 +202,12 @@ func (p *OpenAIProvider) Translate(ctx context.Context, req TranslateRequest) (T
 	return TranslateResponse{
 		Text:       openAIResp.Choices[0].Message.Content,
 		TokensUsed: openAIResp.Usage.TotalTokens,
+		Truncated:  openAIResp.Choices[0].FinishReason == "length",
+		Meta: ResponseMeta{
+			ID:           openAIResp.ID,
+			Model:        openAIResp.Model,
+			FinishReason: openAIResp.Choices[0].FinishReason,
+		},
 	}, nil
 }
 
@@ -203,6 +258,8 @@ func (p *OpenAIProvider) AnalyzeSentiment(ctx context.Context, text string) (Sen
 		return SentimentResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openAIResp.Usage.PromptTokens, openAIResp.Usage.CompletionTokens)
+
 	if openAIResp.Error != nil {
 		return SentimentResponse{}, fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
 	}
@@ -263,6 +320,8 @@ func (p *OpenAIProvider) ExtractTags(ctx context.Context, text string, count int
 		return TagsResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openAIResp.Usage.PromptTokens, openAIResp.Usage.CompletionTokens)
+
 	if openAIResp.Error != nil {
 		return TagsResponse{}, fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
 	}
@@ -321,6 +380,8 @@ func (p *OpenAIProvider) Classify(ctx context.Context, text string) (ClassifyRes
 		return ClassifyResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openAIResp.Usage.PromptTokens, openAIResp.Usage.CompletionTokens)
+
 	if openAIResp.Error != nil {
 		return ClassifyResponse{}, fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
 	}
@@ -379,6 +440,8 @@ func (p *OpenAIProvider) AnalyzeEmotions(ctx context.Context, text string) (Emot
 		return EmotionsResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openAIResp.Usage.PromptTokens, openAIResp.Usage.CompletionTokens)
+
 	if openAIResp.Error != nil {
 		return EmotionsResponse{}, fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
 	}
@@ -437,6 +500,8 @@ func (p *OpenAIProvider) AnalyzeFactuality(ctx context.Context, text string) (Fa
 		return FactualityResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openAIResp.Usage.PromptTokens, openAIResp.Usage.CompletionTokens)
+
 	if openAIResp.Error != nil {
 		return FactualityResponse{}, fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
 	}
@@ -495,6 +560,8 @@ func (p *OpenAIProvider) AnalyzeImpact(ctx context.Context, text string) (Impact
 		return ImpactResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openAIResp.Usage.PromptTokens, openAIResp.Usage.CompletionTokens)
+
 	if openAIResp.Error != nil {
 		return ImpactResponse{}, fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
 	}
@@ -553,6 +620,8 @@ func (p *OpenAIProvider) AnalyzeSensationalism(ctx context.Context, text string)
 		return SensationalismResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openAIResp.Usage.PromptTokens, openAIResp.Usage.CompletionTokens)
+
 	if openAIResp.Error != nil {
 		return SensationalismResponse{}, fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
 	}
@@ -611,6 +680,8 @@ func (p *OpenAIProvider) ExtractEntities(ctx context.Context, text string) (Enti
 		return EntitiesResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openAIResp.Usage.PromptTokens, openAIResp.Usage.CompletionTokens)
+
 	if openAIResp.Error != nil {
 		return EntitiesResponse{}, fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
 	}
@@ -669,6 +740,8 @@ func (p *OpenAIProvider) ExtractEvents(ctx context.Context, text string) (Events
 		return EventsResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openAIResp.Usage.PromptTokens, openAIResp.Usage.CompletionTokens)
+
 	if openAIResp.Error != nil {
 		return EventsResponse{}, fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
 	}
@@ -727,6 +800,8 @@ func (p *OpenAIProvider) AnalyzeUsefulness(ctx context.Context, text string) (Us
 		return UsefulnessResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openAIResp.Usage.PromptTokens, openAIResp.Usage.CompletionTokens)
+
 	if openAIResp.Error != nil {
 		return UsefulnessResponse{}, fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
 	}
@@ -785,6 +860,8 @@ func (p *OpenAIProvider) AnalyzeAdDetect(ctx context.Context, text string) (AdDe
 		return AdDetectResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openAIResp.Usage.PromptTokens, openAIResp.Usage.CompletionTokens)
+
 	if openAIResp.Error != nil {
 		return AdDetectResponse{}, fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
 	}
@@ -843,6 +920,8 @@ func (p *OpenAIProvider) AnalyzeTimeFocus(ctx context.Context, text string) (Tim
 		return TimeFocusResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openAIResp.Usage.PromptTokens, openAIResp.Usage.CompletionTokens)
+
 	if openAIResp.Error != nil {
 		return TimeFocusResponse{}, fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
 	}
@@ -867,6 +946,15 @@ func (p *OpenAIProvider) AnalyzeCombined(ctx context.Context, req CombinedAnalys
 		},
 	}
 
+	if req.Schema {
+		openAIReq.ResponseFormat = &responseFormat{
+			Type:       "json_schema",
+			JSONSchema: &jsonSchema{Name: "analysis", Strict: true, Schema: combinedJSONSchema(req)},
+		}
+	} else if req.JSON {
+		openAIReq.ResponseFormat = &responseFormat{Type: "json_object"}
+	}
+
 	jsonData, err := json.Marshal(openAIReq)
 	if err != nil {
 		return CombinedAnalysisResponse{}, fmt.Errorf("failed to marshal request: %w", err)
@@ -897,6 +985,8 @@ func (p *OpenAIProvider) AnalyzeCombined(ctx context.Context, req CombinedAnalys
 		return CombinedAnalysisResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openAIResp.Usage.PromptTokens, openAIResp.Usage.CompletionTokens)
+
 	if openAIResp.Error != nil {
 		return CombinedAnalysisResponse{}, fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
 	}
@@ -908,6 +998,66 @@ func (p *OpenAIProvider) AnalyzeCombined(ctx context.Context, req CombinedAnalys
 	return ParseCombinedResponse(openAIResp.Choices[0].Message.Content, req), nil
 }
 
+func (p *OpenAIProvider) GenerateHeadline(ctx context.Context, text string) (HeadlineResponse, error) {
+	openAIReq := openAIRequest{
+		Model:       p.config.Model,
+		Temperature: 0.3,
+		MaxTokens:   200,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: HeadlinePrompt,
+			},
+			{
+				Role:    "user",
+				Content: text,
+			},
+		},
+	}
+
+	jsonData, err := json.Marshal(openAIReq)
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/chat/completions"
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var openAIResp openAIResponse
+	if err := json.Unmarshal(body, &openAIResp); err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	p.record(openAIResp.Usage.PromptTokens, openAIResp.Usage.CompletionTokens)
+
+	if openAIResp.Error != nil {
+		return HeadlineResponse{}, fmt.Errorf("OpenAI API error: %s", openAIResp.Error.Message)
+	}
+
+	if len(openAIResp.Choices) == 0 {
+		return HeadlineResponse{}, fmt.Errorf("no choices in response")
+	}
+
+	return ParseHeadlineResponse(openAIResp.Choices[0].Message.Content)
+}
+
 func init() {
 	Register("openai", NewOpenAIProvider)
 }
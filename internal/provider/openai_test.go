@@ -0,0 +1,26 @@
+package provider
+
+import "testing"
+
+func TestIsReasoningModel(t *testing.T) {
+	tests := []struct {
+		model string
+		want  bool
+	}{
+		{"o3", true},
+		{"o4-mini", true},
+		{"gpt-5-mini", true},
+		{"openai/o3", true},
+		{"openai/gpt-5-mini", true},
+		{"OpenAI/O1-Preview", true},
+		{"gpt-4o", false},
+		{"openai/gpt-4o-mini", false},
+		{"o3x", false},
+		{"meta-llama/llama-3.1-8b", false},
+	}
+	for _, tt := range tests {
+		if got := isReasoningModel(tt.model); got != tt.want {
+			t.Errorf("isReasoningModel(%q) = %v, want %v", tt.model, got, tt.want)
+		}
+	}
+}
@@ -17,12 +17,13 @@ type OpenRouterProvider struct {
 }
 
 type openRouterRequest struct {
-	Model       string    `json:"model"`
-	Messages    []message `json:"messages"`
-	Temperature float64   `json:"temperature,omitempty"`
-	MaxTokens   int       `json:"max_tokens,omitempty"`
-	TopP        float64   `json:"top_p,omitempty"`
-	Stream      bool      `json:"stream"`
+	Model          string          `json:"model"`
+	Messages       []message       `json:"messages"`
+	Temperature    float64         `json:"temperature,omitempty"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	TopP           float64         `json:"top_p,omitempty"`
+	Stream         bool            `json:"stream"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
 }
 
 type openRouterResponse struct {
@@ -61,21 +62,7 @@ func NewOpenRouterProvider(cfg config.ProviderConfig, client *http.Client) Provi
 }
 
 func (p *OpenRouterProvider) Translate(ctx context.Context, req TranslateRequest) (TranslateResponse, error) {
-	systemPrompt := fmt.Sprintf(
-		"You are a professional translator. Translate the following text from %s to %s. "+
-			"Preserve the original formatting and structure. "+
-			"Output only the translation without explanations.",
-		req.SourceLang, req.TargetLang,
-	)
-
-	if req.SourceLang == "auto" {
-		systemPrompt = fmt.Sprintf(
-			"You are a professional translator. Detect the source language and translate the text to %s. "+
-				"Preserve the original formatting and structure. "+
-				"Output only the translation without explanations.",
-			req.TargetLang,
-		)
-	}
+	systemPrompt := p.systemPrompt(req)
 
 	fullPrompt := p.buildPrompt(req, systemPrompt)
 
@@ -96,6 +83,8 @@ func (p *OpenRouterProvider) Translate(ctx context.Context, req TranslateRequest
 		},
 	}
 
+	openRouterReq.Messages = continuationMessages(openRouterReq.Messages, req.Partial)
+
 	jsonData, err := json.Marshal(openRouterReq)
 	if err != nil {
 		return TranslateResponse{}, fmt.Errorf("failed to marshal request: %w", err)
@@ -128,6 +117,8 @@ func (p *OpenRouterProvider) Translate(ctx context.Context, req TranslateRequest
 		return TranslateResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openRouterResp.Usage.PromptTokens, openRouterResp.Usage.CompletionTokens)
+
 	if openRouterResp.Error != nil {
 		return TranslateResponse{}, fmt.Errorf("OpenRouter API error: %s", openRouterResp.Error.Message)
 	}
@@ -143,6 +134,12 @@ func (p *OpenRouterProvider) Translate(ctx context.Context, req TranslateRequest
 	return TranslateResponse{
 		Text:       openRouterResp.Choices[0].Message.Content,
 		TokensUsed: openRouterResp.Usage.TotalTokens,
+		Truncated:  openRouterResp.Choices[0].FinishReason == "length",
+		Meta: ResponseMeta{
+			ID:           openRouterResp.ID,
+			Model:        openRouterResp.Model,
+			FinishReason: openRouterResp.Choices[0].FinishReason,
+		},
 	}, nil
 }
 
@@ -196,6 +193,8 @@ func (p *OpenRouterProvider) AnalyzeSentiment(ctx context.Context, text string)
 		return SentimentResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openRouterResp.Usage.PromptTokens, openRouterResp.Usage.CompletionTokens)
+
 	if openRouterResp.Error != nil {
 		return SentimentResponse{}, fmt.Errorf("OpenRouter API error: %s", openRouterResp.Error.Message)
 	}
@@ -259,6 +258,8 @@ func (p *OpenRouterProvider) ExtractTags(ctx context.Context, text string, count
 		return TagsResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openRouterResp.Usage.PromptTokens, openRouterResp.Usage.CompletionTokens)
+
 	if openRouterResp.Error != nil {
 		return TagsResponse{}, fmt.Errorf("OpenRouter API error: %s", openRouterResp.Error.Message)
 	}
@@ -320,6 +321,8 @@ func (p *OpenRouterProvider) Classify(ctx context.Context, text string) (Classif
 		return ClassifyResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openRouterResp.Usage.PromptTokens, openRouterResp.Usage.CompletionTokens)
+
 	if openRouterResp.Error != nil {
 		return ClassifyResponse{}, fmt.Errorf("OpenRouter API error: %s", openRouterResp.Error.Message)
 	}
@@ -381,6 +384,8 @@ func (p *OpenRouterProvider) AnalyzeEmotions(ctx context.Context, text string) (
 		return EmotionsResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openRouterResp.Usage.PromptTokens, openRouterResp.Usage.CompletionTokens)
+
 	if openRouterResp.Error != nil {
 		return EmotionsResponse{}, fmt.Errorf("OpenRouter API error: %s", openRouterResp.Error.Message)
 	}
@@ -442,6 +447,8 @@ func (p *OpenRouterProvider) AnalyzeFactuality(ctx context.Context, text string)
 		return FactualityResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openRouterResp.Usage.PromptTokens, openRouterResp.Usage.CompletionTokens)
+
 	if openRouterResp.Error != nil {
 		return FactualityResponse{}, fmt.Errorf("OpenRouter API error: %s", openRouterResp.Error.Message)
 	}
@@ -503,6 +510,8 @@ func (p *OpenRouterProvider) AnalyzeImpact(ctx context.Context, text string) (Im
 		return ImpactResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openRouterResp.Usage.PromptTokens, openRouterResp.Usage.CompletionTokens)
+
 	if openRouterResp.Error != nil {
 		return ImpactResponse{}, fmt.Errorf("OpenRouter API error: %s", openRouterResp.Error.Message)
 	}
@@ -564,6 +573,8 @@ func (p *OpenRouterProvider) AnalyzeSensationalism(ctx context.Context, text str
 		return SensationalismResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openRouterResp.Usage.PromptTokens, openRouterResp.Usage.CompletionTokens)
+
 	if openRouterResp.Error != nil {
 		return SensationalismResponse{}, fmt.Errorf("OpenRouter API error: %s", openRouterResp.Error.Message)
 	}
@@ -625,6 +636,8 @@ func (p *OpenRouterProvider) ExtractEntities(ctx context.Context, text string) (
 		return EntitiesResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openRouterResp.Usage.PromptTokens, openRouterResp.Usage.CompletionTokens)
+
 	if openRouterResp.Error != nil {
 		return EntitiesResponse{}, fmt.Errorf("OpenRouter API error: %s", openRouterResp.Error.Message)
 	}
@@ -686,6 +699,8 @@ func (p *OpenRouterProvider) ExtractEvents(ctx context.Context, text string) (Ev
 		return EventsResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openRouterResp.Usage.PromptTokens, openRouterResp.Usage.CompletionTokens)
+
 	if openRouterResp.Error != nil {
 		return EventsResponse{}, fmt.Errorf("OpenRouter API error: %s", openRouterResp.Error.Message)
 	}
@@ -747,6 +762,8 @@ func (p *OpenRouterProvider) AnalyzeUsefulness(ctx context.Context, text string)
 		return UsefulnessResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openRouterResp.Usage.PromptTokens, openRouterResp.Usage.CompletionTokens)
+
 	if openRouterResp.Error != nil {
 		return UsefulnessResponse{}, fmt.Errorf("OpenRouter API error: %s", openRouterResp.Error.Message)
 	}
@@ -808,6 +825,8 @@ func (p *OpenRouterProvider) AnalyzeAdDetect(ctx context.Context, text string) (
 		return AdDetectResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openRouterResp.Usage.PromptTokens, openRouterResp.Usage.CompletionTokens)
+
 	if openRouterResp.Error != nil {
 		return AdDetectResponse{}, fmt.Errorf("OpenRouter API error: %s", openRouterResp.Error.Message)
 	}
@@ -869,6 +888,8 @@ func (p *OpenRouterProvider) AnalyzeTimeFocus(ctx context.Context, text string)
 		return TimeFocusResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openRouterResp.Usage.PromptTokens, openRouterResp.Usage.CompletionTokens)
+
 	if openRouterResp.Error != nil {
 		return TimeFocusResponse{}, fmt.Errorf("OpenRouter API error: %s", openRouterResp.Error.Message)
 	}
@@ -900,6 +921,10 @@ func (p *OpenRouterProvider) AnalyzeCombined(ctx context.Context, req CombinedAn
 		},
 	}
 
+	if req.JSON {
+		openRouterReq.ResponseFormat = &responseFormat{Type: "json_object"}
+	}
+
 	jsonData, err := json.Marshal(openRouterReq)
 	if err != nil {
 		return CombinedAnalysisResponse{}, fmt.Errorf("failed to marshal request: %w", err)
@@ -932,6 +957,8 @@ func (p *OpenRouterProvider) AnalyzeCombined(ctx context.Context, req CombinedAn
 		return CombinedAnalysisResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	p.record(openRouterResp.Usage.PromptTokens, openRouterResp.Usage.CompletionTokens)
+
 	if openRouterResp.Error != nil {
 		return CombinedAnalysisResponse{}, fmt.Errorf("OpenRouter API error: %s", openRouterResp.Error.Message)
 	}
@@ -943,6 +970,69 @@ func (p *OpenRouterProvider) AnalyzeCombined(ctx context.Context, req CombinedAn
 	return ParseCombinedResponse(openRouterResp.Choices[0].Message.Content, req), nil
 }
 
+func (p *OpenRouterProvider) GenerateHeadline(ctx context.Context, text string) (HeadlineResponse, error) {
+	openRouterReq := openRouterRequest{
+		Model:       p.config.Model,
+		Temperature: 0.3,
+		MaxTokens:   200,
+		Stream:      false,
+		Messages: []message{
+			{
+				Role:    "system",
+				Content: HeadlinePrompt,
+			},
+			{
+				Role:    "user",
+				Content: text,
+			},
+		},
+	}
+
+	jsonData, err := json.Marshal(openRouterReq)
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/chat/completions"
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	httpReq.Header.Set("HTTP-Referer", "https://github.com/foxzi/llm-translate")
+	httpReq.Header.Set("X-Title", "LLM Translate CLI")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var openRouterResp openRouterResponse
+	if err := json.Unmarshal(body, &openRouterResp); err != nil {
+		return HeadlineResponse{}, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	p.record(openRouterResp.Usage.PromptTokens, openRouterResp.Usage.CompletionTokens)
+
+	if openRouterResp.Error != nil {
+		return HeadlineResponse{}, fmt.Errorf("OpenRouter API error: %s", openRouterResp.Error.Message)
+	}
+
+	if len(openRouterResp.Choices) == 0 {
+		return HeadlineResponse{}, fmt.Errorf("no choices in response")
+	}
+
+	return ParseHeadlineResponse(openRouterResp.Choices[0].Message.Content)
+}
+
 func init() {
 	Register("openrouter", NewOpenRouterProvider)
 }
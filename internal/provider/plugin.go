@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/foxzi/llm-translate/internal/config"
+)
+
+// templateFields are the request fields given to plugins and template
+// providers.
+type templateFields struct {
+	Text       string `json:"text"`
+	SourceLang string `json:"source_lang"`
+	TargetLang string `json:"target_lang"`
+	Prompt     string `json:"prompt"`
+	Model      string `json:"model,omitempty"`
+}
+
+// pluginFactory creates providers that translate with the external command
+// of their plugin config, for in-house backends.
+func pluginFactory(name string) func(config.ProviderConfig, *http.Client) Provider {
+	return func(cfg config.ProviderConfig, client *http.Client) Provider {
+		return newFuncProvider(name, cfg, client, func(ctx context.Context, prompt string, req TranslateRequest) (string, error) {
+			return runPlugin(ctx, name, cfg, prompt, req)
+		})
+	}
+}
+
+// runPlugin runs the plugin command with the request on stdin and returns
+// the translation from its output. The API key, if any, is passed in the
+// LLM_TRANSLATE_API_KEY environment variable.
+func runPlugin(ctx context.Context, name string, cfg config.ProviderConfig, prompt string, req TranslateRequest) (string, error) {
+	fields := templateFields{
+		Text:       req.Text,
+		SourceLang: req.SourceLang,
+		TargetLang: req.TargetLang,
+		Prompt:     prompt,
+		Model:      cfg.Model,
+	}
+	input, err := fillTemplate(cfg.Plugin.Request, fields)
+	if err != nil {
+		return "", err
+	}
+
+	args := config.ShellCommand(cfg.Plugin.Command)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(input)
+	if cfg.APIKey != "" {
+		cmd.Env = append(os.Environ(), "LLM_TRANSLATE_API_KEY="+cfg.APIKey)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("plugin %s failed: %w, stderr: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	if cfg.Plugin.Response == "" {
+		return strings.TrimSpace(stdout.String()), nil
+	}
+	text, err := jsonPathString(stdout.Bytes(), cfg.Plugin.Response)
+	if err != nil {
+		return "", fmt.Errorf("plugin %s: %w", name, err)
+	}
+	return text, nil
+}
+
+// fillTemplate fills {text}, {source_lang}, {target_lang}, {prompt} and
+// {model} in tmpl with JSON strings, or encodes all fields without a
+// template.
+func fillTemplate(tmpl string, fields templateFields) (string, error) {
+	if tmpl == "" {
+		data, err := json.Marshal(fields)
+		return string(data), err
+	}
+	quote := func(s string) string {
+		data, _ := json.Marshal(s)
+		return string(data)
+	}
+	return strings.NewReplacer(
+		"{text}", quote(fields.Text),
+		"{source_lang}", quote(fields.SourceLang),
+		"{target_lang}", quote(fields.TargetLang),
+		"{prompt}", quote(fields.Prompt),
+		"{model}", quote(fields.Model),
+	).Replace(tmpl), nil
+}
+
+// jsonPathString returns the string at a dotted path in JSON output, such
+// as data.translations.0.text.
+func jsonPathString(output []byte, path string) (string, error) {
+	var value interface{}
+	if err := json.Unmarshal(output, &value); err != nil {
+		return "", fmt.Errorf("invalid JSON output: %w", err)
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return "", fmt.Errorf("no element %s in output", key)
+			}
+			value = v[i]
+		default:
+			value = nil
+		}
+		if value == nil {
+			return "", fmt.Errorf("no %s in output", path)
+		}
+	}
+	text, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s in output is not a string", path)
+	}
+	return text, nil
+}
@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// PullProgress is a status line sent while a model is downloaded.
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// modelPuller is implemented by providers that can download models to a
+// local server: Ollama.
+type modelPuller interface {
+	pullModel(ctx context.Context, model string, progress func(PullProgress)) error
+}
+
+// PullModel downloads model with provider p, calling progress for each
+// status line.
+func PullModel(ctx context.Context, p Provider, model string, progress func(PullProgress)) error {
+	puller, ok := p.(modelPuller)
+	if !ok {
+		return fmt.Errorf("provider %s cannot pull models", p.Name())
+	}
+	return puller.pullModel(ctx, model, progress)
+}
+
+func (p *OllamaProvider) pullModel(ctx context.Context, model string, progress func(PullProgress)) error {
+	jsonData, err := json.Marshal(map[string]interface{}{"model": model, "stream": true})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := strings.TrimRight(p.config.BaseURL, "/") + "/api/pull"
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var status PullProgress
+		if json.NewDecoder(resp.Body).Decode(&status) == nil && status.Error != "" {
+			return fmt.Errorf("Ollama API error: %s", status.Error)
+		}
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	// Progress and errors arrive as one JSON object per line
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var status PullProgress
+		if err := json.Unmarshal(line, &status); err != nil {
+			return fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+		if status.Error != "" {
+			return fmt.Errorf("Ollama API error: %s", status.Error)
+		}
+		progress(status)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	return nil
+}
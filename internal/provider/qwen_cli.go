@@ -84,7 +84,9 @@ func (p *QwenCLIProvider) runCLI(ctx context.Context, prompt string, input strin
 		return "", fmt.Errorf("qwen CLI error: %w, stderr: %s", err, stderr.String())
 	}
 
-	return strings.TrimSpace(stdout.String()), nil
+	result := strings.TrimSpace(stdout.String())
+	p.recordEstimate(prompt+input, result)
+	return result, nil
 }
 
 func (p *QwenCLIProvider) runCLIJSON(ctx context.Context, prompt string, input string) (string, int, error) {
@@ -107,16 +109,20 @@ func (p *QwenCLIProvider) runCLIJSON(ctx context.Context, prompt string, input s
 
 	var events []qwenJSONEvent
 	if err := json.Unmarshal(stdout.Bytes(), &events); err != nil {
-		return strings.TrimSpace(stdout.String()), 0, nil
+		result := strings.TrimSpace(stdout.String())
+		p.recordEstimate(prompt+input, result)
+		return result, 0, nil
 	}
 
 	var resultText string
 	var tokensUsed int
+	var usage *qwenUsage
 
 	for _, event := range events {
 		if event.Type == "result" && !event.IsError {
 			resultText = event.Result
 			if event.Usage != nil {
+				usage = event.Usage
 				tokensUsed = event.Usage.TotalTokens
 				if tokensUsed == 0 {
 					tokensUsed = event.Usage.InputTokens + event.Usage.OutputTokens
@@ -138,6 +144,7 @@ func (p *QwenCLIProvider) runCLIJSON(ctx context.Context, prompt string, input s
 				}
 				if resultText != "" {
 					if event.Message.Usage != nil {
+						usage = event.Message.Usage
 						tokensUsed = event.Message.Usage.TotalTokens
 						if tokensUsed == 0 {
 							tokensUsed = event.Message.Usage.InputTokens + event.Message.Usage.OutputTokens
@@ -150,28 +157,24 @@ func (p *QwenCLIProvider) runCLIJSON(ctx context.Context, prompt string, input s
 	}
 
 	if resultText == "" {
-		return strings.TrimSpace(stdout.String()), tokensUsed, nil
+		resultText = strings.TrimSpace(stdout.String())
+	}
+
+	switch {
+	case usage == nil:
+		p.recordEstimate(prompt+input, resultText)
+	case usage.InputTokens+usage.OutputTokens == 0:
+		// Only the total is reported, count it as input
+		p.record(usage.TotalTokens, 0)
+	default:
+		p.record(usage.InputTokens, usage.OutputTokens)
 	}
 
 	return resultText, tokensUsed, nil
 }
 
 func (p *QwenCLIProvider) Translate(ctx context.Context, req TranslateRequest) (TranslateResponse, error) {
-	prompt := fmt.Sprintf(
-		"You are a professional translator. Translate the following text from %s to %s. "+
-			"Preserve the original formatting and structure. "+
-			"Output only the translation without explanations.",
-		req.SourceLang, req.TargetLang,
-	)
-
-	if req.SourceLang == "auto" {
-		prompt = fmt.Sprintf(
-			"You are a professional translator. Detect the source language and translate the text to %s. "+
-				"Preserve the original formatting and structure. "+
-				"Output only the translation without explanations.",
-			req.TargetLang,
-		)
-	}
+	prompt := p.systemPrompt(req)
 
 	fullPrompt := p.buildPrompt(req, prompt)
 
@@ -349,6 +352,18 @@ func (p *QwenCLIProvider) AnalyzeCombined(ctx context.Context, req CombinedAnaly
 	return ParseCombinedResponse(result, req), nil
 }
 
+func (p *QwenCLIProvider) GenerateHeadline(ctx context.Context, text string) (HeadlineResponse, error) {
+	result, _, err := p.runCLIJSON(ctx, HeadlinePrompt, text)
+	if err != nil {
+		result, err = p.runCLI(ctx, HeadlinePrompt, text)
+		if err != nil {
+			return HeadlineResponse{}, err
+		}
+	}
+
+	return ParseHeadlineResponse(result)
+}
+
 func init() {
 	Register("qwen-cli", NewQwenCLIProvider)
 }
@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"sort"
+	"strings"
+)
+
+// structuredOutputProvider is implemented by providers whose API can
+// constrain an answer with a JSON schema: OpenAI (response_format
+// json_schema), Anthropic (forced tool call) and Google (responseSchema).
+type structuredOutputProvider interface {
+	structuredOutput()
+}
+
+// SupportsStructuredOutput reports whether the provider honors
+// CombinedAnalysisRequest.Schema. Other providers should keep the free text
+// analysis prompts, which are parsed with regular expressions.
+func SupportsStructuredOutput(p Provider) bool {
+	_, ok := p.(structuredOutputProvider)
+	return ok
+}
+
+func (p *OpenAIProvider) structuredOutput()    {}
+func (p *AnthropicProvider) structuredOutput() {}
+func (p *GoogleProvider) structuredOutput()    {}
+
+// combinedJSONSchema describes the combinedJSON answer for the requested
+// sections. It follows the strict mode rules of OpenAI: every property is
+// required and objects allow no additional properties.
+func combinedJSONSchema(req CombinedAnalysisRequest) map[string]interface{} {
+	props := make(map[string]interface{})
+
+	if req.Sentiment {
+		props["sentiment"] = schemaObject(map[string]interface{}{
+			"label": schemaEnum("positive", "negative", "neutral"),
+			"score": schemaType("number"),
+		})
+	}
+	if req.TagsCount > 0 {
+		props["tags"] = schemaList(schemaType("string"))
+	}
+	if req.Classify {
+		props["classify"] = schemaObject(map[string]interface{}{
+			"topics": schemaList(schemaEnum("politics", "economics", "technology", "medicine", "incidents")),
+			"scope":  schemaList(schemaEnum("regional", "international")),
+			"type":   schemaList(schemaEnum("corporate", "regulatory", "macro")),
+		})
+	}
+	if req.Emotions {
+		emotions := make(map[string]interface{})
+		for _, name := range []string{"fear", "anger", "hope", "uncertainty", "optimism", "panic"} {
+			emotions[name] = schemaType("number")
+		}
+		props["emotions"] = schemaObject(emotions)
+	}
+	if req.Factuality {
+		props["factuality"] = schemaObject(map[string]interface{}{
+			"type":       schemaEnum("confirmed", "rumors", "forecasts", "unsourced"),
+			"confidence": schemaType("number"),
+			"evidence": schemaList(schemaEnum("official_source", "statistics", "quotes", "documents",
+				"expert_opinion", "anonymous_source", "speculation", "prediction")),
+		})
+	}
+	if req.Impact {
+		props["impact"] = schemaObject(map[string]interface{}{
+			"affected": schemaList(schemaEnum("individuals", "business", "government", "investors", "consumers")),
+		})
+	}
+	if req.Sensationalism {
+		props["sensationalism"] = schemaObject(map[string]interface{}{
+			"type":       schemaEnum("neutral", "emotional", "clickbait", "manipulative"),
+			"confidence": schemaType("number"),
+			"markers":    schemaList(schemaType("string")),
+		})
+	}
+	if req.Usefulness {
+		props["usefulness"] = schemaObject(map[string]interface{}{
+			"useful":     schemaType("boolean"),
+			"confidence": schemaType("number"),
+			"reasons":    schemaList(schemaType("string")),
+		})
+	}
+	if req.Entities {
+		entities := make(map[string]interface{})
+		for _, name := range []string{"persons", "organizations", "locations", "dates", "amounts"} {
+			entities[name] = schemaList(schemaType("string"))
+		}
+		props["entities"] = schemaObject(entities)
+	}
+	if req.Events {
+		props["events"] = schemaList(schemaType("string"))
+	}
+	if req.TimeFocus {
+		props["time_focus"] = schemaObject(map[string]interface{}{
+			"focus":         schemaEnum("past", "present", "future", "mixed"),
+			"confidence":    schemaType("number"),
+			"is_prediction": schemaType("boolean"),
+			"indicators":    schemaList(schemaType("string")),
+		})
+	}
+	if req.AdDetect {
+		props["ad_detect"] = schemaObject(map[string]interface{}{
+			"type":       schemaEnum("none", "direct", "native", "sponsored", "pr"),
+			"confidence": schemaType("number"),
+			"markers":    schemaList(schemaType("string")),
+		})
+	}
+
+	return schemaObject(props)
+}
+
+func schemaType(name string) map[string]interface{} {
+	return map[string]interface{}{"type": name}
+}
+
+func schemaEnum(values ...string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "enum": values}
+}
+
+func schemaList(items map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "array", "items": items}
+}
+
+func schemaObject(props map[string]interface{}) map[string]interface{} {
+	required := make([]string, 0, len(props))
+	for name := range props {
+		required = append(required, name)
+	}
+	sort.Strings(required)
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           props,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// googleSchema converts a schema to the OpenAPI subset Gemini accepts:
+// upper-case type names and no additionalProperties.
+func googleSchema(schema map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		switch key {
+		case "additionalProperties":
+		case "type":
+			out[key] = strings.ToUpper(value.(string))
+		case "items":
+			out[key] = googleSchema(value.(map[string]interface{}))
+		case "properties":
+			props := make(map[string]interface{})
+			for name, prop := range value.(map[string]interface{}) {
+				props[name] = googleSchema(prop.(map[string]interface{}))
+			}
+			out[key] = props
+		default:
+			out[key] = value
+		}
+	}
+	return out
+}
@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/foxzi/llm-translate/internal/config"
+)
+
+// TemplateProvider talks to an LLM gateway whose request and response
+// format are described by the template section of its config.
+type TemplateProvider struct {
+	translateOnly
+}
+
+// templateFactory creates template providers named after their config.
+func templateFactory(name string) func(config.ProviderConfig, *http.Client) Provider {
+	return func(cfg config.ProviderConfig, client *http.Client) Provider {
+		return &TemplateProvider{
+			translateOnly: translateOnly{
+				BaseProvider: BaseProvider{
+					name:       name,
+					config:     cfg,
+					httpClient: client,
+				},
+			},
+		}
+	}
+}
+
+func (p *TemplateProvider) ValidateConfig() error {
+	if p.config.BaseURL == "" {
+		return fmt.Errorf("base URL is required for provider %s", p.name)
+	}
+	if p.config.Template.Response == "" {
+		return fmt.Errorf("template response path is required for provider %s", p.name)
+	}
+	return nil
+}
+
+// Translate sends the filled body template to base_url. The API key, if
+// any, is sent as a bearer token.
+func (p *TemplateProvider) Translate(ctx context.Context, req TranslateRequest) (TranslateResponse, error) {
+	prompt := p.buildPrompt(req, p.systemPrompt(req))
+	body, err := fillTemplate(p.config.Template.Body, templateFields{
+		Text:       req.Text,
+		SourceLang: req.SourceLang,
+		TargetLang: req.TargetLang,
+		Prompt:     prompt,
+		Model:      p.config.Model,
+	})
+	if err != nil {
+		return TranslateResponse{}, err
+	}
+
+	method := p.config.Template.Method
+	if method == "" {
+		method = "POST"
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, p.config.BaseURL, bytes.NewBufferString(body))
+	if err != nil {
+		return TranslateResponse{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.config.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	}
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return TranslateResponse{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return TranslateResponse{}, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if len(data) > 200 {
+			data = data[:200]
+		}
+		return TranslateResponse{}, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, data)
+	}
+
+	text, err := jsonPathString(data, p.config.Template.Response)
+	if err != nil {
+		return TranslateResponse{}, err
+	}
+	p.recordEstimate(prompt+req.Text, text)
+	return TranslateResponse{Text: text}, nil
+}
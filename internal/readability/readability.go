@@ -0,0 +1,139 @@
+package readability
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type Result struct {
+	Grade     float64 // approximate school grade level (Flesch-Kincaid style)
+	Words     int
+	Sentences int
+	Syllables int
+}
+
+// Analyze computes a readability grade for text. Uses Flesch-Kincaid grade
+// level for Latin-script languages and Oborneva's adaptation for Russian and
+// other Cyrillic languages, which have longer words on average.
+func Analyze(text, lang string) Result {
+	result := Result{}
+
+	for _, word := range strings.FieldsFunc(text, isWordSeparator) {
+		syllables := countSyllables(word)
+		if syllables == 0 {
+			continue
+		}
+		result.Words++
+		result.Syllables += syllables
+	}
+
+	result.Sentences = countSentences(text)
+	if result.Words == 0 || result.Sentences == 0 {
+		return result
+	}
+
+	wordsPerSentence := float64(result.Words) / float64(result.Sentences)
+	syllablesPerWord := float64(result.Syllables) / float64(result.Words)
+
+	var grade float64
+	switch strings.ToLower(lang) {
+	case "ru", "uk", "be", "bg", "sr", "mk", "kk":
+		grade = 0.5*wordsPerSentence + 8.4*syllablesPerWord - 15.59
+	default:
+		grade = 0.39*wordsPerSentence + 11.8*syllablesPerWord - 15.59
+	}
+
+	if grade < 0 {
+		grade = 0
+	}
+	result.Grade = math.Round(grade*10) / 10
+
+	return result
+}
+
+// TargetBand converts a reading level specification into a grade band.
+// Accepts CEFR levels (A1-C2) and US grades ("8th grade", "grade 8", "8").
+// Returns ok=false for unrecognized levels, which are still passed to the
+// model as an instruction but not validated.
+func TargetBand(level string) (min, max float64, ok bool) {
+	level = strings.ToLower(strings.TrimSpace(level))
+
+	cefr := map[string][2]float64{
+		"a1": {0, 3},
+		"a2": {2, 5},
+		"b1": {4, 8},
+		"b2": {6, 10},
+		"c1": {9, 13},
+		"c2": {11, 18},
+	}
+	for name, band := range cefr {
+		if level == name || strings.HasPrefix(level, name+" ") {
+			return band[0], band[1], true
+		}
+	}
+
+	matches := gradeRe.FindStringSubmatch(level)
+	if len(matches) < 2 {
+		return 0, 0, false
+	}
+	grade, err := strconv.Atoi(matches[1])
+	if err != nil || grade < 1 || grade > 18 {
+		return 0, 0, false
+	}
+	return math.Max(0, float64(grade)-2), float64(grade) + 2, true
+}
+
+var gradeRe = regexp.MustCompile(`^(?:grade\s*)?(\d{1,2})(?:st|nd|rd|th)?(?:\s*grade)?$`)
+
+func isWordSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && r != '\'' && r != '-'
+}
+
+func countSentences(text string) int {
+	count := 0
+	inTerminator := false
+	for _, r := range text {
+		if r == '.' || r == '!' || r == '?' || r == '…' {
+			if !inTerminator {
+				count++
+			}
+			inTerminator = true
+			continue
+		}
+		if !unicode.IsSpace(r) {
+			inTerminator = false
+		}
+	}
+
+	// Text without terminal punctuation still forms one sentence
+	if count == 0 && strings.TrimSpace(text) != "" {
+		count = 1
+	}
+	return count
+}
+
+// countSyllables approximates syllables as groups of consecutive vowels.
+func countSyllables(word string) int {
+	word = strings.ToLower(word)
+	count := 0
+	prevVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouyàáâäèéêëìíîïòóôöùúûüаеёиоуыэюяієї", r)
+		if vowel && !prevVowel {
+			count++
+		}
+		prevVowel = vowel
+	}
+
+	// Silent trailing "e" in English-like words
+	if count > 1 && strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") {
+		count--
+	}
+	if count == 0 && strings.IndexFunc(word, unicode.IsLetter) >= 0 {
+		count = 1
+	}
+	return count
+}
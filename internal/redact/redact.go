@@ -0,0 +1,146 @@
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/foxzi/llm-translate/internal/config"
+)
+
+// Redactor masks sensitive data with placeholders before text is sent to
+// a provider and restores the original values in the translated output.
+type Redactor struct {
+	types map[string]bool
+	terms []string
+}
+
+var (
+	emailRe = regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`)
+	cardRe  = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	phoneRe = regexp.MustCompile(`\+?\(?\d[\d\s().-]{6,}\d`)
+)
+
+// PlaceholderRe matches the placeholders Redact puts in place of values.
+var PlaceholderRe = regexp.MustCompile(`\[\[(?:EMAIL|CARD|PHONE|NAME)_\d+\]\]`)
+
+// DefaultTypes are the built-in detectors used when none are configured.
+var DefaultTypes = []string{"email", "phone", "card"}
+
+func New(cfg config.Redaction) *Redactor {
+	types := cfg.Types
+	if len(types) == 0 {
+		types = DefaultTypes
+	}
+
+	r := &Redactor{
+		types: make(map[string]bool),
+		terms: append([]string(nil), cfg.Terms...),
+	}
+	for _, t := range types {
+		r.types[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+
+	// Longest terms first so "John Smith" wins over "John"
+	sort.Slice(r.terms, func(i, j int) bool {
+		return len(r.terms[i]) > len(r.terms[j])
+	})
+
+	return r
+}
+
+// Redact replaces sensitive values with placeholders like [[EMAIL_1]].
+// Returns the masked text and the placeholder -> original mapping.
+func (r *Redactor) Redact(text string) (string, map[string]string) {
+	mapping := make(map[string]string)
+	counters := make(map[string]int)
+
+	replace := func(kind string, re *regexp.Regexp, accept func(string) bool) {
+		text = re.ReplaceAllStringFunc(text, func(match string) string {
+			if accept != nil && !accept(match) {
+				return match
+			}
+			counters[kind]++
+			placeholder := fmt.Sprintf("[[%s_%d]]", kind, counters[kind])
+			mapping[placeholder] = match
+			return placeholder
+		})
+	}
+
+	if r.types["email"] {
+		replace("EMAIL", emailRe, nil)
+	}
+	if r.types["card"] {
+		replace("CARD", cardRe, isCardNumber)
+	}
+	if r.types["phone"] {
+		replace("PHONE", phoneRe, isPhoneNumber)
+	}
+
+	for _, term := range r.terms {
+		if term == "" {
+			continue
+		}
+		replace("NAME", regexp.MustCompile(`(?i)\b`+regexp.QuoteMeta(term)+`\b`), nil)
+	}
+
+	return text, mapping
+}
+
+// Restore puts original values back in place of placeholders. Returns the
+// restored text and placeholders that were lost in translation.
+func Restore(text string, mapping map[string]string) (string, []string) {
+	var missing []string
+	for placeholder, original := range mapping {
+		if !strings.Contains(text, placeholder) {
+			missing = append(missing, placeholder)
+			continue
+		}
+		text = strings.ReplaceAll(text, placeholder, original)
+	}
+	sort.Strings(missing)
+	return text, missing
+}
+
+// isCardNumber validates a candidate card number with the Luhn checksum.
+func isCardNumber(s string) bool {
+	digits := digitsOnly(s)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// isPhoneNumber filters out dates, ranges and other short digit runs.
+func isPhoneNumber(s string) bool {
+	digits := digitsOnly(s)
+	if strings.HasPrefix(s, "+") {
+		return len(digits) >= 8 && len(digits) <= 15
+	}
+	return len(digits) >= 10 && len(digits) <= 15
+}
+
+func digitsOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,140 @@
+package rpc
+
+// The messages of api/llmtranslate/v1/translator.proto, encoded by hand
+// since they are few and flat.
+
+// TranslateRequest is a text to translate. Empty languages default to
+// those of the server.
+type TranslateRequest struct {
+	ID         string
+	Text       string
+	SourceLang string
+	TargetLang string
+	Callback   string
+}
+
+func (m *TranslateRequest) unmarshal(data []byte) error {
+	return decodeFields(data, func(field int, v uint64, b []byte) {
+		switch field {
+		case 1:
+			m.ID = string(b)
+		case 2:
+			m.Text = string(b)
+		case 3:
+			m.SourceLang = string(b)
+		case 4:
+			m.TargetLang = string(b)
+		case 5:
+			m.Callback = string(b)
+		}
+	})
+}
+
+// TranslateResponse is a translation with the results of the enabled
+// analyses as a JSON object.
+type TranslateResponse struct {
+	ID            string
+	Text          string
+	SourceLang    string
+	TargetLang    string
+	TokensUsed    int32
+	MetadataJSON  string
+	Error         string
+	CallbackError string
+}
+
+func (m *TranslateResponse) marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, m.ID)
+	b = appendString(b, 2, m.Text)
+	b = appendString(b, 3, m.SourceLang)
+	b = appendString(b, 4, m.TargetLang)
+	b = appendInt(b, 5, m.TokensUsed)
+	b = appendString(b, 6, m.MetadataJSON)
+	b = appendString(b, 7, m.Error)
+	return appendString(b, 8, m.CallbackError)
+}
+
+// DetectRequest is a text to detect the language of.
+type DetectRequest struct {
+	Text string
+}
+
+func (m *DetectRequest) unmarshal(data []byte) error {
+	return decodeFields(data, func(field int, v uint64, b []byte) {
+		if field == 1 {
+			m.Text = string(b)
+		}
+	})
+}
+
+// DetectResponse holds the ISO 639-1 code of the language.
+type DetectResponse struct {
+	Language string
+}
+
+func (m *DetectResponse) marshal() []byte {
+	return appendString(nil, 1, m.Language)
+}
+
+// AnalyzeRequest is a text to analyze without translating it. Empty
+// analyses and language default to those of the server.
+type AnalyzeRequest struct {
+	Text       string
+	Analyses   []string
+	TargetLang string
+}
+
+func (m *AnalyzeRequest) unmarshal(data []byte) error {
+	return decodeFields(data, func(field int, v uint64, b []byte) {
+		switch field {
+		case 1:
+			m.Text = string(b)
+		case 2:
+			m.Analyses = append(m.Analyses, string(b))
+		case 3:
+			m.TargetLang = string(b)
+		}
+	})
+}
+
+// AnalyzeResponse holds the results of the enabled analyses as a JSON
+// object.
+type AnalyzeResponse struct {
+	MetadataJSON string
+	TokensUsed   int32
+}
+
+func (m *AnalyzeResponse) marshal() []byte {
+	return appendInt(appendString(nil, 1, m.MetadataJSON), 2, m.TokensUsed)
+}
+
+// JobRequest names a queued job.
+type JobRequest struct {
+	JobID string
+}
+
+func (m *JobRequest) unmarshal(data []byte) error {
+	return decodeFields(data, func(field int, v uint64, b []byte) {
+		if field == 1 {
+			m.JobID = string(b)
+		}
+	})
+}
+
+// Job is the state of a queued translation. Result is set once the job is
+// done or failed.
+type Job struct {
+	JobID  string
+	Status string
+	Result *TranslateResponse
+}
+
+func (m *Job) marshal() []byte {
+	b := appendString(appendString(nil, 1, m.JobID), 2, m.Status)
+	if m.Result != nil {
+		// An empty result is still sent, so clients see it is set
+		b = appendMessage(b, 3, append([]byte{}, m.Result.marshal()...))
+	}
+	return b
+}
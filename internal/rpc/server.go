@@ -0,0 +1,243 @@
+// Package rpc serves the gRPC API of api/llmtranslate/v1/translator.proto
+// over the HTTP/2 support of net/http, without the gRPC library.
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// servicePath prefixes the request paths of the Translator service methods.
+const servicePath = "/llmtranslate.v1.Translator/"
+
+// maxMessage limits the size of request messages.
+const maxMessage = 64 << 20
+
+// gRPC status codes returned by the server.
+const (
+	CodeInvalidArgument    = 3
+	CodeNotFound           = 5
+	CodeFailedPrecondition = 9
+	CodeUnimplemented      = 12
+	CodeInternal           = 13
+	CodeUnauthenticated    = 16
+)
+
+// Error is a failed call with its gRPC status code.
+type Error struct {
+	Code int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+// Errorf returns an Error with code.
+func Errorf(code int, format string, args ...interface{}) error {
+	return &Error{Code: code, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Service implements the methods of the Translator service. TranslateStream
+// calls Translate for each request. Errors other than Error are reported
+// as internal.
+type Service interface {
+	Translate(ctx context.Context, req *TranslateRequest) (*TranslateResponse, error)
+	Detect(ctx context.Context, req *DetectRequest) (*DetectResponse, error)
+	Analyze(ctx context.Context, req *AnalyzeRequest) (*AnalyzeResponse, error)
+	SubmitJob(ctx context.Context, req *TranslateRequest) (*Job, error)
+	GetJob(ctx context.Context, req *JobRequest) (*Job, error)
+	CancelJob(ctx context.Context, req *JobRequest) (*Job, error)
+	RetryJob(ctx context.Context, req *JobRequest) (*Job, error)
+}
+
+// Handler returns the HTTP/2 handler serving svc. With a token, requests
+// must carry it as "authorization: Bearer <token>".
+func Handler(svc Service, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			http.Error(w, "gRPC requests only", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		w.WriteHeader(http.StatusOK)
+
+		var err error
+		if token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			err = Errorf(CodeUnauthenticated, "missing or invalid token")
+		} else {
+			err = serveMethod(r.Context(), svc, strings.TrimPrefix(r.URL.Path, servicePath), r.Body, w)
+		}
+		code, msg := 0, ""
+		if err != nil {
+			code, msg = CodeInternal, err.Error()
+			var rpcErr *Error
+			if errors.As(err, &rpcErr) {
+				code = rpcErr.Code
+			}
+		}
+		w.Header().Set("Grpc-Status", fmt.Sprint(code))
+		w.Header().Set("Grpc-Message", encodeMessage(msg))
+	})
+}
+
+// serveMethod reads the request messages of method from body and writes
+// the responses to w.
+func serveMethod(ctx context.Context, svc Service, method string, body io.Reader, w http.ResponseWriter) error {
+	switch method {
+	case "Translate":
+		var req TranslateRequest
+		if err := readRequest(body, req.unmarshal); err != nil {
+			return err
+		}
+		resp, err := svc.Translate(ctx, &req)
+		if err != nil {
+			return err
+		}
+		return writeMessage(w, resp.marshal())
+
+	case "TranslateStream":
+		for {
+			data, err := readMessage(body)
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			var req TranslateRequest
+			if err := req.unmarshal(data); err != nil {
+				return Errorf(CodeInvalidArgument, "invalid request: %v", err)
+			}
+			resp, err := svc.Translate(ctx, &req)
+			if err != nil {
+				resp = &TranslateResponse{ID: req.ID, Error: err.Error()}
+			}
+			if err := writeMessage(w, resp.marshal()); err != nil {
+				return err
+			}
+		}
+
+	case "Detect":
+		var req DetectRequest
+		if err := readRequest(body, req.unmarshal); err != nil {
+			return err
+		}
+		resp, err := svc.Detect(ctx, &req)
+		if err != nil {
+			return err
+		}
+		return writeMessage(w, resp.marshal())
+
+	case "Analyze":
+		var req AnalyzeRequest
+		if err := readRequest(body, req.unmarshal); err != nil {
+			return err
+		}
+		resp, err := svc.Analyze(ctx, &req)
+		if err != nil {
+			return err
+		}
+		return writeMessage(w, resp.marshal())
+
+	case "SubmitJob":
+		var req TranslateRequest
+		if err := readRequest(body, req.unmarshal); err != nil {
+			return err
+		}
+		resp, err := svc.SubmitJob(ctx, &req)
+		if err != nil {
+			return err
+		}
+		return writeMessage(w, resp.marshal())
+
+	case "GetJob", "CancelJob", "RetryJob":
+		var req JobRequest
+		if err := readRequest(body, req.unmarshal); err != nil {
+			return err
+		}
+		call := map[string]func(context.Context, *JobRequest) (*Job, error){
+			"GetJob":    svc.GetJob,
+			"CancelJob": svc.CancelJob,
+			"RetryJob":  svc.RetryJob,
+		}[method]
+		resp, err := call(ctx, &req)
+		if err != nil {
+			return err
+		}
+		return writeMessage(w, resp.marshal())
+	}
+	return Errorf(CodeUnimplemented, "unknown method %s", method)
+}
+
+// readRequest reads the single request message of a unary call.
+func readRequest(body io.Reader, unmarshal func([]byte) error) error {
+	data, err := readMessage(body)
+	if err == io.EOF {
+		return Errorf(CodeInvalidArgument, "no request message")
+	}
+	if err != nil {
+		return err
+	}
+	if err := unmarshal(data); err != nil {
+		return Errorf(CodeInvalidArgument, "invalid request: %v", err)
+	}
+	return nil
+}
+
+// readMessage reads one length-prefixed message, or returns io.EOF at the
+// end of the stream.
+func readMessage(body io.Reader) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(body, header[:]); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, Errorf(CodeInvalidArgument, "truncated message: %v", err)
+	}
+	if header[0] != 0 {
+		return nil, Errorf(CodeUnimplemented, "compressed messages are not supported")
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxMessage {
+		return nil, Errorf(CodeInvalidArgument, "message of %d bytes exceeds %d", size, maxMessage)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(body, data); err != nil {
+		return nil, Errorf(CodeInvalidArgument, "truncated message: %v", err)
+	}
+	return data, nil
+}
+
+// writeMessage writes one length-prefixed message and flushes it, so
+// streamed responses reach the client as they are ready.
+func writeMessage(w http.ResponseWriter, data []byte) error {
+	frame := make([]byte, 5, 5+len(data))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+	if _, err := w.Write(append(frame, data...)); err != nil {
+		return err
+	}
+	return http.NewResponseController(w).Flush()
+}
+
+// encodeMessage percent-encodes msg for the grpc-message trailer.
+func encodeMessage(msg string) string {
+	var b strings.Builder
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c < 0x20 || c > 0x7e || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
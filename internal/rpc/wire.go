@@ -0,0 +1,89 @@
+package rpc
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Protobuf wire types used by the messages of the API.
+const (
+	wireVarint = 0
+	wireI64    = 1
+	wireBytes  = 2
+	wireI32    = 5
+)
+
+// appendString appends a string field, omitted when empty as in proto3.
+func appendString(b []byte, field int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = binary.AppendUvarint(b, uint64(field)<<3|wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+// appendInt appends an int32 field, omitted when zero as in proto3.
+func appendInt(b []byte, field int, v int32) []byte {
+	if v == 0 {
+		return b
+	}
+	b = binary.AppendUvarint(b, uint64(field)<<3|wireVarint)
+	// Negative int32 values are sign extended to 64 bits
+	return binary.AppendUvarint(b, uint64(int64(v)))
+}
+
+// appendMessage appends an embedded message field, omitted when nil.
+func appendMessage(b []byte, field int, m []byte) []byte {
+	if m == nil {
+		return b
+	}
+	b = binary.AppendUvarint(b, uint64(field)<<3|wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(m)))
+	return append(b, m...)
+}
+
+// decodeFields calls fn for each field of the encoded message data with
+// the value of varint fields or the bytes of length-delimited ones. Fixed
+// size fields are skipped.
+func decodeFields(data []byte, fn func(field int, v uint64, b []byte)) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("invalid field key")
+		}
+		data = data[n:]
+		field := int(key >> 3)
+
+		switch key & 7 {
+		case wireVarint:
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				return fmt.Errorf("invalid varint in field %d", field)
+			}
+			data = data[n:]
+			fn(field, v, nil)
+		case wireBytes:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || size > uint64(len(data)-n) {
+				return fmt.Errorf("invalid length of field %d", field)
+			}
+			data = data[n:]
+			fn(field, 0, data[:size])
+			data = data[size:]
+		case wireI64:
+			if len(data) < 8 {
+				return fmt.Errorf("truncated field %d", field)
+			}
+			data = data[8:]
+		case wireI32:
+			if len(data) < 4 {
+				return fmt.Errorf("truncated field %d", field)
+			}
+			data = data[4:]
+		default:
+			return fmt.Errorf("unsupported wire type %d in field %d", key&7, field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	azureVersion  = "2021-08-06"
+	azureResource = "https://storage.azure.com/"
+)
+
+// azureContainer is a Blob Storage container of the account in
+// AZURE_STORAGE_CONNECTION_STRING or AZURE_STORAGE_ACCOUNT.
+type azureContainer struct {
+	name     string
+	account  string
+	endpoint string
+	key      []byte // shared key, when given
+	sas      string // SAS token, when given
+	token    cachedToken
+}
+
+func newAzureContainer(name string) (*azureContainer, error) {
+	c := &azureContainer{name: name, account: os.Getenv("AZURE_STORAGE_ACCOUNT"), sas: os.Getenv("AZURE_STORAGE_SAS_TOKEN")}
+	accountKey := os.Getenv("AZURE_STORAGE_KEY")
+	if conn := os.Getenv("AZURE_STORAGE_CONNECTION_STRING"); conn != "" {
+		fields := make(map[string]string)
+		for _, part := range strings.Split(conn, ";") {
+			if k, v, ok := strings.Cut(part, "="); ok {
+				fields[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+		}
+		c.account = fields["AccountName"]
+		accountKey = fields["AccountKey"]
+		c.sas = fields["SharedAccessSignature"]
+		c.endpoint = strings.TrimRight(fields["BlobEndpoint"], "/")
+		if c.endpoint == "" && c.account != "" {
+			protocol, suffix := fields["DefaultEndpointsProtocol"], fields["EndpointSuffix"]
+			if protocol == "" {
+				protocol = "https"
+			}
+			if suffix == "" {
+				suffix = "core.windows.net"
+			}
+			c.endpoint = fmt.Sprintf("%s://%s.blob.%s", protocol, c.account, suffix)
+		}
+	}
+	if c.account == "" && c.endpoint == "" {
+		return nil, fmt.Errorf("az:// URLs need AZURE_STORAGE_ACCOUNT or AZURE_STORAGE_CONNECTION_STRING")
+	}
+	if c.endpoint == "" {
+		c.endpoint = "https://" + c.account + ".blob.core.windows.net"
+	}
+	c.sas = strings.TrimPrefix(c.sas, "?")
+	if accountKey != "" && c.sas == "" {
+		key, err := base64.StdEncoding.DecodeString(accountKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Azure storage account key: %w", err)
+		}
+		c.key = key
+	}
+	return c, nil
+}
+
+func (c *azureContainer) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, escapeKey(key), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return readBody(resp, "azure: get", key)
+}
+
+func (c *azureContainer) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := c.do(ctx, http.MethodPut, escapeKey(key), nil, data)
+	if err != nil {
+		return err
+	}
+	_, err = readBody(resp, "azure: put", key)
+	return err
+}
+
+func (c *azureContainer) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	query := url.Values{"restype": {"container"}, "comp": {"list"}}
+	if prefix != "" {
+		query.Set("prefix", prefix)
+	}
+	for {
+		resp, err := c.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		data, err := readBody(resp, "azure: list", c.name+"/"+prefix)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Blobs []struct {
+				Name string
+			} `xml:"Blobs>Blob"`
+			NextMarker string
+		}
+		if err := xml.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("azure: list %s: %w", prefix, err)
+		}
+		for _, blob := range page.Blobs {
+			keys = append(keys, blob.Name)
+		}
+		if page.NextMarker == "" {
+			return keys, nil
+		}
+		query.Set("marker", page.NextMarker)
+	}
+}
+
+// do sends a request for the blob at the escaped path, or the container
+// for an empty path, authorized with a SAS token, the shared key or a
+// Microsoft Entra token, in that order.
+func (c *azureContainer) do(ctx context.Context, method, path string, query url.Values, body []byte) (*http.Response, error) {
+	rawQuery := query.Encode()
+	if c.sas != "" {
+		rawQuery = strings.TrimPrefix(rawQuery+"&"+c.sas, "&")
+	}
+	rawURL := c.endpoint + "/" + c.name
+	if path != "" {
+		rawURL += "/" + path
+	}
+	if rawQuery != "" {
+		rawURL += "?" + rawQuery
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Ms-Date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("X-Ms-Version", azureVersion)
+	if method == http.MethodPut {
+		req.Header.Set("X-Ms-Blob-Type", "BlockBlob")
+	}
+
+	switch {
+	case c.sas != "":
+	case c.key != nil:
+		c.signSharedKey(req, len(body))
+	default:
+		token, err := c.token.get(func() (interface{}, time.Time, error) {
+			return azureToken(ctx)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("azure: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token.(string))
+	}
+	return client.Do(req)
+}
+
+// signSharedKey signs req with the account key as described in
+// "Authorize with Shared Key" of the Blob Storage REST API.
+func (c *azureContainer) signSharedKey(req *http.Request, length int) {
+	contentLength := ""
+	if length > 0 {
+		contentLength = strconv.Itoa(length)
+	}
+
+	var msHeaders []string
+	for name, values := range req.Header {
+		if name := strings.ToLower(name); strings.HasPrefix(name, "x-ms-") {
+			msHeaders = append(msHeaders, name+":"+strings.TrimSpace(strings.Join(values, ",")))
+		}
+	}
+	sort.Strings(msHeaders)
+
+	resource := "/" + c.account + req.URL.EscapedPath()
+	query := req.URL.Query()
+	params := make([]string, 0, len(query))
+	for name := range query {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	for _, name := range params {
+		values := query[name]
+		sort.Strings(values)
+		resource += "\n" + strings.ToLower(name) + ":" + strings.Join(values, ",")
+	}
+
+	stringToSign := strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date, x-ms-date is used instead
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		strings.Join(msHeaders, "\n"),
+		resource,
+	}, "\n")
+
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(stringToSign))
+	req.Header.Set("Authorization", "SharedKey "+c.account+":"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+// azureToken gets a Microsoft Entra token for Blob Storage as
+// DefaultAzureCredential does: service principal secret or workload
+// identity from the environment, managed identity, then the Azure CLI.
+func azureToken(ctx context.Context) (interface{}, time.Time, error) {
+	tenant, clientID := os.Getenv("AZURE_TENANT_ID"), os.Getenv("AZURE_CLIENT_ID")
+	authority := strings.TrimRight(os.Getenv("AZURE_AUTHORITY_HOST"), "/")
+	if authority == "" {
+		authority = "https://login.microsoftonline.com"
+	}
+	tokenURL := authority + "/" + tenant + "/oauth2/v2.0/token"
+	form := url.Values{
+		"grant_type": {"client_credentials"},
+		"client_id":  {clientID},
+		"scope":      {azureResource + ".default"},
+	}
+
+	if secret := os.Getenv("AZURE_CLIENT_SECRET"); tenant != "" && clientID != "" && secret != "" {
+		form.Set("client_secret", secret)
+		return postTokenForm(ctx, tokenURL, form)
+	}
+	if file := os.Getenv("AZURE_FEDERATED_TOKEN_FILE"); tenant != "" && clientID != "" && file != "" {
+		assertion, err := os.ReadFile(file)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to read federated token: %w", err)
+		}
+		form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+		form.Set("client_assertion", strings.TrimSpace(string(assertion)))
+		return postTokenForm(ctx, tokenURL, form)
+	}
+
+	imds := "http://169.254.169.254/metadata/identity/oauth2/token?api-version=2018-02-01&resource=" + url.QueryEscape(azureResource)
+	if clientID != "" {
+		imds += "&client_id=" + url.QueryEscape(clientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imds, nil)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	req.Header.Set("Metadata", "true")
+	if resp, err := metadataClient.Do(req); err == nil {
+		return oauthTokenResponse(resp)
+	}
+
+	out, err := exec.CommandContext(ctx, "az", "account", "get-access-token", "--resource", azureResource, "--query", "accessToken", "-o", "tsv").Output()
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("no Azure credentials found: set AZURE_STORAGE_KEY, AZURE_STORAGE_SAS_TOKEN or AZURE_CLIENT_SECRET, or run az login")
+	}
+	// Tokens of the CLI live for an hour at least
+	return strings.TrimSpace(string(out)), time.Now().Add(30 * time.Minute), nil
+}
@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const gcsScope = "https://www.googleapis.com/auth/devstorage.read_write"
+
+// gcsBucket is a Cloud Storage bucket, accessed with the JSON API. With
+// STORAGE_EMULATOR_HOST set, requests go to the emulator without
+// credentials.
+type gcsBucket struct {
+	name     string
+	endpoint string
+	emulator bool
+	token    cachedToken
+}
+
+func newGCSBucket(name string) *gcsBucket {
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		if !strings.Contains(host, "://") {
+			host = "http://" + host
+		}
+		return &gcsBucket{name: name, endpoint: strings.TrimRight(host, "/"), emulator: true}
+	}
+	return &gcsBucket{name: name, endpoint: "https://storage.googleapis.com"}
+}
+
+func (b *gcsBucket) Get(ctx context.Context, key string) ([]byte, error) {
+	rawURL := fmt.Sprintf("%s/storage/v1/b/%s/o/%s?alt=media", b.endpoint, b.name, url.PathEscape(key))
+	resp, err := b.do(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return readBody(resp, "gcs: get", key)
+}
+
+func (b *gcsBucket) Put(ctx context.Context, key string, data []byte) error {
+	rawURL := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s", b.endpoint, b.name, url.QueryEscape(key))
+	resp, err := b.do(ctx, http.MethodPost, rawURL, data)
+	if err != nil {
+		return err
+	}
+	_, err = readBody(resp, "gcs: put", key)
+	return err
+}
+
+func (b *gcsBucket) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	query := url.Values{"prefix": {prefix}, "fields": {"items(name),nextPageToken"}}
+	for {
+		resp, err := b.do(ctx, http.MethodGet, fmt.Sprintf("%s/storage/v1/b/%s/o?%s", b.endpoint, b.name, query.Encode()), nil)
+		if err != nil {
+			return nil, err
+		}
+		data, err := readBody(resp, "gcs: list", b.name+"/"+prefix)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Items []struct {
+				Name string `json:"name"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("gcs: list %s: %w", prefix, err)
+		}
+		for _, item := range page.Items {
+			keys = append(keys, item.Name)
+		}
+		if page.NextPageToken == "" {
+			return keys, nil
+		}
+		query.Set("pageToken", page.NextPageToken)
+	}
+}
+
+// do sends a request authorized with a token of the application default
+// credentials.
+func (b *gcsBucket) do(ctx context.Context, method, rawURL string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if !b.emulator {
+		token, err := b.token.get(func() (interface{}, time.Time, error) {
+			return googleToken(ctx)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("gcs: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token.(string))
+	}
+	return client.Do(req)
+}
+
+// googleToken finds an access token as the application default
+// credentials do: GOOGLE_OAUTH_ACCESS_TOKEN, the credentials file of
+// GOOGLE_APPLICATION_CREDENTIALS or gcloud auth application-default
+// login, then the metadata server of Google Cloud.
+func googleToken(ctx context.Context) (interface{}, time.Time, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, time.Time{}, nil
+	}
+
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		home, _ := os.UserHomeDir()
+		path = filepath.Join(home, ".config", "gcloud", "application_default_credentials.json")
+		if dir := os.Getenv("CLOUDSDK_CONFIG"); dir != "" {
+			path = filepath.Join(dir, "application_default_credentials.json")
+		}
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		return googleFileToken(ctx, path, data)
+	} else if os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") != "" {
+		return nil, time.Time{}, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+host+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := metadataClient.Do(req)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("no Google credentials found: set GOOGLE_APPLICATION_CREDENTIALS or run gcloud auth application-default login")
+	}
+	return oauthTokenResponse(resp)
+}
+
+// googleFileToken exchanges the service account key or the user refresh
+// token of a credentials file for an access token.
+func googleFileToken(ctx context.Context, path string, data []byte) (interface{}, time.Time, error) {
+	var file struct {
+		Type         string `json:"type"`
+		ClientEmail  string `json:"client_email"`
+		PrivateKey   string `json:"private_key"`
+		TokenURI     string `json:"token_uri"`
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, time.Time{}, fmt.Errorf("invalid credentials file %s: %w", path, err)
+	}
+	if file.TokenURI == "" {
+		file.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	form := url.Values{}
+	switch file.Type {
+	case "service_account":
+		assertion, err := signServiceAccountJWT(file.ClientEmail, file.PrivateKey, file.TokenURI)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("credentials file %s: %w", path, err)
+		}
+		form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+		form.Set("assertion", assertion)
+	case "authorized_user":
+		form.Set("grant_type", "refresh_token")
+		form.Set("client_id", file.ClientID)
+		form.Set("client_secret", file.ClientSecret)
+		form.Set("refresh_token", file.RefreshToken)
+	default:
+		return nil, time.Time{}, fmt.Errorf("credentials file %s: unsupported type %q", path, file.Type)
+	}
+	return postTokenForm(ctx, file.TokenURI, form)
+}
+
+// signServiceAccountJWT returns the signed assertion of the JWT bearer
+// grant for the service account.
+func signServiceAccountJWT(email, privateKey, audience string) (string, error) {
+	block, _ := pem.Decode([]byte(privateKey))
+	if block == nil {
+		return "", fmt.Errorf("invalid private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("invalid private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("private key is not an RSA key")
+	}
+
+	now := time.Now().Unix()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   email,
+		"scope": gcsScope,
+		"aud":   audience,
+		"iat":   now,
+		"exp":   now + 3600,
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// postTokenForm requests an access token from an OAuth token endpoint.
+func postTokenForm(ctx context.Context, tokenURL string, form url.Values) (interface{}, time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("token request failed: %w", err)
+	}
+	return oauthTokenResponse(resp)
+}
+
+// oauthTokenResponse reads an access token and its lifetime from an OAuth
+// token response.
+func oauthTokenResponse(resp *http.Response) (interface{}, time.Time, error) {
+	data, err := readBody(resp, "token request", "")
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var token struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresIn   json.Number `json:"expires_in"`
+	}
+	if err := json.Unmarshal(data, &token); err != nil || token.AccessToken == "" {
+		return nil, time.Time{}, fmt.Errorf("invalid token response")
+	}
+	seconds, _ := token.ExpiresIn.Int64()
+	if seconds <= 0 {
+		seconds = 300
+	}
+	return token.AccessToken, time.Now().Add(time.Duration(seconds) * time.Second), nil
+}
@@ -0,0 +1,426 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3Bucket is an S3 bucket, or one of an S3-compatible store named by
+// AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL, which is addressed path-style.
+type s3Bucket struct {
+	name     string
+	region   string
+	endpoint string
+	creds    cachedToken
+}
+
+// awsCredentials are the keys requests are signed with.
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+func newS3Bucket(name string) *s3Bucket {
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_S3")
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+	return &s3Bucket{name: name, region: awsRegion(), endpoint: strings.TrimRight(endpoint, "/")}
+}
+
+// objectURL returns the URL of key, or of the bucket for an empty key.
+func (b *s3Bucket) objectURL(key string) string {
+	if b.endpoint != "" {
+		return b.endpoint + "/" + b.name + "/" + escapeKey(key)
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", b.name, b.region, escapeKey(key))
+}
+
+func (b *s3Bucket) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := b.do(ctx, http.MethodGet, b.objectURL(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	return readBody(resp, "s3: get", key)
+}
+
+func (b *s3Bucket) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := b.do(ctx, http.MethodPut, b.objectURL(key), data)
+	if err != nil {
+		return err
+	}
+	_, err = readBody(resp, "s3: put", key)
+	return err
+}
+
+func (b *s3Bucket) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := b.do(ctx, http.MethodGet, b.objectURL("")+"?"+awsQuery(query), nil)
+		if err != nil {
+			return nil, err
+		}
+		data, err := readBody(resp, "s3: list", b.name+"/"+prefix)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Contents []struct {
+				Key string
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		if err := xml.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("s3: list %s: %w", prefix, err)
+		}
+		for _, c := range page.Contents {
+			keys = append(keys, c.Key)
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return keys, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// do sends a request signed with the credentials of the default chain.
+func (b *s3Bucket) do(ctx context.Context, method, rawURL string, body []byte) (*http.Response, error) {
+	creds, err := b.creds.get(func() (interface{}, time.Time, error) {
+		return awsCredentialChain(ctx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("s3: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	signV4(req, creds.(awsCredentials), b.region, "s3", payloadHash, time.Now())
+	return client.Do(req)
+}
+
+// signV4 signs req with AWS Signature Version 4. Host and the x-amz-*
+// headers are signed.
+func signV4(req *http.Request, creds awsCredentials, region, service, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		if name := strings.ToLower(name); strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		awsQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	date := amzDate[:8]
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := []byte("AWS4" + creds.SecretAccessKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// awsQuery encodes query sorted by key with the strict escaping of
+// signature version 4.
+func awsQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, strings.ReplaceAll(escapeKey(k), "/", "%2F")+"="+strings.ReplaceAll(escapeKey(v), "/", "%2F"))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsRegion returns the region of the environment or the profile config,
+// us-east-1 without one.
+func awsRegion() string {
+	for _, name := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region := os.Getenv(name); region != "" {
+			return region
+		}
+	}
+	if region := iniValue(awsFile("AWS_CONFIG_FILE", "config"), awsConfigSection(), "region"); region != "" {
+		return region
+	}
+	return "us-east-1"
+}
+
+func awsProfile() string {
+	if profile := os.Getenv("AWS_PROFILE"); profile != "" {
+		return profile
+	}
+	return "default"
+}
+
+// awsConfigSection returns the section of the profile in ~/.aws/config.
+func awsConfigSection() string {
+	if awsProfile() == "default" {
+		return "default"
+	}
+	return "profile " + awsProfile()
+}
+
+// awsFile returns the path in env, or the file name in ~/.aws.
+func awsFile(env, name string) string {
+	if path := os.Getenv(env); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".aws", name)
+}
+
+// awsUnsupportedKeys are the profile settings of credential sources the
+// chain does not implement: SSO, credential_process and assumed roles.
+var awsUnsupportedKeys = []string{"sso_session", "sso_start_url", "credential_process", "role_arn", "source_profile"}
+
+// awsCredentialChain looks for credentials in the order of the AWS SDKs:
+// environment, shared credentials file, web identity token (EKS), ECS
+// container credentials and EC2 instance metadata. A profile using a
+// source the chain does not implement is an error rather than falling
+// through to other credentials.
+func awsCredentialChain(ctx context.Context) (interface{}, time.Time, error) {
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return awsCredentials{id, secret, os.Getenv("AWS_SESSION_TOKEN")}, time.Time{}, nil
+	}
+	file := awsFile("AWS_SHARED_CREDENTIALS_FILE", "credentials")
+	if id := iniValue(file, awsProfile(), "aws_access_key_id"); id != "" {
+		return awsCredentials{
+			AccessKeyID:     id,
+			SecretAccessKey: iniValue(file, awsProfile(), "aws_secret_access_key"),
+			SessionToken:    iniValue(file, awsProfile(), "aws_session_token"),
+		}, time.Time{}, nil
+	}
+	for _, key := range awsUnsupportedKeys {
+		if iniValue(file, awsProfile(), key) != "" || iniValue(awsFile("AWS_CONFIG_FILE", "config"), awsConfigSection(), key) != "" {
+			return nil, time.Time{}, fmt.Errorf("AWS profile %s sets %s, which is not supported: export its credentials, e.g. with aws configure export-credentials --profile %s --format env", awsProfile(), key, awsProfile())
+		}
+	}
+	if tokenFile, role := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"), os.Getenv("AWS_ROLE_ARN"); tokenFile != "" && role != "" {
+		return awsWebIdentity(ctx, tokenFile, role)
+	}
+	if full, relative := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI"), os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); full != "" || relative != "" {
+		if full == "" {
+			full = "http://169.254.170.2" + relative
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, full, nil)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		if token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN"); token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		return awsMetadataCredentials(req)
+	}
+	if os.Getenv("AWS_EC2_METADATA_DISABLED") != "true" {
+		if creds, expires, err := awsInstanceCredentials(ctx); err == nil {
+			return creds, expires, nil
+		}
+	}
+	return nil, time.Time{}, fmt.Errorf("no AWS credentials found: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY or configure a profile")
+}
+
+// awsInstanceCredentials asks the EC2 instance metadata service (IMDSv2)
+// for the credentials of the instance role.
+func awsInstanceCredentials(ctx context.Context) (interface{}, time.Time, error) {
+	const imds = "http://169.254.169.254/latest"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, imds+"/api/token", nil)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token-Ttl-Seconds", "21600")
+	resp, err := metadataClient.Do(req)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	token, err := readBody(resp, "imds: token", "")
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	get := func(path string) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, imds+"/meta-data/iam/security-credentials/"+path, nil)
+		if err == nil {
+			req.Header.Set("X-Aws-Ec2-Metadata-Token", string(token))
+		}
+		return req, err
+	}
+	req, err = get("")
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	resp, err = metadataClient.Do(req)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	role, err := readBody(resp, "imds: role", "")
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	req, err = get(strings.TrimSpace(string(role)))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return awsMetadataCredentials(req)
+}
+
+// awsMetadataCredentials reads the credentials answered by a container or
+// instance metadata endpoint.
+func awsMetadataCredentials(req *http.Request) (interface{}, time.Time, error) {
+	resp, err := metadataClient.Do(req)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to get AWS credentials: %w", err)
+	}
+	data, err := readBody(resp, "credentials", "")
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var creds struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		Token           string    `json:"Token"`
+		Expiration      time.Time `json:"Expiration"`
+	}
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, time.Time{}, fmt.Errorf("invalid AWS credentials response: %w", err)
+	}
+	return awsCredentials{creds.AccessKeyID, creds.SecretAccessKey, creds.Token}, creds.Expiration, nil
+}
+
+// awsWebIdentity exchanges the web identity token of the pod for
+// credentials of role with STS.
+func awsWebIdentity(ctx context.Context, tokenFile, role string) (interface{}, time.Time, error) {
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to read web identity token: %w", err)
+	}
+	session := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if session == "" {
+		session = "llm-translate"
+	}
+	query := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {role},
+		"RoleSessionName":  {session},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	endpoint := "https://sts.amazonaws.com/"
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		endpoint = "https://sts." + region + ".amazonaws.com/"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(query.Encode()))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to assume role %s: %w", role, err)
+	}
+	data, err := readBody(resp, "sts: assume role", role)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var result struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.Unmarshal(data, &result); err != nil {
+		return nil, time.Time{}, fmt.Errorf("invalid STS response: %w", err)
+	}
+	c := result.Credentials
+	return awsCredentials{c.AccessKeyID, c.SecretAccessKey, c.SessionToken}, c.Expiration, nil
+}
+
+// iniValue returns key in section of an INI file such as ~/.aws/config,
+// or "" when the file, section or key is missing.
+func iniValue(path, section, key string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	current := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if ok && current == section && strings.TrimSpace(name) == key {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
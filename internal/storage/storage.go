@@ -0,0 +1,126 @@
+// Package storage reads and writes documents in object storage: Amazon S3
+// (s3://bucket/key), Google Cloud Storage (gs://bucket/key) and Azure Blob
+// Storage (az://container/blob). Credentials come from the environment,
+// credential files and metadata services, as the SDKs of each cloud find
+// them.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bucket is a bucket or container of an object store.
+type Bucket interface {
+	// Get returns the content of the object key. A missing object is
+	// reported with an error wrapping os.ErrNotExist.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Put creates or replaces the object key.
+	Put(ctx context.Context, key string, data []byte) error
+	// List returns the keys of the objects whose key starts with prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// client transfers objects. Metadata services use their own client with a
+// short timeout, so runs off the cloud do not wait for them.
+var client = &http.Client{Timeout: 5 * time.Minute}
+
+var metadataClient = &http.Client{Timeout: 2 * time.Second}
+
+// IsURL reports whether path is an object storage URL rather than a local
+// path.
+func IsURL(path string) bool {
+	return strings.HasPrefix(path, "s3://") || strings.HasPrefix(path, "gs://") || strings.HasPrefix(path, "az://")
+}
+
+// Open returns the bucket of rawURL and the key within it. The key is
+// empty for a URL naming the bucket only.
+func Open(rawURL string) (Bucket, string, error) {
+	scheme, rest, _ := strings.Cut(rawURL, "://")
+	name, key, _ := strings.Cut(rest, "/")
+	if name == "" {
+		return nil, "", fmt.Errorf("invalid storage URL %q: no bucket", rawURL)
+	}
+	switch scheme {
+	case "s3":
+		return newS3Bucket(name), key, nil
+	case "gs":
+		return newGCSBucket(name), key, nil
+	case "az":
+		bucket, err := newAzureContainer(name)
+		return bucket, key, err
+	}
+	return nil, "", fmt.Errorf("unsupported storage URL %q: use s3://, gs:// or az://", rawURL)
+}
+
+// escapeKey percent-encodes everything in key but unreserved characters
+// and slashes, as the request signatures expect.
+func escapeKey(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c == '/' || isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '_' || c == '.' || c == '~'
+}
+
+// checkResponse returns an error for non-2xx answers, with os.ErrNotExist
+// for 404, and closes their body.
+func checkResponse(resp *http.Response, op, key string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound && key != "" {
+		return fmt.Errorf("%s %s: %w", op, key, os.ErrNotExist)
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 500))
+	return fmt.Errorf("%s %s: %s: %s", op, key, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// readBody reads the body of a successful response.
+func readBody(resp *http.Response, op, key string) ([]byte, error) {
+	if err := checkResponse(resp, op, key); err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// cachedToken keeps a credential until shortly before it expires. A zero
+// expiry never expires.
+type cachedToken struct {
+	mu      sync.Mutex
+	value   interface{}
+	expires time.Time
+}
+
+// get returns the cached value or a new one from fetch.
+func (c *cachedToken) get(fetch func() (interface{}, time.Time, error)) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.value != nil && (c.expires.IsZero() || time.Until(c.expires) > time.Minute) {
+		return c.value, nil
+	}
+	value, expires, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	c.value, c.expires = value, expires
+	return value, nil
+}
@@ -0,0 +1,359 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/foxzi/llm-translate/internal/logging"
+)
+
+// exportInterval is how often spans and counters are sent during a run.
+const exportInterval = 10 * time.Second
+
+// exporter sends spans and counters to an OTLP/HTTP collector as JSON. It
+// is nil until Setup is called with an endpoint, and then every function
+// of the package does nothing.
+var exporter *otlpExporter
+
+type otlpExporter struct {
+	endpoint string
+	headers  map[string]string
+	service  string
+	client   *http.Client
+	started  time.Time
+	stop     chan struct{}
+	done     chan struct{}
+
+	mu       sync.Mutex
+	spans    []*Span
+	counters map[string]*counter
+}
+
+type counter struct {
+	name  string
+	attrs []Attr
+	value int64
+}
+
+// Attr is a span or counter attribute.
+type Attr struct {
+	Key   string
+	Value interface{} // string or int64
+}
+
+func String(key, value string) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+func Int(key string, value int) Attr {
+	return Attr{Key: key, Value: int64(value)}
+}
+
+// Setup enables export to the collector at endpoint, such as
+// http://localhost:4318, or at OTEL_EXPORTER_OTLP_ENDPOINT when endpoint is
+// empty. Without either telemetry stays disabled. OTEL_EXPORTER_OTLP_HEADERS
+// and OTEL_SERVICE_NAME are honored.
+func Setup(endpoint string) {
+	if exporter != nil {
+		return
+	}
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	if endpoint == "" {
+		return
+	}
+
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = "llm-translate"
+	}
+
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	exporter = &otlpExporter{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		headers:  headers,
+		service:  service,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		started:  time.Now(),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		counters: make(map[string]*counter),
+	}
+	go exporter.loop()
+}
+
+// Shutdown sends what is left and stops the export.
+func Shutdown(ctx context.Context) error {
+	e := exporter
+	if e == nil {
+		return nil
+	}
+	exporter = nil
+
+	close(e.stop)
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return e.export(ctx)
+}
+
+func (e *otlpExporter) loop() {
+	defer close(e.done)
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), exportInterval)
+			if err := e.export(ctx); err != nil {
+				logging.Warn("Telemetry export failed: %v", err)
+			}
+			cancel()
+		case <-e.stop:
+			return
+		}
+	}
+}
+
+// Span is one timed operation. A nil Span, returned while telemetry is
+// disabled, ignores all calls.
+type Span struct {
+	name     string
+	traceID  string
+	spanID   string
+	parentID string
+	start    time.Time
+	end      time.Time
+	attrs    []Attr
+	err      error
+}
+
+type spanKey struct{}
+
+// Start begins a span, a child of the span in ctx if there is one, and
+// returns a context carrying it.
+func Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	if exporter == nil {
+		return ctx, nil
+	}
+
+	s := &Span{name: name, spanID: randomID(8), start: time.Now(), attrs: attrs}
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok {
+		s.traceID = parent.traceID
+		s.parentID = parent.spanID
+	} else {
+		s.traceID = randomID(16)
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// SetAttr adds an attribute known only after the span started.
+func (s *Span) SetAttr(attr Attr) {
+	if s == nil {
+		return
+	}
+	s.attrs = append(s.attrs, attr)
+}
+
+// End finishes the span, marking it failed when err is not nil.
+func (s *Span) End(err error) {
+	e := exporter
+	if s == nil || e == nil {
+		return
+	}
+	s.end = time.Now()
+	s.err = err
+
+	e.mu.Lock()
+	e.spans = append(e.spans, s)
+	e.mu.Unlock()
+}
+
+// Count adds n to the counter with the given name and attributes.
+func Count(name string, n int, attrs ...Attr) {
+	e := exporter
+	if e == nil {
+		return
+	}
+
+	key := name
+	for _, a := range attrs {
+		key += fmt.Sprintf("\x00%s=%v", a.Key, a.Value)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	c, ok := e.counters[key]
+	if !ok {
+		c = &counter{name: name, attrs: attrs}
+		e.counters[key] = c
+	}
+	c.value += int64(n)
+}
+
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// export sends the finished spans and the current counter values. Counters
+// are cumulative since the start of the run.
+func (e *otlpExporter) export(ctx context.Context) error {
+	e.mu.Lock()
+	spans := e.spans
+	e.spans = nil
+	counters := make([]counter, 0, len(e.counters))
+	for _, c := range e.counters {
+		counters = append(counters, *c)
+	}
+	e.mu.Unlock()
+
+	if len(spans) > 0 {
+		if err := e.post(ctx, "/v1/traces", e.traces(spans)); err != nil {
+			return err
+		}
+	}
+	if len(counters) > 0 {
+		if err := e.post(ctx, "/v1/metrics", e.metrics(counters)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *otlpExporter) post(ctx context.Context, path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", e.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned status %d for %s", resp.StatusCode, path)
+	}
+	return nil
+}
+
+// The types below follow the OTLP JSON encoding.
+
+type keyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+func otlpAttrs(attrs []Attr) []keyValue {
+	list := make([]keyValue, 0, len(attrs))
+	for _, a := range attrs {
+		switch v := a.Value.(type) {
+		case int64:
+			list = append(list, keyValue{Key: a.Key, Value: map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}})
+		default:
+			list = append(list, keyValue{Key: a.Key, Value: map[string]interface{}{"stringValue": fmt.Sprint(v)}})
+		}
+	}
+	return list
+}
+
+func (e *otlpExporter) resource() map[string]interface{} {
+	return map[string]interface{}{"attributes": otlpAttrs([]Attr{String("service.name", e.service)})}
+}
+
+var scope = map[string]interface{}{"name": "github.com/foxzi/llm-translate"}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func (e *otlpExporter) traces(spans []*Span) map[string]interface{} {
+	list := make([]map[string]interface{}, len(spans))
+	for i, s := range spans {
+		status := map[string]interface{}{"code": 1} // ok
+		if s.err != nil {
+			status = map[string]interface{}{"code": 2, "message": s.err.Error()}
+		}
+		list[i] = map[string]interface{}{
+			"traceId":           s.traceID,
+			"spanId":            s.spanID,
+			"parentSpanId":      s.parentID,
+			"name":              s.name,
+			"kind":              1, // internal
+			"startTimeUnixNano": unixNano(s.start),
+			"endTimeUnixNano":   unixNano(s.end),
+			"attributes":        otlpAttrs(s.attrs),
+			"status":            status,
+		}
+	}
+
+	return map[string]interface{}{"resourceSpans": []interface{}{map[string]interface{}{
+		"resource":   e.resource(),
+		"scopeSpans": []interface{}{map[string]interface{}{"scope": scope, "spans": list}},
+	}}}
+}
+
+func (e *otlpExporter) metrics(counters []counter) map[string]interface{} {
+	sort.Slice(counters, func(i, j int) bool {
+		return counters[i].name < counters[j].name
+	})
+
+	now := time.Now()
+	var list []map[string]interface{}
+	points := make(map[string][]interface{})
+	for _, c := range counters {
+		if _, ok := points[c.name]; !ok {
+			list = append(list, map[string]interface{}{"name": c.name})
+		}
+		points[c.name] = append(points[c.name], map[string]interface{}{
+			"attributes":        otlpAttrs(c.attrs),
+			"startTimeUnixNano": unixNano(e.started),
+			"timeUnixNano":      unixNano(now),
+			"asInt":             strconv.FormatInt(c.value, 10),
+		})
+	}
+	for _, m := range list {
+		m["sum"] = map[string]interface{}{
+			"aggregationTemporality": 2, // cumulative
+			"isMonotonic":            true,
+			"dataPoints":             points[m["name"].(string)],
+		}
+	}
+
+	return map[string]interface{}{"resourceMetrics": []interface{}{map[string]interface{}{
+		"resource":     e.resource(),
+		"scopeMetrics": []interface{}{map[string]interface{}{"scope": scope, "metrics": list}},
+	}}}
+}
@@ -0,0 +1,107 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/provider"
+)
+
+// RunAnalyzer runs a custom analyzer from config on text and returns the
+// value to store in frontmatter. {target_lang} in the prompt is replaced
+// with targetLang. An answer that does not match is a ParseError.
+func (t *Translator) RunAnalyzer(ctx context.Context, a config.Analyzer, text, targetLang string) (interface{}, error) {
+	if err := t.ensureProvider(); err != nil {
+		return nil, err
+	}
+
+	prompt := strings.ReplaceAll(a.Prompt, "{target_lang}", targetLang)
+	if len(a.Schema) > 0 {
+		schema, err := json.Marshal(a.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema: %w", err)
+		}
+		prompt += "\n\nRespond with a single JSON object matching this JSON schema, no markdown:\n" + string(schema)
+	}
+
+	resp, err := t.translateWithRetry(ctx, provider.TranslateRequest{
+		Text:         text,
+		TargetLang:   targetLang,
+		SystemPrompt: prompt,
+		Temperature:  0.1,
+		MaxTokens:    1000,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseAnalyzerResponse(a, resp.Text)
+}
+
+// parseAnalyzerResponse extracts the analyzer value. With a schema the
+// answer is decoded as JSON and must contain the schema's required keys.
+// With a pattern, named groups give a map, otherwise the first group (or the
+// whole match) is the value. Without either the trimmed answer is used.
+// Numeric values are stored as numbers.
+func parseAnalyzerResponse(a config.Analyzer, response string) (interface{}, error) {
+	response = strings.TrimSpace(response)
+
+	if len(a.Schema) > 0 {
+		var value interface{}
+		if err := json.Unmarshal([]byte(provider.ExtractJSONObject(response)), &value); err != nil {
+			return nil, &provider.ParseError{Msg: "invalid JSON analyzer response", Raw: response}
+		}
+		if required, ok := a.Schema["required"].([]interface{}); ok {
+			object, _ := value.(map[string]interface{})
+			for _, key := range required {
+				if _, ok := object[fmt.Sprint(key)]; !ok {
+					return nil, &provider.ParseError{Msg: fmt.Sprintf("analyzer response misses %q", key), Raw: response}
+				}
+			}
+		}
+		return value, nil
+	}
+
+	if a.Pattern == "" {
+		if response == "" {
+			return nil, &provider.ParseError{Msg: "empty analyzer response", Raw: response}
+		}
+		return analyzerValue(response), nil
+	}
+
+	re, err := regexp.Compile(a.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	match := re.FindStringSubmatch(response)
+	if match == nil {
+		return nil, &provider.ParseError{Msg: "analyzer response does not match pattern", Raw: response}
+	}
+
+	named := make(map[string]interface{})
+	for i, name := range re.SubexpNames() {
+		if i > 0 && name != "" {
+			named[name] = analyzerValue(match[i])
+		}
+	}
+	if len(named) > 0 {
+		return named, nil
+	}
+	if len(match) > 1 {
+		return analyzerValue(match[1]), nil
+	}
+	return analyzerValue(match[0]), nil
+}
+
+func analyzerValue(s string) interface{} {
+	s = strings.TrimSpace(s)
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
@@ -0,0 +1,116 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/logging"
+	"github.com/foxzi/llm-translate/internal/metering"
+)
+
+// ErrBudgetExceeded is returned for requests made after the estimated cost
+// of the run reached max_cost.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// budget watches the estimated cost of the run before each provider
+// request. It warns once at each alert threshold, fails requests once the
+// maximum is reached and, with a pace, holds requests back so the cost
+// grows no faster than the maximum spread over the pace duration.
+type budget struct {
+	max       float64
+	alerts    []float64 // fractions of max, ascending
+	pace      time.Duration
+	start     time.Time
+	providers map[string]config.ProviderConfig
+	meter     *metering.Meter
+
+	mu     sync.Mutex
+	warned int // alerts already reported
+}
+
+// newBudget returns nil when no max_cost is set. An invalid pace is
+// reported by config validation and ignored here.
+func newBudget(cfg *config.Config, meter *metering.Meter) *budget {
+	if cfg.Settings.MaxCost <= 0 {
+		return nil
+	}
+
+	b := &budget{
+		max:       cfg.Settings.MaxCost,
+		start:     time.Now(),
+		providers: cfg.Providers,
+		meter:     meter,
+	}
+	for _, pct := range cfg.Settings.BudgetAlerts {
+		b.alerts = append(b.alerts, pct/100)
+	}
+	sort.Float64s(b.alerts)
+	if pace, err := time.ParseDuration(cfg.Settings.BudgetPace); err == nil && pace > 0 {
+		b.pace = pace
+	}
+
+	if p := cfg.Providers[cfg.DefaultProvider]; p.InputPrice == 0 && p.OutputPrice == 0 {
+		logging.Warn("max_cost is set but provider %s has no input_price or output_price, its requests count as free", cfg.DefaultProvider)
+	}
+	return b
+}
+
+// cost estimates what the run has spent so far.
+func (b *budget) cost() float64 {
+	total := 0.0
+	for _, u := range b.meter.Snapshot() {
+		total += b.providers[u.Provider].Cost(u.InputTokens, u.OutputTokens)
+	}
+	return total
+}
+
+// wait blocks until the next request fits the budget and pace. Requests
+// already in flight when the maximum is reached still complete, so the
+// final cost can exceed it by their share. A nil budget never blocks.
+func (b *budget) wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+
+	cost := b.cost()
+	b.warn(cost)
+	if cost >= b.max {
+		return fmt.Errorf("%w: estimated cost %.4f reached max_cost %g", ErrBudgetExceeded, cost, b.max)
+	}
+	if b.pace == 0 {
+		return nil
+	}
+
+	// The cost so far is allowed once its share of the pace has passed
+	delay := time.Until(b.start.Add(time.Duration(cost / b.max * float64(b.pace))))
+	if delay <= 0 {
+		return nil
+	}
+	if delay >= time.Minute {
+		logging.Info("Spent %.4f of %g, pausing %v to keep pace", cost, b.max, delay.Round(time.Second))
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// warn reports the alert thresholds cost has crossed since the last call.
+func (b *budget) warn(cost float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.warned < len(b.alerts) && cost >= b.alerts[b.warned]*b.max {
+		logging.Warn("Budget alert: estimated cost %.4f is %.0f%% of max_cost %g", cost, b.alerts[b.warned]*100, b.max)
+		b.warned++
+	}
+}
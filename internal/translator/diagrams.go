@@ -0,0 +1,215 @@
+package translator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/foxzi/llm-translate/internal/provider"
+)
+
+// diagramFenceRe matches the opening fence of a mermaid or plantuml block.
+var diagramFenceRe = regexp.MustCompile("^\\s*(```|~~~)\\s*(mermaid|plantuml|puml)\\b")
+
+// diagramLabelRes find the human-readable labels of diagram lines, in the
+// first group: quoted text, messages and notes after a colon, titles,
+// aliases, plantuml activities, edge labels between pipes and node shapes
+// after an ID. A label found by an earlier pattern wins. Keywords, IDs and
+// arrows are never matched.
+var diagramLabelRes = []*regexp.Regexp{
+	regexp.MustCompile(`"([^"]+)"`),
+	regexp.MustCompile(`^\s*(?:[^:"]*(?:-+>+|<-+|-+[x)]|\.+>)[^:"]*|[Nn]ote\b[^:"]*)\s*:\s*([^"]+?)\s*$`),
+	regexp.MustCompile(`^\s*title\s*:?\s+([^"]+?)\s*$`),
+	regexp.MustCompile(`^\s*(?:participant|actor)\s+\w+\s+as\s+([^"]+?)\s*$`),
+	regexp.MustCompile(`^\s*:([^;"]+);\s*$`),
+	regexp.MustCompile(`\|([^|"]+)\|`),
+	regexp.MustCompile(`\w\s*(?:\[\[|\[\(|\(\(|\(\[|\{\{|[\[({])([^\[\](){}"|]+)(?:\]\]|\)\]|\)\)|\]\)|\}\}|[\])}])`),
+}
+
+// diagramSkipRe matches lines without labels: styling, links and
+// comments.
+var diagramSkipRe = regexp.MustCompile(`^\s*(?:classDef|class|style|linkStyle|click|skinparam|!|%%|')`)
+
+// diagramLabel is a label within a diagram line.
+type diagramLabel struct {
+	line       int
+	start, end int
+}
+
+// diagramPlaceholder stands for the nth diagram block in the text sent to
+// the model.
+func diagramPlaceholder(n int) string {
+	return fmt.Sprintf("[[DIAGRAM_%d]]", n+1)
+}
+
+// extractDiagrams replaces the mermaid and plantuml blocks of text with
+// placeholders, so the model does not touch their syntax, and returns the
+// blocks.
+func extractDiagrams(text string) (string, []string) {
+	lines := strings.SplitAfter(text, "\n")
+	var blocks []string
+	var out, block strings.Builder
+	fence := ""
+	for _, line := range lines {
+		if fence != "" {
+			block.WriteString(line)
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				out.WriteString(diagramPlaceholder(len(blocks)) + "\n")
+				blocks = append(blocks, block.String())
+				block.Reset()
+				fence = ""
+			}
+			continue
+		}
+		if m := diagramFenceRe.FindStringSubmatch(line); m != nil {
+			fence = m[1]
+			block.WriteString(line)
+			continue
+		}
+		out.WriteString(line)
+	}
+	// An unclosed block is left to the model
+	out.WriteString(block.String())
+	return out.String(), blocks
+}
+
+// restoreDiagrams puts the blocks back in place of their placeholders and
+// returns the placeholders the model lost.
+func restoreDiagrams(text string, blocks []string) (string, []string) {
+	var missing []string
+	for i, block := range blocks {
+		placeholder := diagramPlaceholder(i)
+		if !strings.Contains(text, placeholder) {
+			missing = append(missing, placeholder)
+			continue
+		}
+		// The placeholder was written on a line of its own
+		text = strings.Replace(text, placeholder+"\n", block, 1)
+		text = strings.Replace(text, placeholder, strings.TrimSuffix(block, "\n"), 1)
+	}
+	return text, missing
+}
+
+// translateDiagrams translates the labels of the diagram blocks in one
+// request and returns the blocks with translated labels. Blocks are
+// returned unchanged when the answer does not have a line per label.
+func (t *Translator) translateDiagrams(ctx context.Context, req TranslateRequest, blocks []string) []string {
+	type found struct {
+		lines  []string
+		labels []diagramLabel
+	}
+	var diagrams []found
+	var numbered strings.Builder
+	count := 0
+	for _, block := range blocks {
+		lines := strings.Split(block, "\n")
+		labels := diagramLabels(lines)
+		for _, l := range labels {
+			count++
+			fmt.Fprintf(&numbered, "%d. %s\n", count, lines[l.line][l.start:l.end])
+		}
+		diagrams = append(diagrams, found{lines, labels})
+	}
+	if count == 0 {
+		return blocks
+	}
+
+	resp, err := t.translateWithRetry(ctx, provider.TranslateRequest{
+		Text:        numbered.String(),
+		SourceLang:  req.SourceLang,
+		TargetLang:  req.TargetLang,
+		Style:       t.styleInstructions(req),
+		Context:     "Numbered labels of diagrams. Translate each label, keep the numbers, one label per line, no other text.",
+		Glossary:    req.Glossary,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+	})
+	if err != nil {
+		t.logWarn("Diagram labels not translated: %v", err)
+		return blocks
+	}
+	translated := make(map[int]string)
+	for _, line := range strings.Split(resp.Text, "\n") {
+		number, label, ok := strings.Cut(strings.TrimSpace(line), ". ")
+		if n, err := strconv.Atoi(number); ok && err == nil {
+			translated[n] = strings.TrimSpace(label)
+		}
+	}
+	if len(translated) != count {
+		t.logWarn("Diagram labels not translated: %d labels sent, %d returned", count, len(translated))
+		return blocks
+	}
+
+	result := make([]string, len(blocks))
+	n := 0
+	for i, d := range diagrams {
+		// Replacing a label shifts the later ones of its line
+		offsets := make(map[int]int)
+		for _, l := range d.labels {
+			n++
+			label := strings.NewReplacer("\"", "'", "|", "/", "\n", " ").Replace(translated[n])
+			if label == "" {
+				continue
+			}
+			shift := offsets[l.line]
+			line := d.lines[l.line]
+			d.lines[l.line] = line[:l.start+shift] + label + line[l.end+shift:]
+			offsets[l.line] = shift + len(label) - (l.end - l.start)
+		}
+		result[i] = strings.Join(d.lines, "\n")
+	}
+	return result
+}
+
+// diagramLabels returns the labels of the lines of a diagram block, in
+// order, without the fence lines.
+func diagramLabels(lines []string) []diagramLabel {
+	var labels []diagramLabel
+	for i := 1; i < len(lines)-1; i++ {
+		line := lines[i]
+		if diagramSkipRe.MatchString(line) {
+			continue
+		}
+		var spans []diagramLabel
+		for _, re := range diagramLabelRes {
+			for _, m := range re.FindAllStringSubmatchIndex(line, -1) {
+				start, end := m[2], m[3]
+				// Keep the spaces around a label
+				for start < end && line[start] == ' ' {
+					start++
+				}
+				for end > start && line[end-1] == ' ' {
+					end--
+				}
+				if !hasLetter(line[start:end]) || overlaps(spans, start, end) {
+					continue
+				}
+				spans = append(spans, diagramLabel{i, start, end})
+			}
+		}
+		// In line order, so later replacements see the shifted positions
+		for a := 1; a < len(spans); a++ {
+			for b := a; b > 0 && spans[b].start < spans[b-1].start; b-- {
+				spans[b], spans[b-1] = spans[b-1], spans[b]
+			}
+		}
+		labels = append(labels, spans...)
+	}
+	return labels
+}
+
+func overlaps(spans []diagramLabel, start, end int) bool {
+	for _, s := range spans {
+		if start < s.end && s.start < end {
+			return true
+		}
+	}
+	return false
+}
+
+func hasLetter(s string) bool {
+	return strings.IndexFunc(s, unicode.IsLetter) != -1
+}
@@ -0,0 +1,71 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/foxzi/llm-translate/internal/provider"
+)
+
+// dryRunAPIKey replaces the API key in dry runs, so it never shows up in
+// printed URLs.
+const dryRunAPIKey = "API_KEY"
+
+// ChunkPayload is what a chunk would be sent to the provider with.
+type ChunkPayload struct {
+	Chunk   int
+	Text    string                    // chunk as split, before guard tags
+	Request provider.TranslateRequest // request passed to the provider
+	Payload *provider.Payload         // HTTP request, nil for providers running a local command
+}
+
+// DryRun builds the request of every chunk of req the way Translate would
+// send it to the default provider, and returns them without sending
+// anything. The API key is replaced with a placeholder and request hooks
+// are left out. Context carried over from the translation of preceding
+// chunks does not exist yet, so it is missing from the requests. Text that
+// is all marked not to be translated yields no chunks.
+func (t *Translator) DryRun(ctx context.Context, req TranslateRequest) ([]ChunkPayload, error) {
+	name := t.config.DefaultProvider
+	providerCfg, ok := t.config.Providers[name]
+	if !ok {
+		return nil, fmt.Errorf("provider %s not configured", name)
+	}
+	providerCfg.APIKey = dryRunAPIKey
+	providerCfg.APIKeyCmd, providerCfg.APIKeyKeychain = "", ""
+	providerCfg.RequestHooks = nil
+
+	log := &provider.PayloadLog{}
+	p, err := provider.Get(name, providerCfg, provider.DryRunClient(log))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize provider: %w", err)
+	}
+	t.provider = p
+	t.fitModelLimits(&req)
+
+	m, err := t.mask(req)
+	if err != nil || m.nothingLeft {
+		return nil, err
+	}
+
+	segments, _ := t.chunkSegments(t.splitIntoChunks(m.text, t.chunkSize()), req)
+	payloads := make([]ChunkPayload, 0, len(segments))
+	for i, seg := range segments {
+		chunk := ChunkPayload{
+			Chunk:   i + 1,
+			Text:    seg.Text,
+			Request: t.providerRequest(req, seg, ""),
+		}
+		if !provider.RunsCommand(p) {
+			if _, err := p.Translate(ctx, chunk.Request); !errors.Is(err, provider.ErrNotSent) {
+				return nil, fmt.Errorf("chunk %d: failed to build request: %w", i+1, err)
+			}
+			if sent := log.Take(); len(sent) > 0 {
+				chunk.Payload = &sent[0]
+			}
+		}
+		payloads = append(payloads, chunk)
+	}
+	return payloads, nil
+}
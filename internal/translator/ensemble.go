@@ -0,0 +1,180 @@
+package translator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/provider"
+)
+
+// ensemblePickPrompt asks the judge for the number of the best translation.
+const ensemblePickPrompt = `You are given a source text and numbered translations of it into %s.
+Pick the translation that is most accurate, then most fluent, and keeps the formatting of the source.
+Output only its number, nothing else.`
+
+// ensembleMergePrompt asks the judge for one translation combining the
+// candidates.
+const ensembleMergePrompt = `You are given a source text and numbered translations of it into %s.
+Write the best translation of the source, combining the strengths of the translations and fixing their errors.
+Keep the formatting of the source. Output only the translation, without numbers or comments.`
+
+var judgeNumber = regexp.MustCompile(`\d+`)
+
+// ensemble holds the providers of ensemble mode.
+type ensemble struct {
+	members []provider.Provider
+	judge   provider.Provider
+	merge   bool
+}
+
+// newEnsemble creates the member and judge providers from the ensemble
+// config. It returns nil when no members are configured.
+func (t *Translator) newEnsemble() (*ensemble, error) {
+	cfg := t.config.Ensemble
+	if len(cfg.Members) == 0 {
+		return nil, nil
+	}
+
+	e := &ensemble{judge: t.provider, merge: cfg.Merge}
+	for _, spec := range cfg.Members {
+		p, err := t.ensembleProvider(spec)
+		if err != nil {
+			return nil, err
+		}
+		e.members = append(e.members, p)
+	}
+	if cfg.Judge != "" {
+		judge, err := t.ensembleProvider(cfg.Judge)
+		if err != nil {
+			return nil, err
+		}
+		e.judge = judge
+	}
+	return e, nil
+}
+
+// ensembleProvider creates the provider of a provider[:model] entry.
+func (t *Translator) ensembleProvider(spec string) (provider.Provider, error) {
+	name, model := config.SplitProviderSpec(spec)
+	providerCfg, ok := t.config.Providers[name]
+	if !ok {
+		return nil, fmt.Errorf("ensemble: provider %s not configured", name)
+	}
+
+	// Resolved key is stored back so api_key_cmd runs once per process
+	if err := providerCfg.ResolveAPIKey(); err != nil {
+		return nil, fmt.Errorf("ensemble: provider %s: %w", name, err)
+	}
+	t.config.Providers[name] = providerCfg
+
+	if model != "" {
+		providerCfg.Model = model
+	}
+	client, err := t.createHTTPClient(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+	p, err := provider.Get(name, providerCfg, client)
+	if err != nil {
+		return nil, fmt.Errorf("ensemble: failed to initialize provider %s: %w", name, err)
+	}
+	p.SetMeter(t.meter)
+	return p, nil
+}
+
+// translateEnsemble translates a chunk with all members at once and
+// returns the translation the judge picks or merges. Failed and truncated
+// translations are left out; when one is left, the judge is not asked.
+func (t *Translator) translateEnsemble(ctx context.Context, req provider.TranslateRequest) (provider.TranslateResponse, error) {
+	e := t.ensemble
+	resps := make([]provider.TranslateResponse, len(e.members))
+	errs := make([]error, len(e.members))
+
+	var wg sync.WaitGroup
+	for i, p := range e.members {
+		wg.Add(1)
+		go func(i int, p provider.Provider) {
+			defer wg.Done()
+			resps[i], errs[i] = t.translateChunkWith(ctx, p, req)
+		}(i, p)
+	}
+	wg.Wait()
+
+	var candidates []provider.TranslateResponse
+	var firstErr error
+	truncated := -1
+	for i, p := range e.members {
+		switch {
+		case errs[i] != nil:
+			t.logWarn("Ensemble member %s failed: %v", p.Name(), errs[i])
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+		case resps[i].Truncated:
+			t.logWarn("Ensemble member %s: output truncated at the max tokens limit", p.Name())
+			truncated = i
+		default:
+			candidates = append(candidates, resps[i])
+		}
+	}
+
+	switch {
+	case len(candidates) == 1:
+		return candidates[0], nil
+	case len(candidates) == 0 && truncated >= 0:
+		// Reported as truncated, so the chunk is split
+		return resps[truncated], nil
+	case len(candidates) == 0:
+		return provider.TranslateResponse{}, firstErr
+	}
+
+	var text strings.Builder
+	text.WriteString("Source:\n" + req.Text)
+	for i, c := range candidates {
+		fmt.Fprintf(&text, "\n\nTranslation %d:\n%s", i+1, c.Text)
+	}
+
+	if e.merge {
+		resp, err := t.translateChunkWith(ctx, e.judge, provider.TranslateRequest{
+			Text:         text.String(),
+			TargetLang:   req.TargetLang,
+			SystemPrompt: fmt.Sprintf(ensembleMergePrompt, req.TargetLang),
+			Temperature:  req.Temperature,
+			MaxTokens:    req.MaxTokens,
+		})
+		if err == nil && (resp.Truncated || strings.TrimSpace(resp.Text) == "") {
+			err = fmt.Errorf("no complete translation returned")
+		}
+		if err == nil {
+			return resp, nil
+		}
+		t.logWarn("Ensemble judge %s could not merge the translations (%v), using the first one", e.judge.Name(), err)
+		return candidates[0], nil
+	}
+
+	resp, err := t.requestWithRetry(ctx, e.judge, provider.TranslateRequest{
+		Text:         text.String(),
+		TargetLang:   req.TargetLang,
+		SystemPrompt: fmt.Sprintf(ensemblePickPrompt, req.TargetLang),
+		Temperature:  0.1,
+		MaxTokens:    10,
+	})
+	if err != nil {
+		t.logWarn("Ensemble judge %s failed (%v), using the first translation", e.judge.Name(), err)
+		return candidates[0], nil
+	}
+	n, _ := strconv.Atoi(judgeNumber.FindString(resp.Text))
+	if n < 1 || n > len(candidates) {
+		t.logWarn("Ensemble judge %s gave no valid choice (%q), using the first translation", e.judge.Name(), strings.TrimSpace(resp.Text))
+		return candidates[0], nil
+	}
+	if t.verbose {
+		t.logInfo("Ensemble judge picked translation %d of %d", n, len(candidates))
+	}
+	return candidates[n-1], nil
+}
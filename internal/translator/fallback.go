@@ -0,0 +1,96 @@
+package translator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// fallbackCache keeps the last good translation of every segment in a JSON
+// file, keyed by target language and exact source text. When a segment
+// cannot be translated, its cached translation is used instead of failing
+// the whole document. A nil cache stores nothing and finds nothing.
+type fallbackCache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]string
+	dirty   bool
+}
+
+// loadFallbackCache reads the cache at path; a missing file is an empty
+// cache. Returns nil for an empty path.
+func loadFallbackCache(path string) (*fallbackCache, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	c := &fallbackCache{path: path, entries: make(map[string]string)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func fallbackKey(targetLang, text string) string {
+	sum := sha256.Sum256([]byte(targetLang + "\x00" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *fallbackCache) get(targetLang, text string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	translated, ok := c.entries[fallbackKey(targetLang, text)]
+	return translated, ok
+}
+
+func (c *fallbackCache) put(targetLang, text, translated string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := fallbackKey(targetLang, text)
+	if c.entries[key] != translated {
+		c.entries[key] = translated
+		c.dirty = true
+	}
+}
+
+// save writes the cache atomically if it changed.
+func (c *fallbackCache) save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
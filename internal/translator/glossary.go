@@ -0,0 +1,68 @@
+package translator
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/foxzi/llm-translate/internal/config"
+)
+
+// glossaryInText returns the glossary entries whose source term occurs in
+// text, for trim_glossary.
+func glossaryInText(glossary []config.GlossaryEntry, text string) []config.GlossaryEntry {
+	var found []config.GlossaryEntry
+	for _, entry := range glossary {
+		source, _ := glossarySourceTarget(entry)
+		if termInText(source, text, entry.CaseSensitive) {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+// termInText reports whether term occurs in text, in any inflected form:
+// words longer than four letters may change their last two letters and take
+// any ending, as Russian and German case endings do, shorter ones a plural s.
+func termInText(term, text string, caseSensitive bool) bool {
+	words := strings.Fields(term)
+	if len(words) == 0 {
+		return false
+	}
+	stems := make([]string, len(words))
+	for i, word := range words {
+		stems[i] = glossaryStem(word)
+	}
+	// Most terms are not in the text, skip them before compiling a pattern
+	if !strings.Contains(strings.ToLower(text), strings.ToLower(stems[0])) {
+		return false
+	}
+	return glossaryPattern(stems, words, caseSensitive).MatchString(text)
+}
+
+// glossaryStem drops the last two letters of words longer than four
+// letters, where inflection endings usually are.
+func glossaryStem(word string) string {
+	runes := []rune(word)
+	if len(runes) <= 4 {
+		return word
+	}
+	return string(runes[:len(runes)-2])
+}
+
+// glossaryPattern matches the words of a term, each by its stem, separated
+// by spaces or hyphens.
+func glossaryPattern(stems, words []string, caseSensitive bool) *regexp.Regexp {
+	parts := make([]string, len(stems))
+	for i, stem := range stems {
+		if stem == words[i] {
+			parts[i] = regexp.QuoteMeta(stem) + `s?`
+		} else {
+			parts[i] = regexp.QuoteMeta(stem) + `[\p{L}\p{N}]*`
+		}
+	}
+	pattern := `(?:^|[^\p{L}\p{N}])` + strings.Join(parts, `[\s-]+`) + `(?:$|[^\p{L}\p{N}])`
+	if !caseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.MustCompile(pattern)
+}
@@ -0,0 +1,52 @@
+package translator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// keepRe matches the parts of a text that are not translated: everything
+// between <!-- llm-translate:skip --> and <!-- llm-translate:end -->, or
+// to the end without an end marker, and ::notranslate::spans:: within a
+// line, whose text is the first group.
+var keepRe = regexp.MustCompile(`(?s)<!--\s*llm-translate:skip\s*-->(?:.*?<!--\s*llm-translate:end\s*-->|.*)|::notranslate::([^\n]+?)::`)
+
+// keepPlaceholderRe matches the placeholders of kept parts.
+var keepPlaceholderRe = regexp.MustCompile(`\[\[KEEP_\d+\]\]`)
+
+// keepPlaceholder stands for the nth part kept verbatim in the text sent
+// to the model.
+func keepPlaceholder(n int) string {
+	return fmt.Sprintf("[[KEEP_%d]]", n+1)
+}
+
+// extractKept replaces the parts of text marked as not to be translated
+// with placeholders and returns them. Skip blocks are kept with their
+// markers, notranslate spans without, so they read as plain text.
+func extractKept(text string) (string, []string) {
+	var kept []string
+	text = keepRe.ReplaceAllStringFunc(text, func(match string) string {
+		if m := keepRe.FindStringSubmatch(match); m[1] != "" {
+			match = m[1]
+		}
+		kept = append(kept, match)
+		return keepPlaceholder(len(kept) - 1)
+	})
+	return text, kept
+}
+
+// restoreKept puts the kept parts back in place of their placeholders and
+// returns the placeholders the model lost.
+func restoreKept(text string, kept []string) (string, []string) {
+	var missing []string
+	for i, part := range kept {
+		placeholder := keepPlaceholder(i)
+		if !strings.Contains(text, placeholder) {
+			missing = append(missing, placeholder)
+			continue
+		}
+		text = strings.Replace(text, placeholder, part, 1)
+	}
+	return text, missing
+}
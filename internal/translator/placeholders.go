@@ -0,0 +1,115 @@
+package translator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/provider"
+	"github.com/foxzi/llm-translate/internal/redact"
+)
+
+// sentinelRe matches the sentinels that stand for protected placeholders.
+var sentinelRe = regexp.MustCompile(`\[\[PH_\d+\]\]`)
+
+// maskedRe matches what stands for protected placeholders, kept parts and
+// redacted values in the text sent to the model, which must all come back.
+var maskedRe = regexp.MustCompile(sentinelRe.String() + "|" + keepPlaceholderRe.String() + "|" + redact.PlaceholderRe.String())
+
+// sentinel stands for the nth protected placeholder in the text sent to
+// the model.
+func sentinel(n int) string {
+	return fmt.Sprintf("[[PH_%d]]", n+1)
+}
+
+// placeholderPattern combines the syntaxes of protect and the patterns of
+// protect_patterns into one expression. Returns nil when both are empty.
+func placeholderPattern(settings config.Settings) (*regexp.Regexp, error) {
+	var parts []string
+	for _, name := range settings.Protect {
+		pattern, ok := config.PlaceholderSyntaxes[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown placeholder syntax %s", name)
+		}
+		parts = append(parts, pattern)
+	}
+	for _, pattern := range settings.ProtectPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid protect pattern %q: %w", pattern, err)
+		}
+		parts = append(parts, pattern)
+	}
+	if len(parts) == 0 {
+		return nil, nil
+	}
+	return regexp.Compile("(?:" + strings.Join(parts, ")|(?:") + ")")
+}
+
+// protectPlaceholders replaces the placeholders of text with sentinels and
+// returns the placeholders.
+func protectPlaceholders(text string, re *regexp.Regexp) (string, []string) {
+	var tokens []string
+	text = re.ReplaceAllStringFunc(text, func(token string) string {
+		tokens = append(tokens, token)
+		return sentinel(len(tokens) - 1)
+	})
+	return text, tokens
+}
+
+// restorePlaceholders puts the placeholders back in place of their
+// sentinels.
+func restorePlaceholders(text string, tokens []string) string {
+	for i, token := range tokens {
+		text = strings.Replace(text, sentinel(i), token, 1)
+	}
+	return text
+}
+
+// missingSentinels returns the sentinels, kept part and redaction
+// placeholders of source that translated lacks.
+func missingSentinels(source, translated string) []string {
+	var missing []string
+	for _, s := range maskedRe.FindAllString(source, -1) {
+		if !strings.Contains(translated, s) {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+// enforcePlaceholders re-translates a chunk that lost placeholders, kept
+// parts or redacted values, naming their sentinels in the feedback. The chunk fails when they are still
+// missing after the retries.
+func (t *Translator) enforcePlaceholders(ctx context.Context, providerReq provider.TranslateRequest, chunk, translated string, req TranslateRequest) (string, error) {
+	missing := missingSentinels(chunk, translated)
+	if len(missing) == 0 {
+		return translated, nil
+	}
+
+	retries := t.config.Settings.ProtectRetries
+	for retry := 1; retry <= retries; retry++ {
+		if t.verbose {
+			t.logInfo("Translation lost placeholders %s, retry %d/%d...", strings.Join(missing, ", "), retry, retries)
+		}
+
+		retryReq := providerReq
+		retryReq.Context = fmt.Sprintf(
+			"Previous translation lost these placeholders: %s. Copy every placeholder in double square brackets into the translation exactly as it is. %s",
+			strings.Join(missing, ", "), req.Context,
+		)
+
+		retryResp, err := t.translateChunk(ctx, retryReq)
+		if err != nil {
+			continue
+		}
+
+		missing = missingSentinels(chunk, retryResp.Text)
+		if len(missing) == 0 {
+			return retryResp.Text, nil
+		}
+	}
+
+	return "", fmt.Errorf("translation lost placeholders: %s", strings.Join(missing, ", "))
+}
@@ -0,0 +1,67 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/foxzi/llm-translate/internal/logging"
+	"github.com/foxzi/llm-translate/internal/metering"
+	"github.com/foxzi/llm-translate/internal/provider"
+)
+
+// checkPromptBudget estimates the tokens taken by the parts of a request
+// besides the chunk text. When the document had to be split, it warns once
+// per run if they take more than the text itself or more than the room
+// reserved for them next to the chunk. In verbose mode the parts of every
+// chunk are logged.
+func (t *Translator) checkPromptBudget(chunk int, split bool, req provider.TranslateRequest) {
+	parts := []struct {
+		name   string
+		tokens int
+	}{
+		{"system prompt", metering.EstimateTokens(req.SystemPrompt)},
+		{"glossary", 0},
+		{"context", metering.EstimateTokens(req.Context)},
+		{"previous chunks", metering.EstimateTokens(req.Previous)},
+	}
+	if len(req.Glossary) > 0 {
+		parts[1].tokens = metering.EstimateTokens(provider.GlossaryPrompt(req.Glossary))
+	}
+
+	total := 0
+	var summary []string
+	for _, part := range parts {
+		if part.tokens == 0 {
+			continue
+		}
+		total += part.tokens
+		summary = append(summary, fmt.Sprintf("%s %d", part.name, part.tokens))
+	}
+	text := metering.EstimateTokens(req.Text)
+	if t.verbose {
+		t.logInfo("Chunk %d: ~%d tokens of text, ~%d of prompt (%s)", chunk, text, total, strings.Join(summary, ", "))
+	}
+
+	if !split {
+		// A short prompt for a short text does not crowd anything out
+		return
+	}
+	var problem string
+	switch {
+	case total > text:
+		problem = fmt.Sprintf("more than the ~%d tokens of text", text)
+	case t.provider.Limits().ContextWindow > 0 && total > promptReserve:
+		problem = fmt.Sprintf("more than the %d tokens reserved for them in the context window", promptReserve)
+	default:
+		return
+	}
+	if t.promptWarned.Swap(true) {
+		return
+	}
+
+	hint := "shorten the context or system prompt"
+	if parts[1].tokens > total/2 && !t.config.Settings.TrimGlossary {
+		hint = "use trim_glossary to send only the terms found in each chunk"
+	}
+	logging.Warn("Chunk %d: prompt takes ~%d tokens (%s), %s; %s", chunk, total, strings.Join(summary, ", "), problem, hint)
+}
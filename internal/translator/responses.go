@@ -0,0 +1,66 @@
+package translator
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/foxzi/llm-translate/internal/provider"
+)
+
+// ChunkResponse is a provider response that went into a chunk of the
+// translation: the first answer, continuations and retries of checks.
+type ChunkResponse struct {
+	Chunk int `json:"chunk"`
+	provider.ResponseMeta
+}
+
+// responseLog collects the responses of one document. It travels in the
+// context with the number of the chunk being translated, so workers
+// translating chunks in parallel record into the same log.
+type responseLog struct {
+	mu        sync.Mutex
+	responses []ChunkResponse
+}
+
+type responseLogKey struct{}
+
+type chunkLog struct {
+	log   *responseLog
+	chunk int
+}
+
+func withResponseLog(ctx context.Context, log *responseLog) context.Context {
+	return context.WithValue(ctx, responseLogKey{}, chunkLog{log: log})
+}
+
+// withChunk makes responses recorded with ctx count for chunk.
+func withChunk(ctx context.Context, chunk int) context.Context {
+	cl, ok := ctx.Value(responseLogKey{}).(chunkLog)
+	if !ok {
+		return ctx
+	}
+	cl.chunk = chunk
+	return context.WithValue(ctx, responseLogKey{}, cl)
+}
+
+// recordResponse adds a response to the log in ctx. Requests outside a
+// chunk, such as language detection, are not recorded.
+func recordResponse(ctx context.Context, meta provider.ResponseMeta) {
+	cl, ok := ctx.Value(responseLogKey{}).(chunkLog)
+	if !ok || cl.chunk == 0 || meta == (provider.ResponseMeta{}) {
+		return
+	}
+	cl.log.mu.Lock()
+	defer cl.log.mu.Unlock()
+	cl.log.responses = append(cl.log.responses, ChunkResponse{Chunk: cl.chunk, ResponseMeta: meta})
+}
+
+// list returns the responses in chunk order.
+func (l *responseLog) list() []ChunkResponse {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	list := append([]ChunkResponse(nil), l.responses...)
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Chunk < list[j].Chunk })
+	return list
+}
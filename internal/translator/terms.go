@@ -0,0 +1,248 @@
+package translator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/provider"
+)
+
+// termsPrompt asks which translation was used for each key term of a chunk.
+const termsPrompt = `You review a translation into %s. The input contains a SOURCE text and its TRANSLATION.
+List the key terms of the source (names, domain terms, recurring concepts), at most 15.
+Output one line per term in the format: source term => translation used
+If the term is translated in different ways, separate them with " | ".
+Output only the list.`
+
+// TermIssue is a source term rendered inconsistently across the document or
+// differently from the glossary.
+type TermIssue struct {
+	Term       string
+	Renderings []string
+	Expected   string // glossary translation, or the most frequent rendering
+	chunks     []int  // chunks using a rendering other than Expected
+}
+
+func (i TermIssue) String() string {
+	return fmt.Sprintf("%s: %s (expected %s)", i.Term, strings.Join(i.Renderings, ", "), i.Expected)
+}
+
+// termUse tracks how often each rendering of a term was used and where.
+type termUse struct {
+	term     string
+	counts   map[string]int
+	names    map[string]string
+	order    []string
+	chunks   map[string][]int
+	glossary string
+}
+
+// checkTerminology verifies that key terms are translated the same way in
+// all chunks and as the glossary requires. With fix enabled, chunks using a
+// deviating rendering are translated again with the expected one pinned.
+// Returns the updated results and the issues that remain.
+func (t *Translator) checkTerminology(ctx context.Context, req TranslateRequest, segments []Segment, results []string, fix bool) ([]string, []TermIssue) {
+	uses := make(map[string]*termUse)
+	var keys []string
+	use := func(term string) *termUse {
+		key := strings.ToLower(term)
+		u, ok := uses[key]
+		if !ok {
+			u = &termUse{
+				term:   term,
+				counts: make(map[string]int),
+				names:  make(map[string]string),
+				chunks: make(map[string][]int),
+			}
+			uses[key] = u
+			keys = append(keys, key)
+		}
+		return u
+	}
+
+	for _, entry := range req.Glossary {
+		source, target := glossarySourceTarget(entry)
+		if source == "" || target == "" {
+			continue
+		}
+		use(source).glossary = target
+	}
+
+	for i, seg := range segments {
+		// Glossary terms are checked directly, no model needed. Source
+		// terms left in the output are replaced later, so check that text.
+		processed := applyGlossaryPostProcessing(results[i], req.Glossary)
+		for _, entry := range req.Glossary {
+			source, target := glossarySourceTarget(entry)
+			if source == "" || target == "" || !termInText(source, seg.Text, entry.CaseSensitive) {
+				continue
+			}
+			if termInText(target, processed, false) {
+				uses[strings.ToLower(source)].add(target, i)
+			} else {
+				uses[strings.ToLower(source)].add("(missing)", i)
+			}
+		}
+
+		pairs, err := t.extractTerms(ctx, req, seg.Text, results[i])
+		if err != nil {
+			t.logWarn("Term extraction failed for chunk %d: %v", i+1, err)
+			continue
+		}
+		for term, renderings := range pairs {
+			if u, ok := uses[strings.ToLower(term)]; ok && u.glossary != "" {
+				continue
+			}
+			for _, r := range renderings {
+				use(term).add(r, i)
+			}
+		}
+	}
+
+	sort.Strings(keys)
+	var issues []TermIssue
+	for _, key := range keys {
+		if issue, ok := uses[key].issue(); ok {
+			issues = append(issues, issue)
+		}
+	}
+
+	if !fix || len(issues) == 0 {
+		return results, issues
+	}
+
+	// Pin the expected rendering of every issue in the affected chunks
+	pins := make(map[int][]config.GlossaryEntry)
+	for _, issue := range issues {
+		for _, i := range issue.chunks {
+			pins[i] = append(pins[i], config.GlossaryEntry{Source: issue.Term, Target: issue.Expected})
+		}
+	}
+
+	fixed := make(map[int]bool)
+	for i := range segments {
+		entries, ok := pins[i]
+		if !ok {
+			continue
+		}
+
+		fixReq := req
+		fixReq.Glossary = append(append([]config.GlossaryEntry{}, req.Glossary...), entries...)
+		text, err := t.translateSegment(ctx, fixReq, segments[i], i, len(segments), "")
+		if err != nil {
+			t.logWarn("Re-translation of chunk %d for terminology failed: %v", i+1, err)
+			continue
+		}
+		if t.verbose {
+			t.logInfo("Re-translated chunk %d for consistent terminology", i+1)
+		}
+		results[i] = text
+		fixed[i] = true
+	}
+
+	var remaining []TermIssue
+	for _, issue := range issues {
+		for _, i := range issue.chunks {
+			if !fixed[i] {
+				remaining = append(remaining, issue)
+				break
+			}
+		}
+	}
+	return results, remaining
+}
+
+// extractTerms asks the model which translation each key term received.
+func (t *Translator) extractTerms(ctx context.Context, req TranslateRequest, source, translated string) (map[string][]string, error) {
+	resp, err := t.translateWithRetry(ctx, provider.TranslateRequest{
+		Text:         "SOURCE:\n" + source + "\n\nTRANSLATION:\n" + translated,
+		TargetLang:   req.TargetLang,
+		SystemPrompt: fmt.Sprintf(termsPrompt, req.TargetLang),
+		Temperature:  0.1,
+		MaxTokens:    500,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseTerms(resp.Text), nil
+}
+
+// parseTerms reads "term => rendering | rendering" lines. Renderings not
+// present in the translation are kept, the model is trusted here.
+func parseTerms(text string) map[string][]string {
+	terms := make(map[string][]string)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimLeft(strings.TrimSpace(line), "-*• ")
+		parts := strings.SplitN(line, "=>", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		term := strings.TrimSpace(parts[0])
+		if term == "" {
+			continue
+		}
+		for _, r := range strings.Split(parts[1], "|") {
+			if r = strings.TrimSpace(r); r != "" {
+				terms[term] = append(terms[term], r)
+			}
+		}
+	}
+	return terms
+}
+
+func (u *termUse) add(rendering string, chunk int) {
+	key := strings.ToLower(rendering)
+	if _, ok := u.names[key]; !ok {
+		u.names[key] = rendering
+		u.order = append(u.order, key)
+	}
+	u.counts[key]++
+	u.chunks[key] = append(u.chunks[key], chunk)
+}
+
+// issue reports the term if it has more than one rendering, or a rendering
+// other than the glossary one.
+func (u *termUse) issue() (TermIssue, bool) {
+	if len(u.order) == 0 {
+		return TermIssue{}, false
+	}
+
+	expected := u.glossary
+	if expected == "" {
+		if len(u.order) < 2 {
+			return TermIssue{}, false
+		}
+		// Most frequent rendering wins, the earliest one on ties
+		best := u.order[0]
+		for _, key := range u.order[1:] {
+			if u.counts[key] > u.counts[best] {
+				best = key
+			}
+		}
+		expected = u.names[best]
+	}
+
+	issue := TermIssue{Term: u.term, Expected: expected}
+	seen := make(map[int]bool)
+	for _, key := range u.order {
+		issue.Renderings = append(issue.Renderings, u.names[key])
+		if strings.EqualFold(key, expected) {
+			continue
+		}
+		for _, i := range u.chunks[key] {
+			if !seen[i] {
+				seen[i] = true
+				issue.chunks = append(issue.chunks, i)
+			}
+		}
+	}
+
+	if len(issue.chunks) == 0 {
+		return TermIssue{}, false
+	}
+	sort.Ints(issue.chunks)
+	return issue, true
+}
@@ -0,0 +1,149 @@
+package translator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// tocMarkers are the comments around generated tables of contents: those of
+// doctoc and of markdown-toc, in lowercase since models may change the case.
+var tocMarkers = []struct{ start, end string }{
+	{"<!-- start doctoc", "<!-- end doctoc"},
+	{"<!-- toc -->", "<!-- tocstop -->"},
+}
+
+var (
+	tocHeadingRe = regexp.MustCompile(`^#{1,6}[ \t]+(.+?)[ \t#]*$`)
+	tocEntryRe   = regexp.MustCompile(`^(\s*[-*+][ \t]+)\[.*\]\(#([^)]*)\)\s*$`)
+	tocLinkRe    = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+)
+
+// tocHeading is a markdown heading with its GitHub anchor.
+type tocHeading struct {
+	title  string
+	anchor string
+}
+
+// regenerateTOC rebuilds the table of contents of translated from its
+// headings when source has one, so the entries link to the translated
+// anchors instead of the stale source ones. Entries are matched to
+// headings by position, keeping the selection and nesting of the source
+// table. Other lines of the table, such as its title, stay translated.
+func regenerateTOC(source, translated string) (string, error) {
+	srcLines := strings.Split(source, "\n")
+	srcStart, srcEnd, ok := findTOC(srcLines)
+	if !ok {
+		return translated, nil
+	}
+	lines := strings.Split(translated, "\n")
+	start, end, ok := findTOC(lines)
+	if !ok {
+		return translated, fmt.Errorf("table of contents markers lost in translation")
+	}
+
+	srcHeadings := tocHeadings(srcLines, srcStart, srcEnd)
+	headings := tocHeadings(lines, start, end)
+	if len(headings) != len(srcHeadings) {
+		return translated, fmt.Errorf("%d headings in the source, %d in the translation", len(srcHeadings), len(headings))
+	}
+	index := make(map[string]int, len(srcHeadings))
+	for i, h := range srcHeadings {
+		index[h.anchor] = i
+	}
+
+	var entries []string
+	for _, line := range srcLines[srcStart+1 : srcEnd] {
+		m := tocEntryRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		i, ok := index[m[2]]
+		if !ok {
+			return translated, fmt.Errorf("no heading for entry #%s", m[2])
+		}
+		entries = append(entries, fmt.Sprintf("%s[%s](#%s)", m[1], headings[i].title, headings[i].anchor))
+	}
+
+	var block []string
+	for _, line := range lines[start+1 : end] {
+		if !tocEntryRe.MatchString(line) {
+			block = append(block, line)
+		} else if entries != nil {
+			block = append(block, entries...)
+			entries = nil
+		}
+	}
+	block = append(block, entries...)
+
+	out := append([]string{}, lines[:start+1]...)
+	out = append(out, block...)
+	out = append(out, lines[end:]...)
+	return strings.Join(out, "\n"), nil
+}
+
+// findTOC returns the lines of the start and end markers of a table of
+// contents.
+func findTOC(lines []string) (start, end int, ok bool) {
+	for _, marker := range tocMarkers {
+		start = -1
+		for i, line := range lines {
+			line = strings.ToLower(strings.TrimSpace(line))
+			if start < 0 && strings.Contains(line, marker.start) {
+				start = i
+			} else if start >= 0 && strings.Contains(line, marker.end) {
+				return start, i, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// tocHeadings returns the ATX headings of lines outside code fences and the
+// table of contents between lines start and end.
+func tocHeadings(lines []string, start, end int) []tocHeading {
+	var headings []tocHeading
+	seen := make(map[string]int)
+	fenced := false
+	for i, line := range lines {
+		if i >= start && i <= end {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		m := tocHeadingRe.FindStringSubmatch(line)
+		if fenced || m == nil {
+			continue
+		}
+		title := strings.NewReplacer("`", "", "*", "").Replace(tocLinkRe.ReplaceAllString(m[1], "$1"))
+		anchor := headingAnchor(title)
+		// Repeated headings get -1, -2 and so on, as on GitHub
+		if n := seen[anchor]; n > 0 {
+			seen[anchor] = n + 1
+			anchor = fmt.Sprintf("%s-%d", anchor, n)
+		} else {
+			seen[anchor] = 1
+		}
+		headings = append(headings, tocHeading{title: title, anchor: anchor})
+	}
+	return headings
+}
+
+// headingAnchor returns the anchor GitHub gives a heading: lowercase
+// letters, digits, hyphens and underscores, with spaces as hyphens.
+func headingAnchor(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
@@ -2,23 +2,58 @@ package translator
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"math"
+	"net"
 	"net/http"
-	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/currency"
+	"github.com/foxzi/llm-translate/internal/localize"
+	"github.com/foxzi/llm-translate/internal/logging"
+	"github.com/foxzi/llm-translate/internal/metering"
+	"github.com/foxzi/llm-translate/internal/postprocess"
 	"github.com/foxzi/llm-translate/internal/provider"
 	"github.com/foxzi/llm-translate/internal/proxy"
+	"github.com/foxzi/llm-translate/internal/readability"
+	"github.com/foxzi/llm-translate/internal/redact"
+	"github.com/foxzi/llm-translate/internal/telemetry"
 	"github.com/foxzi/llm-translate/internal/validator"
 )
 
 type Translator struct {
-	config   *config.Config
-	provider provider.Provider
-	verbose  bool
-	client   *http.Client
+	config       *config.Config
+	provider     provider.Provider
+	verbose      bool
+	client       *http.Client
+	limiter      *rateLimiter
+	budget       *budget
+	meter        *metering.Meter
+	health       *metering.Health
+	degraded     atomic.Bool // provider was reported as degraded
+	promptWarned atomic.Bool // prompt crowding out the text was reported
+	fallback     *fallbackCache
+	stale        atomic.Int64 // segments taken from the fallback cache
+	shrunk       atomic.Int64 // chunk size reduced after truncation or timeouts, 0 = configured
+	guardTag     string       // random tag enclosing chunks with the injection guard
+	post         *postprocess.Pipeline
+	ensemble     *ensemble    // members and judge of ensemble mode, nil when off
+	chunks       atomic.Int64 // chunks of translated documents
+	retries      atomic.Int64 // requests repeated after a failure
+
+	fitOnce   sync.Once
+	maxOutput int // max tokens the model allows, 0 = no limit
 }
 
 type TranslateRequest struct {
@@ -33,133 +68,895 @@ type TranslateRequest struct {
 	PreserveFormat bool
 	StrongMode     bool
 	StrongRetries  int
+	ReadingLevel   string
+	ReadingRetries int
+	LengthRetries  int
+	MaxLength      int     // per-segment character limit, 0 = unlimited
+	MaxLenRatio    float64 // per-segment limit relative to source length, 0 = unlimited
+}
+
+// Segment is a chunk of text with its length limit.
+type Segment struct {
+	ID         string
+	Text       string
+	MaxLength  int  // max characters in translation, 0 = unlimited
+	splittable bool // chunk of Translate, may be split when the chunk size shrinks
 }
 
 type TranslateResponse struct {
 	Text         string
 	DetectedLang string
-	TokensUsed   int
+	TermIssues   []TermIssue
+	StaleChunks  int // chunks taken from the fallback cache after a failure
+	Responses    []ChunkResponse
 }
 
 func New(cfg *config.Config, verbose bool) *Translator {
-	return &Translator{
+	t := &Translator{
 		config:  cfg,
 		verbose: verbose,
+		limiter: newRateLimiter(cfg.Settings.RateLimit),
+		meter:   metering.New(),
+		health:  metering.NewHealth(),
+	}
+	t.budget = newBudget(cfg, t.meter)
+
+	if cfg.Settings.InjectionGuard {
+		// A random tag cannot be closed early by the document itself
+		tag := make([]byte, 4)
+		rand.Read(tag)
+		t.guardTag = "text-" + hex.EncodeToString(tag)
+	}
+
+	fallback, err := loadFallbackCache(cfg.Settings.FallbackCache)
+	if err != nil {
+		t.logWarn("Fallback cache disabled: %v", err)
 	}
+	t.fallback = fallback
+	return t
+}
+
+// Meter returns the token usage of all requests made by this translator.
+func (t *Translator) Meter() *metering.Meter {
+	return t.meter
+}
+
+// Chunks returns the number of chunks of all documents translated so far.
+func (t *Translator) Chunks() int {
+	return int(t.chunks.Load())
+}
+
+// Retries returns the number of requests repeated after a failure.
+func (t *Translator) Retries() int {
+	return int(t.retries.Load())
+}
+
+// Health returns latency and error stats of the translation requests made
+// by this translator, including failed attempts that were retried.
+func (t *Translator) Health() *metering.Health {
+	return t.health
 }
 
 func (t *Translator) Translate(ctx context.Context, req TranslateRequest) (TranslateResponse, error) {
-	client, err := t.createHTTPClient()
+	// The client and provider are built once and kept warm across calls
+	if err := t.ensureProvider(); err != nil {
+		return TranslateResponse{}, err
+	}
+	t.fitModelLimits(&req)
+
+	m, err := t.mask(req)
 	if err != nil {
-		return TranslateResponse{}, fmt.Errorf("failed to create HTTP client: %w", err)
+		return TranslateResponse{}, err
 	}
-	t.client = client
+	if m.nothingLeft {
+		return TranslateResponse{Text: req.Text}, nil
+	}
+	text, kept, tokens, redacted, diagrams := m.text, m.kept, m.tokens, m.redacted, m.diagrams
 
-	providerCfg, ok := t.config.Providers[t.config.DefaultProvider]
-	if !ok {
-		return TranslateResponse{}, fmt.Errorf("provider %s not configured", t.config.DefaultProvider)
+	chunks := t.splitIntoChunks(text, t.chunkSize())
+	if t.verbose && len(chunks) > 1 {
+		t.logInfo("Text split into %d chunks", len(chunks))
 	}
 
-	p, err := provider.Get(t.config.DefaultProvider, providerCfg, t.client)
+	// Detect the language once and pin it, so the model cannot read the
+	// chunks of one document as different languages
+	var detected string
+	if req.SourceLang == "auto" && len(chunks) > 1 {
+		detected = t.detectLanguage(ctx, chunks[0])
+		if detected != "" {
+			req.SourceLang = detected
+			if t.verbose {
+				t.logInfo("Detected source language: %s", detected)
+			}
+		}
+	}
+
+	// Their second translation of overlaps is dropped below
+	segments, overlaps := t.chunkSegments(chunks, req)
+
+	staleBefore := t.stale.Load()
+	responses := &responseLog{}
+	ctx = withResponseLog(ctx, responses)
+	results, err := t.translateSegments(ctx, req, segments)
 	if err != nil {
-		return TranslateResponse{}, fmt.Errorf("failed to initialize provider: %w", err)
+		return TranslateResponse{}, err
 	}
-	t.provider = p
+	if err := t.fallback.save(); err != nil {
+		t.logWarn("Failed to save fallback cache: %v", err)
+	}
+
+	for i, overlap := range overlaps {
+		if overlap == "" {
+			continue
+		}
+		results[i] = dropOverlap(results[i], len(splitIntoSentences(overlap)))
+		segments[i].Text = chunks[i]
+	}
+
+	var termIssues []TermIssue
+	if t.config.Settings.CheckTerms || t.config.Settings.FixTerms {
+		results, termIssues = t.checkTerminology(ctx, req, segments, results, t.config.Settings.FixTerms)
+	}
+
+	finalText := strings.Join(results, "\n\n")
 
-	text := req.Text
 	if len(req.Glossary) > 0 {
-		text = applyGlossaryPreProcessing(text, req.Glossary)
+		finalText = applyGlossaryPostProcessing(finalText, req.Glossary)
 	}
 
-	chunks := t.splitIntoChunks(text, t.config.Settings.ChunkSize)
-	if t.verbose && len(chunks) > 1 {
-		t.logInfo("Text split into %d chunks", len(chunks))
+	if len(diagrams) > 0 {
+		var missing []string
+		finalText, missing = restoreDiagrams(finalText, t.translateDiagrams(ctx, req, diagrams))
+		if len(missing) > 0 {
+			logging.Warn("Diagrams lost in translation: %s", strings.Join(missing, ", "))
+		}
 	}
 
-	var results []string
-	totalTokens := 0
+	if len(redacted) > 0 {
+		var missing []string
+		finalText, missing = redact.Restore(finalText, redacted)
+		if len(missing) > 0 {
+			// The original values would be lost from the output
+			return TranslateResponse{}, fmt.Errorf("translation lost redaction placeholders: %s", strings.Join(missing, ", "))
+		}
+	}
 
-	for i, chunk := range chunks {
-		if t.verbose && len(chunks) > 1 {
-			t.logInfo("Translating chunk %d/%d...", i+1, len(chunks))
+	if t.config.Settings.FixCaps {
+		finalText = validator.FixCapitalization(finalText, req.TargetLang)
+	}
+
+	fromLang := req.SourceLang
+	if detected != "" {
+		fromLang = detected
+	}
+	if t.config.Settings.LocalizeDates {
+		finalText = localize.Dates(req.Text, finalText, fromLang, req.TargetLang)
+	}
+	if t.config.Settings.LocalizeNumbers {
+		finalText = localize.Numbers(req.Text, finalText, fromLang, req.TargetLang)
+	}
+	if t.config.Settings.ConvertUnits != "" {
+		finalText = localize.Units(finalText, t.config.Settings.ConvertUnits, req.TargetLang)
+	}
+
+	if t.config.Currency.Target != "" {
+		finalText = currency.New(t.config.Currency).Annotate(finalText)
+	}
+
+	if len(tokens) > 0 {
+		finalText = restorePlaceholders(finalText, tokens)
+	}
+
+	if len(kept) > 0 {
+		var missing []string
+		finalText, missing = restoreKept(finalText, kept)
+		if len(missing) > 0 {
+			return TranslateResponse{}, fmt.Errorf("translation lost do-not-translate parts: %s", strings.Join(missing, ", "))
 		}
+	}
+
+	if toc, err := regenerateTOC(req.Text, finalText); err != nil {
+		logging.Warn("Table of contents not regenerated: %v", err)
+	} else {
+		finalText = toc
+	}
+
+	return TranslateResponse{
+		Text:         finalText,
+		DetectedLang: detected,
+		TermIssues:   termIssues,
+		StaleChunks:  int(t.stale.Load() - staleBefore),
+		Responses:    responses.list(),
+	}, nil
+}
+
+// maskedText is the text of a request as it is sent to the model, with
+// what was taken out of it to be put back into the translation.
+type maskedText struct {
+	text        string
+	kept        []string
+	tokens      []string
+	redacted    map[string]string
+	diagrams    []string
+	nothingLeft bool // only parts not to be translated
+}
 
-		providerReq := provider.TranslateRequest{
-			Text:           chunk,
-			SourceLang:     req.SourceLang,
-			TargetLang:     req.TargetLang,
-			Style:          req.Style,
-			Context:        req.Context,
-			Glossary:       req.Glossary,
-			Temperature:    req.Temperature,
-			MaxTokens:      req.MaxTokens,
-			PreserveFormat: req.PreserveFormat,
+// mask takes the kept parts, placeholders, sensitive values and diagrams
+// out of the text of req. DryRun shares it so that its payloads are those
+// Translate would send.
+func (t *Translator) mask(req TranslateRequest) (maskedText, error) {
+	var m maskedText
+	m.text, m.kept = extractKept(req.Text)
+	if len(m.kept) > 0 && strings.TrimSpace(keepPlaceholderRe.ReplaceAllString(m.text, "")) == "" {
+		m.nothingLeft = true
+		return m, nil
+	}
+
+	protect, err := placeholderPattern(t.config.Settings)
+	if err != nil {
+		return m, err
+	}
+	if protect != nil {
+		m.text, m.tokens = protectPlaceholders(m.text, protect)
+		if t.verbose && len(m.tokens) > 0 {
+			t.logInfo("Protected %d placeholders", len(m.tokens))
 		}
+	}
 
-		resp, err := t.translateWithRetry(ctx, providerReq)
-		if err != nil {
-			return TranslateResponse{}, fmt.Errorf("failed to translate chunk %d: %w", i+1, err)
+	if t.config.Redaction.Enabled {
+		m.text, m.redacted = redact.New(t.config.Redaction).Redact(m.text)
+		if t.verbose && len(m.redacted) > 0 {
+			t.logInfo("Redacted %d sensitive values", len(m.redacted))
 		}
+	}
 
-		translatedChunk := resp.Text
+	if len(req.Glossary) > 0 {
+		m.text = applyGlossaryPreProcessing(m.text, req.Glossary)
+	}
+
+	if t.config.Settings.Diagrams {
+		m.text, m.diagrams = extractDiagrams(m.text)
+	}
+	return m, nil
+}
 
-		if req.StrongMode {
-			validated, err := t.validateTranslation(ctx, chunk, translatedChunk, req)
+// chunkSegments turns chunks into segments. With overlap, each chunk starts
+// with the last sentences of the one before it as a separate paragraph, so
+// the seam is translated with context on both sides; these sentences are
+// returned as overlaps.
+func (t *Translator) chunkSegments(chunks []string, req TranslateRequest) ([]Segment, []string) {
+	overlaps := make([]string, len(chunks))
+	segments := make([]Segment, len(chunks))
+	for i, chunk := range chunks {
+		text := chunk
+		if n := t.config.Settings.ChunkOverlap; n > 0 && i > 0 {
+			overlaps[i] = lastSentences(chunks[i-1], n)
+			text = overlaps[i] + "\n\n" + chunk
+		}
+		segments[i] = Segment{
+			ID:         strconv.Itoa(i + 1),
+			Text:       text,
+			MaxLength:  segmentLengthLimit(text, req.MaxLength, req.MaxLenRatio),
+			splittable: true,
+		}
+	}
+	return segments, overlaps
+}
+
+func (t *Translator) translateSegments(ctx context.Context, req TranslateRequest, segments []Segment) ([]string, error) {
+	t.chunks.Add(int64(len(segments)))
+	settings := t.config.Settings
+	carry := settings.CarrySentences > 0 || settings.CarrySummary
+
+	workers := settings.ChunkConcurrency
+	if workers > len(segments) {
+		workers = len(segments)
+	}
+	if workers > 1 && carry {
+		// Each chunk needs the translation of the one before it
+		t.logWarn("Context carry-over is enabled, translating chunks sequentially")
+		workers = 1
+	}
+	if workers <= 1 {
+		results := make([]string, 0, len(segments))
+		var summary, tail string
+		for i, seg := range segments {
+			text, err := t.translateSegment(ctx, req, seg, i, len(segments), carryContext(summary, tail))
 			if err != nil {
-				if t.verbose {
-					t.logWarn("Strong validation failed for chunk %d: %v", i+1, err)
+				return nil, err
+			}
+			results = append(results, text)
+
+			if !carry || i == len(segments)-1 {
+				continue
+			}
+			if settings.CarrySentences > 0 {
+				tail = lastSentences(text, settings.CarrySentences)
+			}
+			if settings.CarrySummary {
+				summary = t.updateSummary(ctx, req, summary, text)
+			}
+		}
+		return results, nil
+	}
+
+	if t.verbose {
+		t.logInfo("Translating %d chunks with %d workers", len(segments), workers)
+	}
+
+	// Results are stored by index, so the output order does not depend on
+	// which worker finishes first. The first failure cancels the others.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]string, len(segments))
+	errs := make([]error, len(segments))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], errs[i] = t.translateSegment(ctx, req, segments[i], i, len(segments), "")
+				if errs[i] != nil {
+					cancel()
 				}
+			}
+		}()
+	}
 
-				retrySuccess := false
-				for retry := 1; retry <= req.StrongRetries; retry++ {
-					if t.verbose {
-						t.logInfo("Retry %d/%d: requesting re-translation...", retry, req.StrongRetries)
-					}
+	for i := range segments {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Report the earliest real failure rather than a cancellation it caused
+	var firstErr error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if firstErr == nil || (errors.Is(firstErr, context.Canceled) && !errors.Is(err, context.Canceled)) {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
 
-					retryReq := providerReq
-					retryReq.Context = fmt.Sprintf(
-						"Previous translation contained untranslated text. Please ensure all text is properly translated to %s. %s",
-						req.TargetLang, req.Context,
-					)
+// translateSegment translates one segment with strong validation, reading
+// level and length enforcement. i and total are used for logging, previous
+// is the carried-over context of the preceding chunks.
+func (t *Translator) translateSegment(ctx context.Context, req TranslateRequest, seg Segment, i, total int, previous string) (_ string, err error) {
+	if t.verbose && total > 1 {
+		t.logInfo("Translating chunk %d/%d...", i+1, total)
+	}
 
-					retryResp, retryErr := t.translateWithRetry(ctx, retryReq)
-					if retryErr != nil {
-						continue
-					}
+	chunk := seg.Text
 
-					retryValidated, validateErr := t.validateTranslation(ctx, chunk, retryResp.Text, req)
-					if validateErr == nil {
-						translatedChunk = retryValidated
-						retrySuccess = true
-						if t.verbose {
-							t.logInfo("Strong validation passed")
-						}
-						break
-					}
+	ctx, span := telemetry.Start(ctx, "translate_chunk", telemetry.Int("chunk", i+1), telemetry.Int("chars", len(chunk)))
+	defer func() { span.End(err) }()
+	ctx = withChunk(ctx, i+1)
+
+	// Chunks split before the chunk size was reduced
+	if seg.splittable && len(chunk) > t.chunkSize() {
+		return t.translateSplit(ctx, req, seg, i, total, previous)
+	}
+
+	providerReq := t.providerRequest(req, seg, previous)
+	t.checkPromptBudget(i+1, seg.splittable && total > 1, providerReq)
+
+	translate := t.translateChunk
+	if t.ensemble != nil {
+		// Retries below for validation and limits use the default provider
+		translate = t.translateEnsemble
+	}
+	resp, err := translate(ctx, providerReq)
+	if err == nil && resp.Truncated {
+		t.logWarn("Chunk %d: output truncated at the max tokens limit", i+1)
+		if seg.splittable && t.shrinkChunkSize(len(chunk), "truncated output") {
+			return t.translateSplit(ctx, req, seg, i, total, previous)
+		}
+		// A cut translation is never written
+		err = fmt.Errorf("output truncated at the max tokens limit (%d), raise max_tokens or lower chunk_size", req.MaxTokens)
+	}
+	if err != nil && seg.splittable && isTimeout(ctx, err) && t.shrinkChunkSize(len(chunk), "timeouts") {
+		return t.translateSplit(ctx, req, seg, i, total, previous)
+	}
+	if err != nil {
+		if cached, ok := t.fallback.get(req.TargetLang, chunk); ok && ctx.Err() == nil && !errors.Is(err, ErrBudgetExceeded) {
+			logging.Warn("Chunk %d failed (%v), using cached translation", i+1, err)
+			t.stale.Add(1)
+			return cached, nil
+		}
+		return "", fmt.Errorf("failed to translate chunk %d: %w", i+1, err)
+	}
+
+	translatedChunk := resp.Text
+
+	if req.StrongMode {
+		validated, err := t.validateTranslation(ctx, chunk, translatedChunk, req)
+		if err != nil {
+			if t.verbose {
+				t.logWarn("Strong validation failed for chunk %d: %v", i+1, err)
+			}
+
+			retrySuccess := false
+			for retry := 1; retry <= req.StrongRetries; retry++ {
+				if t.verbose {
+					t.logInfo("Retry %d/%d: requesting re-translation...", retry, req.StrongRetries)
 				}
 
-				if !retrySuccess {
-					return TranslateResponse{}, fmt.Errorf("strong validation failed after %d retries", req.StrongRetries)
+				retryReq := providerReq
+				retryReq.Context = fmt.Sprintf(
+					"Previous translation contained untranslated text. Please ensure all text is properly translated to %s. %s",
+					req.TargetLang, req.Context,
+				)
+
+				retryResp, retryErr := t.translateChunk(ctx, retryReq)
+				if retryErr != nil {
+					continue
+				}
+
+				retryValidated, validateErr := t.validateTranslation(ctx, chunk, retryResp.Text, req)
+				if validateErr == nil {
+					translatedChunk = retryValidated
+					retrySuccess = true
+					if t.verbose {
+						t.logInfo("Strong validation passed")
+					}
+					break
 				}
-			} else {
-				translatedChunk = validated
 			}
+
+			if !retrySuccess {
+				return "", fmt.Errorf("strong validation failed after %d retries", req.StrongRetries)
+			}
+		} else {
+			translatedChunk = validated
 		}
+	}
 
-		results = append(results, translatedChunk)
-		totalTokens += resp.TokensUsed
+	if req.ReadingLevel != "" {
+		translatedChunk = t.enforceReadingLevel(ctx, providerReq, translatedChunk, req)
 	}
 
-	finalText := strings.Join(results, "\n\n")
+	if seg.MaxLength > 0 {
+		translatedChunk, err = t.enforceMaxLength(ctx, providerReq, translatedChunk, seg, req)
+		if err != nil {
+			return "", err
+		}
+	}
 
-	if len(req.Glossary) > 0 {
-		finalText = applyGlossaryPostProcessing(finalText, req.Glossary)
+	if len(t.config.Settings.BannedTerms) > 0 {
+		translatedChunk, err = t.enforceBannedTerms(ctx, providerReq, translatedChunk, req)
+		if err != nil {
+			return "", err
+		}
 	}
 
-	return TranslateResponse{
-		Text:       finalText,
-		TokensUsed: totalTokens,
-	}, nil
+	translatedChunk, err = t.enforcePlaceholders(ctx, providerReq, chunk, translatedChunk, req)
+	if err != nil {
+		return "", err
+	}
+
+	t.fallback.put(req.TargetLang, chunk, translatedChunk)
+	return translatedChunk, nil
+}
+
+// providerRequest builds the provider request for seg, enclosed in guard
+// tags when the injection guard is on. With trim_glossary, only the terms
+// found in seg are sent.
+func (t *Translator) providerRequest(req TranslateRequest, seg Segment, previous string) provider.TranslateRequest {
+	glossary := req.Glossary
+	if t.config.Settings.TrimGlossary {
+		glossary = glossaryInText(glossary, seg.Text)
+	}
+
+	providerReq := provider.TranslateRequest{
+		Text:           seg.Text,
+		SourceLang:     req.SourceLang,
+		TargetLang:     req.TargetLang,
+		Style:          t.styleInstructions(req),
+		Context:        req.Context,
+		Glossary:       glossary,
+		Temperature:    req.Temperature,
+		MaxTokens:      req.MaxTokens,
+		PreserveFormat: req.PreserveFormat,
+		ReadingLevel:   req.ReadingLevel,
+		SystemPrompt:   t.renderSystemPrompt(req),
+		Previous:       previous,
+		Delimiter:      t.guardTag,
+		MaxLength:      seg.MaxLength,
+	}
+
+	if t.guardTag != "" {
+		providerReq.Text = "<" + t.guardTag + ">\n" + seg.Text + "\n</" + t.guardTag + ">"
+	}
+	return providerReq
+}
+
+// minChunkSize is the smallest chunk size reached by shrinking.
+const minChunkSize = 250
+
+// chunkSize returns the configured chunk size, or the reduced one after
+// truncated output or timeouts.
+func (t *Translator) chunkSize() int {
+	if size := t.shrunk.Load(); size > 0 {
+		return int(size)
+	}
+	return t.config.Settings.ChunkSize
+}
+
+// promptReserve is the room in the context window left for the system
+// prompt, context and glossary, in tokens.
+const promptReserve = 1000
+
+// bytesPerToken is on the low side, so chunks fit the limits also in
+// languages that take more tokens than English.
+const bytesPerToken = 2
+
+// fitModelLimits lowers max tokens and the chunk size to what the model
+// handles. The limits are checked and reported once per run.
+func (t *Translator) fitModelLimits(req *TranslateRequest) {
+	t.fitOnce.Do(func() {
+		t.maxOutput = t.checkModelLimits(req.MaxTokens)
+	})
+	if t.maxOutput > 0 && req.MaxTokens > t.maxOutput {
+		req.MaxTokens = t.maxOutput
+	}
+}
+
+// checkModelLimits warns about settings the model cannot fit and returns
+// the max tokens to use. The chunk size is reduced so a chunk fits the
+// context window next to the answer and its translation fits max tokens.
+func (t *Translator) checkModelLimits(maxTokens int) int {
+	limits := t.provider.Limits()
+	model := t.config.Providers[t.config.DefaultProvider].Model
+	if model == "" {
+		model = t.provider.Name()
+	}
+
+	if limits.MaxOutput > 0 && maxTokens > limits.MaxOutput {
+		logging.Warn("max_tokens %d exceeds the output limit of %s, using %d", maxTokens, model, limits.MaxOutput)
+		maxTokens = limits.MaxOutput
+	}
+	if limits.ContextWindow > 0 && maxTokens > limits.ContextWindow/2 {
+		logging.Warn("max_tokens %d leaves too little room for input in the %d token context window of %s, using %d",
+			maxTokens, limits.ContextWindow, model, limits.ContextWindow/2)
+		maxTokens = limits.ContextWindow / 2
+	}
+	if maxTokens <= 0 {
+		return 0
+	}
+
+	chunkTokens := maxTokens
+	if limits.ContextWindow > 0 {
+		chunkTokens = min(chunkTokens, limits.ContextWindow-maxTokens-promptReserve)
+	}
+	if size := max(chunkTokens*bytesPerToken, minChunkSize); size < t.chunkSize() {
+		logging.Warn("chunk_size %d does not fit max_tokens %d of %s, using %d", t.chunkSize(), maxTokens, model, size)
+		t.shrunk.Store(int64(size))
+	}
+	return maxTokens
+}
+
+// shrinkChunkSize halves the chunk size for the rest of the run after a
+// chunk of n bytes failed with reason, so the following chunks do not fail
+// the same way. Reports whether the chunk is now over the size and can be
+// split.
+func (t *Translator) shrinkChunkSize(n int, reason string) bool {
+	for {
+		old := t.shrunk.Load()
+		if old > 0 && int(old) < n {
+			// Already reduced by another worker
+			return true
+		}
+		size := max(n/2, minChunkSize)
+		if size >= n {
+			return false
+		}
+		if t.shrunk.CompareAndSwap(old, int64(size)) {
+			logging.Warn("Chunk size reduced to %d after %s", size, reason)
+			return true
+		}
+	}
+}
+
+// translateSplit splits a chunk at the current chunk size and translates
+// the parts one by one.
+func (t *Translator) translateSplit(ctx context.Context, req TranslateRequest, seg Segment, i, total int, previous string) (string, error) {
+	parts := t.splitIntoChunks(seg.Text, t.chunkSize())
+	results := make([]string, len(parts))
+	for j, part := range parts {
+		sub := seg
+		sub.Text = part
+		sub.MaxLength = segmentLengthLimit(part, req.MaxLength, req.MaxLenRatio)
+		// A single word longer than the chunk size cannot be split further
+		sub.splittable = len(parts) > 1
+
+		text, err := t.translateSegment(ctx, req, sub, i, total, previous)
+		if err != nil {
+			return "", err
+		}
+		results[j] = text
+	}
+	return strings.Join(results, "\n\n"), nil
+}
+
+// isTimeout reports whether a request failed because the provider did not
+// answer in time, as opposed to the run being cancelled.
+func isTimeout(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// summaryPrompt asks for a short running summary of an already translated
+// document, used as context for the following chunks.
+const summaryPrompt = `You maintain a running summary of a document being translated into %s.
+Update the summary with the new part. Keep it under 100 words, written in %s.
+List the people and things mentioned with the names, genders and terms chosen in the translation.
+Output only the updated summary.`
+
+// updateSummary extends the rolling summary with a translated chunk. On
+// failure the previous summary is kept, carry-over is best effort.
+func (t *Translator) updateSummary(ctx context.Context, req TranslateRequest, summary, translated string) string {
+	text := "New part:\n" + translated
+	if summary != "" {
+		text = "Summary so far:\n" + summary + "\n\n" + text
+	}
+
+	resp, err := t.translateWithRetry(ctx, provider.TranslateRequest{
+		Text:         text,
+		TargetLang:   req.TargetLang,
+		SystemPrompt: fmt.Sprintf(summaryPrompt, req.TargetLang, req.TargetLang),
+		Temperature:  0.1,
+		MaxTokens:    300,
+	})
+	if err != nil {
+		t.logWarn("Failed to update context summary: %v", err)
+		return summary
+	}
+	return strings.TrimSpace(resp.Text)
+}
+
+// detectPrompt asks for the language code of a text.
+const detectPrompt = `Identify the language of the text. Output only its ISO 639-1 code (for example en, de, ru), nothing else.`
+
+// detectLanguage returns the ISO 639-1 code of the text's language, judged
+// from its beginning, or "" if the model gave no usable answer.
+func (t *Translator) detectLanguage(ctx context.Context, text string) string {
+	code, err := t.requestLanguage(ctx, text)
+	if err != nil {
+		t.logWarn("Language detection failed, chunks are detected separately: %v", err)
+		return ""
+	}
+	return code
+}
+
+// DetectLanguage returns the ISO 639-1 code of the text's language, judged
+// from its beginning.
+func (t *Translator) DetectLanguage(ctx context.Context, text string) (string, error) {
+	if err := t.ensureProvider(); err != nil {
+		return "", err
+	}
+	return t.requestLanguage(ctx, text)
+}
+
+func (t *Translator) requestLanguage(ctx context.Context, text string) (string, error) {
+	runes := []rune(text)
+	if len(runes) > 1000 {
+		runes = runes[:1000]
+	}
+
+	resp, err := t.translateWithRetry(ctx, provider.TranslateRequest{
+		Text:         string(runes),
+		SystemPrompt: detectPrompt,
+		Temperature:  0.1,
+		MaxTokens:    10,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(strings.Trim(strings.TrimSpace(resp.Text), ".`'\""))
+	if len(code) < 2 || len(code) > 3 || strings.IndexFunc(code, func(r rune) bool { return r < 'a' || r > 'z' }) != -1 {
+		return "", fmt.Errorf("no language code in answer %q", resp.Text)
+	}
+	return code, nil
+}
+
+// carryContext formats the summary and last sentences for the prompt.
+func carryContext(summary, tail string) string {
+	var parts []string
+	if summary != "" {
+		parts = append(parts, "Summary: "+summary)
+	}
+	if tail != "" {
+		parts = append(parts, "Last sentences: "+tail)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// lastSentences returns the last n sentences of text.
+func lastSentences(text string, n int) string {
+	sentences := splitIntoSentences(strings.Join(strings.Fields(text), " "))
+	if len(sentences) > n {
+		sentences = sentences[len(sentences)-n:]
+	}
+	return strings.Join(sentences, " ")
+}
+
+// dropOverlap removes the translated overlap from the start of a chunk
+// translation. The overlap was sent as its own paragraph, so it is normally
+// the first paragraph; if the model merged it, as many sentences as were
+// sent are dropped. When that is not possible the text is kept as is, a
+// repeated sentence is better than a lost one.
+func dropOverlap(translated string, sentences int) string {
+	translated = strings.TrimSpace(translated)
+	if head, rest, ok := strings.Cut(translated, "\n\n"); ok {
+		if len(splitIntoSentences(head)) == sentences {
+			return strings.TrimSpace(rest)
+		}
+	}
+
+	end := 0
+	for i := 0; i < len(translated)-1 && sentences > 0; i++ {
+		if strings.ContainsRune(".!?", rune(translated[i])) && unicode.IsSpace(rune(translated[i+1])) {
+			sentences--
+			end = i + 1
+		}
+	}
+	if sentences > 0 {
+		return translated
+	}
+	return strings.TrimSpace(translated[end:])
+}
+
+// segmentLengthLimit returns the stricter of the absolute limit and the
+// limit derived from the source length and ratio. Zero means unlimited.
+func segmentLengthLimit(source string, maxLength int, ratio float64) int {
+	limit := maxLength
+	if ratio > 0 {
+		byRatio := int(math.Ceil(float64(utf8.RuneCountInString(strings.TrimSpace(source))) * ratio))
+		if limit == 0 || byRatio < limit {
+			limit = byRatio
+		}
+	}
+	return limit
+}
+
+// enforceMaxLength re-translates a segment with "shorten" feedback until it
+// fits the segment's character limit.
+func (t *Translator) enforceMaxLength(ctx context.Context, providerReq provider.TranslateRequest, translated string, seg Segment, req TranslateRequest) (string, error) {
+	fits, length := validator.ValidateLength(translated, seg.MaxLength)
+	if fits {
+		return translated, nil
+	}
+
+	for retry := 1; retry <= req.LengthRetries; retry++ {
+		if t.verbose {
+			t.logInfo("Segment %s is %d chars, limit %d, retry %d/%d...", seg.ID, length, seg.MaxLength, retry, req.LengthRetries)
+		}
+
+		retryReq := providerReq
+		retryReq.Context = fmt.Sprintf(
+			"Previous translation was %d characters long, but the limit is %d characters. Shorten the translation to fit the limit while keeping the key meaning. %s",
+			length, seg.MaxLength, req.Context,
+		)
+
+		retryResp, err := t.translateChunk(ctx, retryReq)
+		if err != nil {
+			continue
+		}
+
+		fits, length = validator.ValidateLength(retryResp.Text, seg.MaxLength)
+		if fits {
+			return retryResp.Text, nil
+		}
+	}
+
+	return "", fmt.Errorf("segment %s exceeds max length %d (%d chars)", seg.ID, seg.MaxLength, length)
+}
+
+// enforceBannedTerms re-translates a segment that uses banned terms, naming
+// them in the feedback, until none is left.
+func (t *Translator) enforceBannedTerms(ctx context.Context, providerReq provider.TranslateRequest, translated string, req TranslateRequest) (string, error) {
+	terms := t.config.Settings.BannedTerms
+	found := validator.CheckBanned(translated, terms)
+	if len(found) == 0 {
+		return translated, nil
+	}
+
+	retries := t.config.Settings.BannedRetries
+	for retry := 1; retry <= retries; retry++ {
+		if t.verbose {
+			t.logInfo("Translation uses banned terms %s, retry %d/%d...", strings.Join(found, ", "), retry, retries)
+		}
+
+		retryReq := providerReq
+		retryReq.Context = fmt.Sprintf(
+			"Previous translation used these banned terms: %s. They must not appear in the translation, rephrase without them. %s",
+			strings.Join(found, ", "), req.Context,
+		)
+
+		retryResp, err := t.translateChunk(ctx, retryReq)
+		if err != nil {
+			continue
+		}
+
+		found = validator.CheckBanned(retryResp.Text, terms)
+		if len(found) == 0 {
+			return retryResp.Text, nil
+		}
+	}
+
+	return "", fmt.Errorf("translation uses banned terms: %s", strings.Join(found, ", "))
+}
+
+// styleInstructions returns the prompt of the style of req from
+// prompts.styles, followed by those of the tone, audience and formality.
+func (t *Translator) styleInstructions(req TranslateRequest) string {
+	settings := t.config.Settings
+	var parts []string
+	for _, text := range []string{
+		t.config.Prompts.Styles[req.Style],
+		config.Tones[settings.Tone],
+		config.Audiences[settings.Audience],
+		config.Formalities[settings.Formality],
+	} {
+		if text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// renderSystemPrompt fills {source_lang}, {target_lang} and {style} in
+// prompts.system. {style} expands to the style instructions, which are
+// appended when the template has no {style} placeholder. Returns an empty
+// string without a template so providers fall back to the built-in prompt.
+func (t *Translator) renderSystemPrompt(req TranslateRequest) string {
+	tmpl := strings.TrimSpace(t.config.Prompts.System)
+	if override := strings.TrimSpace(t.config.Prompts.SystemOverride[t.config.DefaultProvider]); override != "" {
+		tmpl = override
+	}
+	if tmpl == "" {
+		return ""
+	}
+
+	sourceLang := req.SourceLang
+	if sourceLang == "auto" {
+		sourceLang = "the source language (detect it automatically)"
+	}
+
+	styleText := t.styleInstructions(req)
+
+	prompt := strings.NewReplacer(
+		"{source_lang}", sourceLang,
+		"{target_lang}", req.TargetLang,
+		"{style}", styleText,
+	).Replace(tmpl)
+
+	if styleText != "" && !strings.Contains(tmpl, "{style}") {
+		prompt += "\n\n" + styleText
+	}
+
+	return prompt
 }
 
 func (t *Translator) ensureProvider() error {
@@ -167,7 +964,7 @@ func (t *Translator) ensureProvider() error {
 		return nil
 	}
 
-	client, err := t.createHTTPClient()
+	client, err := t.createHTTPClient(t.config.DefaultProvider)
 	if err != nil {
 		return fmt.Errorf("failed to create HTTP client: %w", err)
 	}
@@ -178,106 +975,268 @@ func (t *Translator) ensureProvider() error {
 		return fmt.Errorf("provider %s not configured", t.config.DefaultProvider)
 	}
 
+	// Resolved key is stored back so api_key_cmd runs once per process
+	if err := providerCfg.ResolveAPIKey(); err != nil {
+		return fmt.Errorf("provider %s: %w", t.config.DefaultProvider, err)
+	}
+	t.config.Providers[t.config.DefaultProvider] = providerCfg
+
 	p, err := provider.Get(t.config.DefaultProvider, providerCfg, t.client)
 	if err != nil {
 		return fmt.Errorf("failed to initialize provider: %w", err)
 	}
+	p.SetMeter(t.meter)
 	t.provider = p
+
+	post, err := postprocess.New(t.config.Postprocess)
+	if err != nil {
+		return err
+	}
+	t.post = post
+
+	ensemble, err := t.newEnsemble()
+	if err != nil {
+		return err
+	}
+	t.ensemble = ensemble
 	return nil
 }
 
-func (t *Translator) AnalyzeSentiment(ctx context.Context, text string) (provider.SentimentResponse, error) {
+// prepareCall readies the provider for a call outside the translation
+// path, such as an analysis or embedding, and holds it to the budget like
+// translation requests.
+func (t *Translator) prepareCall(ctx context.Context) error {
 	if err := t.ensureProvider(); err != nil {
+		return err
+	}
+	return t.budget.wait(ctx)
+}
+
+func (t *Translator) AnalyzeSentiment(ctx context.Context, text string) (provider.SentimentResponse, error) {
+	if err := t.prepareCall(ctx); err != nil {
 		return provider.SentimentResponse{}, err
 	}
+	if t.structured() {
+		resp, err := t.analyzeStructured(ctx, provider.CombinedAnalysisRequest{Text: text, Sentiment: true})
+		if err != nil {
+			return provider.SentimentResponse{}, err
+		}
+		return *resp.Sentiment, nil
+	}
 	return t.provider.AnalyzeSentiment(ctx, text)
 }
 
 func (t *Translator) ExtractTags(ctx context.Context, text string, count int) (provider.TagsResponse, error) {
-	if err := t.ensureProvider(); err != nil {
+	if err := t.prepareCall(ctx); err != nil {
 		return provider.TagsResponse{}, err
 	}
+	if t.structured() {
+		resp, err := t.analyzeStructured(ctx, provider.CombinedAnalysisRequest{Text: text, TagsCount: count})
+		if err != nil {
+			return provider.TagsResponse{}, err
+		}
+		return *resp.Tags, nil
+	}
 	return t.provider.ExtractTags(ctx, text, count)
 }
 
 func (t *Translator) Classify(ctx context.Context, text string) (provider.ClassifyResponse, error) {
-	if err := t.ensureProvider(); err != nil {
+	if err := t.prepareCall(ctx); err != nil {
 		return provider.ClassifyResponse{}, err
 	}
+	if t.structured() {
+		resp, err := t.analyzeStructured(ctx, provider.CombinedAnalysisRequest{Text: text, Classify: true})
+		if err != nil {
+			return provider.ClassifyResponse{}, err
+		}
+		return *resp.Classify, nil
+	}
 	return t.provider.Classify(ctx, text)
 }
 
 func (t *Translator) AnalyzeEmotions(ctx context.Context, text string) (provider.EmotionsResponse, error) {
-	if err := t.ensureProvider(); err != nil {
+	if err := t.prepareCall(ctx); err != nil {
 		return provider.EmotionsResponse{}, err
 	}
+	if t.structured() {
+		resp, err := t.analyzeStructured(ctx, provider.CombinedAnalysisRequest{Text: text, Emotions: true})
+		if err != nil {
+			return provider.EmotionsResponse{}, err
+		}
+		return *resp.Emotions, nil
+	}
 	return t.provider.AnalyzeEmotions(ctx, text)
 }
 
 func (t *Translator) AnalyzeFactuality(ctx context.Context, text string) (provider.FactualityResponse, error) {
-	if err := t.ensureProvider(); err != nil {
+	if err := t.prepareCall(ctx); err != nil {
 		return provider.FactualityResponse{}, err
 	}
+	if t.structured() {
+		resp, err := t.analyzeStructured(ctx, provider.CombinedAnalysisRequest{Text: text, Factuality: true})
+		if err != nil {
+			return provider.FactualityResponse{}, err
+		}
+		return *resp.Factuality, nil
+	}
 	return t.provider.AnalyzeFactuality(ctx, text)
 }
 
 func (t *Translator) AnalyzeImpact(ctx context.Context, text string) (provider.ImpactResponse, error) {
-	if err := t.ensureProvider(); err != nil {
+	if err := t.prepareCall(ctx); err != nil {
 		return provider.ImpactResponse{}, err
 	}
+	if t.structured() {
+		resp, err := t.analyzeStructured(ctx, provider.CombinedAnalysisRequest{Text: text, Impact: true})
+		if err != nil {
+			return provider.ImpactResponse{}, err
+		}
+		return *resp.Impact, nil
+	}
 	return t.provider.AnalyzeImpact(ctx, text)
 }
 
 func (t *Translator) AnalyzeSensationalism(ctx context.Context, text string) (provider.SensationalismResponse, error) {
-	if err := t.ensureProvider(); err != nil {
+	if err := t.prepareCall(ctx); err != nil {
 		return provider.SensationalismResponse{}, err
 	}
+	if t.structured() {
+		resp, err := t.analyzeStructured(ctx, provider.CombinedAnalysisRequest{Text: text, Sensationalism: true})
+		if err != nil {
+			return provider.SensationalismResponse{}, err
+		}
+		return *resp.Sensationalism, nil
+	}
 	return t.provider.AnalyzeSensationalism(ctx, text)
 }
 
 func (t *Translator) ExtractEntities(ctx context.Context, text string) (provider.EntitiesResponse, error) {
-	if err := t.ensureProvider(); err != nil {
+	if err := t.prepareCall(ctx); err != nil {
 		return provider.EntitiesResponse{}, err
 	}
+	if t.structured() {
+		resp, err := t.analyzeStructured(ctx, provider.CombinedAnalysisRequest{Text: text, Entities: true})
+		if err != nil {
+			return provider.EntitiesResponse{}, err
+		}
+		return *resp.Entities, nil
+	}
 	return t.provider.ExtractEntities(ctx, text)
 }
 
 func (t *Translator) ExtractEvents(ctx context.Context, text string) (provider.EventsResponse, error) {
-	if err := t.ensureProvider(); err != nil {
+	if err := t.prepareCall(ctx); err != nil {
 		return provider.EventsResponse{}, err
 	}
+	if t.structured() {
+		resp, err := t.analyzeStructured(ctx, provider.CombinedAnalysisRequest{Text: text, Events: true})
+		if err != nil {
+			return provider.EventsResponse{}, err
+		}
+		return *resp.Events, nil
+	}
 	return t.provider.ExtractEvents(ctx, text)
 }
 
 func (t *Translator) AnalyzeUsefulness(ctx context.Context, text string) (provider.UsefulnessResponse, error) {
-	if err := t.ensureProvider(); err != nil {
+	if err := t.prepareCall(ctx); err != nil {
 		return provider.UsefulnessResponse{}, err
 	}
+	if t.structured() {
+		resp, err := t.analyzeStructured(ctx, provider.CombinedAnalysisRequest{Text: text, Usefulness: true})
+		if err != nil {
+			return provider.UsefulnessResponse{}, err
+		}
+		return *resp.Usefulness, nil
+	}
 	return t.provider.AnalyzeUsefulness(ctx, text)
 }
 
 func (t *Translator) AnalyzeTimeFocus(ctx context.Context, text string) (provider.TimeFocusResponse, error) {
-	if err := t.ensureProvider(); err != nil {
+	if err := t.prepareCall(ctx); err != nil {
 		return provider.TimeFocusResponse{}, err
 	}
+	if t.structured() {
+		resp, err := t.analyzeStructured(ctx, provider.CombinedAnalysisRequest{Text: text, TimeFocus: true})
+		if err != nil {
+			return provider.TimeFocusResponse{}, err
+		}
+		return *resp.TimeFocus, nil
+	}
 	return t.provider.AnalyzeTimeFocus(ctx, text)
 }
 
 func (t *Translator) AnalyzeAdDetect(ctx context.Context, text string) (provider.AdDetectResponse, error) {
-	if err := t.ensureProvider(); err != nil {
+	if err := t.prepareCall(ctx); err != nil {
 		return provider.AdDetectResponse{}, err
 	}
+	if t.structured() {
+		resp, err := t.analyzeStructured(ctx, provider.CombinedAnalysisRequest{Text: text, AdDetect: true})
+		if err != nil {
+			return provider.AdDetectResponse{}, err
+		}
+		return *resp.AdDetect, nil
+	}
 	return t.provider.AnalyzeAdDetect(ctx, text)
 }
 
 func (t *Translator) AnalyzeCombined(ctx context.Context, req provider.CombinedAnalysisRequest) (provider.CombinedAnalysisResponse, error) {
-	if err := t.ensureProvider(); err != nil {
+	if err := t.prepareCall(ctx); err != nil {
 		return provider.CombinedAnalysisResponse{}, err
 	}
+	req.Schema = t.structured()
 	return t.provider.AnalyzeCombined(ctx, req)
 }
 
+// structured reports whether analyses use the provider's structured output.
+// Providers without it keep the free text prompts.
+func (t *Translator) structured() bool {
+	return t.config.Settings.StructuredOutput && provider.SupportsStructuredOutput(t.provider)
+}
+
+// analyzeStructured runs a single analysis as a combined request with a
+// JSON schema. A section the model did not fill is a ParseError, like a
+// free text answer that does not match.
+func (t *Translator) analyzeStructured(ctx context.Context, req provider.CombinedAnalysisRequest) (provider.CombinedAnalysisResponse, error) {
+	req.Schema = true
+	resp, err := t.provider.AnalyzeCombined(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	if len(resp.Failed) > 0 {
+		return resp, &provider.ParseError{Msg: "invalid " + resp.Failed[0] + " response", Raw: resp.Raw}
+	}
+	return resp, nil
+}
+
+func (t *Translator) GenerateHeadline(ctx context.Context, text string) (provider.HeadlineResponse, error) {
+	if err := t.prepareCall(ctx); err != nil {
+		return provider.HeadlineResponse{}, err
+	}
+	return t.provider.GenerateHeadline(ctx, text)
+}
+
+// maxEmbedRunes keeps embedding input under the ~8k token limit of the
+// embedding models, long documents are embedded by their beginning.
+const maxEmbedRunes = 16000
+
+// Embed returns an embedding vector of text for similarity search.
+func (t *Translator) Embed(ctx context.Context, text string) (provider.EmbedResponse, error) {
+	if err := t.prepareCall(ctx); err != nil {
+		return provider.EmbedResponse{}, err
+	}
+	if runes := []rune(text); len(runes) > maxEmbedRunes {
+		text = string(runes[:maxEmbedRunes])
+	}
+	return t.provider.Embed(ctx, text)
+}
+
 func (t *Translator) translateWithRetry(ctx context.Context, req provider.TranslateRequest) (provider.TranslateResponse, error) {
+	return t.requestWithRetry(ctx, t.provider, req)
+}
+
+// requestWithRetry sends req to p, retrying failures that may pass.
+func (t *Translator) requestWithRetry(ctx context.Context, p provider.Provider, req provider.TranslateRequest) (provider.TranslateResponse, error) {
 	var lastErr error
 	retryCount := t.config.Settings.RetryCount
 	if retryCount == 0 {
@@ -303,8 +1262,32 @@ func (t *Translator) translateWithRetry(ctx context.Context, req provider.Transl
 			}
 		}
 
-		resp, err := t.provider.Translate(ctx, req)
+		if err := t.budget.wait(ctx); err != nil {
+			return provider.TranslateResponse{}, err
+		}
+		if err := t.limiter.wait(ctx); err != nil {
+			return provider.TranslateResponse{}, err
+		}
+
+		name := p.Name()
+		callCtx, span := telemetry.Start(ctx, "provider_call", telemetry.String("provider", name), telemetry.Int("attempt", attempt+1))
+		start := time.Now()
+		resp, err := p.Translate(callCtx, req)
+		if ctx.Err() == nil {
+			t.recordHealth(name, time.Since(start), err != nil)
+		}
+		span.SetAttr(telemetry.Int("tokens", resp.TokensUsed))
+		span.End(err)
+		telemetry.Count("llm_translate.requests", 1, telemetry.String("provider", name))
+		if attempt > 0 {
+			t.retries.Add(1)
+			telemetry.Count("llm_translate.retries", 1, telemetry.String("provider", name))
+		}
+		if err != nil {
+			telemetry.Count("llm_translate.errors", 1, telemetry.String("provider", name))
+		}
 		if err == nil {
+			recordResponse(ctx, resp.Meta)
 			return resp, nil
 		}
 
@@ -322,8 +1305,75 @@ func (t *Translator) translateWithRetry(ctx context.Context, req provider.Transl
 	return provider.TranslateResponse{}, fmt.Errorf("failed after %d retries: %w", retryCount, lastErr)
 }
 
-func (t *Translator) createHTTPClient() (*http.Client, error) {
-	providerCfg, ok := t.config.Providers[t.config.DefaultProvider]
+// maxContinuations is how many times a translation cut at the max tokens
+// limit is continued before it is reported as truncated.
+const maxContinuations = 3
+
+// translateChunk requests a translation of a chunk and runs the postprocess
+// rules on it, so checks and retries see the cleaned-up text. Output cut at
+// the max tokens limit is continued where the provider supports it;
+// Truncated is still set if it could not be completed.
+func (t *Translator) translateChunk(ctx context.Context, req provider.TranslateRequest) (provider.TranslateResponse, error) {
+	return t.translateChunkWith(ctx, t.provider, req)
+}
+
+// translateChunkWith is translateChunk with provider p.
+func (t *Translator) translateChunkWith(ctx context.Context, p provider.Provider, req provider.TranslateRequest) (provider.TranslateResponse, error) {
+	resp, err := t.requestWithRetry(ctx, p, req)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.Truncated && provider.SupportsContinuation(p) {
+		text, tokens := resp.Text, resp.TokensUsed
+		for n := 1; n <= maxContinuations && resp.Truncated; n++ {
+			t.logInfo("Output truncated at the max tokens limit, continuing (%d/%d)...", n, maxContinuations)
+			next := req
+			next.Partial = text
+			resp, err = t.requestWithRetry(ctx, p, next)
+			if err != nil {
+				return resp, err
+			}
+			text += resp.Text
+			tokens += resp.TokensUsed
+		}
+		resp.Text, resp.TokensUsed = text, tokens
+	}
+
+	if req.Delimiter != "" {
+		resp.Text = unwrapGuarded(resp.Text, req.Delimiter)
+	}
+	resp.Text = t.post.Apply(resp.Text, req.TargetLang)
+	return resp, nil
+}
+
+// unwrapGuarded removes the guard tags a model may repeat around its
+// translation.
+func unwrapGuarded(text, tag string) string {
+	re := regexp.MustCompile(`(?i)</?` + regexp.QuoteMeta(tag) + `>`)
+	return strings.TrimSpace(re.ReplaceAllString(text, ""))
+}
+
+// recordHealth adds a request to the provider stats and, in verbose mode,
+// reports when the provider becomes degraded or recovers.
+func (t *Translator) recordHealth(name string, latency time.Duration, failed bool) {
+	stats := t.health.Record(name, latency, failed)
+	degraded := stats.Degraded()
+	if degraded == t.degraded.Swap(degraded) {
+		return
+	}
+	if degraded {
+		t.logWarn("Provider %s degraded: %.0f%% of recent requests failed, latency %dms (run average %dms)",
+			stats.Provider, stats.RecentErrorRate*100, stats.RecentLatencyMs, stats.AvgLatencyMs)
+	} else {
+		t.logInfo("Provider %s recovered", stats.Provider)
+	}
+}
+
+// createHTTPClient returns a client for provider name, using its proxy or
+// the global one.
+func (t *Translator) createHTTPClient(name string) (*http.Client, error) {
+	providerCfg, ok := t.config.Providers[name]
 	if !ok {
 		providerCfg = config.ProviderConfig{}
 	}
@@ -357,6 +1407,71 @@ func (t *Translator) validateTranslation(ctx context.Context, original, translat
 	return translated, nil
 }
 
+// minReadabilityWords is the minimum chunk size in words for which the
+// readability grade is meaningful enough to trigger re-translation.
+const minReadabilityWords = 30
+
+// enforceReadingLevel re-translates a chunk when its readability grade falls
+// outside the band of the requested reading level. If no attempt hits the
+// band, the attempt closest to it is kept.
+func (t *Translator) enforceReadingLevel(ctx context.Context, providerReq provider.TranslateRequest, translated string, req TranslateRequest) string {
+	minGrade, maxGrade, ok := readability.TargetBand(req.ReadingLevel)
+	if !ok {
+		return translated
+	}
+
+	score := readability.Analyze(translated, req.TargetLang)
+	if score.Words < minReadabilityWords {
+		return translated
+	}
+
+	best := translated
+	bestDistance := gradeDistance(score.Grade, minGrade, maxGrade)
+
+	for retry := 1; retry <= req.ReadingRetries && bestDistance > 0; retry++ {
+		direction := "Simplify"
+		if score.Grade < minGrade {
+			direction = "Use richer"
+		}
+		if t.verbose {
+			t.logInfo("Readability grade %.1f outside target %.0f-%.0f, retry %d/%d...", score.Grade, minGrade, maxGrade, retry, req.ReadingRetries)
+		}
+
+		retryReq := providerReq
+		retryReq.Context = fmt.Sprintf(
+			"Previous translation had readability grade %.1f, but the target reading level is %s (grade %.0f-%.0f). %s vocabulary and sentence structure accordingly. %s",
+			score.Grade, req.ReadingLevel, minGrade, maxGrade, direction, req.Context,
+		)
+
+		retryResp, err := t.translateChunk(ctx, retryReq)
+		if err != nil {
+			continue
+		}
+
+		score = readability.Analyze(retryResp.Text, req.TargetLang)
+		if distance := gradeDistance(score.Grade, minGrade, maxGrade); distance < bestDistance {
+			best = retryResp.Text
+			bestDistance = distance
+		}
+	}
+
+	if bestDistance > 0 && t.verbose {
+		t.logWarn("Reading level %s not reached, keeping closest translation", req.ReadingLevel)
+	}
+
+	return best
+}
+
+func gradeDistance(grade, min, max float64) float64 {
+	if grade < min {
+		return min - grade
+	}
+	if grade > max {
+		return grade - max
+	}
+	return 0
+}
+
 func (t *Translator) splitIntoChunks(text string, chunkSize int) []string {
 	if len(text) <= chunkSize {
 		return []string{text}
@@ -423,6 +1538,12 @@ func (t *Translator) splitIntoChunks(text string, chunkSize int) []string {
 	return chunks
 }
 
+// PlanChunks returns the chunks Translate would send for text, without
+// calling the provider.
+func (t *Translator) PlanChunks(text string) []string {
+	return t.splitIntoChunks(text, t.config.Settings.ChunkSize)
+}
+
 func splitIntoSentences(text string) []string {
 	var sentences []string
 	var current strings.Builder
@@ -524,12 +1645,12 @@ func isRetryableError(err error) bool {
 
 func (t *Translator) logInfo(format string, args ...interface{}) {
 	if t.verbose {
-		fmt.Fprintf(os.Stderr, "[INFO] "+format+"\n", args...)
+		logging.Info(format, args...)
 	}
 }
 
 func (t *Translator) logWarn(format string, args ...interface{}) {
 	if t.verbose {
-		fmt.Fprintf(os.Stderr, "[WARN] "+format+"\n", args...)
+		logging.Warn(format, args...)
 	}
 }
@@ -0,0 +1,190 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sentenceCaseLangs write headings in sentence case: only the first word
+// and proper names are capitalized.
+var sentenceCaseLangs = map[string]bool{
+	"ru": true, "uk": true, "be": true, "bg": true, "sr": true, "pl": true, "cs": true, "sk": true,
+	"fr": true, "es": true, "it": true, "pt": true, "nl": true, "sv": true, "no": true, "da": true, "fi": true,
+}
+
+// lowercaseDates are month and weekday names written in lower case inside a
+// sentence. Forms that are also common first names (Марта, Мая, Abril) or
+// words (Mars) are left out, so correcting them is safe.
+var lowercaseDates = map[string][]string{
+	"ru": {
+		"январь", "января", "январе", "февраль", "февраля", "феврале", "март", "марте",
+		"апрель", "апреля", "апреле", "май", "мае", "июнь", "июня", "июне", "июль", "июля", "июле",
+		"август", "августе", "сентябрь", "сентября", "сентябре", "октябрь", "октября", "октябре",
+		"ноябрь", "ноября", "ноябре", "декабрь", "декабря", "декабре",
+		"понедельник", "понедельника", "вторник", "вторника", "среду", "четверг", "четверга",
+		"пятницу", "пятница", "пятницы", "субботу", "суббота", "субботы", "воскресенье", "воскресенья",
+	},
+	"fr": {
+		"janvier", "février", "avril", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre",
+		"lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi", "dimanche",
+	},
+	"es": {
+		"enero", "febrero", "marzo", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre",
+		"lunes", "martes", "miércoles", "jueves", "viernes", "sábado", "domingo",
+	},
+	"it": {
+		"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre",
+		"lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato", "domenica",
+	},
+	"pt": {
+		"janeiro", "fevereiro", "março", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro",
+		"segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado", "domingo",
+	},
+}
+
+var (
+	headingRe = regexp.MustCompile(`(?m)^#{1,6}[ \t]+(.+?)[ \t#]*$`)
+	wordRe    = regexp.MustCompile(`[\p{L}][\p{L}\p{N}'’-]*`)
+)
+
+// baseLang reduces "pt-BR" or "RU" to "pt" or "ru".
+func baseLang(lang string) string {
+	lang = strings.ToLower(lang)
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	return lang
+}
+
+// CheckCapitalization reports capitalization that breaks the conventions
+// of lang: Title Case headings in sentence case languages, capitalized
+// month and weekday names, and German text with hardly any capitalized
+// nouns.
+func CheckCapitalization(text, lang string) []string {
+	lang = baseLang(lang)
+	var issues []string
+
+	if sentenceCaseLangs[lang] {
+		for _, m := range headingRe.FindAllStringSubmatch(text, -1) {
+			if isTitleCase(m[1]) {
+				issues = append(issues, fmt.Sprintf("heading in Title Case: %q", m[1]))
+			}
+		}
+	}
+
+	if names := lowercaseDates[lang]; names != nil {
+		seen := make(map[string]bool)
+		forEachCapitalizedDate(text, names, func(start, end int) {
+			word := text[start:end]
+			if !seen[word] {
+				seen[word] = true
+				issues = append(issues, fmt.Sprintf("month or weekday capitalized: %s", word))
+			}
+		})
+	}
+
+	if lang == "de" && fewCapitalizedNouns(text) {
+		issues = append(issues, "hardly any capitalized words, nouns may be in lower case")
+	}
+
+	return issues
+}
+
+// FixCapitalization applies the corrections that cannot change meaning:
+// month and weekday names inside a sentence are lowercased. Headings are
+// only reported, since they may contain proper names.
+func FixCapitalization(text, lang string) string {
+	names := lowercaseDates[baseLang(lang)]
+	if names == nil {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	forEachCapitalizedDate(text, names, func(start, end int) {
+		b.WriteString(text[last:start])
+		b.WriteString(strings.ToLower(text[start:end]))
+		last = end
+	})
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// forEachCapitalizedDate calls fn for each capitalized month or weekday name
+// that does not start a sentence, line or heading.
+func forEachCapitalizedDate(text string, names []string, fn func(start, end int)) {
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		known[name] = true
+	}
+
+	for _, loc := range wordRe.FindAllStringIndex(text, -1) {
+		word := text[loc[0]:loc[1]]
+		first, _ := utf8.DecodeRuneInString(word)
+		if !unicode.IsUpper(first) || !known[strings.ToLower(word)] || startsSentence(text[:loc[0]]) {
+			continue
+		}
+		// All caps is emphasis or a heading style, not a mistake
+		if strings.ToUpper(word) == word {
+			continue
+		}
+		fn(loc[0], loc[1])
+	}
+}
+
+// startsSentence reports whether a word following prefix begins a sentence,
+// line or quotation.
+func startsSentence(prefix string) bool {
+	prefix = strings.TrimRight(prefix, " \t\"'«„“(*_")
+	if prefix == "" {
+		return true
+	}
+	last, _ := utf8.DecodeLastRuneInString(prefix)
+	return strings.ContainsRune(".!?:…\n#>-|", last)
+}
+
+// isTitleCase reports whether a heading of three or more words capitalizes
+// every word. Short words like prepositions stay lower case in English
+// Title Case, so a heading translated word by word keeps them.
+func isTitleCase(heading string) bool {
+	words := wordRe.FindAllString(heading, -1)
+	if len(words) < 3 {
+		return false
+	}
+	capitalized := 0
+	for _, w := range words[1:] {
+		first, _ := utf8.DecodeRuneInString(w)
+		if strings.ToUpper(w) == w {
+			// Acronyms say nothing about the heading style
+			continue
+		}
+		if unicode.IsUpper(first) {
+			capitalized++
+		} else if utf8.RuneCountInString(w) > 3 {
+			return false
+		}
+	}
+	return capitalized >= 2
+}
+
+// fewCapitalizedNouns reports whether German text of some length has
+// almost no capitalized words inside sentences, which happens when nouns
+// are written in lower case.
+func fewCapitalizedNouns(text string) bool {
+	words, capitalized := 0, 0
+	for _, loc := range wordRe.FindAllStringIndex(text, -1) {
+		if startsSentence(text[:loc[0]]) {
+			continue
+		}
+		words++
+		first, _ := utf8.DecodeRuneInString(text[loc[0]:])
+		if unicode.IsUpper(first) {
+			capitalized++
+		}
+	}
+	// Typical German prose capitalizes a quarter of its words or more
+	return words >= 30 && capitalized*20 < words
+}
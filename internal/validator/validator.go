@@ -4,6 +4,7 @@ import (
 	"regexp"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/foxzi/llm-translate/internal/config"
 )
@@ -18,6 +19,150 @@ func New(cfg config.StrongValidation) *Validator {
 	}
 }
 
+// ValidateLength reports whether text fits within maxLen characters and
+// returns its length. A non-positive maxLen means no limit.
+func ValidateLength(text string, maxLen int) (bool, int) {
+	length := utf8.RuneCountInString(strings.TrimSpace(text))
+	return maxLen <= 0 || length <= maxLen, length
+}
+
+// numberRe matches numbers with decimal/thousands separators. Space-like
+// separators are only accepted before 3-digit groups ("1 000 000").
+var numberRe = regexp.MustCompile(`\d+(?:[.,]\d+|[ \x{00A0}\x{202F}]\d{3}\b)*`)
+
+// CheckNumbers verifies that every number in the source also appears in the
+// translation. Numbers are compared by digit sequence, so locale formatting
+// differences ("1,000.5" vs "1 000,5") are accepted. Returns the source
+// figures that were dropped or altered.
+func CheckNumbers(source, translated string) []string {
+	available := make(map[string]int)
+	for _, n := range numberRe.FindAllString(translated, -1) {
+		available[digitsOnly(n)]++
+	}
+
+	var missing []string
+	for _, n := range numberRe.FindAllString(source, -1) {
+		key := digitsOnly(n)
+		if available[key] > 0 {
+			available[key]--
+			continue
+		}
+		missing = append(missing, n)
+	}
+
+	return missing
+}
+
+// linkRe matches URLs in plain text and markdown/HTML links. Parentheses,
+// brackets and quotes end a URL so "[text](url)" yields just the URL.
+var linkRe = regexp.MustCompile(`https?://[^\s<>()\[\]"'` + "`" + `]+`)
+
+// CheckLinks verifies that every cited URL in the source survives
+// translation unchanged. Returns the URLs that were dropped or altered.
+func CheckLinks(source, translated string) []string {
+	available := make(map[string]bool)
+	for _, link := range linkRe.FindAllString(translated, -1) {
+		available[trimLink(link)] = true
+	}
+
+	seen := make(map[string]bool)
+	var missing []string
+	for _, link := range linkRe.FindAllString(source, -1) {
+		link = trimLink(link)
+		if available[link] || seen[link] {
+			continue
+		}
+		seen[link] = true
+		missing = append(missing, link)
+	}
+
+	return missing
+}
+
+// CheckBanned returns the banned terms found in text as whole words,
+// ignoring case.
+func CheckBanned(text string, terms []string) []string {
+	lower := strings.ToLower(text)
+	var found []string
+	for _, term := range terms {
+		t := strings.ToLower(strings.TrimSpace(term))
+		if t != "" && containsWord(lower, t) {
+			found = append(found, term)
+		}
+	}
+	return found
+}
+
+// containsWord reports whether word occurs in text not as part of a longer
+// word.
+func containsWord(text, word string) bool {
+	for start := 0; ; {
+		i := strings.Index(text[start:], word)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(word)
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if (i == 0 || !isWordRune(before)) && (end == len(text) || !isWordRune(after)) {
+			return true
+		}
+		start = i + 1
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// injectionMarkers are phrases typical of a model answering or obeying the
+// text instead of translating it.
+var injectionMarkers = []string{
+	"as an ai",
+	"as a language model",
+	"i cannot",
+	"i can't",
+	"i'm sorry",
+	"sure, here",
+	"here is the translation",
+	"here's the translation",
+	"system prompt",
+	"ignore previous instructions",
+	"ignore all previous instructions",
+}
+
+// CheckInjection looks for signs that the model followed instructions
+// embedded in the source: assistant-style phrases that appear in the
+// translation but not in the source. Returns the phrases found.
+func CheckInjection(source, translated string) []string {
+	source = strings.ToLower(source)
+	translated = strings.ToLower(translated)
+
+	var found []string
+	for _, marker := range injectionMarkers {
+		if strings.Contains(translated, marker) && !strings.Contains(source, marker) {
+			found = append(found, marker)
+		}
+	}
+	return found
+}
+
+// trimLink drops sentence punctuation that follows a URL in prose.
+func trimLink(link string) string {
+	return strings.TrimRight(link, ".,;:!?")
+}
+
+func digitsOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
 func (v *Validator) Validate(text, sourceLang, targetLang string) (bool, []string) {
 	if !v.config.Enabled {
 		return true, nil
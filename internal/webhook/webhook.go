@@ -0,0 +1,84 @@
+// Package webhook posts the results of finished requests to the callback
+// URLs given with them.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/foxzi/llm-translate/internal/config"
+)
+
+// attempts is how often a callback is tried before it is given up.
+const attempts = 3
+
+// Send posts payload as JSON to callback, signed as configured in cfg.
+// Failed deliveries and non-2xx answers are retried with a growing delay.
+func Send(ctx context.Context, client *http.Client, callback string, cfg config.Webhooks, payload []byte) error {
+	target, err := url.Parse(callback)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("invalid callback URL %q", callback)
+	}
+	secret := config.ExpandEnvVars(cfg.Secret)
+	if secret == "" {
+		return fmt.Errorf("webhooks.secret is not set, callbacks are always signed")
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(attempt) * time.Second):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if lastErr = post(ctx, client, target, cfg, secret, payload); lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+func post(ctx context.Context, client *http.Client, target *url.URL, cfg config.Webhooks, secret string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.String(), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	signatureHeader := cfg.Header
+	if signatureHeader == "" {
+		signatureHeader = "X-Signature"
+	}
+	timestampHeader := cfg.TimestampHeader
+	if timestampHeader == "" {
+		timestampHeader = "X-Timestamp"
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n%s\n%s\n", timestamp, http.MethodPost, target.RequestURI())
+	mac.Write(payload)
+	req.Header.Set(timestampHeader, timestamp)
+	req.Header.Set(signatureHeader, hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send callback: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("callback answered %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
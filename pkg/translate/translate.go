@@ -0,0 +1,189 @@
+// Package translate is the Go API of llm-translate for programs embedding
+// the translator. It uses the same providers, prompts, chunking and checks
+// as the command-line tool:
+//
+//	client, err := translate.New(translate.WithProvider("openai"), translate.WithAPIKey(key))
+//	if err != nil {
+//		return err
+//	}
+//	result, err := client.Translate(ctx, translate.Request{Text: text, TargetLang: "de"})
+package translate
+
+import (
+	"context"
+
+	"github.com/foxzi/llm-translate/internal/config"
+	"github.com/foxzi/llm-translate/internal/provider"
+	"github.com/foxzi/llm-translate/internal/translator"
+)
+
+// GlossaryEntry is a term with its required translation.
+type GlossaryEntry = config.GlossaryEntry
+
+// AnalysisRequest selects the analyses run on Text by Analyze.
+type AnalysisRequest = provider.CombinedAnalysisRequest
+
+// Analysis holds the results of the selected analyses.
+type Analysis = provider.CombinedAnalysisResponse
+
+// ProviderRequest is what a provider registered with RegisterProvider gets
+// to translate.
+type ProviderRequest = provider.TranslateRequest
+
+// TranslateFunc translates req.Text following systemPrompt, the complete
+// instructions built from the request.
+type TranslateFunc = provider.TranslateFunc
+
+// RegisterProvider makes a custom translation backend available under name,
+// to be selected with WithProvider. Such providers translate only.
+func RegisterProvider(name string, translate TranslateFunc) {
+	provider.RegisterFunc(name, translate)
+}
+
+// Client translates and analyzes text with one provider. A Client is not
+// safe for concurrent use; create one per goroutine.
+type Client struct {
+	config     *config.Config
+	translator *translator.Translator
+}
+
+type options struct {
+	configFile  string
+	provider    string
+	model       string
+	apiKey      string
+	baseURL     string
+	chunkSize   int
+	temperature *float64
+}
+
+// Option configures a Client.
+type Option func(*options)
+
+// WithConfigFile reads settings and providers from an llm-translate config
+// file. Without it the built-in defaults are used.
+func WithConfigFile(path string) Option {
+	return func(o *options) { o.configFile = path }
+}
+
+// WithProvider selects the provider, e.g. openai, anthropic, ollama or one
+// registered with RegisterProvider.
+func WithProvider(name string) Option {
+	return func(o *options) { o.provider = name }
+}
+
+// WithModel sets the model of the provider.
+func WithModel(model string) Option {
+	return func(o *options) { o.model = model }
+}
+
+// WithAPIKey sets the API key of the provider.
+func WithAPIKey(key string) Option {
+	return func(o *options) { o.apiKey = key }
+}
+
+// WithBaseURL sets the API base URL of the provider.
+func WithBaseURL(url string) Option {
+	return func(o *options) { o.baseURL = url }
+}
+
+// WithChunkSize sets the maximum chunk size in characters.
+func WithChunkSize(size int) Option {
+	return func(o *options) { o.chunkSize = size }
+}
+
+// WithTemperature sets the sampling temperature.
+func WithTemperature(temperature float64) Option {
+	return func(o *options) { o.temperature = &temperature }
+}
+
+// New creates a Client. The provider is checked on the first request.
+func New(opts ...Option) (*Client, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cfg := config.DefaultConfig()
+	if o.configFile != "" {
+		var err error
+		if cfg, err = config.Load(o.configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.provider != "" {
+		cfg.DefaultProvider = o.provider
+	}
+	providerCfg := cfg.Providers[cfg.DefaultProvider]
+	if o.model != "" {
+		providerCfg.Model = o.model
+	}
+	if o.apiKey != "" {
+		providerCfg.APIKey = o.apiKey
+	}
+	if o.baseURL != "" {
+		providerCfg.BaseURL = o.baseURL
+	}
+	cfg.Providers[cfg.DefaultProvider] = providerCfg
+
+	if o.chunkSize > 0 {
+		cfg.Settings.ChunkSize = o.chunkSize
+	}
+	if o.temperature != nil {
+		cfg.Settings.Temperature = *o.temperature
+	}
+
+	return &Client{config: cfg, translator: translator.New(cfg, false)}, nil
+}
+
+// Request is a text to translate.
+type Request struct {
+	Text           string
+	SourceLang     string // "auto" when empty
+	TargetLang     string
+	Style          string // formal, informal, technical, literary or one of prompts.styles
+	Context        string // what the text is about, passed to the model
+	Glossary       []GlossaryEntry
+	PreserveFormat bool
+}
+
+// Result is a translated text.
+type Result struct {
+	Text         string
+	DetectedLang string
+}
+
+// Translate translates req.Text, split into chunks as needed.
+func (c *Client) Translate(ctx context.Context, req Request) (Result, error) {
+	sourceLang := req.SourceLang
+	if sourceLang == "" {
+		sourceLang = "auto"
+	}
+	settings := c.config.Settings
+	resp, err := c.translator.Translate(ctx, translator.TranslateRequest{
+		Text:           req.Text,
+		SourceLang:     sourceLang,
+		TargetLang:     req.TargetLang,
+		Style:          req.Style,
+		Context:        req.Context,
+		Glossary:       req.Glossary,
+		Temperature:    settings.Temperature,
+		MaxTokens:      settings.MaxTokens,
+		PreserveFormat: req.PreserveFormat,
+		ReadingLevel:   settings.ReadingLevel,
+		ReadingRetries: settings.ReadingRetries,
+		MaxLength:      settings.MaxLength,
+		MaxLenRatio:    settings.MaxLenRatio,
+		LengthRetries:  settings.LengthRetries,
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Text: resp.Text, DetectedLang: resp.DetectedLang}, nil
+}
+
+// Analyze runs the analyses selected in req on req.Text in one request.
+func (c *Client) Analyze(ctx context.Context, req AnalysisRequest) (Analysis, error) {
+	return c.translator.AnalyzeCombined(ctx, req)
+}